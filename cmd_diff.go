@@ -50,9 +50,14 @@ func clonePushOptionsToDiff() {
 		"safe-below-size": "Always permit generating destructive operations for tables below this size in bytes",
 	}
 	hiddenRewrites := map[string]bool{
-		"brief":              false,
-		"dry-run":            true,
-		"foreign-key-checks": true,
+		"brief":                    false,
+		"dry-run":                  true,
+		"foreign-key-checks":       true,
+		"statement-timeout":        true,
+		"continue-after-timeout":   true,
+		"pause-between-risk-tiers": true,
+		"yes":                      true,
+		"statement-log":            true,
 	}
 
 	diffOptions := diff.Options()
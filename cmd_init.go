@@ -3,6 +3,7 @@ package main
 import (
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -174,6 +175,9 @@ func createHostOptionFile(cfg *mybase.Config, hostDir *fs.Dir, inst *tengo.Insta
 		hostOptionFile.SetOptionValue("", "schema", cfg.Get("schema"))
 		hostOptionFile.SetOptionValue("", "default-character-set", schemas[0].CharSet)
 		hostOptionFile.SetOptionValue("", "default-collation", schemas[0].Collation)
+		if schemas[0].Comment != "" {
+			hostOptionFile.SetOptionValue("", "schema-comment", schemas[0].Comment)
+		}
 	}
 
 	// Write the option file
@@ -228,6 +232,9 @@ func PopulateSchemaDir(s *tengo.Schema, parentDir *fs.Dir, makeSubdir bool) erro
 		optionFile.SetOptionValue("", "schema", s.Name)
 		optionFile.SetOptionValue("", "default-character-set", s.CharSet)
 		optionFile.SetOptionValue("", "default-collation", s.Collation)
+		if s.Comment != "" {
+			optionFile.SetOptionValue("", "schema-comment", s.Comment)
+		}
 		if err = dir.CreateOptionFile(optionFile); err != nil {
 			return NewExitValue(CodeCantCreate, "Cannot use dir %s for schema %s: %v", dir.Path, s.Name, err)
 		}
@@ -243,9 +250,42 @@ func PopulateSchemaDir(s *tengo.Schema, parentDir *fs.Dir, makeSubdir bool) erro
 		dumpOpts.Partitioning = tengo.PartitioningRemove
 	}
 
+	warnFileNameCollisions(dir, s, nil)
 	if _, err = dumper.DumpSchema(s, dir, dumpOpts); err != nil {
 		return NewExitValue(CodeCantCreate, "Unable to write in %s: %s", dir, err)
 	}
 	os.Stderr.WriteString("\n")
 	return nil
 }
+
+// warnFileNameCollisions logs a warning for each group of objects in s whose
+// names differ, but which map to the same *.sql filename due to removal of
+// special characters. Groups consisting solely of objects already defined in
+// existing are skipped, to avoid repeating the warning on every pull.
+func warnFileNameCollisions(dir *fs.Dir, s *tengo.Schema, existing map[tengo.ObjectKey]*tengo.Statement) {
+	existingNames := make(map[string]bool, len(existing))
+	for key := range existing {
+		existingNames[key.Name] = true
+	}
+	var names []string
+	for key := range s.Objects() {
+		names = append(names, key.Name)
+	}
+	collisions := fs.FileNameCollisions(names)
+	fileNames := make([]string, 0, len(collisions))
+	for fileName := range collisions {
+		fileNames = append(fileNames, fileName)
+	}
+	sort.Strings(fileNames)
+	for _, fileName := range fileNames {
+		var anyNew bool
+		quotedNames := make([]string, len(collisions[fileName]))
+		for n, name := range collisions[fileName] {
+			anyNew = anyNew || !existingNames[name]
+			quotedNames[n] = tengo.EscapeIdentifier(name)
+		}
+		if anyNew {
+			log.Warnf("Objects %s will share file %s, since their names only differ in special characters or letter case", strings.Join(quotedNames, ", "), filepath.Join(dir.RelPath(), fileName))
+		}
+	}
+}
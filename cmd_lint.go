@@ -144,6 +144,9 @@ func lintDir(dir *fs.Dir) *linter.Result {
 		if wsOpts, err = workspace.OptionsForDir(dir, inst); err != nil {
 			return linter.BadConfigResult(dir, err)
 		}
+		if inst != nil {
+			opts.SQLMode = inst.SQLMode()
+		}
 	}
 
 	result := &linter.Result{}
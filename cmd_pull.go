@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"fmt"
 	"os"
+	"sort"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/skeema/mybase"
@@ -28,11 +29,16 @@ func init() {
 
 	cmd := mybase.NewCommand("pull", summary, desc, PullHandler)
 	cmd.AddOption(mybase.BoolOption("include-auto-inc", 0, false, "Include starting auto-inc values in new table files, and update in existing files"))
+	cmd.AddOption(mybase.BoolOption("strip-auto-inc", 0, false, "Remove auto-inc values from all table files, including existing files"))
 	cmd.AddOption(mybase.BoolOption("format", 0, true, "Reformat SQL statements to match canonical SHOW CREATE"))
 	cmd.AddOption(mybase.BoolOption("normalize", 0, true, "(deprecated alias for format)").Hidden())
 	cmd.AddOption(mybase.BoolOption("new-schemas", 0, true, "Detect any new schemas and populate new dirs for them"))
 	cmd.AddOption(mybase.BoolOption("update-partitioning", 0, false, "Update PARTITION BY clauses in existing table files"))
 	cmd.AddOption(mybase.BoolOption("strip-partitioning", 0, false, "Omit PARTITION BY clause when writing partitioned tables to filesystem"))
+	cmd.AddOption(mybase.BoolOption("strip-display-width", 0, false, "Remove integer display widths from column types in table files, as in MySQL 8.0.19+"))
+	cmd.AddOption(mybase.StringOption("order-file", 0, "", "Name of optional manifest file in each dir listing desired object order within multi-object .sql files"))
+	cmd.AddOption(mybase.BoolOption("preserve-clean-files", 0, false, "Never modify files whose objects are only cosmetically different from the database"))
+	cmd.AddOption(mybase.BoolOption("dry-run", 0, false, "Report which objects would be updated, including formatting-only rewrites, without modifying any files"))
 	workspace.AddCommandOptions(cmd)
 	cmd.AddArg("environment", "production", false)
 	CommandSuite.AddSubCommand(cmd)
@@ -44,6 +50,9 @@ func PullHandler(cfg *mybase.Config) error {
 	if err != nil {
 		return err
 	}
+	if dir.Config.GetBool("include-auto-inc") && dir.Config.GetBool("strip-auto-inc") {
+		return NewExitValue(CodeBadConfig, "Options include-auto-inc and strip-auto-inc cannot be used together")
+	}
 
 	// pullWalker returns the "worst" (highest) exit code it encounters. We care
 	// about the exit code, but not the error message, since any error will already
@@ -81,8 +90,10 @@ func pullWalker(dir *fs.Dir, maxDepth int) error {
 		// Otherwise, we're in a "flat" dir that defines both host and schema: update
 		// the flavor if needed and process the pull operation on *.sql files, but no
 		// need to look for new schemas with this layout
-		updateFlavor(dir, instance)
-		updateGenerator(dir)
+		if !dir.Config.GetBool("dry-run") {
+			updateFlavor(dir, instance)
+			updateGenerator(dir)
+		}
 		_, err := pullSchemaDir(dir, instance) // already logs err (if non-nil)
 		return err
 	}
@@ -114,8 +125,10 @@ func pullWalker(dir *fs.Dir, maxDepth int) error {
 	}
 
 	if instance != nil {
-		updateFlavor(dir, instance)
-		updateGenerator(dir)
+		if !dir.Config.GetBool("dry-run") {
+			updateFlavor(dir, instance)
+			updateGenerator(dir)
+		}
 		if dir.Config.GetBool("new-schemas") && err == nil {
 			if err = findNewSchemas(dir, instance, allSchemaNames); err != nil {
 				log.Warnf("Unable to populate new schemas from %s: %s", dir, err)
@@ -167,7 +180,10 @@ func pullLogicalSchema(dir *fs.Dir, instance *tengo.Instance, logicalSchema *fs.
 		return
 	}
 	instSchema, err := instance.Schema(schemaNames[0])
-	if err == sql.ErrNoRows {
+	if err == sql.ErrNoRows && dir.Config.GetBool("dry-run") {
+		log.Infof("Dry run: directory %s would be deleted -- schema %s no longer exists\n", dir, schemaNames[0])
+		return nil, nil
+	} else if err == sql.ErrNoRows {
 		log.Infof("Deleted directory %s -- schema %s no longer exists\n", dir, schemaNames[0])
 		return nil, dir.Delete()
 	} else if err != nil {
@@ -175,25 +191,26 @@ func pullLogicalSchema(dir *fs.Dir, instance *tengo.Instance, logicalSchema *fs.
 	}
 	instSchema.StripMatches(dir.IgnorePatterns)
 
+	if dir.Config.GetBool("dry-run") {
+		return schemaNames, reportPull(dir, instance, instSchema, logicalSchema)
+	}
+
 	log.Infof("Updating %s to reflect %s %s", dir, instance, instSchema.Name)
 
-	// Handle changes in schema's default character set and/or collation by
-	// persisting changes to the dir's option file.
+	// Handle changes in schema's default character set, collation, and/or
+	// comment by persisting changes to the dir's option file.
 	if err := updateCharSetCollation(dir, instSchema); err != nil {
 		return nil, err
 	}
-
-	dumpOpts := dumper.Options{
-		IncludeAutoInc: dir.Config.GetBool("include-auto-inc"),
+	if err := updateSchemaComment(dir, instSchema); err != nil {
+		return nil, err
 	}
-	if !dir.Config.GetBool("update-partitioning") {
-		if dir.Config.GetBool("strip-partitioning") {
-			// Undocumented due to potential confusion, but supported just like in init
-			dumpOpts.Partitioning = tengo.PartitioningRemove
-		} else {
-			// Without --update-partitioning, retain whatever partitioning clause (or
-			// lack of clause) was already present in the *.sql files for existing tables.
-			dumpOpts.Partitioning = tengo.PartitioningKeep
+
+	warnFileNameCollisions(dir, instSchema, logicalSchema.Creates)
+	dumpOpts := dumpOptionsForPull(dir.Config)
+	if orderFile := dir.Config.Get("order-file"); orderFile != "" {
+		if dumpOpts.ObjectOrder, err = dir.ObjectOrder(orderFile); err != nil {
+			return nil, NewExitValue(CodeBadConfig, err.Error())
 		}
 	}
 
@@ -214,6 +231,11 @@ func pullLogicalSchema(dir *fs.Dir, instance *tengo.Instance, logicalSchema *fs.
 		dumpOpts.OnlyKeys(inDiff)
 	}
 
+	if dir.Config.GetBool("preserve-clean-files") {
+		for _, sqlFile := range dir.SQLFiles {
+			sqlFile.PreserveClean = true
+		}
+	}
 	_, err = dumper.DumpSchema(instSchema, dir, dumpOpts)
 	if err == nil {
 		os.Stderr.WriteString("\n")
@@ -221,6 +243,83 @@ func pullLogicalSchema(dir *fs.Dir, instance *tengo.Instance, logicalSchema *fs.
 	return
 }
 
+// reportPull logs what pull would change in dir's *.sql files for
+// logicalSchema, without modifying anything. Each existing object which would be
+// rewritten is classified by whether it has functional differences, or only
+// differs in formatting from the canonical form on instance.
+func reportPull(dir *fs.Dir, instance *tengo.Instance, instSchema *tengo.Schema, logicalSchema *fs.LogicalSchema) error {
+	mods := statementModifiersForPull(dir.Config, instance)
+	opts, err := workspace.OptionsForDir(dir, instance)
+	if err != nil {
+		return NewExitValue(CodeBadConfig, err.Error())
+	}
+	inDiff, err := objectsInDiff(logicalSchema, instSchema, opts, mods)
+	if err != nil {
+		return err
+	}
+	changed := make(map[tengo.ObjectKey]bool, len(inDiff))
+	for _, key := range inDiff {
+		changed[key] = true
+	}
+
+	log.Infof("Dry run: examining %s against %s %s", dir, instance, instSchema.Name)
+	format := dir.Config.GetBool("format") && dir.Config.GetBool("normalize")
+	var formatOnly int
+	for _, key := range dumper.RewrittenKeys(instSchema, dir, dumpOptionsForPull(dir.Config)) {
+		if changed[key] {
+			log.Infof("%s would be updated: differs functionally from %s", key, instance)
+		} else if format {
+			log.Infof("%s would be reformatted: functionally identical to %s, but not in canonical format", key, instance)
+			formatOnly++
+		} else {
+			log.Infof("%s is not in canonical format, but would be left as-is due to --skip-format", key)
+			formatOnly++
+		}
+	}
+	var messages []string
+	instObjects := instSchema.Objects()
+	for key := range instObjects {
+		if logicalSchema.Creates[key] == nil {
+			messages = append(messages, key.String()+" would be added")
+		}
+	}
+	for key := range logicalSchema.Creates {
+		if instObjects[key] == nil {
+			messages = append(messages, key.String()+" would be removed")
+		}
+	}
+	sort.Strings(messages)
+	for _, msg := range messages {
+		log.Info(msg)
+	}
+	if formatOnly > 0 {
+		log.Infof("%s: %d object(s) have formatting-only differences", dir, formatOnly)
+	}
+	os.Stderr.WriteString("\n")
+	return nil
+}
+
+// dumpOptionsForPull returns dumper options reflecting the pull-related options
+// in config.
+func dumpOptionsForPull(config *mybase.Config) dumper.Options {
+	dumpOpts := dumper.Options{
+		IncludeAutoInc:    config.GetBool("include-auto-inc"),
+		StripAutoInc:      config.GetBool("strip-auto-inc"),
+		StripDisplayWidth: config.GetBool("strip-display-width"),
+	}
+	if !config.GetBool("update-partitioning") {
+		if config.GetBool("strip-partitioning") {
+			// Undocumented due to potential confusion, but supported just like in init
+			dumpOpts.Partitioning = tengo.PartitioningRemove
+		} else {
+			// Without --update-partitioning, retain whatever partitioning clause (or
+			// lack of clause) was already present in the *.sql files for existing tables.
+			dumpOpts.Partitioning = tengo.PartitioningKeep
+		}
+	}
+	return dumpOpts
+}
+
 func statementModifiersForPull(config *mybase.Config, instance *tengo.Instance) tengo.StatementModifiers {
 	// We're permissive of unsafe operations here since we don't ever actually
 	// execute the generated statement! We just examine its type.
@@ -228,8 +327,11 @@ func statementModifiersForPull(config *mybase.Config, instance *tengo.Instance)
 		AllowUnsafe: true,
 	}
 	// pull command updates next auto-increment value for existing table always
-	// if requested, or only if previously present in file otherwise
-	if config.GetBool("include-auto-inc") {
+	// if requested, or only if previously present in file otherwise. With
+	// --strip-auto-inc, auto-increment values are never considered.
+	if config.GetBool("strip-auto-inc") {
+		mods.NextAutoInc = tengo.NextAutoIncIgnore
+	} else if config.GetBool("include-auto-inc") {
 		mods.NextAutoInc = tengo.NextAutoIncAlways
 	} else {
 		mods.NextAutoInc = tengo.NextAutoIncIfAlready
@@ -332,6 +434,24 @@ func updateCharSetCollation(dir *fs.Dir, instSchema *tengo.Schema) error {
 	return nil
 }
 
+// updateSchemaComment updates the dir's .skeema option file if the schema's
+// current comment does not match what's in the file.
+func updateSchemaComment(dir *fs.Dir, instSchema *tengo.Schema) error {
+	if dir.Config.Get("schema-comment") == instSchema.Comment {
+		return nil
+	}
+	if instSchema.Comment == "" {
+		dir.OptionFile.UnsetOptionValue("", "schema-comment")
+	} else {
+		dir.OptionFile.SetOptionValue("", "schema-comment", instSchema.Comment)
+	}
+	if err := dir.OptionFile.Write(true); err != nil {
+		return fmt.Errorf("Unable to update schema comment for %s: %s", dir.OptionFile.Path(), err)
+	}
+	log.Infof("Wrote %s -- updated schema-level schema-comment", dir.OptionFile.Path())
+	return nil
+}
+
 func findNewSchemas(dir *fs.Dir, instance *tengo.Instance, seenNames []string) error {
 	subdirHasSchema := make(map[string]bool)
 	for _, name := range seenNames {
@@ -345,6 +465,10 @@ func findNewSchemas(dir *fs.Dir, instance *tengo.Instance, seenNames []string) e
 	for _, name := range schemaNames {
 		// If no existing subdir maps to the schema, we need to create and populate new dir
 		if !subdirHasSchema[name] {
+			if dir.Config.GetBool("dry-run") {
+				log.Infof("Dry run: new schema %s would be populated in a new subdir of %s", name, dir)
+				continue
+			}
 			s, err := instance.Schema(name)
 			if err != nil {
 				return err
@@ -38,6 +38,10 @@ func init() {
 		mybase.StringOption("alter-lock", 0, "", `Apply a LOCK clause to all ALTER TABLEs (valid values: "none", "shared", "exclusive")`),
 		mybase.StringOption("alter-algorithm", 0, "", `Apply an ALGORITHM clause to all ALTER TABLEs (valid values: "inplace", "copy", "instant", "nocopy")`),
 		mybase.StringOption("partitioning", 0, "keep", `Specify handling of partitioning status on the database side (valid values: "keep", "remove", "modify")`),
+		mybase.BoolOption("order-drops", 0, false, "Run DROP TABLEs last, dropping tables before any tables they reference via foreign keys"),
+		mybase.BoolOption("ddl-comments", 0, false, "Precede each ALTER TABLE with SQL comments describing the reason for each change"),
+		mybase.BoolOption("unvalidated-foreign-keys", 0, false, "Add foreign keys to existing tables in-place, without validating existing rows, where supported"),
+		mybase.StringOption("force-rebuild", 0, "", "Rebuild existing tables matching this regex using ALTER TABLE ... FORCE, even if they have no differences"),
 	)
 
 	cmd.AddOptions("External tool",
@@ -57,6 +61,13 @@ func init() {
 		mybase.BoolOption("dry-run", 0, false, "Output DDL but don't run it; equivalent to `skeema diff`"),
 		mybase.BoolOption("foreign-key-checks", 0, false, "Force the server to check referential integrity of any new foreign key"),
 		mybase.StringOption("safe-below-size", 0, "0", "Always permit destructive operations for tables below this size in bytes"),
+		mybase.StringOption("statement-timeout", 0, "0", "Cancel any DDL statement running longer than this duration (e.g. 30s or 5m); 0 for no limit"),
+		mybase.BoolOption("rename-drops", 0, false, "Instead of DROP TABLE, rename dropped tables using rename-drops-prefix and a timestamp, retaining their data"),
+		mybase.StringOption("rename-drops-retention", 0, "0", "With --rename-drops, drop previously-renamed tables once they are older than this duration (e.g. 720h); 0 to retain them indefinitely"),
+		mybase.BoolOption("continue-after-timeout", 0, false, "After a statement-timeout, continue with the remaining DDL for the same schema"),
+		mybase.BoolOption("pause-between-risk-tiers", 0, false, "Prompt for confirmation before running rebuild-class, and then destructive, DDL"),
+		mybase.BoolOption("yes", 0, false, "Automatically confirm any prompts from pause-between-risk-tiers; required if STDIN is not a TTY"),
+		mybase.StringOption("statement-log", 0, "", "Append each executed statement, its timing, and its outcome to this file as JSON lines"),
 	)
 
 	cmd.AddOptions("sharding",
@@ -101,6 +112,14 @@ func PushHandler(cfg *mybase.Config) error {
 	}
 	printer := applier.NewPrinter(dir.Config)
 
+	var stmtLog *applier.StatementLog
+	if logPath := dir.Config.Get("statement-log"); logPath != "" && !dir.Config.GetBool("dry-run") {
+		if stmtLog, err = applier.OpenStatementLog(logPath); err != nil {
+			return NewExitValue(CodeCantCreate, "Unable to open statement-log: %s", err)
+		}
+		defer stmtLog.Close()
+	}
+
 	g, ctx := errgroup.WithContext(context.Background())
 	g.SetLimit(concurrency)
 	groups, skipCount := applier.TargetGroupsForDir(dir)
@@ -116,6 +135,7 @@ func PushHandler(cfg *mybase.Config) error {
 				case <-ctx.Done():
 					return nil // Exit early if context cancelled
 				default:
+					t.StatementLog = stmtLog
 					result, err := applier.ApplyTarget(t, printer)
 					if err != nil {
 						return err
@@ -4,8 +4,10 @@ package applier
 
 import (
 	"fmt"
+	"time"
 
 	log "github.com/sirupsen/logrus"
+	"github.com/skeema/mybase"
 	"github.com/skeema/skeema/internal/fs"
 	"github.com/skeema/skeema/internal/linter"
 	"github.com/skeema/skeema/internal/tengo"
@@ -84,6 +86,8 @@ func ApplyTarget(t *Target, printer Printer) (Result, error) {
 		return result, ConfigError(err.Error())
 	}
 	mods.Flavor = t.Instance.Flavor()
+	mods.ImplicitTSDefaults = !t.Instance.ExplicitDefaultsForTimestamp()
+	mods.InnoDBPageSize = t.Instance.InnoDBPageSize()
 	if mods.Partitioning == tengo.PartitioningRemove {
 		// With partitioning=remove, forcibly treat all filesystem definitions as if
 		// they didn't have a partitioning clause. This is designed to aid in the
@@ -97,11 +101,38 @@ func ApplyTarget(t *Target, printer Printer) (Result, error) {
 	if err := VerifyDiff(diff, t); err != nil {
 		return result, err
 	}
+	if t.Dir.Config.GetBool("order-drops") {
+		diff.OrderDrops()
+	}
 
 	// Build PlannedStatement for each ObjectDiff, handling pre-execution errors
 	// accordingly. Also track ObjectKeys for modified objects, for subsequent
 	// use in linting.
 	objDiffs := diff.ObjectDiffs()
+	rebuilds, err := rebuildTableDiffs(schemaFromInstance, schemaFromDir, t.Dir.Config)
+	if err != nil {
+		return result, err
+	}
+	objDiffs = append(objDiffs, rebuilds...)
+	droppedTables, err := t.droppedTablesFromInstance(mods.DropTableRenamePrefix)
+	if err != nil {
+		result.SkipCount += len(objDiffs)
+		log.Errorf("Skipping %s schema %s for %s: %s\n", t.Instance, t.SchemaName, t.Dir, err)
+		return result, err
+	}
+	if err := checkDroppedTableNames(objDiffs, mods, schemaFromInstance, droppedTables); err != nil {
+		result.SkipCount += len(objDiffs)
+		log.Errorf("Skipping %s schema %s for %s: %s\n", t.Instance, t.SchemaName, t.Dir, err)
+		return result, nil
+	}
+	if mods.DropTableRenameRetain > 0 {
+		// Previously-renamed tables are ignored by the diff, so generate their
+		// drops here; Statement returns a blank statement for any which are still
+		// within the retention period.
+		for _, table := range droppedTables {
+			objDiffs = append(objDiffs, tengo.NewDropTable(table))
+		}
+	}
 	stmts := make([]PlannedStatement, 0, len(objDiffs))
 	keys := make([]tengo.ObjectKey, 0, len(objDiffs))
 	for _, objDiff := range objDiffs {
@@ -113,6 +144,11 @@ func ApplyTarget(t *Target, printer Printer) (Result, error) {
 		if err == nil {
 			stmts = append(stmts, ddl)
 			keys = append(keys, objDiff.ObjectKey())
+			if td, ok := objDiff.(*tengo.TableDiff); ok {
+				for _, warning := range td.Warnings(mods) {
+					log.Warnf("%s: %s", td.ObjectKey(), warning)
+				}
+			}
 		} else if unsupportedErr, ok := err.(*tengo.UnsupportedDiffError); ok {
 			result.UnsupportedCount++
 			log.Warnf("Skipping %s: Skeema does not support generating a diff of this table. Use --debug to see which properties of this table are not supported.", unsupportedErr.ObjectKey)
@@ -138,6 +174,7 @@ func ApplyTarget(t *Target, printer Printer) (Result, error) {
 			return result, ConfigError(err.Error())
 		}
 		lintOpts.OnlyKeys(keys)
+		lintOpts.SQLMode = t.Instance.SQLMode()
 		lintOpts.StripAnnotationNewlines = !util.StderrIsTerminal()
 		lintResult := linter.CheckSchema(t.DesiredSchema, lintOpts)
 		lintResult.SortByFile()
@@ -157,6 +194,28 @@ func ApplyTarget(t *Target, printer Printer) (Result, error) {
 	return result, nil
 }
 
+// rebuildTableDiffs returns an ALTER TABLE ... FORCE diff for each table whose
+// name matches the force-rebuild option, if any. Only tables which exist both
+// in from and to are rebuilt, using from's definition. Rebuilds are intended to
+// run after any other changes, so they are returned separately from the
+// schema diff.
+func rebuildTableDiffs(from, to *tengo.Schema, config *mybase.Config) ([]tengo.ObjectDiff, error) {
+	re, err := config.GetRegexp("force-rebuild")
+	if err != nil {
+		return nil, ConfigError(err.Error())
+	} else if re == nil || from == nil || to == nil {
+		return nil, nil
+	}
+	toTables := to.TablesByName()
+	var result []tengo.ObjectDiff
+	for _, table := range from.Tables {
+		if _, stillExists := toTables[table.Name]; stillExists && re.MatchString(table.Name) {
+			result = append(result, tengo.NewRebuildTable(table))
+		}
+	}
+	return result, nil
+}
+
 func stripPartitionClauses(tables []*tengo.Table, flavor tengo.Flavor) {
 	for _, table := range tables {
 		if table.Partitioning != nil {
@@ -181,6 +240,54 @@ func countAndNoun(n int, nouns ...string) string {
 	return fmt.Sprintf("%d %s", n, plural)
 }
 
+// checkDroppedTableNames returns an error if rename-drops would rename a table
+// to the name of another existing table, or rename multiple tables to the same
+// name. This is possible when DroppedTableName truncates long table names.
+// droppedTables should contain previously-renamed tables, which are excluded
+// from schemaFromInstance.
+func checkDroppedTableNames(objDiffs []tengo.ObjectDiff, mods tengo.StatementModifiers, schemaFromInstance *tengo.Schema, droppedTables []*tengo.Table) error {
+	if mods.DropTableRenamePrefix == "" {
+		return nil
+	}
+	existing := make(map[string]bool, len(droppedTables))
+	for _, table := range droppedTables {
+		existing[table.Name] = true
+	}
+	renamedFrom := make(map[string]string)
+	for _, objDiff := range objDiffs {
+		td, ok := objDiff.(*tengo.TableDiff)
+		if !ok {
+			continue
+		}
+		newName := td.DroppedTableName(mods)
+		if newName == "" {
+			continue
+		}
+		if existing[newName] || schemaFromInstance.HasTable(newName) {
+			return fmt.Errorf("Option rename-drops cannot rename table %s to %s, since a table with that name already exists", tengo.EscapeIdentifier(td.From.Name), tengo.EscapeIdentifier(newName))
+		} else if other, already := renamedFrom[newName]; already {
+			return fmt.Errorf("Option rename-drops cannot rename both tables %s and %s to %s", tengo.EscapeIdentifier(other), tengo.EscapeIdentifier(td.From.Name), tengo.EscapeIdentifier(newName))
+		}
+		renamedFrom[newName] = td.From.Name
+	}
+	return nil
+}
+
+// getRenameDropsRetention parses the rename-drops-retention option, returning
+// how long tables renamed by rename-drops should be retained before being
+// dropped. A zero value means such tables are never dropped.
+func getRenameDropsRetention(config *mybase.Config) (time.Duration, error) {
+	value := config.Get("rename-drops-retention")
+	if value == "" || value == "0" {
+		return 0, nil
+	}
+	retention, err := time.ParseDuration(value)
+	if err != nil || retention < 0 {
+		return 0, fmt.Errorf("Option rename-drops-retention must be a non-negative duration, such as 168h; instead found %q", value)
+	}
+	return retention, nil
+}
+
 // StatementModifiersForDir returns a set of DDL modifiers, based on the
 // directory's configuration.
 func StatementModifiersForDir(dir *fs.Dir) (mods tengo.StatementModifiers, err error) {
@@ -188,6 +295,18 @@ func StatementModifiersForDir(dir *fs.Dir) (mods tengo.StatementModifiers, err e
 	mods.AllowUnsafe = dir.Config.GetBool("allow-unsafe")
 	mods.CompareMetadata = dir.Config.GetBool("compare-metadata")
 	mods.VirtualColValidation = dir.Config.GetBool("alter-validate-virtual")
+	if mods.UnvalidatedForeignKeys = dir.Config.GetBool("unvalidated-foreign-keys"); mods.UnvalidatedForeignKeys && dir.Config.GetBool("foreign-key-checks") {
+		return mods, ConfigError("Options unvalidated-foreign-keys and foreign-key-checks cannot be used together")
+	}
+	if dir.Config.GetBool("rename-drops") {
+		if mods.DropTableRenamePrefix = dir.Config.Get("rename-drops-prefix"); mods.DropTableRenamePrefix == "" {
+			return mods, ConfigError("Option rename-drops-prefix cannot be empty when rename-drops is enabled")
+		}
+		mods.DropTableRenameTime = time.Now()
+		if mods.DropTableRenameRetain, err = getRenameDropsRetention(dir.Config); err != nil {
+			return mods, ConfigError(err.Error())
+		}
+	}
 	if dir.Config.GetBool("exact-match") {
 		mods.StrictIndexOrder = true
 		mods.StrictCheckOrder = true // only affects MariaDB
@@ -3,8 +3,11 @@ package applier
 import (
 	"fmt"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/skeema/mybase"
 	"github.com/skeema/skeema/internal/tengo"
 	"github.com/skeema/skeema/internal/util"
 	"golang.org/x/sync/errgroup"
@@ -40,6 +43,107 @@ func TestResultMerge(t *testing.T) {
 	}
 }
 
+func TestRebuildTableDiffs(t *testing.T) {
+	from := &tengo.Schema{Name: "foo", Tables: []*tengo.Table{
+		{Name: "a", Engine: "InnoDB"},
+		{Name: "actor", Engine: "InnoDB"},
+		{Name: "actress", Engine: "InnoDB"},
+	}}
+	to := &tengo.Schema{Name: "foo", Tables: from.Tables[0:2]}
+
+	cfg := mybase.SimpleConfig(map[string]string{"force-rebuild": "^act"})
+	diffs, err := rebuildTableDiffs(from, to, cfg)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	} else if len(diffs) != 1 {
+		t.Fatalf("Expected 1 diff, instead found %d", len(diffs))
+	}
+	if key := diffs[0].ObjectKey(); key.Name != "actor" {
+		t.Errorf("Expected rebuild of actor, instead found %s", key)
+	}
+	if stmt, err := diffs[0].Statement(tengo.StatementModifiers{}); err != nil || stmt != "ALTER TABLE `actor` FORCE" {
+		t.Errorf("Unexpected statement %q / %v", stmt, err)
+	}
+
+	// Rebuilding of existing tables only occurs if the option is set; schemas
+	// which don't exist yet are never rebuilt
+	cfg = mybase.SimpleConfig(map[string]string{"force-rebuild": ""})
+	if diffs, err := rebuildTableDiffs(from, to, cfg); err != nil || len(diffs) != 0 {
+		t.Errorf("Expected no diffs or error with empty force-rebuild, instead found %d diffs, err=%v", len(diffs), err)
+	}
+	cfg = mybase.SimpleConfig(map[string]string{"force-rebuild": ".*"})
+	if diffs, err := rebuildTableDiffs(nil, to, cfg); err != nil || len(diffs) != 0 {
+		t.Errorf("Expected no diffs or error with nil from schema, instead found %d diffs, err=%v", len(diffs), err)
+	}
+
+	cfg = mybase.SimpleConfig(map[string]string{"force-rebuild": "[invalid"})
+	if _, err := rebuildTableDiffs(from, to, cfg); err == nil {
+		t.Error("Expected error from invalid regex, but err was nil")
+	}
+}
+
+func TestCheckDroppedTableNames(t *testing.T) {
+	longName := strings.Repeat("x", 60)
+	schema := &tengo.Schema{Name: "foo", Tables: []*tengo.Table{
+		{Name: "actor", Engine: "InnoDB"},
+		{Name: longName + "1", Engine: "InnoDB"},
+		{Name: longName + "2", Engine: "InnoDB"},
+		{Name: "film", Engine: "InnoDB"},
+	}}
+	// Previously-renamed tables are excluded from the instance schema by the
+	// dir's IgnorePatterns, so they're supplied separately
+	droppedTables := []*tengo.Table{{Name: "_dropped_film_20230405060708", Engine: "InnoDB"}}
+	mods := tengo.StatementModifiers{
+		DropTableRenamePrefix: "_dropped_",
+		DropTableRenameTime:   time.Date(2023, 4, 5, 6, 7, 8, 0, time.UTC),
+	}
+	dropDiffs := func(tables ...*tengo.Table) (objDiffs []tengo.ObjectDiff) {
+		for _, table := range tables {
+			objDiffs = append(objDiffs, tengo.NewDropTable(table))
+		}
+		return objDiffs
+	}
+
+	// No problem dropping actor, or with several drops if rename-drops not in use
+	if err := checkDroppedTableNames(dropDiffs(schema.Tables[0]), mods, schema, droppedTables); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if err := checkDroppedTableNames(dropDiffs(schema.Tables...), tengo.StatementModifiers{}, schema, droppedTables); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	// Truncation of long names causes collision between the two renames
+	if err := checkDroppedTableNames(dropDiffs(schema.Tables[1:3]...), mods, schema, droppedTables); err == nil {
+		t.Error("Expected error from colliding renames, but err was nil")
+	}
+
+	// Renaming film would collide with an existing table
+	if err := checkDroppedTableNames(dropDiffs(schema.Tables[3]), mods, schema, droppedTables); err == nil {
+		t.Error("Expected error from rename to existing table name, but err was nil")
+	}
+}
+
+func TestGetRenameDropsRetention(t *testing.T) {
+	cases := map[string]time.Duration{
+		"":     0,
+		"0":    0,
+		"720h": 720 * time.Hour,
+		"90m":  90 * time.Minute,
+	}
+	for value, expected := range cases {
+		cfg := mybase.SimpleConfig(map[string]string{"rename-drops-retention": value})
+		if actual, err := getRenameDropsRetention(cfg); err != nil || actual != expected {
+			t.Errorf("For rename-drops-retention=%q, expected %s, instead found %s / %v", value, expected, actual, err)
+		}
+	}
+	for _, value := range []string{"-1h", "30", "7 days"} {
+		cfg := mybase.SimpleConfig(map[string]string{"rename-drops-retention": value})
+		if _, err := getRenameDropsRetention(cfg); err == nil {
+			t.Errorf("For rename-drops-retention=%q, expected an error, but err was nil", value)
+		}
+	}
+}
+
 func TestIntegration(t *testing.T) {
 	images := tengo.SplitEnv("SKEEMA_TEST_IMAGES")
 	if len(images) == 0 {
@@ -1,11 +1,15 @@
 package applier
 
 import (
+	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 	"os"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/skeema/mybase"
@@ -13,6 +17,11 @@ import (
 	"github.com/skeema/skeema/internal/util"
 )
 
+// warnedStrippedConnectOptions tracks which connect-options names have already
+// been warned about being omitted from {CONNOPTS}, to avoid repeating the
+// warning for every statement.
+var warnedStrippedConnectOptions sync.Map
+
 // DDLStatement represents a DDL SQL statement (CREATE TABLE, ALTER TABLE, etc).
 // It may represent an external command to shell out to, or a DDL statement to
 // run directly against a DB.
@@ -20,10 +29,28 @@ type DDLStatement struct {
 	stmt     string
 	compound bool
 	shellOut *util.ShellOut
+	comments []string // descriptions of the changes, only if ddl-comments enabled
+	notes    []string // follow-up notes to log after successful execution
+	risk     tengo.RiskLevel
+	key      tengo.ObjectKey
+	file     string // file defining the object, if any
 
 	instance      *tengo.Instance
 	schemaName    string
 	connectParams string
+	timeout       time.Duration // if > 0, cancel statement after this amount of time
+	rowsAffected  int64
+}
+
+// StatementTimeoutError is returned by DDLStatement.Execute if the statement
+// was cancelled for exceeding the duration of the statement-timeout option.
+type StatementTimeoutError struct {
+	Timeout time.Duration
+}
+
+// Error satisfies the builtin error interface.
+func (ste StatementTimeoutError) Error() string {
+	return fmt.Sprintf("statement timed out after %s and was cancelled", ste.Timeout)
 }
 
 // NewDDLStatement creates and returns a DDLStatement. If the statement ends up
@@ -35,8 +62,16 @@ func NewDDLStatement(diff tengo.ObjectDiff, mods tengo.StatementModifiers, targe
 	ddl = &DDLStatement{
 		instance:   target.Instance,
 		schemaName: target.SchemaName,
+		key:        diff.ObjectKey(),
+	}
+	if target.DesiredSchema != nil && target.DesiredSchema.LogicalSchema != nil {
+		if stmt := target.DesiredSchema.LogicalSchema.Creates[ddl.key]; stmt != nil {
+			ddl.file = stmt.File
+		}
 	}
 
+	ddl.risk = diffRisk(diff)
+
 	// Don't run database-level DDL in a schema; not even possible for CREATE
 	// DATABASE anyway
 	if diff.ObjectKey().Type == tengo.ObjectTypeDatabase {
@@ -81,6 +116,19 @@ func NewDDLStatement(diff tengo.ObjectDiff, mods tengo.StatementModifiers, targe
 		return nil, nil
 	}
 
+	// Optionally document the reason for each ALTER TABLE in SQL comments
+	if td, ok := diff.(*tengo.TableDiff); ok && td.DiffType() == tengo.DiffTypeAlter && target.Dir.Config.GetBool("ddl-comments") {
+		ddl.comments = td.Explain(mods)
+	}
+
+	// If foreign keys are being added without validating existing rows, suggest
+	// a query for validating them separately after execution
+	if td, ok := diff.(*tengo.TableDiff); ok && mods.UnvalidatedForeignKeys {
+		for _, fk := range td.AddedForeignKeys() {
+			ddl.notes = append(ddl.notes, fmt.Sprintf("Foreign key %s was added to table %s without validating existing rows. To check for rows violating it, run:\n%s", tengo.EscapeIdentifier(fk.Name), tengo.EscapeIdentifier(td.To.Name), foreignKeyValidationQuery(td.To.Name, fk)))
+		}
+	}
+
 	// Determine if the statement is a compound statement, requiring special
 	// delimiter handling in output. Only stored program diffs (e.g. procs, funcs)
 	// implement this interface; others never generate compound statements.
@@ -90,6 +138,9 @@ func NewDDLStatement(diff tengo.ObjectDiff, mods tengo.StatementModifiers, targe
 
 	if wrapper == "" {
 		ddl.connectParams = getConnectParams(diff, target.Dir.Config)
+		if ddl.timeout, err = getStatementTimeout(target.Dir.Config); err != nil {
+			return nil, ConfigError(err.Error())
+		}
 	} else {
 		var socket, port, connOpts string
 		if ddl.instance.SocketPath != "" {
@@ -97,7 +148,17 @@ func NewDDLStatement(diff tengo.ObjectDiff, mods tengo.StatementModifiers, targe
 		} else {
 			port = strconv.Itoa(ddl.instance.Port)
 		}
-		if connOpts, err = util.RealConnectOptions(target.Dir.Config.Get("connect-options")); err != nil {
+		var stripped []string
+		if connOpts, stripped, err = util.RealConnectOptionsStripped(target.Dir.Config.Get("connect-options")); err != nil {
+			return nil, ConfigError(err.Error())
+		}
+		for _, name := range stripped {
+			if _, already := warnedStrippedConnectOptions.LoadOrStore(name, true); !already {
+				log.Warnf("Ignoring connect-options %s for external commands, since this option is managed by Skeema's own connections", name)
+			}
+		}
+		password, err := util.ConfiguredPassword(target.Dir.Config)
+		if err != nil {
 			return nil, ConfigError(err.Error())
 		}
 		variables := map[string]string{
@@ -106,7 +167,7 @@ func NewDDLStatement(diff tengo.ObjectDiff, mods tengo.StatementModifiers, targe
 			"SOCKET":      socket,
 			"SCHEMA":      ddl.schemaName,
 			"USER":        target.Dir.Config.GetAllowEnvVar("user"),
-			"PASSWORD":    target.Dir.Config.GetAllowEnvVar("password"),
+			"PASSWORD":    password,
 			"ENVIRONMENT": target.Dir.Config.Get("environment"),
 			"DDL":         ddl.stmt,
 			"CLAUSES":     "", // filled in below only for tables
@@ -207,6 +268,26 @@ func getWrapper(config *mybase.Config, diff tengo.ObjectDiff, tableSize int64, m
 	return wrapper, nil
 }
 
+// getStatementTimeout returns the value of the statement-timeout option. A
+// value without a unit is interpreted as a number of seconds. A zero value
+// means statements have no time limit. This timeout is separate from any
+// connection-level timeouts in connect-options: it applies to the total
+// execution time of each DDL statement run directly by Skeema, but does not
+// apply to external commands from alter-wrapper or ddl-wrapper.
+func getStatementTimeout(config *mybase.Config) (time.Duration, error) {
+	value := config.Get("statement-timeout")
+	if value == "" {
+		return 0, nil
+	} else if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, nil
+	}
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout < 0 {
+		return 0, fmt.Errorf("option statement-timeout must be a non-negative duration, such as 30s or 5m; instead found %q", value)
+	}
+	return timeout, nil
+}
+
 // getConnectParams returns the necessary connection params (session variables)
 // for the supplied diff and config.
 func getConnectParams(diff tengo.ObjectDiff, config *mybase.Config) string {
@@ -215,11 +296,8 @@ func getConnectParams(diff tengo.ObjectDiff, config *mybase.Config) string {
 	// For ALTER TABLE, if requested, also use foreign_key_checks=1 if adding
 	// new foreign key constraints.
 	if td, ok := diff.(*tengo.TableDiff); ok && td.Type == tengo.DiffTypeAlter {
-		if config.GetBool("foreign-key-checks") {
-			_, addFKs := td.SplitAddForeignKeys()
-			if addFKs != nil {
-				return "readTimeout=0&foreign_key_checks=1"
-			}
+		if config.GetBool("foreign-key-checks") && addsForeignKeys(td) {
+			return "readTimeout=0&foreign_key_checks=1"
 		}
 		return "readTimeout=0"
 	} else if ok && td.Type == tengo.DiffTypeDrop {
@@ -228,6 +306,34 @@ func getConnectParams(diff tengo.ObjectDiff, config *mybase.Config) string {
 	return ""
 }
 
+// addsForeignKeys returns true if diff is an ALTER TABLE which adds one or more
+// foreign keys to a preexisting table.
+func addsForeignKeys(diff tengo.ObjectDiff) bool {
+	if td, ok := diff.(*tengo.TableDiff); ok && td.Type == tengo.DiffTypeAlter {
+		_, addFKs := td.SplitAddForeignKeys()
+		return addFKs != nil
+	}
+	return false
+}
+
+// foreignKeyValidationQuery returns a query which counts the rows of tableName
+// which violate fk. Rows with a NULL value in any column of fk are not
+// considered to be violations, matching the server's behavior.
+func foreignKeyValidationQuery(tableName string, fk *tengo.ForeignKey) string {
+	parentTable := tengo.EscapeIdentifier(fk.ReferencedTableName)
+	if fk.ReferencedSchemaName != "" {
+		parentTable = tengo.EscapeIdentifier(fk.ReferencedSchemaName) + "." + parentTable
+	}
+	joinConds := make([]string, len(fk.ColumnNames))
+	whereConds := make([]string, len(fk.ColumnNames), len(fk.ColumnNames)+1)
+	for n, col := range fk.ColumnNames {
+		joinConds[n] = fmt.Sprintf("c.%s = p.%s", tengo.EscapeIdentifier(col), tengo.EscapeIdentifier(fk.ReferencedColumnNames[n]))
+		whereConds[n] = fmt.Sprintf("c.%s IS NOT NULL", tengo.EscapeIdentifier(col))
+	}
+	whereConds = append(whereConds, fmt.Sprintf("p.%s IS NULL", tengo.EscapeIdentifier(fk.ReferencedColumnNames[0])))
+	return fmt.Sprintf("SELECT COUNT(*) FROM %s c LEFT JOIN %s p ON %s WHERE %s;", tengo.EscapeIdentifier(tableName), parentTable, strings.Join(joinConds, " AND "), strings.Join(whereConds, " AND "))
+}
+
 // Execute runs the DDL statement, either by running a SQL query against a DB,
 // or shelling out to an external program, as appropriate.
 func (ddl *DDLStatement) Execute() error {
@@ -238,18 +344,100 @@ func (ddl *DDLStatement) Execute() error {
 	if err != nil {
 		return err
 	}
-	_, err = db.Exec(ddl.stmt)
+	var result sql.Result
+	if ddl.timeout <= 0 {
+		if result, err = db.Exec(ddl.stmt); err == nil {
+			ddl.rowsAffected, _ = result.RowsAffected()
+		}
+		return err
+	}
+
+	// With a timeout, use a dedicated connection so that its ID is known. When
+	// the context is cancelled, the driver closes the connection, but the server
+	// may continue running the statement, so it must be killed explicitly.
+	ctx, cancel := context.WithTimeout(context.Background(), ddl.timeout)
+	defer cancel()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	var connID int64
+	if err := conn.QueryRowContext(ctx, "SELECT CONNECTION_ID()").Scan(&connID); err != nil {
+		return err
+	}
+	result, err = conn.ExecContext(ctx, ddl.stmt)
+	if ctx.Err() == context.DeadlineExceeded {
+		killDB, killErr := ddl.instance.CachedConnectionPool("", "")
+		if killErr == nil {
+			_, killErr = killDB.Exec(fmt.Sprintf("KILL QUERY %d", connID))
+		}
+		if killErr != nil {
+			log.Warnf("Unable to kill timed-out statement on %s (connection ID %d); it may still be running: %s", ddl.instance, connID, killErr)
+		}
+		return StatementTimeoutError{Timeout: ddl.timeout}
+	} else if err == nil {
+		ddl.rowsAffected, _ = result.RowsAffected()
+	}
 	return err
 }
 
 // Statement returns a string representation of ddl. If an external command is
 // in use, the returned string will be prefixed with "\!", the MySQL CLI command
-// shortcut for "system" shellout.
+// shortcut for "system" shellout. If the ddl-comments option was enabled, the
+// returned string will be preceded by one SQL comment line per change.
 func (ddl *DDLStatement) Statement() string {
+	var b strings.Builder
+	for _, comment := range ddl.comments {
+		b.WriteString("-- " + comment + "\n")
+	}
 	if ddl.shellOut != nil {
-		return "\\! " + ddl.shellOut.String()
+		b.WriteString("\\! " + ddl.shellOut.String())
+	} else {
+		b.WriteString(ddl.stmt)
 	}
-	return ddl.stmt
+	return b.String()
+}
+
+// Notes returns any follow-up notes which should be logged after the statement
+// is executed successfully.
+func (ddl *DDLStatement) Notes() []string {
+	return ddl.notes
+}
+
+// Risk returns the RiskLevel of the statement.
+func (ddl *DDLStatement) Risk() tengo.RiskLevel {
+	return ddl.risk
+}
+
+// diffRisk returns the RiskLevel of diff. Any DROP is considered
+// RiskDestructive. Other changes to objects besides tables are considered
+// RiskMetadata.
+func diffRisk(diff tengo.ObjectDiff) tengo.RiskLevel {
+	if td, ok := diff.(*tengo.TableDiff); ok {
+		return td.Risk()
+	} else if diff.DiffType() == tengo.DiffTypeDrop {
+		return tengo.RiskDestructive
+	}
+	return tengo.RiskMetadata
+}
+
+// ObjectKey returns the key of the object affected by the statement.
+func (ddl *DDLStatement) ObjectKey() tengo.ObjectKey {
+	return ddl.key
+}
+
+// File returns the path of the *.sql file defining the affected object, or an
+// empty string if the object is being dropped or is not defined in a file.
+func (ddl *DDLStatement) File() string {
+	return ddl.file
+}
+
+// RowsAffected returns the number of rows affected by the statement, as
+// reported by the server after a successful call to Execute. This is always 0
+// for statements which shell out to an external command.
+func (ddl *DDLStatement) RowsAffected() int64 {
+	return ddl.rowsAffected
 }
 
 // ClientState returns a representation of the client state which would be
@@ -6,6 +6,7 @@ import (
 	"runtime"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/skeema/mybase"
 	"github.com/skeema/skeema/internal/fs"
@@ -62,8 +63,10 @@ func (s ApplierIntegrationSuite) TestNewDDLStatement(t *testing.T) {
 		"alter-algorithm":        "inplace",
 		"alter-lock":             "none",
 		"safe-below-size":        "0",
+		"statement-timeout":      "0",
 		"connect-options":        "",
 		"environment":            "production",
+		"ddl-comments":           "",
 	}
 	if flavor.Matches(tengo.FlavorMySQL55) {
 		delete(configMap, "alter-algorithm")
@@ -171,3 +174,203 @@ func objectDiffExpected(t *testing.T, diff tengo.ObjectDiff, ddl *DDLStatement,
 	}
 	return
 }
+
+func TestGetWrapper(t *testing.T) {
+	from := &tengo.Table{Name: "foo", Engine: "InnoDB", Comment: "before"}
+	to := &tengo.Table{Name: "foo", Engine: "InnoDB", Comment: "after"}
+	to.CreateStatement = to.GeneratedCreateStatement(tengo.FlavorUnknown)
+	from.CreateStatement = from.GeneratedCreateStatement(tengo.FlavorUnknown)
+	alter := tengo.NewAlterTable(from, to)
+	drop := tengo.NewDropTable(from)
+
+	cfg := mybase.SimpleConfig(map[string]string{
+		"ddl-wrapper":            "ddl {TABLE}",
+		"alter-wrapper":          "osc --alter {CLAUSES} {SCHEMA}.{TABLE}",
+		"alter-wrapper-min-size": "10k",
+	})
+	cases := []struct {
+		diff      tengo.ObjectDiff
+		tableSize int64
+		expected  string
+		keepMods  bool
+	}{
+		{alter, 1024, "ddl {TABLE}", true},
+		{alter, 10240, "osc --alter {CLAUSES} {SCHEMA}.{TABLE}", false},
+		{alter, 1024 * 1024, "osc --alter {CLAUSES} {SCHEMA}.{TABLE}", false},
+		{drop, 1024 * 1024, "ddl {TABLE}", true},
+	}
+	for n, c := range cases {
+		mods := tengo.StatementModifiers{AlgorithmClause: "inplace", LockClause: "none"}
+		wrapper, err := getWrapper(cfg, c.diff, c.tableSize, &mods)
+		if err != nil {
+			t.Errorf("cases[%d]: Unexpected error from getWrapper: %v", n, err)
+		} else if wrapper != c.expected {
+			t.Errorf("cases[%d]: Expected wrapper %q, instead found %q", n, c.expected, wrapper)
+		}
+		// When the external OSC tool is used for a table above the min size,
+		// ALGORITHM and LOCK clauses should be stripped
+		if keptMods := (mods.AlgorithmClause != "" && mods.LockClause != ""); keptMods != c.keepMods {
+			t.Errorf("cases[%d]: Expected mods to be retained=%t, instead found %+v", n, c.keepMods, mods)
+		}
+	}
+
+	// Invalid min size should result in an error
+	cfg = mybase.SimpleConfig(map[string]string{
+		"ddl-wrapper":            "",
+		"alter-wrapper":          "osc {TABLE}",
+		"alter-wrapper-min-size": "invalid",
+	})
+	if _, err := getWrapper(cfg, alter, 0, &tengo.StatementModifiers{}); err == nil {
+		t.Error("Expected error from invalid alter-wrapper-min-size, but err was nil")
+	}
+}
+
+func TestGetStatementTimeout(t *testing.T) {
+	cases := map[string]time.Duration{
+		"":     0,
+		"0":    0,
+		"30":   30 * time.Second,
+		"1.5s": 1500 * time.Millisecond,
+		"5m":   5 * time.Minute,
+	}
+	for value, expected := range cases {
+		cfg := mybase.SimpleConfig(map[string]string{"statement-timeout": value})
+		if actual, err := getStatementTimeout(cfg); err != nil || actual != expected {
+			t.Errorf("For statement-timeout=%q, expected %s, instead found %s / %v", value, expected, actual, err)
+		}
+	}
+	for _, value := range []string{"-5s", "-1", "soon", "5 minutes"} {
+		cfg := mybase.SimpleConfig(map[string]string{"statement-timeout": value})
+		if _, err := getStatementTimeout(cfg); err == nil {
+			t.Errorf("For statement-timeout=%q, expected an error, but err was nil", value)
+		}
+	}
+}
+
+func (s ApplierIntegrationSuite) TestDDLStatementTimeout(t *testing.T) {
+	ddl := &DDLStatement{
+		stmt:     "DO SLEEP(20)",
+		instance: s.d[0].Instance,
+		timeout:  500 * time.Millisecond,
+	}
+	start := time.Now()
+	err := ddl.Execute()
+	if _, ok := err.(StatementTimeoutError); !ok {
+		t.Fatalf("Expected Execute to return StatementTimeoutError, instead found %T %v", err, err)
+	} else if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("Expected statement to be cancelled after timeout, but Execute took %s", elapsed)
+	}
+
+	// Confirm the statement was killed on the server side too
+	db, err := s.d[0].CachedConnectionPool("", "")
+	if err != nil {
+		t.Fatalf("Unable to connect to DockerizedInstance: %s", err)
+	}
+	var count int
+	for attempt := 0; attempt < 10; attempt++ {
+		if err := db.QueryRow("SELECT COUNT(*) FROM information_schema.processlist WHERE info = 'DO SLEEP(20)'").Scan(&count); err != nil {
+			t.Fatalf("Unexpected error querying processlist: %v", err)
+		} else if count == 0 {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if count > 0 {
+		t.Error("Expected timed-out statement to be killed, but it is still running")
+	}
+
+	// Without a timeout, a fast statement should be unaffected
+	ddl.stmt, ddl.timeout = "DO SLEEP(0.1)", 0
+	if err := ddl.Execute(); err != nil {
+		t.Errorf("Unexpected error from Execute: %v", err)
+	}
+	ddl.timeout = 5 * time.Second
+	if err := ddl.Execute(); err != nil {
+		t.Errorf("Unexpected error from Execute: %v", err)
+	}
+}
+
+func TestGetConnectParamsForeignKeys(t *testing.T) {
+	from := &tengo.Table{Name: "foo", Engine: "InnoDB"}
+	to := &tengo.Table{Name: "foo", Engine: "InnoDB", ForeignKeys: []*tengo.ForeignKey{
+		{Name: "foo_bar", ColumnNames: []string{"bar_id"}, ReferencedTableName: "bar", ReferencedColumnNames: []string{"id"}},
+	}}
+	comment := &tengo.Table{Name: "foo", Engine: "InnoDB", Comment: "hello"}
+	diffs := []tengo.ObjectDiff{
+		tengo.NewAlterTable(from, to),
+		tengo.NewAlterTable(from, comment),
+	}
+	cfg := mybase.SimpleConfig(map[string]string{"foreign-key-checks": ""})
+	if params := getConnectParams(diffs[0], cfg); params != "readTimeout=0" {
+		t.Errorf("Unexpected connect params %q", params)
+	}
+
+	cfg = mybase.SimpleConfig(map[string]string{"foreign-key-checks": "1"})
+	if params := getConnectParams(diffs[0], cfg); params != "readTimeout=0&foreign_key_checks=1" {
+		t.Errorf("Unexpected connect params %q", params)
+	}
+	if params := getConnectParams(diffs[1], cfg); params != "readTimeout=0" {
+		t.Errorf("Unexpected connect params %q", params)
+	}
+}
+
+func TestDDLStatementComments(t *testing.T) {
+	ddl := &DDLStatement{
+		stmt:     "ALTER TABLE `foo` MODIFY COLUMN `email` varchar(320) NOT NULL",
+		comments: []string{"modify column `email`: varchar(255) -> varchar(320)"},
+	}
+	expected := "-- modify column `email`: varchar(255) -> varchar(320)\nALTER TABLE `foo` MODIFY COLUMN `email` varchar(320) NOT NULL"
+	if actual := ddl.Statement(); actual != expected {
+		t.Errorf("Expected Statement():\n%s\nActual Statement():\n%s", expected, actual)
+	}
+	ddl.comments = nil
+	if actual := ddl.Statement(); actual != ddl.stmt {
+		t.Errorf("Expected Statement() without comments to be unchanged, instead found %q", actual)
+	}
+}
+
+func TestDiffRisk(t *testing.T) {
+	table := &tengo.Table{Name: "foo", Engine: "InnoDB"}
+	routine := &tengo.Routine{Name: "bar", Type: tengo.ObjectTypeProc}
+	cases := []struct {
+		diff     tengo.ObjectDiff
+		expected tengo.RiskLevel
+	}{
+		{tengo.NewCreateTable(table), tengo.RiskMetadata},
+		{tengo.NewDropTable(table), tengo.RiskDestructive},
+		{&tengo.RoutineDiff{To: routine}, tengo.RiskMetadata},
+		{&tengo.RoutineDiff{From: routine}, tengo.RiskDestructive},
+	}
+	for _, c := range cases {
+		if actual := diffRisk(c.diff); actual != c.expected {
+			t.Errorf("Expected diffRisk for %s of %s to return %s, instead found %s", c.diff.DiffType(), c.diff.ObjectKey(), c.expected, actual)
+		}
+	}
+}
+
+func TestForeignKeyValidationQuery(t *testing.T) {
+	fk := &tengo.ForeignKey{
+		Name:                  "fk_product",
+		ColumnNames:           []string{"product_line", "model"},
+		ReferencedSchemaName:  "purchasing",
+		ReferencedTableName:   "products",
+		ReferencedColumnNames: []string{"line", "model"},
+	}
+	expected := "SELECT COUNT(*) FROM `orders` c LEFT JOIN `purchasing`.`products` p ON c.`product_line` = p.`line` AND c.`model` = p.`model` WHERE c.`product_line` IS NOT NULL AND c.`model` IS NOT NULL AND p.`line` IS NULL;"
+	if actual := foreignKeyValidationQuery("orders", fk); actual != expected {
+		t.Errorf("Unexpected result from foreignKeyValidationQuery:\nexpected: %s\nfound:    %s", expected, actual)
+	}
+}
+
+func TestStatementModifiersForDirUnvalidatedForeignKeys(t *testing.T) {
+	dir := &fs.Dir{Config: mybase.SimpleConfig(map[string]string{
+		"allow-unsafe":             "0",
+		"compare-metadata":         "0",
+		"alter-validate-virtual":   "0",
+		"unvalidated-foreign-keys": "1",
+		"foreign-key-checks":       "1",
+	})}
+	if _, err := StatementModifiersForDir(dir); err == nil {
+		t.Error("Expected error combining unvalidated-foreign-keys with foreign-key-checks, but err was nil")
+	}
+}
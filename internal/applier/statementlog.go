@@ -0,0 +1,92 @@
+package applier
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/skeema/skeema/internal/tengo"
+)
+
+// StatementLog records each executed statement to a file, as one JSON object
+// per line. It is safe for concurrent use by multiple goroutines.
+type StatementLog struct {
+	w   io.Writer
+	enc *json.Encoder
+	m   sync.Mutex
+}
+
+// StatementLogEntry represents a single line of a StatementLog.
+type StatementLogEntry struct {
+	Time         time.Time `json:"time"`
+	Instance     string    `json:"instance"`
+	Schema       string    `json:"schema"`
+	ObjectType   string    `json:"objectType,omitempty"`
+	ObjectName   string    `json:"objectName,omitempty"`
+	File         string    `json:"file,omitempty"`
+	Statement    string    `json:"statement"`
+	DurationMS   float64   `json:"durationMs"`
+	RowsAffected int64     `json:"rowsAffected"`
+	Success      bool      `json:"success"`
+	Error        string    `json:"error,omitempty"`
+}
+
+// OpenStatementLog opens the file at path for appending, creating it if it
+// does not already exist, and returns a StatementLog which writes to it.
+func OpenStatementLog(path string) (*StatementLog, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0666)
+	if err != nil {
+		return nil, err
+	}
+	return NewStatementLog(f), nil
+}
+
+// NewStatementLog returns a StatementLog which writes to w.
+func NewStatementLog(w io.Writer) *StatementLog {
+	return &StatementLog{
+		w:   w,
+		enc: json.NewEncoder(w),
+	}
+}
+
+// Record writes an entry for stmt, which began executing at start and took
+// elapsed to complete, with execErr being the value returned by its Execute
+// method. The object key, originating file, and number of rows affected are
+// only included if stmt supplies them, as DDLStatement does.
+func (sl *StatementLog) Record(stmt PlannedStatement, start time.Time, elapsed time.Duration, execErr error) error {
+	cs := stmt.ClientState()
+	entry := StatementLogEntry{
+		Time:       start,
+		Instance:   cs.InstanceName,
+		Schema:     cs.SchemaName,
+		Statement:  stmt.Statement(),
+		DurationMS: float64(elapsed.Microseconds()) / 1000,
+		Success:    execErr == nil,
+	}
+	if keyer, ok := stmt.(interface{ ObjectKey() tengo.ObjectKey }); ok {
+		key := keyer.ObjectKey()
+		entry.ObjectType, entry.ObjectName = string(key.Type), key.Name
+	}
+	if filer, ok := stmt.(interface{ File() string }); ok {
+		entry.File = filer.File()
+	}
+	if counter, ok := stmt.(interface{ RowsAffected() int64 }); ok {
+		entry.RowsAffected = counter.RowsAffected()
+	}
+	if execErr != nil {
+		entry.Error = execErr.Error()
+	}
+	sl.m.Lock()
+	defer sl.m.Unlock()
+	return sl.enc.Encode(entry)
+}
+
+// Close closes the underlying writer, if it implements io.Closer.
+func (sl *StatementLog) Close() error {
+	if closer, ok := sl.w.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
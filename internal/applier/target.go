@@ -2,11 +2,14 @@ package applier
 
 import (
 	"database/sql"
+	"errors"
 	"os"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/skeema/skeema/internal/fs"
 	"github.com/skeema/skeema/internal/tengo"
+	"github.com/skeema/skeema/internal/util"
 	"github.com/skeema/skeema/internal/workspace"
 )
 
@@ -19,6 +22,7 @@ type Target struct {
 	Dir           *fs.Dir
 	SchemaName    string
 	DesiredSchema *workspace.Schema
+	StatementLog  *StatementLog // if non-nil, executed statements are recorded here
 }
 
 // SchemaFromInstance introspects and returns the instance's version of the
@@ -32,6 +36,29 @@ func (t *Target) SchemaFromInstance() (*tengo.Schema, error) {
 	return schema, err
 }
 
+// droppedTablesFromInstance introspects and returns tables in the instance's
+// version of the schema which were previously renamed by rename-drops using
+// prefix. SchemaFromInstance excludes these, since the dir's IgnorePatterns
+// always ignore them.
+func (t *Target) droppedTablesFromInstance(prefix string) ([]*tengo.Table, error) {
+	if prefix == "" {
+		return nil, nil
+	}
+	schema, err := t.Instance.Schema(t.SchemaName)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var tables []*tengo.Table
+	for _, table := range schema.Tables {
+		if _, _, ok := tengo.ParseDroppedTableName(prefix, table.Name); ok {
+			tables = append(tables, table)
+		}
+	}
+	return tables, nil
+}
+
 // SchemaFromDir returns the desired schema expressed in the filesystem.
 func (t *Target) SchemaFromDir() *tengo.Schema {
 	schemaCopy := *t.DesiredSchema.Schema
@@ -63,11 +90,32 @@ func (t *Target) logApplyEnd(result Result) {
 }
 
 func (t *Target) processSQL(stmts []PlannedStatement, printer Printer) (skipCount int) {
+	// With pause-between-risk-tiers, statements still run in their original
+	// order, since the SchemaDiff ordering accounts for dependencies between
+	// them. Execution pauses before the first statement of any risk tier higher
+	// than those already run.
+	pauseTiers := t.Dir.Config.GetBool("pause-between-risk-tiers") && !t.Dir.Config.GetBool("dry-run")
+	var confirmedRisk tengo.RiskLevel
 	for i, stmt := range stmts {
+		if pauseTiers && i == 0 {
+			confirmedRisk = statementRisk(stmt)
+		} else if pauseTiers && statementRisk(stmt) > confirmedRisk {
+			confirmedRisk = statementRisk(stmt)
+			if !t.confirmRiskTier(confirmedRisk) {
+				skipCount += len(stmts) - i
+				log.Warnf("Skipping %s for %s %s", countAndNoun(len(stmts)-i, "remaining operation"), t.Instance, t.SchemaName)
+				return
+			}
+		}
 		printer.Print(stmt)
 		if !t.Dir.Config.GetBool("dry-run") {
-			if err := stmt.Execute(); err != nil {
+			if err := t.executeStatement(stmt); err != nil {
 				log.Errorf("Error running SQL statement on %s %s: %s\nFull SQL statement: %s%s", t.Instance, t.SchemaName, err, stmt.Statement(), stmt.ClientState().Delimiter)
+				var timeoutErr StatementTimeoutError
+				if errors.As(err, &timeoutErr) && t.Dir.Config.GetBool("continue-after-timeout") {
+					skipCount++
+					continue
+				}
 				skipped := len(stmts) - i
 				skipCount += skipped
 				if skipped > 1 {
@@ -75,11 +123,57 @@ func (t *Target) processSQL(stmts []PlannedStatement, printer Printer) (skipCoun
 				}
 				return
 			}
+			if ddl, ok := stmt.(*DDLStatement); ok {
+				for _, note := range ddl.Notes() {
+					log.Warn(note)
+				}
+			}
 		}
 	}
 	return
 }
 
+// executeStatement executes stmt, recording it to t.StatementLog if non-nil.
+// Failure to write to the statement log is logged, but is not considered fatal.
+func (t *Target) executeStatement(stmt PlannedStatement) error {
+	if t.StatementLog == nil {
+		return stmt.Execute()
+	}
+	start := time.Now()
+	err := stmt.Execute()
+	if logErr := t.StatementLog.Record(stmt, start, time.Since(start), err); logErr != nil {
+		log.Warnf("Unable to write to statement log: %s", logErr)
+	}
+	return err
+}
+
+// statementRisk returns the RiskLevel of stmt. Statements which cannot classify
+// their own risk are conservatively treated as tengo.RiskRebuild.
+func statementRisk(stmt PlannedStatement) tengo.RiskLevel {
+	if risker, ok := stmt.(tengo.Risker); ok {
+		return risker.Risk()
+	}
+	return tengo.RiskRebuild
+}
+
+// confirmRiskTier is called before executing the first statement of a higher
+// risk tier than any previously executed statements, when the
+// pause-between-risk-tiers option is enabled. It returns true if execution
+// should proceed. If the yes option is enabled, no prompt is displayed.
+// Otherwise, the user is prompted interactively, which requires STDIN to be a
+// TTY.
+func (t *Target) confirmRiskTier(risk tengo.RiskLevel) bool {
+	if t.Dir.Config.GetBool("yes") {
+		log.Infof("Proceeding to %s operations for %s %s", risk, t.Instance, t.SchemaName)
+		return true
+	}
+	proceed, err := util.PromptConfirm("Proceed to %s operations for %s %s?", risk, t.Instance, t.SchemaName)
+	if err != nil {
+		log.Errorf("Unable to confirm %s operations for %s %s: %s. Use --yes to proceed without prompting.", risk, t.Instance, t.SchemaName, err)
+	}
+	return proceed
+}
+
 // TargetGroup represents a group of Targets that all have the same Instance.
 type TargetGroup []*Target
 
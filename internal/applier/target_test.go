@@ -1,9 +1,13 @@
 package applier
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/skeema/mybase"
 	"github.com/skeema/skeema/internal/fs"
@@ -273,6 +277,9 @@ func getBaseConfig(t *testing.T, cliFlags string) *mybase.Config {
 	cmd.AddOption(mybase.BoolOption("first-only", '1', false, "For dirs mapping to multiple instances or schemas, just run against the first per dir"))
 	cmd.AddOption(mybase.BoolOption("exact-match", 0, false, "Follow *.sql table definitions exactly, even for differences with no functional impact"))
 	cmd.AddOption(mybase.BoolOption("foreign-key-checks", 0, false, "Force the server to check referential integrity of any new foreign key"))
+	cmd.AddOption(mybase.BoolOption("unvalidated-foreign-keys", 0, false, "Add foreign keys to existing tables in-place, without validating existing rows, where supported"))
+	cmd.AddOption(mybase.BoolOption("order-drops", 0, false, "Run DROP TABLEs last, dropping tables before any tables they reference via foreign keys"))
+	cmd.AddOption(mybase.BoolOption("ddl-comments", 0, false, "Precede each ALTER TABLE with SQL comments describing the reason for each change"))
 	cmd.AddOption(mybase.BoolOption("brief", 'q', false, "<overridden by diff command>").Hidden())
 	cmd.AddOption(mybase.StringOption("alter-wrapper", 'x', "", "External bin to shell out to for ALTER TABLE; see manual for template vars"))
 	cmd.AddOption(mybase.StringOption("alter-wrapper-min-size", 0, "0", "Ignore --alter-wrapper for tables smaller than this size in bytes"))
@@ -280,6 +287,8 @@ func getBaseConfig(t *testing.T, cliFlags string) *mybase.Config {
 	cmd.AddOption(mybase.StringOption("alter-algorithm", 0, "", `Apply an ALGORITHM clause to all ALTER TABLEs (valid values: "inplace", "copy", "instant", "nocopy")`))
 	cmd.AddOption(mybase.StringOption("ddl-wrapper", 'X', "", "Like --alter-wrapper, but applies to all DDL types (CREATE, DROP, ALTER)"))
 	cmd.AddOption(mybase.StringOption("safe-below-size", 0, "0", "Always permit destructive operations for tables below this size in bytes"))
+	cmd.AddOption(mybase.StringOption("statement-timeout", 0, "0", "Cancel any DDL statement running longer than this duration (e.g. 30s or 5m); 0 for no limit"))
+	cmd.AddOption(mybase.BoolOption("continue-after-timeout", 0, false, "After a statement-timeout, continue with the remaining DDL for the same schema"))
 	cmd.AddOption(mybase.StringOption("concurrent-instances", 'c', "1", "Perform operations on this number of instances concurrently"))
 	cmd.AddArg("environment", "production", false)
 	util.AddGlobalOptions(cmd)
@@ -309,3 +318,156 @@ func setupHostList(t *testing.T, instances ...*tengo.Instance) {
 		fs.RemoveTestDirectory(t, "testdata/.scratch")
 	})
 }
+
+// mockStatement is a PlannedStatement which records its execution instead of
+// running anything.
+type mockStatement struct {
+	stmt     string
+	risk     tengo.RiskLevel
+	executed *[]string
+}
+
+func (ms mockStatement) Execute() error           { *ms.executed = append(*ms.executed, ms.stmt); return nil }
+func (ms mockStatement) Statement() string        { return ms.stmt }
+func (ms mockStatement) ClientState() ClientState { return ClientState{Delimiter: ";"} }
+func (ms mockStatement) Risk() tengo.RiskLevel    { return ms.risk }
+
+type nopPrinter struct{}
+
+func (nopPrinter) Print(PlannedStatement) {}
+
+func TestProcessSQLRiskTiers(t *testing.T) {
+	inst, err := tengo.NewInstance("mysql", "root:@tcp(127.0.0.1:3306)/")
+	if err != nil {
+		t.Fatalf("Unexpected error from NewInstance: %v", err)
+	}
+	var executed []string
+	stmts := func() []PlannedStatement {
+		executed = nil
+		return []PlannedStatement{
+			mockStatement{"meta1", tengo.RiskMetadata, &executed},
+			mockStatement{"rebuild1", tengo.RiskRebuild, &executed},
+			mockStatement{"meta2", tengo.RiskMetadata, &executed},
+			mockStatement{"drop1", tengo.RiskDestructive, &executed},
+			mockStatement{"rebuild2", tengo.RiskRebuild, &executed},
+		}
+	}
+	const allInOrder = "meta1,rebuild1,meta2,drop1,rebuild2"
+	target := func(options map[string]string) *Target {
+		return &Target{
+			Instance:   inst,
+			Dir:        &fs.Dir{Config: mybase.SimpleConfig(options)},
+			SchemaName: "analytics",
+		}
+	}
+	var prompts int
+	respond := func(responses ...string) {
+		prompts = 0
+		util.ConfirmPromptInput = func() (string, error) {
+			prompts++
+			response := responses[0]
+			responses = responses[1:]
+			return response, nil
+		}
+	}
+	defer func() {
+		util.ConfirmPromptInput = util.ConfirmInputSource(util.NoInteractiveConfirm)
+	}()
+
+	// Without pause-between-risk-tiers, statements run in their original order
+	tgt := target(map[string]string{"pause-between-risk-tiers": "0", "dry-run": "0", "yes": "0"})
+	if skipCount := tgt.processSQL(stmts(), nopPrinter{}); skipCount != 0 || strings.Join(executed, ",") != allInOrder {
+		t.Errorf("Unexpected result: skipCount=%d, executed=%v", skipCount, executed)
+	}
+
+	// With --yes, statements also run in their original order without prompting,
+	// since reordering could violate dependencies between statements
+	tgt = target(map[string]string{"pause-between-risk-tiers": "1", "dry-run": "0", "yes": "1"})
+	if skipCount := tgt.processSQL(stmts(), nopPrinter{}); skipCount != 0 || strings.Join(executed, ",") != allInOrder {
+		t.Errorf("Unexpected result: skipCount=%d, executed=%v", skipCount, executed)
+	}
+
+	// Without --yes and without a TTY, execution stops at the first statement of
+	// a higher risk tier
+	util.ConfirmPromptInput = util.ConfirmInputSource(util.NoInteractiveConfirm)
+	tgt = target(map[string]string{"pause-between-risk-tiers": "1", "dry-run": "0", "yes": "0"})
+	if skipCount := tgt.processSQL(stmts(), nopPrinter{}); skipCount != 4 || strings.Join(executed, ",") != "meta1" {
+		t.Errorf("Unexpected result: skipCount=%d, executed=%v", skipCount, executed)
+	}
+
+	// Confirming the rebuild tier but declining the destructive tier should run
+	// everything up to the first destructive statement. Returning to a lower tier
+	// does not prompt again.
+	respond("y", "n")
+	if skipCount := tgt.processSQL(stmts(), nopPrinter{}); skipCount != 2 || strings.Join(executed, ",") != "meta1,rebuild1,meta2" || prompts != 2 {
+		t.Errorf("Unexpected result: skipCount=%d, executed=%v, prompts=%d", skipCount, executed, prompts)
+	}
+
+	// Confirming both tiers runs everything in order, with only one prompt per
+	// tier, even though a rebuild statement follows the destructive one
+	respond("y", "y")
+	if skipCount := tgt.processSQL(stmts(), nopPrinter{}); skipCount != 0 || strings.Join(executed, ",") != allInOrder || prompts != 2 {
+		t.Errorf("Unexpected result: skipCount=%d, executed=%v, prompts=%d", skipCount, executed, prompts)
+	}
+}
+
+func TestProcessSQLStatementLog(t *testing.T) {
+	inst, err := tengo.NewInstance("mysql", "root:@tcp(127.0.0.1:3306)/")
+	if err != nil {
+		t.Fatalf("Unexpected error from NewInstance: %v", err)
+	}
+	var executed []string
+	var buf bytes.Buffer
+	tgt := &Target{
+		Instance:     inst,
+		Dir:          &fs.Dir{Config: mybase.SimpleConfig(map[string]string{"pause-between-risk-tiers": "0", "dry-run": "0"})},
+		SchemaName:   "analytics",
+		StatementLog: NewStatementLog(&buf),
+	}
+	stmts := []PlannedStatement{
+		mockStatement{"meta1", tengo.RiskMetadata, &executed},
+		mockStatement{"rebuild1", tengo.RiskRebuild, &executed},
+	}
+	if skipCount := tgt.processSQL(stmts, nopPrinter{}); skipCount != 0 {
+		t.Fatalf("Unexpected skipCount %d", skipCount)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != len(stmts) {
+		t.Fatalf("Expected %d lines in statement log, instead found %d: %s", len(stmts), len(lines), buf.String())
+	}
+	for n, line := range lines {
+		var entry StatementLogEntry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("Unexpected error unmarshaling line %d: %v", n+1, err)
+		}
+		if entry.Statement != stmts[n].Statement() || !entry.Success || entry.Error != "" || entry.Time.IsZero() || entry.DurationMS < 0 {
+			t.Errorf("Unexpected entry on line %d: %+v", n+1, entry)
+		}
+	}
+
+	// Statements with an error should be recorded as failures, and should
+	// include object information from DDLStatement
+	buf.Reset()
+	ddl := &DDLStatement{
+		stmt:     "DROP TABLE `foo`",
+		instance: inst,
+		key:      tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: "foo"},
+		file:     "foo.sql",
+	}
+	execErr := errors.New("oops")
+	if err := tgt.StatementLog.Record(ddl, time.Now(), 1500*time.Microsecond, execErr); err != nil {
+		t.Fatalf("Unexpected error from Record: %v", err)
+	}
+	for _, key := range []string{`"objectType":`, `"objectName":`, `"durationMs":`, `"rowsAffected":`} {
+		if !strings.Contains(buf.String(), key) {
+			t.Errorf("Expected statement log entry to contain key %s, but it did not: %s", key, buf.String())
+		}
+	}
+	var entry StatementLogEntry
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Unexpected error unmarshaling entry: %v", err)
+	}
+	if entry.Success || entry.Error != "oops" || entry.ObjectType != "table" || entry.ObjectName != "foo" || entry.File != "foo.sql" || entry.DurationMS != 1.5 {
+		t.Errorf("Unexpected entry: %+v", entry)
+	}
+}
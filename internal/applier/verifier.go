@@ -54,6 +54,7 @@ func VerifyDiff(diff *tengo.SchemaDiff, t *Target) error {
 	logicalSchema := fs.NewLogicalSchema()
 	logicalSchema.CharSet = t.Dir.Config.Get("default-character-set")
 	logicalSchema.Collation = t.Dir.Config.Get("default-collation")
+	logicalSchema.Comment = t.Dir.Config.Get("schema-comment")
 	desiredTables := make(map[string]*tengo.Table)
 	for _, td := range altersInDiff {
 		stmt, err := td.Statement(mods)
@@ -6,11 +6,14 @@ import (
 
 // Options controls dumper behavior.
 type Options struct {
-	IncludeAutoInc bool                     // if false, strip AUTO_INCREMENT clauses from CREATE TABLE
-	Partitioning   tengo.PartitioningMode   // PartitioningKeep: retain previous FS partitioning clause; PartitioningRemove: strip partitioning clause
-	CountOnly      bool                     // if true, skip writing files, just report count of rewrites
-	skipKeys       map[tengo.ObjectKey]bool // skip objects with true values
-	onlyKeys       map[tengo.ObjectKey]bool // if map is non-nil, only format objects with true values
+	IncludeAutoInc    bool                     // if false, strip AUTO_INCREMENT clauses from CREATE TABLE
+	StripAutoInc      bool                     // if true, strip AUTO_INCREMENT clauses even if already present in fs; overrides IncludeAutoInc
+	Partitioning      tengo.PartitioningMode   // PartitioningKeep: retain previous FS partitioning clause; PartitioningRemove: strip partitioning clause
+	StripDisplayWidth bool                     // if true, strip integer display widths from CREATE TABLE column definitions
+	CountOnly         bool                     // if true, skip writing files, just report count of rewrites
+	ObjectOrder       []tengo.ObjectKey        // if non-empty, rearrange statements in multi-object files to this order; see fs.SQLFile.OrderStatements
+	skipKeys          map[tengo.ObjectKey]bool // skip objects with true values
+	onlyKeys          map[tengo.ObjectKey]bool // if map is non-nil, only format objects with true values
 }
 
 // OnlyKeys specifies a list of tengo.ObjectKeys that the dump should
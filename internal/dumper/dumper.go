@@ -6,6 +6,7 @@ package dumper
 
 import (
 	"errors"
+	"strings"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/skeema/skeema/internal/fs"
@@ -17,7 +18,10 @@ import (
 // match the canonical format from the live schema. Objects that no longer exist
 // in the live schema will have their statements removed. A count of modified
 // files is returned, along with any fatal write error. If opts.CountOnly is
-// true, no actual filesystem writes occur, but a file count is still returned.
+// true, no actual filesystem writes occur, but a file count is still returned;
+// in this case opts.ObjectOrder is not considered. Files with PreserveClean set
+// are left untouched, and excluded from the count, if their changes are purely
+// cosmetic.
 func DumpSchema(schema *tengo.Schema, dir *fs.Dir, opts Options) (int, error) {
 	// Ensure that this dir does not reference any schemas by name, either via
 	// USE commands or CREATEs with schema name qualifiers
@@ -35,16 +39,29 @@ func DumpSchema(schema *tengo.Schema, dir *fs.Dir, opts Options) (int, error) {
 	if err := updateCreateStatements(schema, dir, opts); err != nil {
 		return 0, err
 	}
-	filesWithDiffs := dir.DirtyFiles()
-	for n, file := range filesWithDiffs {
+	if len(opts.ObjectOrder) > 0 && !opts.CountOnly {
+		for _, file := range dir.SQLFiles {
+			file.OrderStatements(opts.ObjectOrder)
+		}
+	}
+	var count int
+	for _, file := range dir.DirtyFiles() {
 		if opts.CountOnly {
 			log.Infof("File %s requires formatting changes", file.FilePath)
 			file.Dirty = false // since we marked it as dirty artificially / without actually changing anything
+			count++
+			continue
+		}
+		if file.PreserveClean && file.SemanticallyUnchanged() {
+			log.Debugf("Leaving %s as-is, since it only has formatting differences", file.FilePath)
+			file.Dirty = false
 			continue
 		}
 		exists, _ := file.Exists()
-		if bytesWritten, err := file.Write(); err != nil {
-			return n, err
+		// PreserveClean was already handled above, so WriteAtomic is used directly
+		// to avoid re-parsing the existing file in Write
+		if bytesWritten, err := file.WriteAtomic(); err != nil {
+			return count, err
 		} else if bytesWritten == 0 {
 			log.Infof("Deleted %s", file.FilePath)
 		} else if exists {
@@ -52,8 +69,76 @@ func DumpSchema(schema *tengo.Schema, dir *fs.Dir, opts Options) (int, error) {
 		} else {
 			log.Infof("Created %s (%d bytes)", file.FilePath, bytesWritten)
 		}
+		count++
 	}
-	return len(filesWithDiffs), nil
+	return count, nil
+}
+
+// RewrittenKeys returns the keys of objects which exist in both schema and dir,
+// but whose CREATE statement in dir differs textually from what DumpSchema would
+// write with the same opts. The result is sorted. No changes are made to dir,
+// and no files are marked as dirty.
+func RewrittenKeys(schema *tengo.Schema, dir *fs.Dir, opts Options) []tengo.ObjectKey {
+	// TODO: handle dirs that contain multiple logical schemas by name
+	logicalSchema := dir.LogicalSchemas[0]
+	var keys []tengo.ObjectKey
+	for key, object := range schema.Objects() {
+		stmt := logicalSchema.Creates[key]
+		if stmt == nil || opts.shouldIgnore(object) {
+			continue
+		}
+		fsCreate, _ := stmt.SplitTextBody()
+		if !sameIgnoringLineEndings(fsCreate, canonicalCreateFor(object, fsCreate, opts)) {
+			keys = append(keys, key)
+		}
+	}
+	tengo.SortObjectKeys(keys)
+	return keys
+}
+
+// sameIgnoringLineEndings returns true if a and b are identical, aside from
+// any differences in use of CRLF vs LF line endings. A file with CRLF line
+// endings retains them when rewritten, so these differences need not trigger a
+// rewrite.
+func sameIgnoringLineEndings(a, b string) bool {
+	return a == b || strings.ReplaceAll(a, "\r\n", "\n") == strings.ReplaceAll(b, "\r\n", "\n")
+}
+
+// canonicalCreateFor returns the CREATE statement that should be written for
+// object, given its existing CREATE in the filesystem (or "" if none) and opts.
+func canonicalCreateFor(object tengo.DefKeyer, fsCreate string, opts Options) string {
+	key := object.ObjectKey()
+	canonicalCreate := object.Def()
+
+	// Include or strip auto_increment clause. (Note that if fs representation
+	// already exists and explicitly had an autoinc value > 1, we keep and update
+	// it regardless, unless opts.StripAutoInc is set.)
+	if key.Type == tengo.ObjectTypeTable && opts.StripAutoInc {
+		canonicalCreate, _ = tengo.ParseCreateAutoInc(canonicalCreate)
+	} else if key.Type == tengo.ObjectTypeTable && !opts.IncludeAutoInc {
+		if _, fsAutoInc := tengo.ParseCreateAutoInc(fsCreate); fsAutoInc <= 1 {
+			canonicalCreate, _ = tengo.ParseCreateAutoInc(canonicalCreate)
+		}
+	}
+
+	// If requested, strip integer display widths, for consistency with MySQL
+	// 8.0.19+ which omits them
+	if key.Type == tengo.ObjectTypeTable && opts.StripDisplayWidth {
+		canonicalCreate = tengo.StripCreateDisplayWidths(canonicalCreate)
+	}
+
+	// If requested, adjust the canonical create to add the partitioning clause
+	// from the filesystem create, or remove it
+	if key.Type == tengo.ObjectTypeTable && opts.Partitioning != tengo.PartitioningPermissive {
+		dbCreateBase, _ := tengo.ParseCreatePartitioning(canonicalCreate)
+		if opts.Partitioning == tengo.PartitioningKeep && fsCreate != "" {
+			_, fsCreatePart := tengo.ParseCreatePartitioning(fsCreate)
+			canonicalCreate = dbCreateBase + fsCreatePart
+		} else if opts.Partitioning == tengo.PartitioningRemove {
+			canonicalCreate = dbCreateBase
+		}
+	}
+	return canonicalCreate
 }
 
 // updateCreateStatements determines what SQLFile and Statement changes are
@@ -71,33 +156,12 @@ func updateCreateStatements(schema *tengo.Schema, dir *fs.Dir, opts Options) err
 		if opts.shouldIgnore(object) {
 			continue
 		}
-		canonicalCreate := object.Def()
 		var fsCreate string
 		stmt := logicalSchema.Creates[key]
 		if stmt != nil {
 			fsCreate, _ = stmt.SplitTextBody()
 		}
-
-		// Include or strip auto_increment clause. (Note that if fs representation
-		// already exists and explicitly had an autoinc value > 1, we keep and update
-		// it regardless.)
-		if key.Type == tengo.ObjectTypeTable && !opts.IncludeAutoInc {
-			if _, fsAutoInc := tengo.ParseCreateAutoInc(fsCreate); fsAutoInc <= 1 {
-				canonicalCreate, _ = tengo.ParseCreateAutoInc(canonicalCreate)
-			}
-		}
-
-		// If requested, adjust the canonical create to add the partitioning clause
-		// from the filesystem create, or remove it
-		if key.Type == tengo.ObjectTypeTable && opts.Partitioning != tengo.PartitioningPermissive {
-			dbCreateBase, _ := tengo.ParseCreatePartitioning(canonicalCreate)
-			if opts.Partitioning == tengo.PartitioningKeep && fsCreate != "" {
-				_, fsCreatePart := tengo.ParseCreatePartitioning(fsCreate)
-				canonicalCreate = dbCreateBase + fsCreatePart
-			} else if opts.Partitioning == tengo.PartitioningRemove {
-				canonicalCreate = dbCreateBase
-			}
-		}
+		canonicalCreate := canonicalCreateFor(object, fsCreate, opts)
 
 		newStmt := tengo.ParseStatementInString(canonicalCreate)
 		if newStmt.Type != tengo.StatementTypeCreate || newStmt.ObjectKey() != key {
@@ -112,17 +176,17 @@ func updateCreateStatements(schema *tengo.Schema, dir *fs.Dir, opts Options) err
 			sqlFile := dir.FileFor(object)
 			if opts.CountOnly {
 				sqlFile.Dirty = true
-			} else {
-				sqlFile.AddStatement(newStmt)
+			} else if err := sqlFile.AddStatement(newStmt); err != nil {
+				return err
 			}
-		} else if fsCreate != canonicalCreate {
+		} else if !sameIgnoringLineEndings(fsCreate, canonicalCreate) {
 			// Statement came from the fs and we need to update it, or just mark its
 			// file as dirty if doing CountOnly
 			sqlFile := dir.FileFor(stmt)
 			if opts.CountOnly {
 				sqlFile.Dirty = true
-			} else {
-				sqlFile.EditStatementText(stmt, canonicalCreate, newStmt.Compound)
+			} else if err := sqlFile.EditStatementText(stmt, canonicalCreate, newStmt.Compound); err != nil {
+				return err
 			}
 		}
 	}
@@ -1,7 +1,10 @@
 package fs
 
 import (
+	"archive/tar"
+	"compress/gzip"
 	"fmt"
+	"io"
 	"net/url"
 	"os"
 	"path/filepath"
@@ -10,6 +13,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/skeema/mybase"
@@ -28,6 +32,8 @@ type Dir struct {
 	NamedSchemaStatements []*tengo.Statement    // statements with explicit schema names: USE command or CREATEs with schema name qualifier
 	LogicalSchemas        []*LogicalSchema      // for now, always 0 or 1 elements; 2+ in same dir to be supported in future
 	IgnorePatterns        []tengo.ObjectPattern // regexes for matching objects that should be ignored
+	Layout                FileLayout            // determines placement of files for objects not yet in any file
+	FileFormat            SQLFileFormat         // formatting normalizations applied when writing *.sql files
 	ParseError            error                 // any fatal error found parsing dir's config or contents
 	repoBase              string                // absolute path of containing repo, or topmost-found .skeema file
 }
@@ -102,17 +108,23 @@ func (dir *Dir) HasFile(name string) (bool, error) {
 }
 
 // Subdirs reads the list of direct, non-hidden subdirectories of dir, parses
-// them (*.sql and .skeema files), and returns them. An error will be returned
-// if there are problems reading dir's the directory list. Otherwise, err is
-// nil, but some of the returned Dir values will have a non-nil ParseError if
-// any problems were encountered in that subdir.
+// them (*.sql and .skeema files), and returns them. If dir uses
+// TypeDirFileLayout, its object type subdirectories are excluded, since their
+// *.sql files belong to dir itself. An error will be returned if there are
+// problems reading dir's directory list. Otherwise, err is nil, but some of
+// the returned Dir values will have a non-nil ParseError if any problems were
+// encountered in that subdir.
 func (dir *Dir) Subdirs() ([]*Dir, error) {
 	entries, err := os.ReadDir(dir.Path)
 	if err != nil {
 		return nil, err
 	}
+	_, typeDirLayout := dir.Layout.(TypeDirFileLayout)
 	result := make([]*Dir, 0, len(entries))
 	for _, entry := range entries {
+		if typeDirLayout && isTypeDir(entry.Name()) {
+			continue // part of dir itself, rather than a separate subdir
+		}
 		if entry.IsDir() && entry.Name()[0] != '.' {
 			if sub, _ := dir.Subdir(entry.Name()); sub != nil {
 				result = append(result, sub)
@@ -169,6 +181,7 @@ func (dir *Dir) CreateOptionFile(optionFile *mybase.File) (err error) {
 		ls := NewLogicalSchema()
 		ls.CharSet = dir.Config.Get("default-character-set")
 		ls.Collation = dir.Config.Get("default-collation")
+		ls.Comment = dir.Config.Get("schema-comment")
 		dir.LogicalSchemas = []*LogicalSchema{ls}
 	}
 
@@ -211,15 +224,20 @@ func (dir *Dir) Port() (int, bool) {
 // FileFor returns a SQLFile associated with the supplied keyer. If keyer is a
 // *tengo.Statement with non-empty File field, that path will be used as-is.
 // Otherwise, FileFor returns the default location for the supplied keyer based
-// on its type and name. In either case, if no known SQLFile exists at that
-// location yet, FileFor will instantiate a new SQLFile value for it.
+// on its type and name, as determined by dir.Layout. In either case, if no
+// known SQLFile exists at that location yet, FileFor will instantiate a new
+// SQLFile value for it.
 func (dir *Dir) FileFor(keyer tengo.ObjectKeyer) *SQLFile {
 	var filePath string
 	if stmt, ok := keyer.(*tengo.Statement); ok && stmt.File != "" {
 		filePath = stmt.File
 	} else {
-		objName := keyer.ObjectKey().Name
-		filePath = PathForObject(dir.Path, NormalizeFileName(objName))
+		key := keyer.ObjectKey()
+		layout := dir.Layout
+		if layout == nil {
+			layout = ObjectFileLayout{}
+		}
+		filePath = filepath.Join(dir.Path, layout.RelPath(key.Type, NormalizeFileName(key.Name)))
 	}
 
 	// No file yet at that path: return a new SQLFile, but no need to mark it
@@ -228,6 +246,7 @@ func (dir *Dir) FileFor(keyer tengo.ObjectKeyer) *SQLFile {
 		dir.SQLFiles[filePath] = &SQLFile{
 			FilePath:   filePath,
 			Statements: []*tengo.Statement{},
+			Format:     dir.FileFormat,
 		}
 	}
 	return dir.SQLFiles[filePath]
@@ -243,6 +262,483 @@ func (dir *Dir) DirtyFiles() (result []*SQLFile) {
 	return
 }
 
+// PatchPreview returns a unified-diff (git-style) representation of the
+// changes that writing the directory's dirty files would make. Files are
+// ordered by name, and the diff headers use paths relative to the directory.
+func (dir *Dir) PatchPreview() (string, error) {
+	dirtyFiles := dir.DirtyFiles()
+	sort.Slice(dirtyFiles, func(i, j int) bool {
+		return dirtyFiles[i].FilePath < dirtyFiles[j].FilePath
+	})
+	var b strings.Builder
+	for _, sf := range dirtyFiles {
+		displayPath, err := filepath.Rel(dir.Path, sf.FilePath)
+		if err != nil {
+			displayPath = sf.FileName()
+		}
+		patch, err := sf.PatchPreview(filepath.ToSlash(displayPath))
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(patch)
+	}
+	return b.String(), nil
+}
+
+// DropImpact reports how dir's *.sql files would be affected by removing the
+// CREATE statements for the supplied objects, for example as a result of
+// dropping the objects and then running `skeema pull`. Files which would no
+// longer contain any CREATE statements (and would therefore be deleted by
+// SQLFile.Write) are returned in deleted; files which would lose some, but not
+// all, of their CREATE statements are returned in modified. Both slices are
+// sorted by file path. Only statements operating on the dir's default schema
+// (i.e. without any USE command or schema name qualifier) are considered. This
+// method does not modify dir or its files.
+func (dir *Dir) DropImpact(keys []tengo.ObjectKey) (deleted, modified []*SQLFile) {
+	dropping := make(map[tengo.ObjectKey]bool, len(keys))
+	for _, key := range keys {
+		dropping[key] = true
+	}
+	filePaths := make([]string, 0, len(dir.SQLFiles))
+	for filePath := range dir.SQLFiles {
+		filePaths = append(filePaths, filePath)
+	}
+	sort.Strings(filePaths)
+	for _, filePath := range filePaths {
+		sqlFile := dir.SQLFiles[filePath]
+		var removed, kept bool
+		for _, stmt := range sqlFile.Statements {
+			if stmt.Type == tengo.StatementTypeNoop || stmt.Type == tengo.StatementTypeCommand {
+				continue
+			}
+			if stmt.Type == tengo.StatementTypeCreate && stmt.Schema() == "" && dropping[stmt.ObjectKey()] {
+				removed = true
+			} else {
+				kept = true
+			}
+		}
+		if removed && kept {
+			modified = append(modified, sqlFile)
+		} else if removed {
+			deleted = append(deleted, sqlFile)
+		}
+	}
+	return deleted, modified
+}
+
+// DropDependents returns keys for objects in dir which depend on the object
+// with the supplied key, either directly or transitively: tables with foreign
+// keys referencing it, and stored programs which query it or call it, as
+// determined by tengo.Statement.References. Each of these objects would break,
+// or fail to be created, if the object were dropped. Cyclic dependencies are
+// permitted, and key itself is never included in the result. The result is
+// sorted. As with DropImpact, only statements operating on the dir's default
+// schema are considered.
+func (dir *Dir) DropDependents(key tengo.ObjectKey) []tengo.ObjectKey {
+	dependents := make(map[tengo.ObjectKey][]tengo.ObjectKey)
+	for _, sqlFile := range dir.SQLFiles {
+		for _, stmt := range sqlFile.Statements {
+			if stmt.Type == tengo.StatementTypeCreate && stmt.Schema() == "" {
+				for _, ref := range stmt.References() {
+					dependents[ref] = append(dependents[ref], stmt.ObjectKey())
+				}
+			}
+		}
+	}
+
+	seen := map[tengo.ObjectKey]bool{key: true}
+	queue := []tengo.ObjectKey{key}
+	var result []tengo.ObjectKey
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, dependent := range dependents[current] {
+			if !seen[dependent] {
+				seen[dependent] = true
+				queue = append(queue, dependent)
+				result = append(result, dependent)
+			}
+		}
+	}
+	tengo.SortObjectKeys(result)
+	return result
+}
+
+// ObjectDifference describes an object whose definition differs between two
+// directories, as returned by CompareDirs.
+type ObjectDifference struct {
+	Schema   string          // logical schema name; blank for the dir's default schema
+	Key      tengo.ObjectKey // object type and name
+	Type     tengo.DiffType  // DiffTypeCreate if added, DiffTypeDrop if removed, DiffTypeAlter if changed
+	From     *tengo.Statement
+	To       *tengo.Statement
+	Elements []DefinitionDifference // for DiffTypeAlter, differing columns, indexes, etc
+}
+
+// CompareDirs compares the object definitions in the *.sql files of from and
+// to, without requiring a database server. Objects are matched by logical
+// schema and ObjectKey. An object only present in to is considered added; only
+// present in from is considered removed; or present in both with a different
+// tengo.Statement.CanonicalText is considered changed. For changed objects,
+// Elements lists the differences as determined by CompareDefinitions, with
+// from's version as "ours" and to's version as "theirs"; this may be empty if
+// only the order of elements changed. Purely cosmetic differences, such as
+// whitespace or comments, are not reported.
+//
+// The result is sorted by schema name, then object type, then object name, so
+// that output is stable. Subdirectories are not examined, nor are option files.
+func CompareDirs(from, to *Dir) (result []ObjectDifference) {
+	type schemaKey struct {
+		schema string
+		key    tengo.ObjectKey
+	}
+	creates := func(dir *Dir) map[schemaKey]*tengo.Statement {
+		m := make(map[schemaKey]*tengo.Statement)
+		for _, ls := range dir.LogicalSchemas {
+			for key, stmt := range ls.Creates {
+				m[schemaKey{ls.Name, key}] = stmt
+			}
+		}
+		return m
+	}
+	fromCreates, toCreates := creates(from), creates(to)
+	for sk, toStmt := range toCreates {
+		fromStmt := fromCreates[sk]
+		diff := ObjectDifference{Schema: sk.schema, Key: sk.key, From: fromStmt, To: toStmt}
+		if fromStmt == nil {
+			diff.Type = tengo.DiffTypeCreate
+		} else if fromStmt.CanonicalText() != toStmt.CanonicalText() {
+			diff.Type = tengo.DiffTypeAlter
+			diff.Elements = CompareDefinitions(nil, fromStmt, toStmt)
+		} else {
+			continue
+		}
+		result = append(result, diff)
+	}
+	for sk, fromStmt := range fromCreates {
+		if _, stillExists := toCreates[sk]; !stillExists {
+			result = append(result, ObjectDifference{Schema: sk.schema, Key: sk.key, Type: tengo.DiffTypeDrop, From: fromStmt})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Schema != result[j].Schema {
+			return result[i].Schema < result[j].Schema
+		}
+		return result[i].Key.Less(result[j].Key)
+	})
+	return result
+}
+
+// Checksum returns a hex-encoded SHA-256 checksum of the object definitions in
+// dir's *.sql files, which can be used to cheaply detect whether the schema has
+// changed. Like SQLFile.Fingerprint, the checksum is semantic rather than
+// byte-level, and it is also independent of which file each object is defined
+// in. Two directories defining the same objects therefore produce the same
+// checksum, even if formatted or organized differently. Subdirectories and
+// option files are not considered.
+func (dir *Dir) Checksum() string {
+	var stmts []*tengo.Statement
+	for _, sqlFile := range dir.SQLFiles {
+		stmts = append(stmts, sqlFile.Statements...)
+	}
+	return fingerprintStatements(stmts)
+}
+
+// ObjectOrder reads the order manifest fileName (relative to dir.Path), which
+// lists the desired order of object definitions within *.sql files containing
+// multiple objects. Each non-blank line of the manifest names one object,
+// optionally preceded by its type, for example "table foo" or "procedure bar".
+// Entries without a type match an object of any type with that name. Lines
+// beginning with # are treated as comments. If the manifest does not exist, a
+// nil slice and nil error are returned. The manifest is never modified by this
+// package.
+func (dir *Dir) ObjectOrder(fileName string) ([]tengo.ObjectKey, error) {
+	contents, err := os.ReadFile(filepath.Join(dir.Path, fileName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var keys []tengo.ObjectKey
+	for n, line := range strings.Split(string(contents), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		var key tengo.ObjectKey
+		switch len(fields) {
+		case 1:
+			key.Name = fields[0]
+		case 2:
+			key.Type, key.Name = tengo.ObjectType(strings.ToLower(fields[0])), fields[1]
+			if key.Type != tengo.ObjectTypeTable && key.Type != tengo.ObjectTypeProc && key.Type != tengo.ObjectTypeFunc {
+				return nil, fmt.Errorf("%s line %d: unsupported object type %q", filepath.Join(dir.Path, fileName), n+1, fields[0])
+			}
+		default:
+			return nil, fmt.Errorf("%s line %d: expected optional object type followed by object name", filepath.Join(dir.Path, fileName), n+1)
+		}
+		key.Name = strings.Trim(key.Name, "`")
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// FlavorConcern describes a clause of a CREATE statement which would be
+// rejected by one of the flavors checked by Dir.FlavorConcerns.
+type FlavorConcern struct {
+	Statement *tengo.Statement
+	Flavor    tengo.Flavor
+	Issue     tengo.SyntaxIssue
+}
+
+func (fc FlavorConcern) String() string {
+	return fmt.Sprintf("%s: %s %s is not compatible with %s: %s", fc.Statement.Location(), fc.Statement.ObjectType, tengo.EscapeIdentifier(fc.Statement.ObjectName), fc.Flavor, fc.Issue)
+}
+
+// FlavorConcerns examines the CREATE statements in dir's *.sql files, and
+// returns any clauses which would be rejected by at least one of the supplied
+// flavors, as per tengo.ValidateFlavorSyntax. This is useful for repos which
+// are deployed to servers of several different flavors, such as a mix of MySQL
+// and MariaDB. No database interaction is required. Results are ordered by
+// file path and then by position in the file; each statement may produce
+// multiple concerns, one per issue per incompatible flavor.
+func (dir *Dir) FlavorConcerns(flavors ...tengo.Flavor) (concerns []FlavorConcern) {
+	filePaths := make([]string, 0, len(dir.SQLFiles))
+	for filePath := range dir.SQLFiles {
+		filePaths = append(filePaths, filePath)
+	}
+	sort.Strings(filePaths)
+	for _, filePath := range filePaths {
+		for _, stmt := range dir.SQLFiles[filePath].StatementsByType(tengo.StatementTypeCreate) {
+			for _, flavor := range flavors {
+				for _, issue := range tengo.ValidateFlavorSyntax(stmt.Text, flavor) {
+					concerns = append(concerns, FlavorConcern{
+						Statement: stmt,
+						Flavor:    flavor,
+						Issue:     issue,
+					})
+				}
+			}
+		}
+	}
+	return concerns
+}
+
+// RenameIssue describes a possible reference to a renamed object which could
+// not be confidently rewritten by Dir.RenameObjects, and requires manual
+// review.
+type RenameIssue struct {
+	Statement *tengo.Statement
+	Key       tengo.ObjectKey
+}
+
+func (ri RenameIssue) String() string {
+	return fmt.Sprintf("%s: possible reference to %s could not be rewritten automatically", ri.Statement.Location(), ri.Key)
+}
+
+// RenameObjects applies renames, which maps old object keys to new object
+// names, to dir's *.sql files in-memory. Each renamed object's CREATE
+// statement is rewritten to use the new name, and references to renamed
+// objects in other CREATE statements (foreign keys, and DML or CALLs in stored
+// programs) are rewritten as per tengo.Statement.RenamedBody. If a renamed
+// object is the only object defined in a file at its default location, the
+// file is moved to the default location for the new name. Modified files are
+// marked as dirty, but not written; a file which is moved leaves behind an
+// empty dirty SQLFile at its old path, which will be deleted by SQLFile.Write.
+// Any possible references which could not be rewritten confidently are
+// returned, for manual review. An error is returned if a new name conflicts
+// with an existing object in the same logical schema, in which case dir is not
+// modified.
+func (dir *Dir) RenameObjects(renames map[tengo.ObjectKey]string) ([]RenameIssue, error) {
+	for _, logicalSchema := range dir.LogicalSchemas {
+		for oldKey, newName := range renames {
+			newKey := tengo.ObjectKey{Type: oldKey.Type, Name: newName}
+			if _, renamed := renames[newKey]; renamed || newKey == oldKey {
+				continue
+			}
+			if existing := logicalSchema.Creates[newKey]; existing != nil && logicalSchema.Creates[oldKey] != nil {
+				return nil, fmt.Errorf("cannot rename %s to %s: %s already defined at %s", oldKey, tengo.EscapeIdentifier(newName), newKey, existing.Location())
+			}
+		}
+	}
+
+	filePaths := make([]string, 0, len(dir.SQLFiles))
+	for filePath := range dir.SQLFiles {
+		filePaths = append(filePaths, filePath)
+	}
+	sort.Strings(filePaths)
+	var issues []RenameIssue
+	var moves []*SQLFile
+	for _, filePath := range filePaths {
+		sqlFile := dir.SQLFiles[filePath]
+		var creates int
+		var movable bool
+		for _, stmt := range sqlFile.Statements {
+			if stmt.Type != tengo.StatementTypeCreate {
+				continue
+			}
+			creates++
+			oldKey := stmt.ObjectKey()
+			body, unresolved := stmt.RenamedBody(renames)
+			for _, key := range unresolved {
+				issues = append(issues, RenameIssue{Statement: stmt, Key: key})
+			}
+			if oldBody, _ := stmt.SplitTextBody(); body != oldBody {
+				if err := sqlFile.EditStatementText(stmt, body, stmt.Compound); err != nil {
+					return issues, fmt.Errorf("%s: %w", stmt.Location(), err)
+				}
+			}
+			if newName, ok := renames[oldKey]; ok {
+				stmt.ObjectName = newName
+				for _, logicalSchema := range dir.LogicalSchemas {
+					if logicalSchema.Creates[oldKey] == stmt {
+						delete(logicalSchema.Creates, oldKey)
+						logicalSchema.Creates[stmt.ObjectKey()] = stmt
+					}
+				}
+				movable = (filePath == PathForObject(dir.Path, oldKey.Name))
+			}
+		}
+		if movable && creates == 1 {
+			moves = append(moves, sqlFile)
+		}
+	}
+
+	// Move files after all statements have been processed, to avoid modifying
+	// dir.SQLFiles while iterating over it
+	for _, sqlFile := range moves {
+		var newPath string
+		for _, stmt := range sqlFile.Statements {
+			if stmt.Type == tengo.StatementTypeCreate {
+				newPath = PathForObject(dir.Path, stmt.ObjectName)
+			}
+		}
+		if newPath == sqlFile.FilePath || dir.SQLFiles[newPath] != nil {
+			continue // leave as-is if already at the right path or new path is taken
+		}
+		movedFile := &SQLFile{
+			FilePath:   newPath,
+			Statements: sqlFile.Statements,
+			Dirty:      true,
+			LineEnding: sqlFile.LineEnding,
+			Format:     sqlFile.Format,
+		}
+		for _, stmt := range movedFile.Statements {
+			stmt.File = newPath
+		}
+		dir.SQLFiles[newPath] = movedFile
+		sqlFile.Statements = []*tengo.Statement{}
+		sqlFile.Dirty = true
+	}
+	return issues, nil
+}
+
+// RenameObjectsRecursively applies renames to dir and all of its
+// subdirectories, as per Dir.RenameObjects, and then writes all resulting
+// dirty files. Subdirectories are parsed anew from the filesystem. Any
+// possible references which could not be rewritten confidently are returned,
+// for manual review. If an error is returned, some directories may have
+// already been rewritten.
+func (dir *Dir) RenameObjectsRecursively(renames map[tengo.ObjectKey]string) ([]RenameIssue, error) {
+	issues, err := dir.RenameObjects(renames)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", dir, err)
+	}
+	for _, sqlFile := range dir.DirtyFiles() {
+		if _, err := sqlFile.Write(); err != nil {
+			return nil, err
+		}
+	}
+	subdirs, err := dir.Subdirs()
+	if err != nil {
+		return nil, err
+	}
+	for _, sub := range subdirs {
+		if sub.ParseError != nil {
+			return nil, sub.ParseError
+		}
+		subIssues, err := sub.RenameObjectsRecursively(renames)
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, subIssues...)
+	}
+	return issues, nil
+}
+
+// WriteArchive writes the *.sql files of dir, along with those of the supplied
+// subdirs, to w as a tar archive, or a gzipped tar archive if compress is true.
+// Each of subdirs must be a descendant of dir; typically these are the same
+// Dir values which the caller has already parsed and possibly modified, as
+// nothing is re-parsed from the filesystem. File contents reflect each
+// SQLFile's in-memory statements, as returned by SQLFile.WritePreview, rather
+// than the on-disk contents. Files which would be deleted by SQLFile.Write are
+// omitted. The archive is reproducible: entries are ordered by path, paths are
+// relative to dir and slash-separated, and all header metadata (timestamps,
+// ownership, permissions) is fixed.
+func (dir *Dir) WriteArchive(w io.Writer, compress bool, subdirs ...*Dir) (err error) {
+	contents := make(map[string][]byte)
+	if err := dir.archiveContents(contents, ""); err != nil {
+		return err
+	}
+	for _, sub := range subdirs {
+		rel, err := filepath.Rel(dir.Path, sub.Path)
+		if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("Cannot archive %s: not a subdirectory of %s", sub, dir)
+		}
+		if err := sub.archiveContents(contents, filepath.ToSlash(rel)+"/"); err != nil {
+			return err
+		}
+	}
+	paths := make([]string, 0, len(contents))
+	for path := range contents {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	if compress {
+		gzw := gzip.NewWriter(w)
+		defer func() {
+			if closeErr := gzw.Close(); err == nil {
+				err = closeErr
+			}
+		}()
+		w = gzw
+	}
+	tw := tar.NewWriter(w)
+	for _, path := range paths {
+		header := &tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     path,
+			Size:     int64(len(contents[path])),
+			Mode:     0644,
+			ModTime:  time.Unix(0, 0),
+			Format:   tar.FormatPAX,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := tw.Write(contents[path]); err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
+
+// archiveContents populates contents with the in-memory contents of dir's
+// *.sql files, keyed by slash-separated path with the supplied prefix.
+func (dir *Dir) archiveContents(contents map[string][]byte, prefix string) error {
+	if dir.ParseError != nil {
+		return dir.ParseError
+	}
+	for _, sqlFile := range dir.SQLFiles {
+		if data := sqlFile.WritePreview(); data != nil {
+			contents[prefix+sqlFile.FileName()] = data
+		}
+	}
+	return nil
+}
+
 // Instances returns 0 or more tengo.Instance pointers, based on the
 // directory's configuration. The Instances will NOT be checked for
 // connectivity. However, if the configuration is invalid (for example, illegal
@@ -385,11 +881,15 @@ func (dir *Dir) SchemaNames(instance *tengo.Instance) (names []string, err error
 
 	rawSchemaValue := dir.Config.GetRaw("schema")                  // Does not strip quotes
 	if rawSchemaValue != schemaValue && rawSchemaValue[0] == '`' { // no need to check len: since non-raw value isn't empty, raw value can't be empty
+		password, err := util.ConfiguredPassword(dir.Config)
+		if err != nil {
+			return nil, err
+		}
 		variables := map[string]string{
 			"HOST":        instance.Host,
 			"PORT":        strconv.Itoa(instance.Port),
 			"USER":        dir.Config.GetAllowEnvVar("user"),
-			"PASSWORD":    dir.Config.GetAllowEnvVar("password"),
+			"PASSWORD":    password,
 			"ENVIRONMENT": dir.Config.Get("environment"),
 			"DIRNAME":     dir.BaseName(),
 			"DIRPATH":     dir.Path,
@@ -521,7 +1021,7 @@ func (dir *Dir) InstanceDefaultParams() (string, error) {
 		"default_storage_engine": true, // always set to InnoDB later in this method
 	}
 
-	options, err := util.SplitConnectOptions(dir.Config.Get("connect-options"))
+	options, err := util.SplitConnectOptionsOrdered(dir.Config.Get("connect-options"))
 	if err != nil {
 		return "", ConfigError{err}
 	}
@@ -550,7 +1050,8 @@ func (dir *Dir) InstanceDefaultParams() (string, error) {
 	v.Set("tls", sslMode)
 
 	// Set values from connect-options
-	for name, value := range options {
+	for _, opt := range options {
+		name, value := opt.Name, opt.Value
 		if banned[strings.ToLower(name)] {
 			return "", ConfigErrorf("connect-options is not allowed to contain %s", name)
 		}
@@ -588,7 +1089,9 @@ var cachedInteractivePasswords = make(map[string]string)
 // password from STDIN if one should be obtained based on the directory's
 // configuration. If interactive input is requested and successful, the password
 // will be returned and also cached, so that subsequent identical requests
-// return the password without prompting.
+// return the password without prompting. A configured password value of the
+// form "file:/path/to/file" is read from the named file; see
+// util.ConfiguredPassword for details.
 //
 // Optionally supply one or more hostnames to affect the behavior of interactive
 // password prompts and caching: with no hosts, the prompt text will mention the
@@ -605,7 +1108,7 @@ func (dir *Dir) Password(hosts ...string) (string, error) {
 	// like other Config getters. This allows us to differentiate between "prompt
 	// on STDIN" and "intentionally no/blank password" situations.
 	if dir.Config.GetRaw("password") != "" {
-		return dir.Config.GetAllowEnvVar("password"), nil
+		return util.ConfiguredPassword(dir.Config)
 	}
 
 	cacheKeys := make([]string, len(hosts))
@@ -682,16 +1185,30 @@ func (dir *Dir) parseContents() {
 		return
 	}
 
-	// Tokenize and parse any *.sql files
+	if dir.Layout, dir.ParseError = LayoutFromConfig(dir.Config); dir.ParseError != nil {
+		return
+	}
+	dir.FileFormat = SQLFileFormatFromConfig(dir.Config)
+
+	// Tokenize and parse any *.sql files, including those in object type subdirs
+	// if using TypeDirFileLayout
 	var sqlFilePaths []string
 	if sqlFilePaths, dir.ParseError = sqlFiles(dir.Path, dir.repoBase); dir.ParseError != nil {
 		return
 	}
+	if _, ok := dir.Layout.(TypeDirFileLayout); ok {
+		var typeDirPaths []string
+		if typeDirPaths, dir.ParseError = typeDirSQLFiles(dir.Path, dir.repoBase); dir.ParseError != nil {
+			return
+		}
+		sqlFilePaths = append(sqlFilePaths, typeDirPaths...)
+	}
 	dir.SQLFiles = make(map[string]*SQLFile, len(sqlFilePaths))
 	logicalSchemasByName := make(map[string]*LogicalSchema)
 	for _, filePath := range sqlFilePaths {
 		sf := &SQLFile{
 			FilePath: filePath,
+			Format:   dir.FileFormat,
 		}
 		sf.Statements, dir.ParseError = tengo.ParseStatementsInFile(filePath)
 		if dir.ParseError != nil {
@@ -703,6 +1220,8 @@ func (dir *Dir) parseContents() {
 			// quote for example.
 			return
 		}
+		sf.Header, sf.Statements = splitHeader(sf.Statements)
+		sf.LineEnding = DetectLineEnding(sf.Statements)
 		for _, stmt := range sf.Statements {
 			// Statements that are ignored due to ignore-table, ignore-proc, etc are
 			// simply not placed into a LogicalSchema, so that all other logic won't
@@ -755,6 +1274,7 @@ func (dir *Dir) parseContents() {
 	if ls, ok := logicalSchemasByName[""]; ok {
 		ls.CharSet = dir.Config.Get("default-character-set")
 		ls.Collation = dir.Config.Get("default-collation")
+		ls.Comment = dir.Config.Get("schema-comment")
 		dir.LogicalSchemas = append(dir.LogicalSchemas, ls)
 		delete(logicalSchemasByName, "")
 	}
@@ -863,6 +1383,212 @@ func HostDefaultDirName(hostname string, port int) string {
 	return hostname
 }
 
+// InitSchemaDir introspects the schema named schemaName on instance, and then
+// uses WriteSchemaDir to create a new directory at dirPath containing its
+// definitions. This is the reusable core of `skeema init`, minus any
+// host-level directory handling.
+func InitSchemaDir(instance *tengo.Instance, schemaName, dirPath string) error {
+	schema, err := instance.Schema(schemaName)
+	if err != nil {
+		return err
+	}
+	return WriteSchemaDir(schema, dirPath)
+}
+
+// renameDir is used by WriteSchemaDir to move its fully-populated temporary
+// directory into place. It is a variable to permit failure injection in tests.
+var renameDir = os.Rename
+
+// WriteSchemaDir creates a new directory at dirPath, containing a .skeema
+// option file (with the schema name, default character set, and default
+// collation) and *.sql files for all tables and routines in schema. Table
+// definitions have their AUTO_INCREMENT clause stripped, matching the default
+// behavior of `skeema init`. It is an error if dirPath already exists.
+// All files are first written to a temporary sibling directory, which is only
+// renamed to dirPath once everything has been written successfully. If any step
+// fails, the temporary directory is removed, so a failed call never leaves a
+// partially-populated directory behind.
+func WriteSchemaDir(schema *tengo.Schema, dirPath string) (err error) {
+	dirPath, err = filepath.Abs(filepath.Clean(dirPath))
+	if err != nil {
+		return err
+	}
+	if _, err := os.Lstat(dirPath); err == nil {
+		return fmt.Errorf("Cannot initialize %s: path already exists", dirPath)
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	parentPath, baseName := filepath.Split(dirPath)
+	tmpPath := filepath.Join(parentPath, fmt.Sprintf(".%s.%d.tmp", baseName, os.Getpid()))
+	if err := os.Mkdir(tmpPath, 0777); err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			os.RemoveAll(tmpPath)
+		}
+	}()
+
+	optionFile := mybase.NewFile(tmpPath, ".skeema")
+	optionFile.SetOptionValue("", "schema", schema.Name)
+	optionFile.SetOptionValue("", "default-character-set", schema.CharSet)
+	optionFile.SetOptionValue("", "default-collation", schema.Collation)
+	if schema.Comment != "" {
+		optionFile.SetOptionValue("", "schema-comment", schema.Comment)
+	}
+	if err := optionFile.Write(false); err != nil {
+		return fmt.Errorf("Unable to write to %s: %s", optionFile.Path(), err)
+	}
+
+	// Sort object keys so that files containing multiple objects (e.g. names
+	// which only differ by special characters) have a deterministic order
+	objects := schema.Objects()
+	keys := make([]tengo.ObjectKey, 0, len(objects))
+	for key := range objects {
+		keys = append(keys, key)
+	}
+	tengo.SortObjectKeys(keys)
+	files := make(map[string]*SQLFile)
+	var filePaths []string
+	for _, key := range keys {
+		create := objects[key].Def()
+		if key.Type == tengo.ObjectTypeTable {
+			create, _ = tengo.ParseCreateAutoInc(create)
+		}
+		stmt := tengo.ParseStatementInString(create)
+		if stmt.Type != tengo.StatementTypeCreate || stmt.ObjectKey() != key {
+			return fmt.Errorf("%s is unexpectedly not able to be parsed by Skeema", key)
+		}
+		filePath := PathForObject(tmpPath, key.Name)
+		if files[filePath] == nil {
+			files[filePath] = &SQLFile{FilePath: filePath}
+			filePaths = append(filePaths, filePath)
+		}
+		if err := files[filePath].AddStatement(stmt); err != nil {
+			return err
+		}
+	}
+	for _, filePath := range filePaths {
+		if _, err := files[filePath].Write(); err != nil {
+			return err
+		}
+	}
+	return renameDir(tmpPath, dirPath)
+}
+
+// SplitDumpFile parses a single combined dump file, such as the output of
+// mysqldump, and writes a new directory at dirPath using the standard layout of
+// one *.sql file per object name. Only CREATE TABLE, CREATE PROCEDURE, and
+// CREATE FUNCTION statements are retained; all other statements (including
+// DROP TABLE IF EXISTS, INSERTs, LOCK TABLES, and SET commands) are skipped.
+// Schema name qualifiers are removed from object names, DELIMITER commands are
+// regenerated as needed for compound statements, and table definitions have
+// their AUTO_INCREMENT clause stripped, matching the default behavior of
+// `skeema init`.
+// If the dump's statements are all associated with a single schema (via a USE
+// command or schema-qualified object names), a .skeema option file is written
+// to dirPath with that schema name. If the dump spans multiple schemas, a
+// subdirectory (with its own .skeema file) is written for each schema instead,
+// aside from any statements preceding the first USE command.
+// It is an error if dirPath already exists. As with WriteSchemaDir, a failed
+// call never leaves a partially-populated directory behind.
+func SplitDumpFile(dumpPath, dirPath string) (err error) {
+	stmts, err := tengo.ParseStatementsInFile(dumpPath)
+	if err != nil {
+		return err
+	}
+	dirPath, err = filepath.Abs(filepath.Clean(dirPath))
+	if err != nil {
+		return err
+	}
+	if _, err := os.Lstat(dirPath); err == nil {
+		return fmt.Errorf("Cannot split %s into %s: path already exists", dumpPath, dirPath)
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	// Group CREATE statements by schema, retaining the order of the dump
+	var schemaNames []string
+	bySchema := make(map[string][]*tengo.Statement)
+	seen := make(map[string]map[tengo.ObjectKey]bool)
+	for _, stmt := range stmts {
+		if stmt.Type != tengo.StatementTypeCreate {
+			continue
+		}
+		schemaName := stmt.Schema()
+		if stmt.ObjectType == tengo.ObjectTypeDatabase {
+			schemaName = stmt.ObjectName
+		} else if stmt.ObjectType != tengo.ObjectTypeTable && stmt.ObjectType != tengo.ObjectTypeProc && stmt.ObjectType != tengo.ObjectTypeFunc {
+			continue
+		}
+		if seen[schemaName] == nil {
+			seen[schemaName] = make(map[tengo.ObjectKey]bool)
+			schemaNames = append(schemaNames, schemaName)
+		}
+		if stmt.ObjectType == tengo.ObjectTypeDatabase {
+			continue
+		} else if seen[schemaName][stmt.ObjectKey()] {
+			return fmt.Errorf("%s: %s is defined more than once", stmt.Location(), stmt.ObjectKey())
+		}
+		seen[schemaName][stmt.ObjectKey()] = true
+		bySchema[schemaName] = append(bySchema[schemaName], stmt)
+	}
+
+	parentPath, baseName := filepath.Split(dirPath)
+	tmpPath := filepath.Join(parentPath, fmt.Sprintf(".%s.%d.tmp", baseName, os.Getpid()))
+	if err := os.Mkdir(tmpPath, 0777); err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			os.RemoveAll(tmpPath)
+		}
+	}()
+
+	for _, schemaName := range schemaNames {
+		schemaPath := tmpPath
+		if len(schemaNames) > 1 && schemaName != "" {
+			schemaPath = filepath.Join(tmpPath, strings.TrimSuffix(FileNameForObject(schemaName), ".sql"))
+			if err := os.Mkdir(schemaPath, 0777); err != nil {
+				return err
+			}
+		}
+		if schemaName != "" {
+			optionFile := mybase.NewFile(schemaPath, ".skeema")
+			optionFile.SetOptionValue("", "schema", schemaName)
+			if err := optionFile.Write(false); err != nil {
+				return fmt.Errorf("Unable to write to %s: %s", optionFile.Path(), err)
+			}
+		}
+		files := make(map[string]*SQLFile)
+		var filePaths []string
+		for _, dumpStmt := range bySchema[schemaName] {
+			create := dumpStmt.Body()
+			if dumpStmt.ObjectType == tengo.ObjectTypeTable {
+				create, _ = tengo.ParseCreateAutoInc(create)
+			}
+			stmt := tengo.ParseStatementInString(create)
+			if stmt.Type != tengo.StatementTypeCreate || stmt.ObjectKey() != dumpStmt.ObjectKey() {
+				return fmt.Errorf("%s: %s is unexpectedly not able to be parsed by Skeema", dumpStmt.Location(), dumpStmt.ObjectKey())
+			}
+			filePath := PathForObject(schemaPath, stmt.ObjectName)
+			if files[filePath] == nil {
+				files[filePath] = &SQLFile{FilePath: filePath}
+				filePaths = append(filePaths, filePath)
+			}
+			if err := files[filePath].AddStatement(stmt); err != nil {
+				return fmt.Errorf("%s: %w", dumpStmt.Location(), err)
+			}
+		}
+		for _, filePath := range filePaths {
+			if _, err := files[filePath].Write(); err != nil {
+				return err
+			}
+		}
+	}
+	return renameDir(tmpPath, dirPath)
+}
+
 // ancestorPaths returns a slice of absolute paths of dirPath and all its
 // ancestor directories. The result is ordered such that dirPath is first,
 // followed by its parent dir, then grandparent, etc, with the root of the
@@ -917,6 +1643,9 @@ func parseOptionFile(dirPath, repoBase string, baseConfig *mybase.Config) (*myba
 	if err := f.Parse(baseConfig); err != nil {
 		return nil, ConfigError{err}
 	}
+	if err := util.ValidateConnectOptions(f); err != nil {
+		return nil, ConfigError{err}
+	}
 	_ = f.UseSection(baseConfig.Get("environment")) // we don't care if the section doesn't exist
 	return f, nil
 }
@@ -970,6 +1699,73 @@ func sqlFiles(dirPath, repoBase string) (result []string, err error) {
 	return result, nil
 }
 
+// IgnoredSQLFiles returns a slice of absolute file paths for all *.sql files
+// in dirPath and its non-hidden subdirectories (recursively) which match the
+// supplied glob patterns, for example to skip seed data or migration scripts
+// stored alongside schema files. Patterns use the syntax of filepath.Match, and
+// are evaluated against each file's path relative to dirPath, or just against
+// the file's base name if the pattern does not contain a path separator.
+// Patterns are evaluated in order, and the last matching pattern determines
+// the result: a pattern beginning with "!" un-ignores files matched by
+// earlier patterns. An error is returned if any pattern is malformed, or if
+// dirPath cannot be read.
+func IgnoredSQLFiles(dirPath string, patterns []string) (result []string, err error) {
+	for _, pattern := range patterns {
+		if _, err := filepath.Match(strings.TrimPrefix(pattern, "!"), ""); err != nil {
+			return nil, fmt.Errorf("Invalid SQL file ignore pattern %q: %w", pattern, err)
+		}
+	}
+	if dirPath, err = filepath.Abs(dirPath); err != nil {
+		return nil, err
+	}
+	var walk func(subPath string) error
+	walk = func(subPath string) error {
+		filePaths, err := sqlFiles(subPath, dirPath)
+		if err != nil {
+			return err
+		}
+		for _, filePath := range filePaths {
+			if relPath, err := filepath.Rel(dirPath, filePath); err == nil && sqlFileIgnored(relPath, patterns) {
+				result = append(result, filePath)
+			}
+		}
+		entries, err := os.ReadDir(subPath)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if entry.IsDir() && entry.Name()[0] != '.' {
+				if err := walk(filepath.Join(subPath, entry.Name())); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	if err := walk(dirPath); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// sqlFileIgnored returns true if relPath should be ignored based on the
+// supplied glob patterns, as described in IgnoredSQLFiles. Malformed patterns
+// are treated as non-matching.
+func sqlFileIgnored(relPath string, patterns []string) (ignored bool) {
+	for _, pattern := range patterns {
+		negate := strings.HasPrefix(pattern, "!")
+		pattern = filepath.FromSlash(strings.TrimPrefix(pattern, "!"))
+		subject := relPath
+		if !strings.ContainsRune(pattern, filepath.Separator) {
+			subject = filepath.Base(relPath)
+		}
+		if matched, _ := filepath.Match(pattern, subject); matched {
+			ignored = !negate
+		}
+	}
+	return ignored
+}
+
 // ConfigError indicates a misconfiguration in the directory's .skeema file or
 // the command-line overrides.
 type ConfigError struct {
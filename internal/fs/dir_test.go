@@ -1,13 +1,18 @@
 package fs
 
 import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
 	"errors"
 	"fmt"
+	"io"
 	"net/url"
 	"os"
 	"path/filepath"
 	"reflect"
 	"runtime"
+	"sort"
 	"strings"
 	"testing"
 
@@ -410,6 +415,26 @@ func TestDirSubdirs(t *testing.T) {
 	}
 }
 
+func TestDirFileFormat(t *testing.T) {
+	dir := getDir(t, "testdata/host/db")
+	if dir.FileFormat != (SQLFileFormat{}) {
+		t.Errorf("Expected zero-value FileFormat by default, instead found %+v", dir.FileFormat)
+	}
+	dir = getDirWithCLI(t, "testdata/host/db", "--normalize-whitespace")
+	expected := SQLFileFormat{TrailingNewline: true, BlankLineBetween: true}
+	if dir.FileFormat != expected {
+		t.Errorf("Expected FileFormat %+v, instead found %+v", expected, dir.FileFormat)
+	}
+	for _, sf := range dir.SQLFiles {
+		if sf.Format != expected {
+			t.Errorf("Expected %s to have Format %+v, instead found %+v", sf.FilePath, expected, sf.Format)
+		}
+	}
+	if sf := dir.FileFor(tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: "doesnt_exist"}); sf.Format != expected {
+		t.Errorf("Expected new SQLFile to have Format %+v, instead found %+v", expected, sf.Format)
+	}
+}
+
 func TestDirFileFor(t *testing.T) {
 	dir := getDir(t, "testdata/host/db")
 
@@ -477,6 +502,330 @@ func TestDirDirtyFiles(t *testing.T) {
 			}
 		}
 	}
+
+	// Since the dirty files' contents haven't actually changed, the patch preview
+	// should be empty. After removing a statement, it should reflect that.
+	if patch, err := dir.PatchPreview(); patch != "" || err != nil {
+		t.Errorf("Unexpected result from PatchPreview: %q / %v", patch, err)
+	}
+	for _, sf := range dir.DirtyFiles() {
+		if strings.HasSuffix(sf.FilePath, "posts.sql") {
+			body, _ := sf.Statements[0].SplitTextBody()
+			sf.EditStatementText(sf.Statements[0], strings.Replace(body, "`body` text", "`body` mediumtext", 1), false)
+		}
+	}
+	if patch, err := dir.PatchPreview(); !strings.HasPrefix(patch, "--- a/posts.sql\n+++ b/posts.sql\n") || !strings.Contains(patch, "\n+  `body` mediumtext,\n") || err != nil {
+		t.Errorf("Unexpected result from PatchPreview: %q / %v", patch, err)
+	}
+}
+
+func TestDirWriteArchive(t *testing.T) {
+	dir := getDir(t, "testdata/host")
+	sub, err := dir.Subdir("db")
+	if err != nil {
+		t.Fatalf("Unexpected error from Subdir: %v", err)
+	}
+
+	var b1, b2 bytes.Buffer
+	if err := dir.WriteArchive(&b1, false, sub); err != nil {
+		t.Fatalf("Unexpected error from WriteArchive: %v", err)
+	}
+	if err := dir.WriteArchive(&b2, false, sub); err != nil {
+		t.Fatalf("Unexpected error from WriteArchive: %v", err)
+	} else if !bytes.Equal(b1.Bytes(), b2.Bytes()) {
+		t.Error("Expected WriteArchive output to be deterministic, but it was not")
+	}
+	tr := tar.NewReader(&b1)
+	var names []string
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("Unexpected error reading archive: %v", err)
+		}
+		names = append(names, header.Name)
+		data, _ := io.ReadAll(tr)
+		expected := sub.SQLFiles[filepath.Join(sub.Path, filepath.Base(header.Name))].WritePreview()
+		if !bytes.Equal(data, expected) {
+			t.Errorf("Unexpected contents for %s in archive", header.Name)
+		}
+	}
+	expectedNames := []string{"db/comments.sql", "db/posts.sql", "db/subscriptions.sql", "db/users.sql"}
+	if !reflect.DeepEqual(names, expectedNames) {
+		t.Errorf("Unexpected archive entries: expected %v, found %v", expectedNames, names)
+	}
+
+	// Without any subdirs supplied, only dir's own files are included, and it
+	// has none. Supplying a non-descendant is an error.
+	b2.Reset()
+	if err := dir.WriteArchive(&b2, false); err != nil {
+		t.Fatalf("Unexpected error from WriteArchive: %v", err)
+	} else if _, err := tar.NewReader(&b2).Next(); err != io.EOF {
+		t.Error("Expected archive without subdirs to be empty, but it was not")
+	}
+	if err := sub.WriteArchive(&b2, false, dir); err == nil {
+		t.Error("Expected error from WriteArchive with non-descendant subdir, but err was nil")
+	}
+
+	// In-memory changes to the supplied subdir should be reflected in the
+	// archive, and files lacking any CREATEs omitted; confirm gzip output is
+	// readable too
+	posts := sub.FileFor(tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: "posts"})
+	posts.AddStatement(tengo.ParseStatementInString("CREATE TABLE posts_archive (id int)"))
+	comments := sub.FileFor(tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: "comments"})
+	comments.Statements = nil
+	b1.Reset()
+	if err := dir.WriteArchive(&b1, true, sub); err != nil {
+		t.Fatalf("Unexpected error from WriteArchive: %v", err)
+	}
+	gzr, err := gzip.NewReader(&b1)
+	if err != nil {
+		t.Fatalf("Unexpected error from gzip.NewReader: %v", err)
+	}
+	tr = tar.NewReader(gzr)
+	names = nil
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("Unexpected error reading archive: %v", err)
+		}
+		names = append(names, header.Name)
+		if data, _ := io.ReadAll(tr); header.Name == "db/posts.sql" && !bytes.Equal(data, posts.WritePreview()) {
+			t.Errorf("Unexpected contents for %s in archive", header.Name)
+		}
+	}
+	expectedNames = []string{"db/posts.sql", "db/subscriptions.sql", "db/users.sql"}
+	if !reflect.DeepEqual(names, expectedNames) {
+		t.Errorf("Unexpected archive entries: expected %v, found %v", expectedNames, names)
+	}
+}
+
+func TestDirDropImpact(t *testing.T) {
+	dir := getDir(t, "testdata/host/db")
+	posts := dir.FileFor(tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: "posts"})
+	posts.AddStatement(tengo.ParseStatementInString("CREATE TABLE posts_archive (id int)"))
+	keys := []tengo.ObjectKey{
+		{Type: tengo.ObjectTypeTable, Name: "users"},
+		{Type: tengo.ObjectTypeTable, Name: "comments"},
+		{Type: tengo.ObjectTypeTable, Name: "posts_archive"},
+		{Type: tengo.ObjectTypeTable, Name: "doesnt_exist"},
+		{Type: tengo.ObjectTypeProc, Name: "posts"},
+	}
+	deleted, modified := dir.DropImpact(keys)
+	if len(deleted) != 2 || deleted[0].FileName() != "comments.sql" || deleted[1].FileName() != "users.sql" {
+		t.Errorf("Unexpected deleted files returned by DropImpact: %v", deleted)
+	}
+	if len(modified) != 1 || modified[0] != posts {
+		t.Errorf("Unexpected modified files returned by DropImpact: %v", modified)
+	}
+	if len(posts.Statements) != 2 || !posts.Dirty {
+		t.Error("DropImpact unexpectedly modified a SQLFile")
+	}
+}
+
+func TestDirDropDependents(t *testing.T) {
+	dir := getDir(t, "testdata/host/db")
+	posts := dir.FileFor(tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: "posts"})
+	for _, text := range []string{
+		"CREATE TABLE post_tags (post_id int, tag_id int, FOREIGN KEY (post_id) REFERENCES posts (id))",
+		"CREATE TABLE tag_stats (tag_id int, FOREIGN KEY (tag_id) REFERENCES post_tags (tag_id))",
+		// cycle between two tables which both reference post_tags indirectly
+		"CREATE TABLE cycle_a (id int, b_id int, tag_id int, FOREIGN KEY (b_id) REFERENCES cycle_b (id), FOREIGN KEY (tag_id) REFERENCES tag_stats (tag_id))",
+		"CREATE TABLE cycle_b (id int, a_id int, FOREIGN KEY (a_id) REFERENCES cycle_a (id))",
+		"CREATE TABLE unrelated (id int, FOREIGN KEY (id) REFERENCES users (id))",
+	} {
+		posts.AddStatement(tengo.ParseStatementInString(text))
+	}
+	posts.AddStatement(tengo.ParseStatementInString("CREATE PROCEDURE count_tags() SELECT COUNT(*) FROM post_tags;\n"))
+	posts.AddStatement(tengo.ParseStatementInString("CREATE PROCEDURE report() CALL count_tags();\n"))
+	posts.AddStatement(tengo.ParseStatementInString("CREATE PROCEDURE unrelated_proc() SELECT * FROM users;\n"))
+
+	expected := []tengo.ObjectKey{
+		{Type: tengo.ObjectTypeProc, Name: "count_tags"},
+		{Type: tengo.ObjectTypeProc, Name: "report"},
+		{Type: tengo.ObjectTypeTable, Name: "cycle_a"},
+		{Type: tengo.ObjectTypeTable, Name: "cycle_b"},
+		{Type: tengo.ObjectTypeTable, Name: "post_tags"},
+		{Type: tengo.ObjectTypeTable, Name: "tag_stats"},
+	}
+	if actual := dir.DropDependents(tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: "posts"}); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Unexpected result from DropDependents:\nExpected: %v\nActual:   %v", expected, actual)
+	}
+
+	// Object within a cycle should not include itself
+	expected = []tengo.ObjectKey{
+		{Type: tengo.ObjectTypeTable, Name: "cycle_a"},
+	}
+	if actual := dir.DropDependents(tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: "cycle_b"}); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Unexpected result from DropDependents:\nExpected: %v\nActual:   %v", expected, actual)
+	}
+
+	// Object without any dependents
+	if actual := dir.DropDependents(tengo.ObjectKey{Type: tengo.ObjectTypeProc, Name: "report"}); len(actual) != 0 {
+		t.Errorf("Expected no dependents, instead found %v", actual)
+	}
+}
+
+func TestDirObjectOrder(t *testing.T) {
+	dir := &Dir{Path: t.TempDir()}
+	if keys, err := dir.ObjectOrder(".order"); keys != nil || err != nil {
+		t.Errorf("Expected nonexistent manifest to return nil, nil; instead found %v, %v", keys, err)
+	}
+
+	contents := "# desired order\nusers\n\nprocedure `make_user`\n  TABLE posts  \n"
+	if err := os.WriteFile(filepath.Join(dir.Path, ".order"), []byte(contents), 0666); err != nil {
+		t.Fatalf("Unexpected error writing manifest: %v", err)
+	}
+	keys, err := dir.ObjectOrder(".order")
+	expected := []tengo.ObjectKey{
+		{Name: "users"},
+		{Type: tengo.ObjectTypeProc, Name: "make_user"},
+		{Type: tengo.ObjectTypeTable, Name: "posts"},
+	}
+	if err != nil || !reflect.DeepEqual(keys, expected) {
+		t.Errorf("Unexpected result from ObjectOrder: %v, %v", keys, err)
+	}
+
+	for _, contents := range []string{"view foo\n", "table foo bar\n"} {
+		if err := os.WriteFile(filepath.Join(dir.Path, ".order"), []byte(contents), 0666); err != nil {
+			t.Fatalf("Unexpected error writing manifest: %v", err)
+		}
+		if _, err := dir.ObjectOrder(".order"); err == nil {
+			t.Errorf("Expected error from ObjectOrder with manifest contents %q, but err was nil", contents)
+		}
+	}
+}
+
+func TestDirChecksum(t *testing.T) {
+	dir := getDir(t, "testdata/host/db")
+	checksum := dir.Checksum()
+	if len(checksum) != 64 {
+		t.Fatalf("Unexpected checksum %q", checksum)
+	}
+
+	// Reformatting a statement should not affect the checksum, but should affect
+	// the byte-level contents
+	posts := dir.FileFor(tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: "posts"})
+	fingerprint := posts.Fingerprint()
+	var stmt *tengo.Statement
+	for _, stmt = range posts.Statements {
+		if stmt.Type == tengo.StatementTypeCreate {
+			break
+		}
+	}
+	body, _ := stmt.SplitTextBody()
+	reformatted := "-- reformatted\n" + strings.ReplaceAll(strings.ReplaceAll(body, "`", ""), "\n", "\n\n")
+	posts.EditStatementText(stmt, reformatted, false)
+	if posts.Fingerprint() != fingerprint || dir.Checksum() != checksum {
+		t.Error("Expected cosmetic reformatting to leave checksum unchanged")
+	}
+
+	// Moving a statement to another file should not affect the dir checksum,
+	// but should affect the per-file fingerprints
+	users := dir.FileFor(tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: "users"})
+	posts.RemoveStatement(stmt)
+	users.AddStatement(stmt)
+	if dir.Checksum() != checksum {
+		t.Error("Expected moving a statement between files to leave checksum unchanged")
+	}
+	if posts.Fingerprint() == fingerprint {
+		t.Error("Expected removing a statement to change the file's fingerprint")
+	}
+
+	// Semantic changes should change the checksum
+	users.EditStatementText(stmt, strings.Replace(body, "(", "(extra int, ", 1), false)
+	if dir.Checksum() == checksum {
+		t.Error("Expected semantic change to alter checksum")
+	}
+}
+
+func TestDirFlavorConcerns(t *testing.T) {
+	dir := getDir(t, "testdata/host/db")
+	mysql80, mariadb106 := tengo.ParseFlavor("mysql:8.0"), tengo.ParseFlavor("mariadb:10.6")
+	if concerns := dir.FlavorConcerns(mysql80, mariadb106); len(concerns) != 0 {
+		t.Fatalf("Expected no flavor concerns in unmodified dir, instead found %v", concerns)
+	}
+
+	posts := dir.FileFor(tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: "posts"})
+	posts.AddStatement(tengo.ParseStatementInString("CREATE TABLE addrs (id int, ip inet6, KEY (ip) IGNORED);\n"))
+	posts.AddStatement(tengo.ParseStatementInString("CREATE TABLE pics (id int, loc point SRID 4326) COLLATE=utf8mb4_0900_ai_ci;\n"))
+	concerns := dir.FlavorConcerns(mysql80, mariadb106)
+	expected := []struct {
+		name   string
+		flavor tengo.Flavor
+		clause string
+	}{
+		{"addrs", mysql80, "inet6"},
+		{"addrs", mysql80, "IGNORED"},
+		{"pics", mariadb106, "SRID"},
+		{"pics", mariadb106, "utf8mb4_0900_ai_ci"},
+	}
+	if len(concerns) != len(expected) {
+		t.Fatalf("Expected %d flavor concerns, instead found %d: %v", len(expected), len(concerns), concerns)
+	}
+	for n, concern := range concerns {
+		if concern.Statement.ObjectName != expected[n].name || concern.Flavor != expected[n].flavor || concern.Issue.Clause != expected[n].clause {
+			t.Errorf("Unexpected concern at position %d: %s", n, concern)
+		}
+	}
+}
+
+func TestDirRenameObjects(t *testing.T) {
+	basePath := t.TempDir()
+	WriteTestFile(t, filepath.Join(basePath, ".skeema"), "schema=db\n")
+	WriteTestFile(t, filepath.Join(basePath, "customers.sql"), "-- customer data\nCREATE TABLE `customers` (\n  id int,\n  PRIMARY KEY (id)\n);\n")
+	WriteTestFile(t, filepath.Join(basePath, "orders.sql"), "CREATE TABLE orders (\n  customer_id int,\n  CONSTRAINT fk FOREIGN KEY (customer_id) REFERENCES customers (id)\n);\n")
+	WriteTestFile(t, filepath.Join(basePath, "procs.sql"), "DELIMITER //\nCREATE PROCEDURE tally()\nBEGIN\n  SELECT customers.id FROM customers;\nEND//\nDELIMITER ;\n")
+	WriteTestFile(t, filepath.Join(basePath, "sub", ".skeema"), "schema=db2\n")
+	WriteTestFile(t, filepath.Join(basePath, "sub", "customers.sql"), "CREATE TABLE customers (id int);\nCREATE TABLE clients (id int);\n")
+	renames := map[tengo.ObjectKey]string{
+		{Type: tengo.ObjectTypeTable, Name: "customers"}: "clients",
+	}
+
+	// In-memory rename of a single dir
+	dir := getDir(t, basePath)
+	issues, err := dir.RenameObjects(renames)
+	if err != nil {
+		t.Fatalf("Unexpected error from RenameObjects: %v", err)
+	}
+	if len(issues) != 1 || issues[0].Statement.ObjectName != "tally" || issues[0].Key != (tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: "customers"}) {
+		t.Errorf("Unexpected issues returned from RenameObjects: %+v", issues)
+	} else if !strings.HasSuffix(issues[0].String(), "procs.sql:2:1: possible reference to table `customers` could not be rewritten automatically") {
+		t.Errorf("Unexpected issue string: %s", issues[0])
+	}
+	clientsKey := tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: "clients"}
+	if stmt := dir.LogicalSchemas[0].Creates[clientsKey]; stmt == nil || stmt.File != filepath.Join(basePath, "clients.sql") {
+		t.Errorf("Logical schema not updated as expected: %+v", stmt)
+	}
+	oldFile, newFile := dir.SQLFiles[filepath.Join(basePath, "customers.sql")], dir.SQLFiles[filepath.Join(basePath, "clients.sql")]
+	if !oldFile.Dirty || oldFile.WritePreview() != nil {
+		t.Error("Expected old file to be marked for deletion, but it was not")
+	}
+	if !newFile.Dirty || string(newFile.WritePreview()) != "-- customer data\nCREATE TABLE `clients` (\n  id int,\n  PRIMARY KEY (id)\n);\n" {
+		t.Errorf("Unexpected contents of new file: %q", newFile.WritePreview())
+	}
+	if orders := dir.SQLFiles[filepath.Join(basePath, "orders.sql")]; !orders.Dirty || !strings.Contains(string(orders.WritePreview()), "REFERENCES `clients` (id)") {
+		t.Errorf("Expected foreign key reference to be rewritten, but it was not: %s", orders.WritePreview())
+	}
+
+	// Recursive rename should write the changes, but fail in the subdir due to a
+	// conflict with an existing object
+	dir = getDir(t, basePath)
+	if _, err := dir.RenameObjectsRecursively(renames); err == nil {
+		t.Error("Expected an error from RenameObjectsRecursively due to name conflict in subdir, but err was nil")
+	}
+	if _, err := os.Stat(filepath.Join(basePath, "customers.sql")); !os.IsNotExist(err) {
+		t.Errorf("Expected customers.sql to be removed, but stat returned %v", err)
+	}
+	if contents := ReadTestFile(t, filepath.Join(basePath, "procs.sql")); !strings.Contains(contents, "FROM `clients`;\nEND//\n") {
+		t.Errorf("Unexpected contents of procs.sql after rename:\n%s", contents)
+	}
+	if contents := ReadTestFile(t, filepath.Join(basePath, "sub", "customers.sql")); strings.Contains(contents, "`clients`") {
+		t.Errorf("Expected subdir with conflict to remain unchanged, but it was modified:\n%s", contents)
+	}
 }
 
 func TestDirInstances(t *testing.T) {
@@ -652,6 +1001,58 @@ func TestHostDefaultDirName(t *testing.T) {
 	}
 }
 
+func TestWriteSchemaDir(t *testing.T) {
+	schema := &tengo.Schema{
+		Name:      "product",
+		CharSet:   "utf8mb4",
+		Collation: "utf8mb4_general_ci",
+		Tables: []*tengo.Table{
+			{Name: "posts", CreateStatement: "CREATE TABLE `posts` (\n  `id` int NOT NULL AUTO_INCREMENT,\n  PRIMARY KEY (`id`)\n) ENGINE=InnoDB AUTO_INCREMENT=123 DEFAULT CHARSET=utf8mb4"},
+			{Name: "users", CreateStatement: "CREATE TABLE `users` (\n  `id` int NOT NULL\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4"},
+		},
+		Routines: []*tengo.Routine{
+			{Name: "ping", Type: tengo.ObjectTypeFunc, CreateStatement: "CREATE DEFINER=`root`@`localhost` FUNCTION `ping`() RETURNS int\n    DETERMINISTIC\nBEGIN\n  RETURN 1;\nEND"},
+		},
+	}
+	parentPath := t.TempDir()
+	dirPath := filepath.Join(parentPath, "product")
+
+	// Simulate a failure at the final step: nothing should be left behind
+	renameDir = func(oldPath, newPath string) error {
+		return errors.New("injected failure")
+	}
+	err := WriteSchemaDir(schema, dirPath)
+	renameDir = os.Rename
+	if err == nil {
+		t.Fatal("Expected WriteSchemaDir to return an error, but it did not")
+	}
+	if entries, err := os.ReadDir(parentPath); err != nil || len(entries) > 0 {
+		t.Fatalf("Expected failed WriteSchemaDir to leave no files behind, instead found %d entries (err=%v)", len(entries), err)
+	}
+
+	if err := WriteSchemaDir(schema, dirPath); err != nil {
+		t.Fatalf("Unexpected error from WriteSchemaDir: %v", err)
+	}
+	dir := getDir(t, dirPath)
+	if dir.Config.Get("schema") != "product" || dir.Config.Get("default-collation") != "utf8mb4_general_ci" {
+		t.Errorf("Option file not written as expected: schema=%q default-collation=%q", dir.Config.Get("schema"), dir.Config.Get("default-collation"))
+	}
+	if len(dir.SQLFiles) != 3 || len(dir.LogicalSchemas) != 1 || len(dir.LogicalSchemas[0].Creates) != 3 {
+		t.Fatalf("Unexpected dir contents: %d SQLFiles, %d LogicalSchemas", len(dir.SQLFiles), len(dir.LogicalSchemas))
+	}
+	if contents := ReadTestFile(t, PathForObject(dirPath, "posts")); strings.Contains(contents, "AUTO_INCREMENT=") {
+		t.Errorf("Expected AUTO_INCREMENT clause to be stripped, but it was not:\n%s", contents)
+	}
+	if contents := ReadTestFile(t, PathForObject(dirPath, "ping")); !strings.Contains(contents, "DELIMITER //") {
+		t.Errorf("Expected DELIMITER commands around compound statement, but none found:\n%s", contents)
+	}
+
+	// Writing to an existing path should fail without touching it
+	if err := WriteSchemaDir(schema, dirPath); err == nil {
+		t.Error("Expected WriteSchemaDir to return an error for an existing path, but it did not")
+	}
+}
+
 func TestAncestorPaths(t *testing.T) {
 	type testcase struct {
 		input    string
@@ -800,6 +1201,65 @@ func TestDirPassword(t *testing.T) {
 	}
 }
 
+func TestDirLayout(t *testing.T) {
+	layouts := []struct {
+		layout   FileLayout
+		expected string
+	}{
+		{ObjectFileLayout{}, "foo_bar.sql"},
+		{SchemaFileLayout{}, "schema.sql"},
+		{SchemaFileLayout{FileName: "all.sql"}, "all.sql"},
+		{TypeDirFileLayout{}, filepath.Join("procs", "foo_bar.sql")},
+	}
+	for _, tc := range layouts {
+		if actual := tc.layout.RelPath(tengo.ObjectTypeProc, "foo_bar"); actual != tc.expected {
+			t.Errorf("Expected %T to return %q, instead found %q", tc.layout, tc.expected, actual)
+		}
+	}
+
+	basePath := t.TempDir()
+	WriteTestFile(t, filepath.Join(basePath, ".skeema"), "schema=db\nfile-layout=type\n")
+	WriteTestFile(t, filepath.Join(basePath, "tables", "customers.sql"), "CREATE TABLE customers (id int);\n")
+	WriteTestFile(t, filepath.Join(basePath, "sub", ".skeema"), "schema=db2\n")
+	dir := getDir(t, basePath)
+	if _, ok := dir.Layout.(TypeDirFileLayout); !ok {
+		t.Fatalf("Expected dir to have TypeDirFileLayout, instead found %T", dir.Layout)
+	}
+	if subs, err := dir.Subdirs(); err != nil || len(subs) != 1 || subs[0].BaseName() != "sub" {
+		t.Errorf("Unexpected result from Subdirs: %v, %v", subs, err)
+	}
+	if _, ok := dir.LogicalSchemas[0].Creates[tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: "customers"}]; !ok {
+		t.Error("Expected table in type subdir to be parsed as part of dir, but it was not")
+	}
+
+	// New objects are placed in the appropriate subdir, which is created upon
+	// writing if needed
+	stmt := tengo.ParseStatementInString("CREATE PROCEDURE tally() SELECT 1")
+	sqlFile := dir.FileFor(stmt)
+	if expected := filepath.Join(basePath, "procs", "tally.sql"); sqlFile.FilePath != expected {
+		t.Errorf("Expected FileFor to return path %s, instead found %s", expected, sqlFile.FilePath)
+	}
+	sqlFile.AddStatement(stmt)
+	if _, err := sqlFile.Write(); err != nil {
+		t.Fatalf("Unexpected error from Write: %v", err)
+	}
+	dir = getDir(t, basePath)
+	if len(dir.LogicalSchemas[0].Creates) != 2 {
+		t.Errorf("Expected 2 CREATEs after re-parsing dir, instead found %d", len(dir.LogicalSchemas[0].Creates))
+	}
+
+	// Schema layout: all new objects go in the same file
+	dir = getDirWithCLI(t, basePath, "--file-layout=schema")
+	if sqlFile := dir.FileFor(tengo.ObjectKey{Type: tengo.ObjectTypeFunc, Name: "other"}); sqlFile.FilePath != filepath.Join(basePath, "schema.sql") {
+		t.Errorf("Unexpected path from FileFor: %s", sqlFile.FilePath)
+	}
+
+	// Invalid value is a config error
+	if _, err := ParseDir(basePath, getValidConfigWithCLI(t, "--file-layout=nested")); !errors.As(err, &ConfigError{}) {
+		t.Errorf("Expected invalid file-layout to return ConfigError, instead found %v", err)
+	}
+}
+
 func getValidConfigWithCLI(t *testing.T, cliOptions string) *mybase.Config {
 	t.Helper()
 	cmd := mybase.NewCommand("fstest", "", "", nil)
@@ -835,3 +1295,168 @@ func countParseErrors(subs []*Dir) (count int) {
 	}
 	return
 }
+
+func TestIgnoredSQLFiles(t *testing.T) {
+	dirPath := t.TempDir()
+	for _, name := range []string{"users.sql", "seed_users.sql", "seed_keep.sql", "data/seed_posts.sql", "data/posts.sql", "data/deep/seed_x.sql", "migrations/001.sql", ".hidden/seed_y.sql", "notes.txt"} {
+		WriteTestFile(t, filepath.Join(dirPath, name), "")
+	}
+	assertIgnored := func(patterns []string, expected ...string) {
+		t.Helper()
+		result, err := IgnoredSQLFiles(dirPath, patterns)
+		if err != nil {
+			t.Fatalf("Unexpected error from IgnoredSQLFiles(%v): %v", patterns, err)
+		}
+		var relPaths []string
+		for _, filePath := range result {
+			relPath, _ := filepath.Rel(dirPath, filePath)
+			relPaths = append(relPaths, filepath.ToSlash(relPath))
+		}
+		sort.Strings(relPaths)
+		sort.Strings(expected)
+		if strings.Join(relPaths, ",") != strings.Join(expected, ",") {
+			t.Errorf("Unexpected result from IgnoredSQLFiles(%v)\nExpected: %v\nFound:    %v", patterns, expected, relPaths)
+		}
+	}
+
+	assertIgnored(nil)
+	assertIgnored([]string{"seed_*.sql"}, "seed_users.sql", "seed_keep.sql", "data/seed_posts.sql", "data/deep/seed_x.sql")
+	assertIgnored([]string{"data/*.sql"}, "data/seed_posts.sql", "data/posts.sql")
+	assertIgnored([]string{"data/*/*.sql", "migrations/*"}, "data/deep/seed_x.sql", "migrations/001.sql")
+
+	// Last matching pattern wins, so negation only un-ignores files matched by
+	// earlier patterns
+	assertIgnored([]string{"seed_*.sql", "!seed_keep.sql"}, "seed_users.sql", "data/seed_posts.sql", "data/deep/seed_x.sql")
+	assertIgnored([]string{"!seed_keep.sql", "seed_*.sql"}, "seed_users.sql", "seed_keep.sql", "data/seed_posts.sql", "data/deep/seed_x.sql")
+	assertIgnored([]string{"seed_*.sql", "!data/*.sql", "data/deep/*"}, "seed_users.sql", "seed_keep.sql", "data/deep/seed_x.sql")
+
+	if _, err := IgnoredSQLFiles(dirPath, []string{"[seed"}); err == nil {
+		t.Error("Expected error from malformed pattern, but err was nil")
+	}
+	if _, err := IgnoredSQLFiles(filepath.Join(dirPath, "does-not-exist"), []string{"*"}); err == nil {
+		t.Error("Expected error from nonexistent dir, but err was nil")
+	}
+}
+
+func TestSplitDumpFile(t *testing.T) {
+	dump := "-- MySQL dump 10.13\n" +
+		"/*!40101 SET @OLD_CHARACTER_SET_CLIENT=@@CHARACTER_SET_CLIENT */;\n" +
+		"/*!40103 SET TIME_ZONE='+00:00' */;\n\n" +
+		"CREATE DATABASE /*!32312 IF NOT EXISTS*/ `shop` /*!40100 DEFAULT CHARACTER SET utf8mb4 */;\n\n" +
+		"USE `shop`;\n\n" +
+		"--\n-- Table structure for table `orders`\n--\n\n" +
+		"DROP TABLE IF EXISTS `orders`;\n" +
+		"/*!40101 SET @saved_cs_client     = @@character_set_client */;\n" +
+		"CREATE TABLE `orders` (\n  `id` int NOT NULL AUTO_INCREMENT,\n  PRIMARY KEY (`id`)\n) ENGINE=InnoDB AUTO_INCREMENT=4 DEFAULT CHARSET=utf8mb4;\n" +
+		"/*!40101 SET character_set_client = @saved_cs_client */;\n\n" +
+		"LOCK TABLES `orders` WRITE;\n" +
+		"/*!40000 ALTER TABLE `orders` DISABLE KEYS */;\n" +
+		"INSERT INTO `orders` VALUES (1),(2),(3);\n" +
+		"/*!40000 ALTER TABLE `orders` ENABLE KEYS */;\n" +
+		"UNLOCK TABLES;\n\n" +
+		"DELIMITER ;;\n" +
+		"CREATE DEFINER=`root`@`localhost` PROCEDURE `count_orders`()\nBEGIN\n  SELECT COUNT(*) FROM orders;\nEND ;;\n" +
+		"DELIMITER ;\n" +
+		"CREATE DATABASE /*!32312 IF NOT EXISTS*/ `logs`;\n\n" +
+		"USE `logs`;\n" +
+		"DROP TABLE IF EXISTS `events`;\n" +
+		"CREATE TABLE `events` (\n  `id` bigint NOT NULL\n) ENGINE=InnoDB;\n"
+	baseDir := t.TempDir()
+	dumpPath := filepath.Join(baseDir, "dump.sql")
+	WriteTestFile(t, dumpPath, dump)
+	dirPath := filepath.Join(baseDir, "schemas")
+	if err := SplitDumpFile(dumpPath, dirPath); err != nil {
+		t.Fatalf("Unexpected error from SplitDumpFile: %v", err)
+	}
+
+	expected := map[string]string{
+		"shop/.skeema":          "schema=shop\n",
+		"shop/orders.sql":       "CREATE TABLE `orders` (\n  `id` int NOT NULL AUTO_INCREMENT,\n  PRIMARY KEY (`id`)\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;\n",
+		"shop/count_orders.sql": "DELIMITER //\nCREATE DEFINER=`root`@`localhost` PROCEDURE `count_orders`()\nBEGIN\n  SELECT COUNT(*) FROM orders;\nEND//\nDELIMITER ;\n",
+		"logs/.skeema":          "schema=logs\n",
+		"logs/events.sql":       "CREATE TABLE `events` (\n  `id` bigint NOT NULL\n) ENGINE=InnoDB;\n",
+	}
+	for relPath, contents := range expected {
+		if actual := ReadTestFile(t, filepath.Join(dirPath, relPath)); actual != contents {
+			t.Errorf("Unexpected contents of %s:\nExpected: %q\nFound:    %q", relPath, contents, actual)
+		}
+	}
+	var found int
+	filepath.WalkDir(dirPath, func(path string, d os.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			found++
+		}
+		return nil
+	})
+	if found != len(expected) {
+		t.Errorf("Expected %d files, instead found %d", len(expected), found)
+	}
+
+	// Single-schema dump should be written directly to dirPath
+	dump = "USE `shop`;\nDROP TABLE IF EXISTS `orders`;\nCREATE TABLE `orders` (`id` int);\nINSERT INTO `orders` VALUES (1);\n"
+	WriteTestFile(t, dumpPath, dump)
+	dirPath = filepath.Join(baseDir, "single")
+	if err := SplitDumpFile(dumpPath, dirPath); err != nil {
+		t.Fatalf("Unexpected error from SplitDumpFile: %v", err)
+	}
+	if contents := ReadTestFile(t, filepath.Join(dirPath, ".skeema")); contents != "schema=shop\n" {
+		t.Errorf("Unexpected .skeema contents: %q", contents)
+	}
+	if contents := ReadTestFile(t, filepath.Join(dirPath, "orders.sql")); contents != "CREATE TABLE `orders` (`id` int);\n" {
+		t.Errorf("Unexpected orders.sql contents: %q", contents)
+	}
+
+	// Existing dest dir, or duplicate definitions, should error
+	if err := SplitDumpFile(dumpPath, dirPath); err == nil {
+		t.Error("Expected error from SplitDumpFile with existing dir, but err was nil")
+	}
+	WriteTestFile(t, dumpPath, dump+dump)
+	if err := SplitDumpFile(dumpPath, filepath.Join(baseDir, "dupe")); err == nil {
+		t.Error("Expected error from SplitDumpFile with duplicate definitions, but err was nil")
+	} else if _, err := os.Stat(filepath.Join(baseDir, "dupe")); !os.IsNotExist(err) {
+		t.Errorf("Expected failed SplitDumpFile to not create dir, but Stat returned %v", err)
+	}
+}
+
+func TestCompareDirs(t *testing.T) {
+	fromPath, toPath := t.TempDir(), t.TempDir()
+	WriteTestFile(t, filepath.Join(fromPath, "users.sql"), "CREATE TABLE users (\n  id int NOT NULL,\n  name varchar(30),\n  PRIMARY KEY (id)\n);\n")
+	WriteTestFile(t, filepath.Join(fromPath, "posts.sql"), "CREATE TABLE posts (id int NOT NULL);\n")
+	WriteTestFile(t, filepath.Join(fromPath, "hello.sql"), "CREATE PROCEDURE hello() SELECT 'hello';\n")
+	WriteTestFile(t, filepath.Join(toPath, "users.sql"), "CREATE TABLE users (\n  id int NOT NULL,\n  name varchar(40),\n  email varchar(100),\n  PRIMARY KEY (id)\n);\n")
+	WriteTestFile(t, filepath.Join(toPath, "comments.sql"), "CREATE TABLE comments (id int NOT NULL);\n")
+	WriteTestFile(t, filepath.Join(toPath, "hello.sql"), "-- reformatted\nCREATE   PROCEDURE `hello`()\n  SELECT 'hello';\n")
+
+	diffs := CompareDirs(getDir(t, fromPath), getDir(t, toPath))
+	expected := []struct {
+		key      tengo.ObjectKey
+		diffType tengo.DiffType
+	}{
+		{tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: "comments"}, tengo.DiffTypeCreate},
+		{tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: "posts"}, tengo.DiffTypeDrop},
+		{tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: "users"}, tengo.DiffTypeAlter},
+	}
+	if len(diffs) != len(expected) {
+		t.Fatalf("Expected %d differences, instead found %d: %+v", len(expected), len(diffs), diffs)
+	}
+	for n, diff := range diffs {
+		if diff.Key != expected[n].key || diff.Type != expected[n].diffType || diff.Schema != "" {
+			t.Errorf("Unexpected difference at position %d: %+v", n, diff)
+		}
+	}
+	if diffs[0].From != nil || diffs[0].To == nil || diffs[1].From == nil || diffs[1].To != nil {
+		t.Errorf("Unexpected From/To statements for added or removed object: %+v", diffs[:2])
+	}
+	var elementNames []string
+	for _, dd := range diffs[2].Elements {
+		elementNames = append(elementNames, dd.Element)
+	}
+	if strings.Join(elementNames, ",") != "column `name`,column `email`" {
+		t.Errorf("Unexpected element differences for changed table: %+v", diffs[2].Elements)
+	}
+
+	// Comparing a dir to itself should yield no differences
+	if diffs := CompareDirs(getDir(t, toPath), getDir(t, toPath)); len(diffs) != 0 {
+		t.Errorf("Expected no differences, instead found %+v", diffs)
+	}
+}
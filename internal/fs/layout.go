@@ -0,0 +1,119 @@
+package fs
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/skeema/mybase"
+	"github.com/skeema/skeema/internal/tengo"
+)
+
+// FileLayout determines where the CREATE statement for each object should be
+// placed within a schema's directory, when it does not already exist in a
+// file. This affects which file new objects are written to, for example by
+// `skeema pull`; objects whose statements are already present in a file are
+// never moved by a change in layout.
+type FileLayout interface {
+	// RelPath returns the path of the *.sql file for the supplied object, relative
+	// to the schema's directory.
+	RelPath(objType tengo.ObjectType, objName string) string
+}
+
+// ObjectFileLayout places each object in a file named after the object, as
+// determined by FileNameForObject. This is the default layout.
+type ObjectFileLayout struct{}
+
+// RelPath returns a path for the supplied object. objType is ignored.
+func (ObjectFileLayout) RelPath(_ tengo.ObjectType, objName string) string {
+	return FileNameForObject(objName)
+}
+
+// SchemaFileLayout places all objects in a single file for the schema. If
+// FileName is empty, "schema.sql" is used.
+type SchemaFileLayout struct {
+	FileName string
+}
+
+// RelPath returns the same path for every object.
+func (layout SchemaFileLayout) RelPath(_ tengo.ObjectType, _ string) string {
+	if layout.FileName == "" {
+		return "schema.sql"
+	}
+	return layout.FileName
+}
+
+// TypeDirFileLayout places each object in a file named after the object, within
+// a subdirectory for its object type: tables/foo.sql, procs/foo.sql, or
+// funcs/foo.sql. These subdirectories are treated as part of the schema's
+// directory, rather than as separate directories with their own schemas.
+type TypeDirFileLayout struct{}
+
+// typeDirs maps object types to subdirectory names used by TypeDirFileLayout.
+var typeDirs = map[tengo.ObjectType]string{
+	tengo.ObjectTypeTable: "tables",
+	tengo.ObjectTypeProc:  "procs",
+	tengo.ObjectTypeFunc:  "funcs",
+}
+
+// RelPath returns a path for the supplied object in its type's subdirectory.
+func (TypeDirFileLayout) RelPath(objType tengo.ObjectType, objName string) string {
+	return filepath.Join(typeDirs[objType], FileNameForObject(objName))
+}
+
+// typeDirNames returns the subdirectory names used by TypeDirFileLayout, in
+// sorted order.
+func typeDirNames() []string {
+	names := make([]string, 0, len(typeDirs))
+	for _, name := range typeDirs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// isTypeDir returns true if name is one of the subdirectory names used by
+// TypeDirFileLayout.
+func isTypeDir(name string) bool {
+	for _, typeDir := range typeDirs {
+		if name == typeDir {
+			return true
+		}
+	}
+	return false
+}
+
+// LayoutFromConfig returns the FileLayout specified by the file-layout option
+// in cfg. Valid values are "object" (the default), "schema", and "type". An
+// error is returned if the value is not recognized.
+func LayoutFromConfig(cfg *mybase.Config) (FileLayout, error) {
+	switch value := strings.ToLower(cfg.Get("file-layout")); value {
+	case "", "object":
+		return ObjectFileLayout{}, nil
+	case "schema":
+		return SchemaFileLayout{}, nil
+	case "type":
+		return TypeDirFileLayout{}, nil
+	default:
+		return nil, ConfigErrorf("Option file-layout must be one of \"object\", \"schema\", or \"type\"; found %q", value)
+	}
+}
+
+// typeDirSQLFiles returns the paths of *.sql files in dirPath's object type
+// subdirectories, as used by TypeDirFileLayout.
+func typeDirSQLFiles(dirPath, repoBase string) (result []string, err error) {
+	for _, typeDir := range typeDirNames() {
+		subPath := filepath.Join(dirPath, typeDir)
+		if fi, err := os.Stat(subPath); err != nil || !fi.IsDir() {
+			continue
+		}
+		paths, err := sqlFiles(subPath, repoBase)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to read %s: %w", subPath, err)
+		}
+		result = append(result, paths...)
+	}
+	return result, nil
+}
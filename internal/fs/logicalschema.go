@@ -16,6 +16,7 @@ type LogicalSchema struct {
 	Name      string
 	CharSet   string
 	Collation string
+	Comment   string
 	Creates   map[tengo.ObjectKey]*tengo.Statement
 	Alters    []*tengo.Statement // Alterations that are run after the Creates
 }
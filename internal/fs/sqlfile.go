@@ -2,13 +2,19 @@ package fs
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"os"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
+	"time"
 	"unicode"
 
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/skeema/mybase"
 	"github.com/skeema/skeema/internal/tengo"
 )
 
@@ -17,6 +23,166 @@ type SQLFile struct {
 	FilePath   string
 	Statements []*tengo.Statement
 	Dirty      bool
+	LineEnding string        // "\r\n" if the file predominantly uses CRLF line endings; otherwise "\n" or empty
+	Format     SQLFileFormat // optional formatting normalizations applied by Write
+	Delimiter  string        // preferred delimiter for wrapping compound statements; "//" if empty
+
+	// Header holds the leading comments of the file as originally parsed, if
+	// any, provided that a blank line separates them from the first statement.
+	// Write always emits these first, even if the file's statements have since
+	// been rearranged, so that banners such as copyright notices or "managed by
+	// Skeema" warnings remain stable. Comments directly preceding the first
+	// statement are not a header, as they are considered to describe it.
+	Header []*tengo.Statement
+
+	// PreserveClean causes Write to leave the file's existing bytes untouched if
+	// its statements are semantically unchanged from the file's existing
+	// contents; see SemanticallyUnchanged.
+	PreserveClean bool
+}
+
+// SQLFileFormat controls optional formatting normalizations which are applied
+// when writing a SQLFile. The zero value writes each statement's text exactly
+// as-is.
+type SQLFileFormat struct {
+	// TrailingNewline ensures the file ends with a line ending.
+	TrailingNewline bool
+
+	// BlankLineBetween separates consecutive statements by exactly one blank
+	// line, replacing any existing whitespace between them. Comments and
+	// commands (USE, DELIMITER) preceding a statement are kept together with it,
+	// as is a DELIMITER command restoring the standard delimiter after it.
+	BlankLineBetween bool
+}
+
+// SQLFileFormatFromConfig returns the SQLFileFormat specified by the
+// normalize-whitespace option in cfg.
+func SQLFileFormatFromConfig(cfg *mybase.Config) SQLFileFormat {
+	normalize := cfg.GetBool("normalize-whitespace")
+	return SQLFileFormat{
+		TrailingNewline:  normalize,
+		BlankLineBetween: normalize,
+	}
+}
+
+// DetectLineEnding returns "\r\n" if the majority of line endings in stmts'
+// text are CRLF, or "\n" otherwise.
+func DetectLineEnding(stmts []*tengo.Statement) string {
+	var crlf, lf int
+	for _, stmt := range stmts {
+		total := strings.Count(stmt.Text, "\n")
+		windows := strings.Count(stmt.Text, "\r\n")
+		crlf += windows
+		lf += total - windows
+	}
+	if crlf > lf {
+		return "\r\n"
+	}
+	return "\n"
+}
+
+// splitHeader examines the leading run of comments and whitespace in stmts,
+// and returns the portion of it which forms a header: everything up to and
+// including the last blank line, provided that at least one comment precedes
+// that blank line. If the header ends partway through a statement, that
+// statement is split in two, so the returned statements may differ from the
+// supplied ones. A nil header is returned if there is no header.
+func splitHeader(stmts []*tengo.Statement) (header, result []*tengo.Statement) {
+	var b strings.Builder
+	var n int
+	for n < len(stmts) && stmts[n].Type == tengo.StatementTypeNoop {
+		b.WriteString(stmts[n].Text)
+		n++
+	}
+	pos := headerEnd(b.String())
+	if pos < 0 {
+		return nil, stmts
+	}
+	for n = 0; pos > len(stmts[n].Text); n++ {
+		pos -= len(stmts[n].Text)
+	}
+	if pos == len(stmts[n].Text) {
+		return append([]*tengo.Statement(nil), stmts[:n+1]...), stmts
+	}
+	first, rest := *stmts[n], *stmts[n]
+	first.Text, rest.Text = first.Text[:pos], rest.Text[pos:]
+	rest.LineNo += strings.Count(first.Text, "\n")
+	rest.CharNo = 1
+	result = make([]*tengo.Statement, 0, len(stmts)+1)
+	result = append(result, stmts[:n]...)
+	result = append(result, &first, &rest)
+	result = append(result, stmts[n+1:]...)
+	return append([]*tengo.Statement(nil), result[:n+1]...), result
+}
+
+// headerEnd returns the offset just past the last blank line in text, which
+// must consist solely of comments and whitespace. Blank lines inside of block
+// comments are not considered. If no blank line is found after a comment, -1
+// is returned.
+func headerEnd(text string) int {
+	end := -1
+	var sawComment bool
+	lineBlank := true
+	for i := 0; i < len(text); i++ {
+		switch {
+		case text[i] == '\n':
+			if lineBlank && sawComment {
+				end = i + 1
+			}
+			lineBlank = true
+		case text[i] == ' ' || text[i] == '\t' || text[i] == '\r':
+			// whitespace does not affect lineBlank
+		case strings.HasPrefix(text[i:], "/*"):
+			if closePos := strings.Index(text[i+2:], "*/"); closePos < 0 {
+				i = len(text)
+			} else {
+				i += closePos + 3
+			}
+			sawComment, lineBlank = true, false
+		default: // line comment
+			if eol := strings.IndexByte(text[i:], '\n'); eol < 0 {
+				i = len(text)
+			} else {
+				i += eol - 1
+			}
+			sawComment, lineBlank = true, false
+		}
+	}
+	return end
+}
+
+// statementsWithHeader returns sqlFile's statements, with sqlFile.Header moved
+// to the beginning.
+func (sqlFile *SQLFile) statementsWithHeader() []*tengo.Statement {
+	if len(sqlFile.Header) == 0 {
+		return sqlFile.Statements
+	}
+	inHeader := make(map[*tengo.Statement]bool, len(sqlFile.Header))
+	result := make([]*tengo.Statement, 0, len(sqlFile.Header)+len(sqlFile.Statements))
+	for _, stmt := range sqlFile.Header {
+		inHeader[stmt] = true
+		result = append(result, stmt)
+	}
+	for _, stmt := range sqlFile.Statements {
+		if !inHeader[stmt] {
+			result = append(result, stmt)
+		}
+	}
+	return result
+}
+
+// applyLineEnding converts any bare LF line endings in sqlFile's statements to
+// CRLF, if sqlFile.LineEnding indicates CRLF is in use. This permits methods
+// which add or edit statements to always use LF internally.
+func (sqlFile *SQLFile) applyLineEnding() {
+	if sqlFile.LineEnding != "\r\n" {
+		return
+	}
+	for _, stmt := range sqlFile.Statements {
+		if strings.Count(stmt.Text, "\n") != strings.Count(stmt.Text, "\r\n") {
+			stmt.Text = strings.ReplaceAll(strings.ReplaceAll(stmt.Text, "\r\n", "\n"), "\n", "\r\n")
+		}
+	}
 }
 
 // FileName returns the file name of sqlFile without its directory path.
@@ -40,29 +206,455 @@ func (sqlFile *SQLFile) Delete() error {
 	return os.Remove(sqlFile.FilePath)
 }
 
+// Rename moves sqlFile to newPath, and updates sqlFile.FilePath as well as the
+// File field of each of its statements. If the file already exists in the
+// filesystem, it is moved using os.Rename, so that version control systems
+// can track the change as a move; otherwise only the in-memory values are
+// updated. An error is returned if a different file already exists at newPath,
+// in which case nothing is modified. The file's dirty status is unaffected.
+func (sqlFile *SQLFile) Rename(newPath string) error {
+	if newPath == sqlFile.FilePath {
+		return nil
+	}
+	oldInfo, err := os.Lstat(sqlFile.FilePath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if newInfo, err := os.Lstat(newPath); err == nil {
+		// On a case-insensitive filesystem, newPath may just differ in letter case
+		if oldInfo == nil || !os.SameFile(oldInfo, newInfo) {
+			return fmt.Errorf("cannot rename %s to %s: destination already exists", sqlFile.FilePath, newPath)
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	if oldInfo != nil {
+		if err := os.Rename(sqlFile.FilePath, newPath); err != nil {
+			return err
+		}
+	}
+	sqlFile.FilePath = newPath
+	for _, stmt := range sqlFile.Statements {
+		stmt.File = newPath
+	}
+	return nil
+}
+
+// Reload re-reads and re-parses sqlFile from the filesystem, replacing its
+// statements and unmarking it as dirty. Any in-memory modifications are
+// discarded. If the file cannot be read (including if it no longer exists),
+// the underlying error is returned unchanged and sqlFile is not modified.
+func (sqlFile *SQLFile) Reload() error {
+	stmts, err := tengo.ParseStatementsInFile(sqlFile.FilePath)
+	if err != nil {
+		return err
+	}
+	sqlFile.Header, sqlFile.Statements = splitHeader(stmts)
+	sqlFile.LineEnding = DetectLineEnding(stmts)
+	sqlFile.Dirty = false
+	return nil
+}
+
+// Validate confirms that sqlFile's in-memory statements would be parsed back
+// into the same statements after being written. This can detect corruption
+// introduced by modifications to statement text, such as EditStatementText
+// being supplied text containing an unescaped delimiter, which would otherwise
+// silently change how the file is interpreted. The concatenated text of all
+// statements is re-parsed, and each resulting statement other than whitespace
+// and comments must match the corresponding existing statement's type and text
+// (ignoring surrounding whitespace). The returned error describes the location
+// of the first mismatch, relative to the re-parsed contents.
+func (sqlFile *SQLFile) Validate() error {
+	var b strings.Builder
+	for _, stmt := range sqlFile.Statements {
+		b.WriteString(stmt.Text)
+	}
+	reparsed, err := tengo.ParseStatementsInString(b.String())
+	if err != nil {
+		return fmt.Errorf("%s: %w", sqlFile.FilePath, err)
+	}
+	significant := func(stmts []*tengo.Statement) (result []*tengo.Statement) {
+		for _, stmt := range stmts {
+			if stmt.Type != tengo.StatementTypeNoop {
+				result = append(result, stmt)
+			}
+		}
+		return result
+	}
+	expected, actual := significant(sqlFile.Statements), significant(reparsed)
+	for n, stmt := range actual {
+		if n >= len(expected) {
+			return fmt.Errorf("%s:%d: unexpected additional statement %q", sqlFile.FilePath, stmt.LineNo, strings.TrimSpace(stmt.Text))
+		} else if stmt.Type != expected[n].Type || strings.TrimSpace(stmt.Text) != strings.TrimSpace(expected[n].Text) {
+			return fmt.Errorf("%s:%d: statement boundaries do not match after re-parsing; found %q, expected %q", sqlFile.FilePath, stmt.LineNo, strings.TrimSpace(stmt.Text), strings.TrimSpace(expected[n].Text))
+		}
+	}
+	if len(actual) < len(expected) {
+		return fmt.Errorf("%s: statement %q is missing after re-parsing", sqlFile.FilePath, strings.TrimSpace(expected[len(actual)].Text))
+	}
+	return nil
+}
+
 // Write creates or replaces the SQLFile with the current statements, returning
 // the number of bytes written. If the file's statements now only consist of
 // comments, whitespace, and commands (e.g. USE, DELIMITER) then the file will
 // be deleted instead, and a length of 0 will be returned. The file will be
-// unmarked as dirty if the operation was successful.
+// unmarked as dirty if the operation was successful. The file is replaced
+// atomically unless it has multiple hard links, and permission bits of an
+// existing file are preserved; see WriteAtomic for details.
+//
+// If sqlFile.PreserveClean is true and sqlFile.SemanticallyUnchanged returns
+// true, the file is not modified at all, even if its in-memory statements
+// differ cosmetically from its existing contents. The file is unmarked as dirty,
+// and the size of the existing file is returned.
 func (sqlFile *SQLFile) Write() (n int, err error) {
+	if sqlFile.PreserveClean && sqlFile.SemanticallyUnchanged() {
+		fi, err := os.Stat(sqlFile.FilePath)
+		if err != nil {
+			return 0, err
+		}
+		sqlFile.Dirty = false
+		return int(fi.Size()), nil
+	}
+	return sqlFile.WriteAtomic()
+}
+
+// WriteAtomic behaves like Write, but never leaves a partially-written file in
+// place of the original: the new contents are written and synced to a temporary
+// file in the same directory, which is then renamed over the original. If the
+// file already exists, its permission bits are preserved, as is its owner where
+// the OS permits; otherwise the file is created with the usual 0666 mode,
+// subject to umask. If sqlFile.FilePath is a symlink, the symlink's target is
+// replaced instead of the symlink itself. On error the temporary file is
+// removed, the original file is left untouched, and the file remains marked as
+// dirty.
+//
+// Files with multiple hard links cannot be renamed over without breaking the
+// link, so their contents are copied in from the synced temporary file instead.
+// If this copy fails partway, the temporary file is retained, and the returned
+// error includes its path.
+func (sqlFile *SQLFile) WriteAtomic() (n int, err error) {
+	contents := sqlFile.WritePreview()
+	if contents == nil {
+		if err = sqlFile.Delete(); err == nil {
+			sqlFile.Dirty = false
+		}
+		return 0, err
+	}
+
+	// The parent directory may not exist yet, for example with TypeDirFileLayout
+	if err = os.MkdirAll(filepath.Dir(sqlFile.FilePath), 0777); err != nil {
+		return 0, err
+	}
+	targetPath := sqlFile.FilePath
+	if resolved, evalErr := filepath.EvalSymlinks(targetPath); evalErr == nil {
+		targetPath = resolved
+	}
+	existing, statErr := os.Stat(targetPath)
+
+	tempFile, err := createTempSibling(targetPath)
+	if err != nil {
+		return 0, err
+	}
+	tempPath := tempFile.Name()
+	var keepTemp bool
+	defer func() {
+		if err != nil && !keepTemp {
+			os.Remove(tempPath)
+		}
+	}()
+	if n, err = tempFile.Write(contents); err != nil {
+		tempFile.Close()
+		return 0, err
+	}
+	if err = tempFile.Sync(); err != nil {
+		tempFile.Close()
+		return 0, err
+	}
+	if err = tempFile.Close(); err != nil {
+		return 0, err
+	}
+
+	if statErr == nil && existing.Mode().IsRegular() && hardLinkCount(existing) > 1 {
+		if err = overwriteFile(targetPath, contents); err != nil {
+			keepTemp = true
+			return 0, fmt.Errorf("%w; new contents of %s remain in %s", err, sqlFile.FilePath, tempPath)
+		}
+		os.Remove(tempPath)
+		sqlFile.Dirty = false
+		return n, nil
+	}
+
+	if statErr == nil {
+		if err = os.Chmod(tempPath, existing.Mode().Perm()); err != nil {
+			return 0, err
+		}
+		copyOwner(tempPath, existing) // best-effort: typically requires privileges
+	}
+	if err = os.Rename(tempPath, targetPath); err != nil {
+		return 0, err
+	}
+	sqlFile.Dirty = false
+	return n, nil
+}
+
+// overwriteFile replaces the contents of the existing file at filePath in
+// place, preserving its inode and therefore any hard links to it. The file is
+// only truncated after the new contents have been written, and is synced
+// before returning.
+func overwriteFile(filePath string, contents []byte) error {
+	f, err := os.OpenFile(filePath, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	if _, err = f.WriteAt(contents, 0); err == nil {
+		if err = f.Truncate(int64(len(contents))); err == nil {
+			err = f.Sync()
+		}
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// createTempSibling creates and opens a new temporary file in the same
+// directory as filePath. Unlike os.CreateTemp, the file is created with mode
+// 0666 (before umask), to match the mode of files created by os.WriteFile. The
+// file name begins with a dot and does not end in .sql, so it will not be
+// mistaken for a SQLFile if it is somehow left behind.
+func createTempSibling(filePath string) (f *os.File, err error) {
+	dir, base := filepath.Split(filePath)
+	for attempt := 0; attempt < 10; attempt++ {
+		tempPath := filepath.Join(dir, fmt.Sprintf(".%s.%d.%d.tmp", base, os.Getpid(), time.Now().UnixNano()+int64(attempt)))
+		f, err = os.OpenFile(tempPath, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0666)
+		if !os.IsExist(err) {
+			return f, err
+		}
+	}
+	return nil, err
+}
+
+// WritePreview returns the contents that Write would write to the file, without
+// actually modifying the filesystem. Any normalizations requested by
+// sqlFile.Format are applied to the returned contents, but not to sqlFile's
+// statements; the same is true of re-emitting sqlFile.Header first. If Write
+// would delete the file instead, due to it lacking any statements other than
+// comments, whitespace, and commands, a nil slice is returned.
+func (sqlFile *SQLFile) WritePreview() []byte {
 	var b bytes.Buffer
 	var keepFile bool
-	for _, stmt := range sqlFile.Statements {
-		b.WriteString(stmt.Text)
+	lineEnding := "\n"
+	if sqlFile.LineEnding == "\r\n" {
+		lineEnding = "\r\n"
+	}
+	endLine := func() {
+		if b.Len() > 0 && !bytes.HasSuffix(b.Bytes(), []byte("\n")) {
+			b.WriteString(lineEnding)
+		}
+	}
+	var pendingBlank bool
+	for _, stmt := range sqlFile.statementsWithHeader() {
+		text := stmt.Text
+		if sqlFile.Format.BlankLineBetween {
+			if stmt.Type == tengo.StatementTypeNoop && strings.TrimSpace(text) == "" {
+				continue // whitespace between statements is replaced by a single blank line
+			}
+			if pendingBlank && !(isDelimiterCommand(stmt) && delimiterCommandValue(stmt) == ";") {
+				endLine()
+				b.WriteString(lineEnding)
+				pendingBlank = false
+				if stmt.Type == tengo.StatementTypeNoop {
+					text = strings.TrimLeft(text, " \t\r\n") // comment may include preceding whitespace
+				}
+			} else if pendingBlank {
+				endLine()
+			}
+		}
+		b.WriteString(text)
 		if stmt.Type != tengo.StatementTypeNoop && stmt.Type != tengo.StatementTypeCommand {
 			keepFile = true
+			pendingBlank = true
 		}
 	}
-	if keepFile {
-		n, err = b.Len(), os.WriteFile(sqlFile.FilePath, b.Bytes(), 0666)
-	} else {
-		err = sqlFile.Delete()
+	if !keepFile {
+		return nil
 	}
-	if err == nil {
-		sqlFile.Dirty = false
+	if sqlFile.Format.TrailingNewline {
+		endLine()
+	}
+	return b.Bytes()
+}
+
+// StatementsByType returns sqlFile's statements which have any of the supplied
+// types, in their original order. For example, this can be used to iterate
+// over only the CREATE statements in the file, skipping commands, comments,
+// and whitespace.
+func (sqlFile *SQLFile) StatementsByType(types ...tengo.StatementType) (result []*tengo.Statement) {
+	for _, stmt := range sqlFile.Statements {
+		for _, typ := range types {
+			if stmt.Type == typ {
+				result = append(result, stmt)
+				break
+			}
+		}
+	}
+	return result
+}
+
+// Fingerprint returns a hex-encoded SHA-256 checksum of the object definitions
+// in sqlFile's current statements. The checksum is semantic rather than
+// byte-level: it is unaffected by the order of statements, comments,
+// whitespace, commands, and other cosmetic formatting differences, as per
+// tengo.Statement.CanonicalText.
+func (sqlFile *SQLFile) Fingerprint() string {
+	return fingerprintStatements(sqlFile.Statements)
+}
+
+// SemanticallyUnchanged returns true if sqlFile already exists in the
+// filesystem, and its in-memory statements are semantically identical to the
+// file's existing contents, meaning any differences only affect comments,
+// whitespace, commands, backtick quoting, keyword case, or statement order.
+// Unlike Fingerprint, differences in identifier case are considered
+// significant, since identifiers may be case-sensitive. The existing file is re-read and re-parsed
+// to perform this comparison; false is returned if this fails for any reason.
+func (sqlFile *SQLFile) SemanticallyUnchanged() bool {
+	existing, err := tengo.ParseStatementsInFile(sqlFile.FilePath)
+	if err != nil {
+		return false
+	}
+	return caseSensitiveSignature(existing) == caseSensitiveSignature(sqlFile.Statements)
+}
+
+// caseSensitiveSignature returns a string summarizing the CREATE statements in
+// stmts, independent of their order and formatting, but retaining identifier
+// case.
+func caseSensitiveSignature(stmts []*tengo.Statement) string {
+	lines := make([]string, 0, len(stmts))
+	for _, stmt := range stmts {
+		if stmt.Type == tengo.StatementTypeCreate {
+			lines = append(lines, fmt.Sprintf("%s\t%s\t%s", stmt.Schema(), stmt.ObjectKey(), stmt.CaseSensitiveText()))
+		}
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, "\n")
+}
+
+// fingerprintStatements returns a hex-encoded SHA-256 checksum of the CREATE
+// statements in stmts, independent of their order and formatting.
+func fingerprintStatements(stmts []*tengo.Statement) string {
+	lines := make([]string, 0, len(stmts))
+	for _, stmt := range stmts {
+		if stmt.Type == tengo.StatementTypeCreate {
+			lines = append(lines, fmt.Sprintf("%s\t%s\t%s", stmt.Schema(), stmt.ObjectKey(), stmt.CanonicalText()))
+		}
+	}
+	sort.Strings(lines)
+	h := sha256.New()
+	for _, line := range lines {
+		h.Write([]byte(line))
+		h.Write([]byte{'\n'})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// ByteSize returns the total size in bytes of the text of sqlFile's current
+// statements, including any comments, whitespace, and commands. This reflects
+// the in-memory statements, which may differ from the file in the filesystem if
+// sqlFile is dirty.
+func (sqlFile *SQLFile) ByteSize() (size int) {
+	for _, stmt := range sqlFile.Statements {
+		size += len(stmt.Text)
+	}
+	return size
+}
+
+// LineCount returns the total number of lines in the text of sqlFile's current
+// statements. A final line lacking a trailing newline is still counted. Like
+// ByteSize, this reflects the in-memory statements, not the filesystem.
+func (sqlFile *SQLFile) LineCount() (count int) {
+	var lastText string
+	for _, stmt := range sqlFile.Statements {
+		count += strings.Count(stmt.Text, "\n")
+		if stmt.Text != "" {
+			lastText = stmt.Text
+		}
+	}
+	if lastText != "" && !strings.HasSuffix(lastText, "\n") {
+		count++
+	}
+	return count
+}
+
+// PatchPreview returns a unified-diff (git-style) representation of the
+// changes that Write would make to the file, relative to its current contents
+// in the filesystem. The supplied displayPath is used in the diff headers; it
+// is typically a path relative to the repo or directory root. An empty string
+// is returned if Write would not change the file.
+func (sqlFile *SQLFile) PatchPreview(displayPath string) (string, error) {
+	before, err := os.ReadFile(sqlFile.FilePath)
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+	after := sqlFile.WritePreview()
+	if bytes.Equal(before, after) {
+		return "", nil
+	}
+	diff := difflib.UnifiedDiff{
+		A:        splitLines(before),
+		B:        splitLines(after),
+		FromFile: "a/" + displayPath,
+		ToFile:   "b/" + displayPath,
+		Context:  3,
+	}
+	if before == nil {
+		diff.FromFile = "/dev/null"
+	}
+	if after == nil {
+		diff.ToFile = "/dev/null"
 	}
-	return n, err
+	return difflib.GetUnifiedDiffString(diff)
+}
+
+// splitLines splits contents into lines, each retaining its trailing newline.
+// Unlike difflib.SplitLines, this does not introduce a spurious empty final
+// line when contents ends in a newline.
+func splitLines(contents []byte) []string {
+	lines := strings.SplitAfter(string(contents), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// compoundDelimiters lists the delimiters which may be used for wrapping a
+// compound statement, in order of preference after sqlFile.Delimiter.
+var compoundDelimiters = []string{"//", "$$", ";;"}
+
+// compoundDelimiter returns the delimiter to use when wrapping a compound
+// statement with the supplied body in DELIMITER commands. This is
+// sqlFile.Delimiter if set, or "//" otherwise, unless that delimiter appears
+// in the body; in that case the next safe value from compoundDelimiters is
+// used instead. An error is returned if no candidate is safe.
+func (sqlFile *SQLFile) compoundDelimiter(body string) (string, error) {
+	candidates := compoundDelimiters
+	if sqlFile.Delimiter != "" && sqlFile.Delimiter != ";" {
+		candidates = append([]string{sqlFile.Delimiter}, candidates...)
+	}
+	for _, delimiter := range candidates {
+		if delimiterSafe(body, delimiter) {
+			return delimiter, nil
+		}
+	}
+	return "", fmt.Errorf("Unable to find a safe delimiter for statement: its text contains each of %s", strings.Join(candidates, " "))
+}
+
+// delimiterSafe returns true if body can be terminated by delimiter without
+// any ambiguity upon being re-parsed: the delimiter must not appear anywhere in
+// the body, including partially overlapping with the end of the body. For
+// simplicity, string literals and comments are not treated specially.
+func delimiterSafe(body, delimiter string) bool {
+	return strings.Index(body+delimiter, delimiter) == len(body)
 }
 
 func makeDelimiterCommand(newDelimiter, defaultDatabase, filePath string) *tengo.Statement {
@@ -80,8 +672,23 @@ func makeDelimiterCommand(newDelimiter, defaultDatabase, filePath string) *tengo
 // This method may adjust stmt.Text and stmt.Delimiter as needed to ensure the
 // text contains the appropriate delimiter for the type of statement, as well as
 // a trailing newline. DELIMITER command statements may also be inserted into
-// sqlFile as necessary for stmt.
-func (sqlFile *SQLFile) AddStatement(stmt *tengo.Statement) {
+// sqlFile as necessary for stmt, using sqlFile.Delimiter (or "//" by default)
+// for compound statements. If sqlFile.LineEnding is CRLF, line endings of
+// stmt and any other LF-terminated statements are converted to CRLF.
+// If stmt is a compound statement and no safe delimiter can be found for it
+// (see compoundDelimiter), an error is returned and sqlFile is not modified.
+func (sqlFile *SQLFile) AddStatement(stmt *tengo.Statement) error {
+	// If a special delimiter will be needed, determine it before making any
+	// changes
+	body, _ := stmt.SplitTextBody()
+	var compoundDelimiter string
+	if stmt.Compound {
+		var err error
+		if compoundDelimiter, err = sqlFile.compoundDelimiter(body); err != nil {
+			return fmt.Errorf("%s: %w", stmt.ObjectKey(), err)
+		}
+	}
+
 	// Prune any trailing DELIMITER or USE commands from the end of the file, as
 	// these have no effect at the end of the file anyway.
 	for len(sqlFile.Statements) > 0 && sqlFile.Statements[len(sqlFile.Statements)-1].Type == tengo.StatementTypeCommand {
@@ -100,10 +707,11 @@ func (sqlFile *SQLFile) AddStatement(stmt *tengo.Statement) {
 		lastStmt.NormalizeTrailer()
 	}
 
-	// Add a DELIMITER command before stmt, if needed
-	if stmt.Compound && currentDelimiter == ";" {
-		sqlFile.Statements = append(sqlFile.Statements, makeDelimiterCommand("//", defaultDatabase, sqlFile.FilePath))
-		currentDelimiter = "//"
+	// Add a DELIMITER command before stmt, if needed. A compound statement may
+	// reuse the current special delimiter, unless it appears in the statement.
+	if stmt.Compound && (currentDelimiter == ";" || !delimiterSafe(body, currentDelimiter)) {
+		currentDelimiter = compoundDelimiter
+		sqlFile.Statements = append(sqlFile.Statements, makeDelimiterCommand(currentDelimiter, defaultDatabase, sqlFile.FilePath))
 	} else if !stmt.Compound && currentDelimiter != ";" {
 		sqlFile.Statements = append(sqlFile.Statements, makeDelimiterCommand(";", defaultDatabase, sqlFile.FilePath))
 		currentDelimiter = ";"
@@ -119,51 +727,512 @@ func (sqlFile *SQLFile) AddStatement(stmt *tengo.Statement) {
 	if currentDelimiter != ";" {
 		sqlFile.Statements = append(sqlFile.Statements, makeDelimiterCommand(";", defaultDatabase, sqlFile.FilePath))
 	}
+	sqlFile.applyLineEnding()
 	sqlFile.Dirty = true
+	return nil
+}
+
+// AppendRaw appends a new statement to the end of sqlFile, consisting of text
+// which must only contain whitespace and/or comments. This is useful for
+// inserting banners, license text, or blank lines. If text does not end in a
+// newline, one is added, so that a subsequent statement cannot become part of
+// a trailing single-line comment. Unlike AddStatement, no DELIMITER commands
+// are inserted, and no existing statements are modified, aside from line
+// ending conversion if sqlFile.LineEnding is CRLF. The file is marked as
+// dirty. An error is returned, without modifying sqlFile, if text contains
+// anything other than whitespace and comments.
+func (sqlFile *SQLFile) AppendRaw(text string) error {
+	stmts, err := tengo.ParseStatementsInString(text)
+	if err != nil {
+		return err
+	}
+	for _, stmt := range stmts {
+		if stmt.Type != tengo.StatementTypeNoop {
+			return fmt.Errorf("AppendRaw text may only contain whitespace and comments, but found %q", strings.TrimSpace(stmt.Text))
+		}
+	}
+	if text != "" && !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+	stmt := &tengo.Statement{
+		File:      sqlFile.FilePath,
+		Text:      text,
+		Type:      tengo.StatementTypeNoop,
+		Delimiter: ";",
+	}
+	if len(sqlFile.Statements) > 0 {
+		lastStmt := sqlFile.Statements[len(sqlFile.Statements)-1]
+		stmt.Delimiter = lastStmt.Delimiter
+		stmt.DefaultDatabase = lastStmt.DefaultDatabase
+		if isDelimiterCommand(lastStmt) {
+			stmt.Delimiter = delimiterCommandValue(lastStmt)
+		}
+	}
+	sqlFile.Statements = append(sqlFile.Statements, stmt)
+	sqlFile.applyLineEnding()
+	sqlFile.Dirty = true
+	return nil
+}
+
+// OrderStatements rearranges the CREATE statements in sqlFile to match order,
+// which lists object keys in the desired sequence. Keys with an empty Type
+// match objects of any type with that name. CREATE statements for objects not
+// found in order are moved after those which are, retaining their previous
+// relative order. Comments and whitespace immediately preceding a CREATE are
+// kept with it, and DELIMITER commands are regenerated as needed. Files
+// containing any other statements, such as USE commands or DML, are not
+// rearranged; neither are files containing a compound statement for which no
+// safe delimiter can be found. The file is marked as dirty if any change was
+// made, and the return value indicates whether this occurred.
+func (sqlFile *SQLFile) OrderStatements(order []tengo.ObjectKey) bool {
+	rank := func(key tengo.ObjectKey) int {
+		for n, orderKey := range order {
+			if orderKey.Name == key.Name && (orderKey.Type == "" || orderKey.Type == key.Type) {
+				return n
+			}
+		}
+		return len(order)
+	}
+
+	// Group each CREATE with any comments and whitespace preceding it
+	type unit struct {
+		stmts []*tengo.Statement
+		rank  int
+	}
+	var units []unit
+	var pending []*tengo.Statement
+	for _, stmt := range sqlFile.Statements {
+		if stmt.Type == tengo.StatementTypeNoop {
+			pending = append(pending, stmt)
+		} else if stmt.Type == tengo.StatementTypeCreate {
+			units = append(units, unit{stmts: append(pending, stmt), rank: rank(stmt.ObjectKey())})
+			pending = nil
+		} else if !isDelimiterCommand(stmt) {
+			return false
+		}
+	}
+	if sort.SliceIsSorted(units, func(i, j int) bool { return units[i].rank < units[j].rank }) {
+		return false
+	}
+	// Ensure AddStatement will succeed for every compound statement before
+	// making any changes
+	for _, u := range units {
+		if stmt := u.stmts[len(u.stmts)-1]; stmt.Compound {
+			body, _ := stmt.SplitTextBody()
+			if _, err := sqlFile.compoundDelimiter(body); err != nil {
+				return false
+			}
+		}
+	}
+	sort.SliceStable(units, func(i, j int) bool { return units[i].rank < units[j].rank })
+
+	// Rebuild the file. Existing delimiters are stripped from CREATEs, and then
+	// AddStatement inserts the appropriate DELIMITER commands. Errors from
+	// AddStatement are ignored here: it can only fail for a compound statement
+	// lacking a safe delimiter, which was already ruled out above.
+	sqlFile.Statements = nil
+	for _, u := range units {
+		for _, stmt := range u.stmts {
+			if stmt.Type == tengo.StatementTypeCreate {
+				body, _ := stmt.SplitTextBody()
+				stmt.Text = body
+			}
+			sqlFile.AddStatement(stmt)
+		}
+	}
+	for _, stmt := range pending {
+		sqlFile.AddStatement(stmt)
+	}
+	sqlFile.ResetTrailingDelimiter()
+	sqlFile.removeRedundantDelimiters()
+	return true
+}
+
+// ResetTrailingDelimiter ensures sqlFile ends in the default semicolon
+// delimiter state, so that it may be safely concatenated with other files.
+// Any DELIMITER commands after the file's last statement are removed, and then
+// a single DELIMITER ; command is appended if the last statement used some
+// other delimiter. Trailing comments and whitespace are retained. The file is
+// marked as dirty if any change was made, and the return value indicates
+// whether this occurred.
+func (sqlFile *SQLFile) ResetTrailingDelimiter() bool {
+	// Find the last statement which isn't a DELIMITER command or noop
+	lastPos := -1
+	currentDelimiter := ";"
+	var defaultDatabase string
+	for n := len(sqlFile.Statements) - 1; n >= 0; n-- {
+		if stmt := sqlFile.Statements[n]; stmt.Type != tengo.StatementTypeNoop && !isDelimiterCommand(stmt) {
+			lastPos = n
+			currentDelimiter = stmt.Delimiter
+			defaultDatabase = stmt.DefaultDatabase
+			break
+		}
+	}
+
+	// Rebuild the tail of the file: a DELIMITER ; command if needed (reusing an
+	// existing one if already present), followed by any comments or whitespace
+	tail := sqlFile.Statements[lastPos+1:]
+	var newTail []*tengo.Statement
+	if currentDelimiter != ";" {
+		if len(tail) > 0 && isDelimiterCommand(tail[0]) && strings.TrimSpace(tail[0].Text) == "DELIMITER ;" {
+			newTail = append(newTail, tail[0])
+		} else {
+			newTail = append(newTail, makeDelimiterCommand(";", defaultDatabase, sqlFile.FilePath))
+		}
+	}
+	for _, stmt := range tail {
+		if !isDelimiterCommand(stmt) {
+			newTail = append(newTail, stmt)
+		}
+	}
+	changed := len(newTail) != len(tail)
+	for n := 0; !changed && n < len(tail); n++ {
+		changed = (tail[n] != newTail[n])
+	}
+	if changed {
+		sqlFile.Statements = append(sqlFile.Statements[:lastPos+1:lastPos+1], newTail...)
+		sqlFile.applyLineEnding()
+		sqlFile.Dirty = true
+	}
+	return changed
 }
 
 // EditStatementText sets stmt.Text to a new value consisting of newText plus
 // an appropriate delimiter and newline. It marks the file as dirty, and (if
 // needed for a compound statement) adds DELIMITER commands around stmt in the
-// file's list of statements. The supplied newText should NOT have a delimiter
-// or trailing newline. This method panics if stmt's address is not actually
-// found among the file's statement pointers slice.
-func (sqlFile *SQLFile) EditStatementText(stmt *tengo.Statement, newText string, compound bool) {
+// file's list of statements, using sqlFile.Delimiter as with AddStatement.
+// Afterwards, any DELIMITER commands which have become unnecessary are removed
+// from the file; see removeRedundantDelimiters.
+// The supplied newText should NOT have a delimiter or trailing newline. Line
+// endings are converted to match sqlFile.LineEnding, as with AddStatement.
+// If stmt's current special delimiter appears in newText, stmt is re-wrapped
+// using a different delimiter. An error is returned, without modifying
+// sqlFile, if no safe delimiter can be found. This method panics if stmt's
+// address is not actually found among the file's statement pointers slice.
+func (sqlFile *SQLFile) EditStatementText(stmt *tengo.Statement, newText string, compound bool) error {
+	// Determine whether stmt needs to be wrapped in new DELIMITER commands: a
+	// compound statement cannot use the standard semicolon delimiter, and no
+	// statement may use a special delimiter which appears in its text
+	oldDelimiter, newDelimiter := stmt.Delimiter, stmt.Delimiter
+	if (compound && oldDelimiter == ";") || (oldDelimiter != ";" && !delimiterSafe(newText, oldDelimiter)) {
+		if !compound {
+			newDelimiter = ";"
+		} else if delimiter, err := sqlFile.compoundDelimiter(newText); err != nil {
+			return fmt.Errorf("%s: %w", stmt.ObjectKey(), err)
+		} else {
+			newDelimiter = delimiter
+		}
+	}
 	sqlFile.Dirty = true
 	i := sqlFile.statementIndex(stmt)
 
 	// Short-cut in situations that don't require inserting new DELIMITER commands
-	// TODO: remove extraneous DELIMITER commands if they are unnecessary.
-	// Currently we only add them if needed, but never remove them, nor avoid
-	// introducing duplicate ones in a multi-statement file.
-	if stmt.Delimiter != ";" || !compound {
+	if newDelimiter == oldDelimiter {
 		_, oldFooter := stmt.SplitTextBody()
 		stmt.Text = newText + oldFooter
 		stmt.Compound = compound
-		return
+		sqlFile.removeRedundantDelimiters()
+		sqlFile.applyLineEnding()
+		return nil
 	}
 
 	newStatements := make([]*tengo.Statement, len(sqlFile.Statements)+2)
 	copy(newStatements, sqlFile.Statements[0:i])
-	newStatements[i] = makeDelimiterCommand("//", stmt.DefaultDatabase, sqlFile.FilePath)
-	stmt.Delimiter = "//"
-	stmt.Text = newText + "//\n"
+	newStatements[i] = makeDelimiterCommand(newDelimiter, stmt.DefaultDatabase, sqlFile.FilePath)
+	stmt.Delimiter = newDelimiter
+	stmt.Text = newText + newDelimiter + "\n"
 	stmt.Compound = compound
 	newStatements[i+1] = stmt
-	newStatements[i+2] = makeDelimiterCommand(";", stmt.DefaultDatabase, sqlFile.FilePath)
+	newStatements[i+2] = makeDelimiterCommand(oldDelimiter, stmt.DefaultDatabase, sqlFile.FilePath)
 	copy(newStatements[i+3:], sqlFile.Statements[i+1:])
 	sqlFile.Statements = newStatements
+	sqlFile.removeRedundantDelimiters()
+	sqlFile.applyLineEnding()
+	return nil
+}
+
+// isDelimiterCommand returns true if stmt is a DELIMITER command.
+func isDelimiterCommand(stmt *tengo.Statement) bool {
+	return stmt.Type == tengo.StatementTypeCommand && stmt.Delimiter == "\000"
+}
+
+// delimiterCommandValue returns the new delimiter set by a DELIMITER command.
+func delimiterCommandValue(stmt *tengo.Statement) string {
+	if fields := strings.Fields(stmt.Text); len(fields) > 1 {
+		return fields[1]
+	}
+	return ""
+}
+
+// removeRedundantDelimiters removes DELIMITER commands which have no purpose
+// in sqlFile. This involves two passes:
+//
+//   - Any DELIMITER command which changes away from the default semicolon
+//     delimiter, but only applies to non-compound statements, is removed. The
+//     statements it applied to are converted to use the semicolon delimiter.
+//   - Any DELIMITER command which is immediately followed by another DELIMITER
+//     command (ignoring comments and whitespace), or which sets the delimiter
+//     to its current value, is removed.
+func (sqlFile *SQLFile) removeRedundantDelimiters() {
+	// First pass: unwrap non-compound statements from non-semicolon delimiters
+	statements := make([]*tengo.Statement, 0, len(sqlFile.Statements))
+	for n := 0; n < len(sqlFile.Statements); n++ {
+		stmt := sqlFile.Statements[n]
+		if !isDelimiterCommand(stmt) || delimiterCommandValue(stmt) == ";" {
+			statements = append(statements, stmt)
+			continue
+		}
+		end := n + 1
+		needed := false
+		for ; end < len(sqlFile.Statements) && !isDelimiterCommand(sqlFile.Statements[end]); end++ {
+			needed = needed || sqlFile.Statements[end].Compound
+		}
+		if needed {
+			statements = append(statements, stmt)
+			continue
+		}
+		for _, wrapped := range sqlFile.Statements[n+1 : end] {
+			body, footer := wrapped.SplitTextBody()
+			footer = strings.TrimLeft(footer, "\n\r\t ")
+			if wrapped.Type != tengo.StatementTypeNoop && strings.HasPrefix(footer, wrapped.Delimiter) {
+				wrapped.Text = body + ";" + footer[len(wrapped.Delimiter):]
+			}
+			wrapped.Delimiter = ";"
+		}
+	}
+	sqlFile.Statements = statements
+
+	// Second pass: remove duplicate or no-op DELIMITER commands
+	statements = make([]*tengo.Statement, 0, len(sqlFile.Statements))
+	currentDelimiter := ";"
+	for n, stmt := range sqlFile.Statements {
+		if !isDelimiterCommand(stmt) {
+			statements = append(statements, stmt)
+			continue
+		}
+		next := n + 1
+		for next < len(sqlFile.Statements) && sqlFile.Statements[next].Type == tengo.StatementTypeNoop {
+			next++
+		}
+		if next < len(sqlFile.Statements) && isDelimiterCommand(sqlFile.Statements[next]) {
+			continue
+		} else if newDelimiter := delimiterCommandValue(stmt); newDelimiter != currentDelimiter {
+			currentDelimiter = newDelimiter
+			statements = append(statements, stmt)
+		}
+	}
+	sqlFile.Statements = statements
 }
 
 // RemoveStatement removes stmt from the file's in-memory list of statements,
-// and marks the file as dirty. Panics if the address of stmt is not actually
-// found in its expected file's in-memory representation.
+// as well as from sqlFile.Header if present there, and marks the file as
+// dirty. Panics if the address of stmt is not actually found in its expected
+// file's in-memory representation.
 func (sqlFile *SQLFile) RemoveStatement(stmt *tengo.Statement) {
 	i := sqlFile.statementIndex(stmt)
 	sqlFile.Dirty = true
 	copy(sqlFile.Statements[i:], sqlFile.Statements[i+1:])
 	sqlFile.Statements[len(sqlFile.Statements)-1] = nil
 	sqlFile.Statements = sqlFile.Statements[:len(sqlFile.Statements)-1]
+	for n, headerStmt := range sqlFile.Header {
+		if headerStmt == stmt {
+			sqlFile.Header = append(sqlFile.Header[:n], sqlFile.Header[n+1:]...)
+			break
+		}
+	}
+}
+
+// References returns keys for objects referenced by the bodies of the file's
+// statements, for example tables referenced by foreign keys, or procedures
+// invoked by other stored programs. See tengo.Statement.References for more
+// information. The result is sorted and has no duplicates. Objects defined in
+// this same file may be included, if referenced by other statements.
+func (sqlFile *SQLFile) References() []tengo.ObjectKey {
+	seen := make(map[tengo.ObjectKey]bool)
+	var result []tengo.ObjectKey
+	for _, stmt := range sqlFile.Statements {
+		for _, key := range stmt.References() {
+			if !seen[key] {
+				seen[key] = true
+				result = append(result, key)
+			}
+		}
+	}
+	tengo.SortObjectKeys(result)
+	return result
+}
+
+// StatementsMissingDelimiter returns any statements in sqlFile which appear to
+// have been merged with a subsequent statement due to a missing delimiter, as
+// determined by tengo.Statement.MissingDelimiter. This is useful for flagging a
+// common manual-editing mistake, before it causes confusing diffs.
+func (sqlFile *SQLFile) StatementsMissingDelimiter() (result []*tengo.Statement) {
+	for _, stmt := range sqlFile.Statements {
+		if stmt.MissingDelimiter() {
+			result = append(result, stmt)
+		}
+	}
+	return result
+}
+
+// SessionSetting represents a single assignment within a SET statement.
+type SessionSetting struct {
+	Scope string // "session", "global", "persist", "persist_only", or "user" for user-defined variables
+	Name  string // lowercased variable name, or "names" or "character set" for SET NAMES or SET CHARACTER SET
+	Value string // value expression, as written in the statement
+}
+
+// SessionCommand represents a SET statement in a SQLFile, which affects the
+// execution context of subsequent statements in the file.
+type SessionCommand struct {
+	Statement *tengo.Statement
+	Settings  []SessionSetting // may be empty if the statement could not be fully parsed
+}
+
+// SessionCommands returns all SET statements (including SET NAMES and SET
+// CHARACTER SET) in sqlFile, in their original order. SET PASSWORD statements
+// are excluded, since they do not affect session state. The returned
+// statements remain part of sqlFile.Statements and are written as-is by
+// SQLFile.Write.
+func (sqlFile *SQLFile) SessionCommands() (result []SessionCommand) {
+	for _, stmt := range sqlFile.Statements {
+		if stmt.Type != tengo.StatementTypeUnknown {
+			continue
+		}
+		if settings, ok := parseSetStatement(stmt.Body()); ok {
+			result = append(result, SessionCommand{Statement: stmt, Settings: settings})
+		}
+	}
+	return result
+}
+
+// setToken is a lexical token used by parseSetStatement, tracking its position
+// in the original statement text.
+type setToken struct {
+	val string
+	typ tengo.TokenType
+	pos int
+}
+
+// parseSetStatement determines whether body is a SET statement, and if so,
+// returns the individual variable assignments that it contains.
+func parseSetStatement(body string) (settings []SessionSetting, isSet bool) {
+	lex := tengo.NewLexer(strings.NewReader(body), "\000", 8192)
+	var tokens []setToken
+	var pos int
+	for {
+		val, typ, err := lex.Scan()
+		if len(val) > 0 && typ != tengo.TokenFiller {
+			tokens = append(tokens, setToken{val: string(val), typ: typ, pos: pos})
+		}
+		pos += len(val)
+		if err != nil {
+			break
+		}
+	}
+	if len(tokens) < 2 || tokens[0].typ != tengo.TokenWord || !strings.EqualFold(tokens[0].val, "SET") {
+		return nil, false
+	} else if tokens[1].typ == tengo.TokenWord && strings.EqualFold(tokens[1].val, "PASSWORD") {
+		return nil, false
+	}
+
+	// Split into comma-separated assignments, ignoring commas inside of parens
+	var start, depth int
+	tokens = tokens[1:]
+	for n := 0; n <= len(tokens); n++ {
+		if n < len(tokens) && tokens[n].typ == tengo.TokenSymbol {
+			switch tokens[n].val {
+			case "(":
+				depth++
+			case ")":
+				depth--
+			}
+		}
+		if n == len(tokens) || (depth == 0 && tokens[n].typ == tengo.TokenSymbol && tokens[n].val == ",") {
+			end := len(body)
+			if n < len(tokens) {
+				end = tokens[n].pos
+			}
+			if setting, ok := parseSessionSetting(body, tokens[start:n], end); ok {
+				settings = append(settings, setting)
+			}
+			start = n + 1
+		}
+	}
+	return settings, true
+}
+
+// parseSessionSetting parses a single assignment from a SET statement. The
+// supplied end is the position in body where the assignment ends.
+func parseSessionSetting(body string, tokens []setToken, end int) (setting SessionSetting, ok bool) {
+	if len(tokens) < 2 {
+		return setting, false
+	}
+	valueFrom := func(n int) string {
+		return strings.TrimSpace(body[tokens[n].pos:end])
+	}
+
+	setting.Scope = "session"
+	first := strings.ToLower(tokens[0].val)
+	if tokens[0].typ == tengo.TokenWord {
+		switch first {
+		case "names", "charset":
+			if first == "charset" {
+				first = "character set"
+			}
+			return SessionSetting{Scope: "session", Name: first, Value: valueFrom(1)}, true
+		case "character":
+			if len(tokens) > 2 && strings.EqualFold(tokens[1].val, "SET") {
+				return SessionSetting{Scope: "session", Name: "character set", Value: valueFrom(2)}, true
+			}
+		case "global", "session", "local", "persist", "persist_only":
+			if first != "local" {
+				setting.Scope = first
+			}
+			tokens = tokens[1:]
+		}
+	}
+
+	// Find the assignment operator, which is either = or :=
+	var nameEnd, valueStart int
+	for n := range tokens {
+		if tokens[n].typ == tengo.TokenSymbol && tokens[n].val == "=" {
+			nameEnd, valueStart = n, n+1
+			if n > 0 && tokens[n-1].typ == tengo.TokenSymbol && tokens[n-1].val == ":" {
+				nameEnd = n - 1
+			}
+			break
+		}
+	}
+	if nameEnd == 0 || valueStart >= len(tokens) {
+		return setting, false
+	}
+	var name strings.Builder
+	for _, t := range tokens[0:nameEnd] {
+		name.WriteString(t.val)
+	}
+	setting.Name = name.String()
+	setting.Value = valueFrom(valueStart)
+
+	lowerName := strings.ToLower(setting.Name)
+	for _, scope := range []string{"global", "session", "local", "persist", "persist_only"} {
+		if strings.HasPrefix(lowerName, "@@"+scope+".") {
+			if scope != "local" {
+				setting.Scope = scope
+			}
+			lowerName = lowerName[len(scope)+3:]
+			break
+		}
+	}
+	if strings.HasPrefix(lowerName, "@@") {
+		lowerName = lowerName[2:]
+	} else if strings.HasPrefix(lowerName, "@") {
+		// User-defined variable names are case-insensitive too, but retain their
+		// leading @ to distinguish them from system variables
+		setting.Scope = "user"
+	}
+	setting.Name = strings.Trim(lowerName, "`")
+	return setting, true
 }
 
 func (sqlFile *SQLFile) statementIndex(stmt *tengo.Statement) int {
@@ -180,22 +1249,117 @@ func (sqlFile *SQLFile) statementIndex(stmt *tengo.Statement) int {
 // use in string-keyed maps, to avoid the possibility of having multiple
 // distinct map keys which actually refer to the same file.
 func NormalizeFileName(name string) string {
-	if runtime.GOOS == "darwin" || runtime.GOOS == "windows" {
+	return normalizeFileName(name, caseInsensitiveOS())
+}
+
+// caseInsensitiveOS returns true if the current operating system typically
+// uses case-insensitive filesystems.
+func caseInsensitiveOS() bool {
+	return runtime.GOOS == "darwin" || runtime.GOOS == "windows"
+}
+
+// normalizeFileName forces name to lowercase if lowerCase is true, regardless
+// of operating system.
+func normalizeFileName(name string, lowerCase bool) string {
+	if lowerCase {
 		return strings.ToLower(name)
 	}
 	return name
 }
 
+// FileNameOptions controls how special characters in object names are handled
+// by FileNameForObjectWithOptions and PathForObjectWithOptions. The zero value
+// removes all special characters, which is the behavior of FileNameForObject.
+type FileNameOptions struct {
+	Preserve    string // special characters to retain as-is; path separators cannot be preserved
+	Replacement rune   // if non-zero, replace other special characters with this rune instead of removing them
+}
+
 // FileNameForObject returns a string containing the filename to use for the
 // SQLFile representing the supplied object name. Special characters in the
 // objectName will be removed; however, there is no risk of "conflicts" since
 // a single SQLFile can store definitions for multiple objects.
 func FileNameForObject(objectName string) string {
-	objectName = strings.Map(removeSpecialChars, objectName)
+	return FileNameForObjectWithOptions(objectName, FileNameOptions{})
+}
+
+// FileNameForObjectWithOptions behaves like FileNameForObject, but permits the
+// caller to retain some special characters, or replace them with a different
+// rune instead of removing them. If opts.Replacement is itself a special
+// character which is not listed in opts.Preserve, it is ignored.
+func FileNameForObjectWithOptions(objectName string, opts FileNameOptions) string {
+	return NormalizeFileName(fileNameBase(objectName, opts)) + ".sql"
+}
+
+// fileNameBase returns objectName with special characters handled as per
+// opts, without any letter case normalization or file extension.
+func fileNameBase(objectName string, opts FileNameOptions) string {
+	replacement := opts.Replacement
+	if replacement != 0 && isSpecialChar(replacement) && (isPathSeparator(replacement) || !strings.ContainsRune(opts.Preserve, replacement)) {
+		replacement = 0
+	}
+	objectName = strings.Map(func(r rune) rune {
+		if !isSpecialChar(r) || (!isPathSeparator(r) && strings.ContainsRune(opts.Preserve, r)) {
+			return r
+		} else if replacement != 0 {
+			return replacement
+		}
+		return -1
+	}, objectName)
 	if objectName == "" {
 		objectName = "symbols"
 	}
-	return NormalizeFileName(objectName) + ".sql"
+	return objectName
+}
+
+// GroupByFileName buckets the object names in objects, which maps object names
+// to their definitions, by the filename of the SQLFile that each would be
+// written to by FileNameForObjectWithOptions using opts. If lowerCase is true,
+// filenames are forced to lowercase, as is appropriate for case-insensitive
+// filesystems; otherwise, letter case is retained as-is, regardless of the
+// current operating system. The result maps each filename to the sorted names
+// of the objects it would contain.
+func GroupByFileName(objects map[string]string, opts FileNameOptions, lowerCase bool) map[string][]string {
+	names := make([]string, 0, len(objects))
+	for name := range objects {
+		names = append(names, name)
+	}
+	return groupNamesByFileName(names, opts, lowerCase)
+}
+
+// groupNamesByFileName implements GroupByFileName and FileNameCollisions.
+// Duplicate entries in names are only included once in the result.
+func groupNamesByFileName(names []string, opts FileNameOptions, lowerCase bool) map[string][]string {
+	byFileName := make(map[string][]string, len(names))
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		fileName := normalizeFileName(fileNameBase(name, opts), lowerCase) + ".sql"
+		byFileName[fileName] = append(byFileName[fileName], name)
+	}
+	for _, group := range byFileName {
+		sort.Strings(group)
+	}
+	return byFileName
+}
+
+// FileNameCollisions returns a map of filename to the distinct object names
+// which map to that filename via FileNameForObject, including only filenames
+// which two or more of the supplied names map to. This can be used to warn
+// users that several objects will share a single file, since special
+// characters are removed from filenames (and, on case-insensitive operating
+// systems, letter case is normalized). Each slice of object names is sorted.
+func FileNameCollisions(objectNames []string) map[string][]string {
+	byFileName := groupNamesByFileName(objectNames, FileNameOptions{}, caseInsensitiveOS())
+	for fileName, names := range byFileName {
+		if len(names) < 2 {
+			delete(byFileName, fileName)
+		}
+	}
+	return byFileName
 }
 
 // PathForObject returns a string containing a path to use for the SQLFile
@@ -206,9 +1370,16 @@ func PathForObject(dirPath, objectName string) string {
 	return filepath.Join(dirPath, FileNameForObject(objectName))
 }
 
-func removeSpecialChars(r rune) rune {
+// PathForObjectWithOptions behaves like PathForObject, but handles special
+// characters as described by opts. See FileNameForObjectWithOptions.
+func PathForObjectWithOptions(dirPath, objectName string, opts FileNameOptions) string {
+	return filepath.Join(dirPath, FileNameForObjectWithOptions(objectName, opts))
+}
+
+// isSpecialChar returns true if r should not be used in filenames by default.
+func isSpecialChar(r rune) bool {
 	if unicode.IsSpace(r) {
-		return -1
+		return true
 	}
 	banned := []rune{
 		'.',
@@ -219,8 +1390,113 @@ func removeSpecialChars(r rune) rune {
 	}
 	for _, bad := range banned {
 		if r == bad {
-			return -1
+			return true
 		}
 	}
-	return r
+	return false
+}
+
+// isPathSeparator returns true if r is a forward slash or backslash. These may
+// never be retained in filenames, regardless of FileNameOptions.
+func isPathSeparator(r rune) bool {
+	return r == '/' || r == '\\'
+}
+
+// DefinitionDifference describes one element of an object's definition which
+// differs between two conflicting versions of the object, for example after a
+// git merge. Base, Ours, and Theirs hold the element's text in each version,
+// or an empty string if the element is absent from that version.
+type DefinitionDifference struct {
+	Element       string
+	Base          string
+	Ours          string
+	Theirs        string
+	OursChanged   bool // true if Ours differs from Base, or no base was supplied
+	TheirsChanged bool // true if Theirs differs from Base, or no base was supplied
+}
+
+// Conflict returns true if both sides changed the element relative to the base
+// version, in different ways.
+func (dd DefinitionDifference) Conflict() bool {
+	return dd.OursChanged && dd.TheirsChanged
+}
+
+// Resolved returns the element's text that should be kept, if only one side
+// changed it. If both sides changed the element, ok will be false. An empty
+// string with ok true indicates the element should be removed.
+func (dd DefinitionDifference) Resolved() (text string, ok bool) {
+	if dd.Conflict() {
+		return "", false
+	} else if dd.OursChanged {
+		return dd.Ours, true
+	}
+	return dd.Theirs, true
+}
+
+// CompareDefinitions compares two conflicting versions of the same object's
+// CREATE statement, ours and theirs, returning a difference for each element
+// (column, index, constraint, or table options) which differs between them.
+// base may be nil; if supplied, it should be the common ancestor version of the
+// object, and will be used to determine which side(s) changed each element.
+// Differences are returned in order of appearance in ours, followed by any
+// elements only present in theirs or base. Changes to element ordering alone
+// are not reported.
+func CompareDefinitions(base, ours, theirs *tengo.Statement) []DefinitionDifference {
+	var order []string
+	texts := make(map[string]*DefinitionDifference)
+	addElements := func(stmt *tengo.Statement, setter func(dd *DefinitionDifference, text string)) {
+		if stmt == nil {
+			return
+		}
+		for _, elem := range stmt.DefinitionElements() {
+			dd, ok := texts[elem.Name]
+			if !ok {
+				dd = &DefinitionDifference{Element: elem.Name}
+				texts[elem.Name] = dd
+				order = append(order, elem.Name)
+			}
+			setter(dd, elem.Text)
+		}
+	}
+	addElements(ours, func(dd *DefinitionDifference, text string) { dd.Ours = text })
+	addElements(theirs, func(dd *DefinitionDifference, text string) { dd.Theirs = text })
+	addElements(base, func(dd *DefinitionDifference, text string) { dd.Base = text })
+
+	var result []DefinitionDifference
+	for _, name := range order {
+		dd := texts[name]
+		if dd.Ours == dd.Theirs {
+			continue
+		}
+		dd.OursChanged = (base == nil || dd.Ours != dd.Base)
+		dd.TheirsChanged = (base == nil || dd.Theirs != dd.Base)
+		result = append(result, *dd)
+	}
+	return result
+}
+
+// ReconcileSQLFiles locates the CREATE statement for the object with the
+// supplied key in each of the supplied SQLFiles, and then returns the result
+// of CompareDefinitions on them. base may be nil, or may lack the object, in
+// which case the comparison is two-way. An error is returned if either ours or
+// theirs lacks the object.
+func ReconcileSQLFiles(base, ours, theirs *SQLFile, key tengo.ObjectKey) ([]DefinitionDifference, error) {
+	findStatement := func(sqlFile *SQLFile) *tengo.Statement {
+		if sqlFile == nil {
+			return nil
+		}
+		for _, stmt := range sqlFile.Statements {
+			if stmt.Type == tengo.StatementTypeCreate && stmt.ObjectKey() == key {
+				return stmt
+			}
+		}
+		return nil
+	}
+	oursStmt, theirsStmt := findStatement(ours), findStatement(theirs)
+	if oursStmt == nil {
+		return nil, fmt.Errorf("%s not found in %s", key, ours.FilePath)
+	} else if theirsStmt == nil {
+		return nil, fmt.Errorf("%s not found in %s", key, theirs.FilePath)
+	}
+	return CompareDefinitions(findStatement(base), oursStmt, theirsStmt), nil
 }
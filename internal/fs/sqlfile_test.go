@@ -2,6 +2,9 @@ package fs
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
 	"runtime"
 	"strings"
 	"testing"
@@ -63,8 +66,10 @@ func TestSQLFileAddStatement(t *testing.T) {
 		Delimiter:  "", // this matches how ParseStatementInString will return it for compound statement
 		Compound:   true,
 	}
+	// Since the proc's body contains "//" in a comment, the next candidate
+	// delimiter is used instead
 	sf.AddStatement(stmt)
-	if len(sf.Statements) != 4 || !sf.Dirty || sf.Statements[2].Text != create+"//\n" {
+	if len(sf.Statements) != 4 || !sf.Dirty || sf.Statements[2].Text != create+"$$\n" {
 		t.Fatalf("Unexpected values in SQLFile: dirty=%t, len(statements)=%d, text[2]=%q", sf.Dirty, len(sf.Statements), sf.Statements[2].Text)
 	}
 
@@ -74,7 +79,7 @@ func TestSQLFileAddStatement(t *testing.T) {
 	create = strings.Replace(create, "whatever", "Whatever2", 1)
 	routine2.Text = create
 	sf.AddStatement(&routine2)
-	if len(sf.Statements) != 5 || !sf.Dirty || sf.Statements[3].Text != create+"//\n" {
+	if len(sf.Statements) != 5 || !sf.Dirty || sf.Statements[3].Text != create+"$$\n" {
 		t.Fatalf("Unexpected values in SQLFile: dirty=%t, len(statements)=%d", sf.Dirty, len(sf.Statements))
 	}
 
@@ -95,6 +100,142 @@ func TestSQLFileAddStatement(t *testing.T) {
 	}
 }
 
+func TestSQLFileAddStatementDelimiter(t *testing.T) {
+	sf := &SQLFile{Delimiter: "$$"}
+	sf.AddStatement(tengo.ParseStatementInString("CREATE TABLE foo (id int)"))
+	sf.AddStatement(tengo.ParseStatementInString("CREATE PROCEDURE bar()\nBEGIN\n  SELECT 1;\nEND"))
+	expected := "CREATE TABLE foo (id int);\nDELIMITER $$\nCREATE PROCEDURE bar()\nBEGIN\n  SELECT 1;\nEND$$\nDELIMITER ;\n"
+	if actual := string(sf.WritePreview()); actual != expected {
+		t.Errorf("Unexpected file contents after AddStatement:\n%s", actual)
+	}
+
+	// EditStatementText should also use the preferred delimiter when wrapping a
+	// statement which has become compound
+	stmt := tengo.ParseStatementInString("CREATE FUNCTION baz() RETURNS int RETURN 1")
+	sf = &SQLFile{Delimiter: "$$"}
+	sf.AddStatement(stmt)
+	sf.EditStatementText(stmt, "CREATE FUNCTION baz() RETURNS int\nBEGIN\n  RETURN 1;\nEND", true)
+	expected = "DELIMITER $$\nCREATE FUNCTION baz() RETURNS int\nBEGIN\n  RETURN 1;\nEND$$\nDELIMITER ;\n"
+	if actual := string(sf.WritePreview()); actual != expected {
+		t.Errorf("Unexpected file contents after EditStatementText:\n%s", actual)
+	} else if stmt.Delimiter != "$$" {
+		t.Errorf("Expected statement delimiter to be $$, instead found %q", stmt.Delimiter)
+	}
+
+	// Default remains "//"
+	sf = &SQLFile{}
+	sf.AddStatement(tengo.ParseStatementInString("CREATE PROCEDURE bar()\nBEGIN\n  SELECT 1;\nEND"))
+	if actual := string(sf.WritePreview()); !strings.HasPrefix(actual, "DELIMITER //\n") {
+		t.Errorf("Expected default delimiter of //, instead found:\n%s", actual)
+	}
+}
+
+func TestSQLFileDelimiterConflicts(t *testing.T) {
+	assertReparse := func(sf *SQLFile, expectedCreates int) {
+		t.Helper()
+		stmts, err := tengo.ParseStatementsInString(string(sf.WritePreview()))
+		if err != nil {
+			t.Fatalf("Unexpected error re-parsing file: %v", err)
+		}
+		var creates int
+		for _, stmt := range stmts {
+			if stmt.Type == tengo.StatementTypeCreate {
+				creates++
+			} else if stmt.Type != tengo.StatementTypeCommand && stmt.Type != tengo.StatementTypeNoop {
+				t.Errorf("Unexpected statement after re-parsing file: %q", stmt.Text)
+			}
+		}
+		if creates != expectedCreates {
+			t.Errorf("Expected %d CREATEs after re-parsing file, instead found %d:\n%s", expectedCreates, creates, sf.WritePreview())
+		}
+	}
+
+	sf := &SQLFile{}
+	proc := tengo.ParseStatementInString("CREATE PROCEDURE p1()\nBEGIN\n  SELECT 'a//b';\nEND")
+	if err := sf.AddStatement(proc); err != nil {
+		t.Fatalf("Unexpected error from AddStatement: %v", err)
+	} else if proc.Delimiter != "$$" {
+		t.Errorf("Expected delimiter $$, instead found %q", proc.Delimiter)
+	}
+	assertReparse(sf, 1)
+
+	// Preferred delimiter is skipped if present in the body
+	sf = &SQLFile{Delimiter: "$$"}
+	proc = tengo.ParseStatementInString("CREATE PROCEDURE p2()\nBEGIN\n  SELECT '$$';\nEND")
+	if err := sf.AddStatement(proc); err != nil || proc.Delimiter != "//" {
+		t.Errorf("Unexpected result from AddStatement: delimiter=%q, err=%v", proc.Delimiter, err)
+	}
+
+	// If every candidate delimiter is present, an error is returned and the file
+	// is unchanged
+	sf = &SQLFile{}
+	proc = tengo.ParseStatementInString("CREATE PROCEDURE p3()\nBEGIN\n  SELECT '// $$ ;;';\nEND")
+	if err := sf.AddStatement(proc); err == nil {
+		t.Error("Expected error from AddStatement, but err was nil")
+	} else if len(sf.Statements) != 0 || sf.Dirty {
+		t.Errorf("Expected file to be unchanged after error, instead found %d statements, dirty=%t", len(sf.Statements), sf.Dirty)
+	}
+
+	// Editing a statement to contain its current delimiter re-wraps it with a
+	// different delimiter, leaving other statements using the old one
+	sf = &SQLFile{}
+	p4 := tengo.ParseStatementInString("CREATE PROCEDURE p4()\nBEGIN\n  SELECT 1;\nEND")
+	p5 := tengo.ParseStatementInString("CREATE PROCEDURE p5()\nBEGIN\n  SELECT 2;\nEND")
+	sf.AddStatement(p4)
+	sf.AddStatement(p5)
+	if p4.Delimiter != "//" || p5.Delimiter != "//" {
+		t.Fatalf("Unexpected delimiters: %q, %q", p4.Delimiter, p5.Delimiter)
+	}
+	if err := sf.EditStatementText(p4, "CREATE PROCEDURE p4()\nBEGIN\n  SELECT 4 /* 8//2 */;\nEND", true); err != nil {
+		t.Fatalf("Unexpected error from EditStatementText: %v", err)
+	} else if p4.Delimiter != "$$" || p5.Delimiter != "//" {
+		t.Errorf("Unexpected delimiters after edit: %q, %q", p4.Delimiter, p5.Delimiter)
+	}
+	assertReparse(sf, 2)
+	if err := sf.EditStatementText(p5, "CREATE PROCEDURE p5()\nBEGIN\n  SELECT '// $$ ;;';\nEND", true); err == nil {
+		t.Error("Expected error from EditStatementText, but err was nil")
+	}
+	assertReparse(sf, 2)
+}
+
+func TestSQLFileAppendRaw(t *testing.T) {
+	sf := &SQLFile{}
+	banner := "-- Generated by schema tooling\n-- Do not edit by hand\n"
+	if err := sf.AppendRaw(banner); err != nil {
+		t.Fatalf("Unexpected error from AppendRaw: %v", err)
+	} else if !sf.Dirty || len(sf.Statements) != 1 || sf.Statements[0].Type != tengo.StatementTypeNoop {
+		t.Fatalf("Unexpected state after AppendRaw: dirty=%t, len(statements)=%d", sf.Dirty, len(sf.Statements))
+	}
+	sf.AddStatement(tengo.ParseStatementInString("CREATE TABLE foo (id int)"))
+	if err := sf.AppendRaw("\n/* end of file */"); err != nil {
+		t.Fatalf("Unexpected error from AppendRaw: %v", err)
+	}
+	expected := banner + "CREATE TABLE foo (id int);\n\n/* end of file */\n"
+	if actual := string(sf.WritePreview()); actual != expected {
+		t.Errorf("Unexpected file contents:\n%s", actual)
+	}
+	if err := sf.Validate(); err != nil {
+		t.Errorf("Unexpected error from Validate: %v", err)
+	}
+
+	// Line endings are converted to match the file
+	sf = &SQLFile{LineEnding: "\r\n"}
+	sf.AppendRaw("# hello\n")
+	if sf.Statements[0].Text != "# hello\r\n" {
+		t.Errorf("Unexpected statement text %q", sf.Statements[0].Text)
+	}
+
+	// Text must consist solely of whitespace and comments
+	for _, text := range []string{"-- ok\nCREATE TABLE bar (id int);\n", "/* unterminated"} {
+		sf = &SQLFile{}
+		if err := sf.AppendRaw(text); err == nil {
+			t.Errorf("Expected error from AppendRaw(%q), but err was nil", text)
+		} else if len(sf.Statements) != 0 || sf.Dirty {
+			t.Errorf("Expected file to be unmodified after error from AppendRaw(%q)", text)
+		}
+	}
+}
+
 func TestSQLFileEditStatementText(t *testing.T) {
 	// Initial setup: two statements in one file, both with standard semicolon
 	// delimiter
@@ -148,17 +289,38 @@ func TestSQLFileEditStatementText(t *testing.T) {
 		t.Fatal("Statement fields not updated as expected")
 	}
 
-	// Adjust the func statement back to its original text. DELIMITERs should
-	// remain in place since we do not currently clean them up!
+	// Adjust the func statement back to its original text. The DELIMITER
+	// commands are no longer necessary, so they should be removed.
 	sf.EditStatementText(stmt1, create1, false)
-	if len(sf.Statements) != 4 {
-		t.Fatalf("Wrong statement count in file: expected 4, found %d", len(sf.Statements))
-	} else if sf.Statements[1] != stmt1 || sf.Statements[3] != stmt2 {
+	if len(sf.Statements) != 2 {
+		t.Fatalf("Wrong statement count in file: expected 2, found %d", len(sf.Statements))
+	} else if sf.Statements[0] != stmt1 || sf.Statements[1] != stmt2 {
 		t.Fatal("Unexpected CREATE statement positions in file")
-	} else if sf.Statements[0].Type != tengo.StatementTypeCommand || sf.Statements[2].Type != tengo.StatementTypeCommand {
-		t.Fatal("Unexpected DELIMITER statement positions in file")
-	} else if sf.Statements[1].Compound || sf.Statements[1].Delimiter != "//" {
-		t.Fatalf("Statement fields not updated as expected: %+v", sf.Statements[1])
+	} else if sf.Statements[0].Compound || sf.Statements[0].Delimiter != ";" || sf.Statements[0].Text != create1+";\n" {
+		t.Fatalf("Statement fields not updated as expected: %+v", sf.Statements[0])
+	}
+
+	// Make the func compound again, and then also make a second compound
+	// statement directly after it. Only one pair of DELIMITER commands should
+	// be present, rather than a redundant DELIMITER ; followed by DELIMITER //.
+	create3 := "CREATE PROCEDURE whatever2()\nBEGIN\n\tSELECT 1;\nEND"
+	sf.EditStatementText(stmt1, create1, true)
+	sf.EditStatementText(stmt2, create3, true)
+	expected := "DELIMITER //\n" + create1 + "//\n" + create3 + "//\nDELIMITER ;\n"
+	if actual := string(sf.WritePreview()); actual != expected {
+		t.Errorf("Unexpected file contents after editing two statements to be compound\nExpected:\n%s\nActual:\n%s", expected, actual)
+	}
+
+	// Convert both back to non-compound, one at a time
+	sf.EditStatementText(stmt1, create1, false)
+	expected = "DELIMITER //\n" + create1 + "//\n" + create3 + "//\nDELIMITER ;\n"
+	if actual := string(sf.WritePreview()); actual != expected {
+		t.Errorf("Unexpected file contents after editing first statement to be non-compound\nExpected:\n%s\nActual:\n%s", expected, actual)
+	}
+	sf.EditStatementText(stmt2, create2, false)
+	expected = create1 + ";\n" + create2 + ";\n"
+	if actual := string(sf.WritePreview()); actual != expected {
+		t.Errorf("Unexpected file contents after editing both statements to be non-compound\nExpected:\n%s\nActual:\n%s", expected, actual)
 	}
 }
 
@@ -185,6 +347,21 @@ func TestSQLFileWrite(t *testing.T) {
 		t.Error("File contents differ from expectation")
 	}
 
+	// Rewriting the file should preserve any custom permissions
+	if runtime.GOOS != "windows" {
+		if err := os.Chmod(sqlFile.FilePath, 0640); err != nil {
+			t.Fatalf("Unexpected error from Chmod: %v", err)
+		}
+		if _, err := sqlFile.Write(); err != nil {
+			t.Fatalf("Unexpected error from Write: %s", err)
+		}
+		if fi, err := os.Stat(sqlFile.FilePath); err != nil {
+			t.Errorf("Unexpected error from Stat: %v", err)
+		} else if fi.Mode().Perm() != 0640 {
+			t.Errorf("Expected Write to preserve file mode 0640, instead found %v", fi.Mode())
+		}
+	}
+
 	// Remove everything except commands and whitespace/comments. Write should
 	// now delete the file.
 	for n := len(sqlFile.Statements) - 1; n >= 0; n-- {
@@ -203,6 +380,348 @@ func TestSQLFileWrite(t *testing.T) {
 	}
 }
 
+func TestSQLFileWritePreviewFormat(t *testing.T) {
+	contents := "CREATE TABLE a (id int);\n-- comment about b\nCREATE TABLE b (id int);\n\n\n\n" +
+		"DELIMITER //\nCREATE PROCEDURE p() BEGIN SELECT 1; END//\nDELIMITER ;\nCREATE TABLE c (id int); CREATE TABLE d (id int);"
+	statements, err := tengo.ParseStatementsInString(contents)
+	if err != nil {
+		t.Fatalf("Unexpected error from ParseStatementsInString: %v", err)
+	}
+	sqlFile := &SQLFile{
+		FilePath:   "testdata/format.sql",
+		Statements: statements,
+	}
+
+	// Zero value of Format should not modify anything
+	if actual := string(sqlFile.WritePreview()); actual != contents {
+		t.Errorf("Unexpected contents with zero value Format: %q", actual)
+	}
+
+	sqlFile.Format.TrailingNewline = true
+	if actual := string(sqlFile.WritePreview()); actual != contents+"\n" {
+		t.Errorf("Unexpected contents with TrailingNewline: %q", actual)
+	}
+
+	sqlFile.Format.BlankLineBetween = true
+	expected := "CREATE TABLE a (id int);\n\n-- comment about b\nCREATE TABLE b (id int);\n\n" +
+		"DELIMITER //\nCREATE PROCEDURE p() BEGIN SELECT 1; END//\nDELIMITER ;\n\nCREATE TABLE c (id int);\n\nCREATE TABLE d (id int);\n"
+	if actual := string(sqlFile.WritePreview()); actual != expected {
+		t.Errorf("Unexpected contents with BlankLineBetween:\n%q\nexpected:\n%q", actual, expected)
+	}
+
+	// Formatting should be idempotent, and should not affect the statements
+	statements, _ = tengo.ParseStatementsInString(expected)
+	sqlFile.Statements = statements
+	if actual := string(sqlFile.WritePreview()); actual != expected {
+		t.Errorf("Expected formatting to be idempotent, instead found %q", actual)
+	}
+	if sqlFile.Statements[0].Text != "CREATE TABLE a (id int);\n" {
+		t.Errorf("Expected WritePreview to leave statements unmodified, but first statement is now %q", sqlFile.Statements[0].Text)
+	}
+
+	// CRLF line endings should be used if the file uses them
+	statements, _ = tengo.ParseStatementsInString("CREATE TABLE a (id int);\r\nCREATE TABLE b (id int);")
+	sqlFile.Statements = statements
+	sqlFile.LineEnding = "\r\n"
+	if actual := string(sqlFile.WritePreview()); actual != "CREATE TABLE a (id int);\r\n\r\nCREATE TABLE b (id int);\r\n" {
+		t.Errorf("Unexpected contents with CRLF line endings: %q", actual)
+	}
+}
+
+func TestSQLFileWriteAtomic(t *testing.T) {
+	dir := t.TempDir()
+	sqlFile := &SQLFile{
+		FilePath:   filepath.Join(dir, "foo.sql"),
+		Statements: []*tengo.Statement{{Text: "CREATE TABLE foo (id int);\n", Type: tengo.StatementTypeCreate}},
+		Dirty:      true,
+	}
+	if _, err := sqlFile.WriteAtomic(); err != nil || sqlFile.Dirty {
+		t.Fatalf("Unexpected result from WriteAtomic: err=%v, dirty=%t", err, sqlFile.Dirty)
+	}
+
+	// Permission bits of an existing file should be preserved
+	if runtime.GOOS != "windows" {
+		if err := os.Chmod(sqlFile.FilePath, 0600); err != nil {
+			t.Fatalf("Unexpected error from Chmod: %v", err)
+		}
+		sqlFile.Statements[0].Text = "CREATE TABLE foo (id bigint);\n"
+		if n, err := sqlFile.WriteAtomic(); n != len(sqlFile.Statements[0].Text) || err != nil {
+			t.Fatalf("Unexpected result from WriteAtomic: %d, %v", n, err)
+		}
+		if fi, err := os.Stat(sqlFile.FilePath); err != nil {
+			t.Errorf("Unexpected error from Stat: %v", err)
+		} else if fi.Mode().Perm() != 0600 {
+			t.Errorf("Expected file mode to be preserved, instead found %v", fi.Mode())
+		}
+		if contents := ReadTestFile(t, sqlFile.FilePath); contents != sqlFile.Statements[0].Text {
+			t.Errorf("Unexpected file contents: %q", contents)
+		}
+	}
+
+	// Symlinks and hard links should be written through, rather than replaced
+	// with a regular file
+	if runtime.GOOS != "windows" {
+		linkDir := t.TempDir()
+		realPath := filepath.Join(linkDir, "real.sql")
+		WriteTestFile(t, realPath, "CREATE TABLE foo (id int);\n")
+		symPath := filepath.Join(linkDir, "sym.sql")
+		hardPath := filepath.Join(linkDir, "hard.sql")
+		if err := os.Symlink("real.sql", symPath); err != nil {
+			t.Fatalf("Unexpected error from Symlink: %v", err)
+		}
+		if err := os.Link(realPath, hardPath); err != nil {
+			t.Fatalf("Unexpected error from Link: %v", err)
+		}
+		for n, path := range []string{symPath, hardPath} {
+			linkFile := &SQLFile{
+				FilePath:   path,
+				Statements: []*tengo.Statement{{Text: fmt.Sprintf("CREATE TABLE foo (id int, col%d int);\n", n), Type: tengo.StatementTypeCreate}},
+			}
+			if _, err := linkFile.WriteAtomic(); err != nil {
+				t.Fatalf("Unexpected error from WriteAtomic on %s: %v", path, err)
+			}
+			for _, checkPath := range []string{realPath, symPath, hardPath} {
+				if contents := ReadTestFile(t, checkPath); contents != linkFile.Statements[0].Text {
+					t.Errorf("After writing %s, unexpected contents of %s: %q", path, checkPath, contents)
+				}
+			}
+		}
+		if entries, err := os.ReadDir(linkDir); err != nil || len(entries) != 3 {
+			t.Errorf("Expected temp files to be cleaned up, instead found %d entries (err=%v)", len(entries), err)
+		}
+		if fi, err := os.Lstat(symPath); err != nil {
+			t.Errorf("Unexpected error from Lstat: %v", err)
+		} else if fi.Mode()&os.ModeSymlink == 0 {
+			t.Errorf("Expected %s to remain a symlink, instead found mode %v", symPath, fi.Mode())
+		}
+	}
+
+	// If the rename fails, the temp file should be cleaned up and the file should
+	// remain dirty
+	sqlFile.FilePath = filepath.Join(dir, "subdir")
+	if err := os.Mkdir(sqlFile.FilePath, 0777); err != nil {
+		t.Fatalf("Unexpected error from Mkdir: %v", err)
+	}
+	sqlFile.Dirty = true
+	if _, err := sqlFile.WriteAtomic(); err == nil || !sqlFile.Dirty {
+		t.Errorf("Expected WriteAtomic to fail and leave file dirty; instead err=%v, dirty=%t", err, sqlFile.Dirty)
+	}
+	if entries, err := os.ReadDir(dir); err != nil || len(entries) != 2 {
+		t.Errorf("Expected temp file to be cleaned up, instead found %d entries (err=%v)", len(entries), err)
+	}
+}
+
+func TestSQLFileRename(t *testing.T) {
+	dir := t.TempDir()
+	sqlFile := &SQLFile{
+		FilePath:   filepath.Join(dir, "foo.sql"),
+		Statements: []*tengo.Statement{{Text: "CREATE TABLE foo (id int);\n", Type: tengo.StatementTypeCreate}},
+	}
+	sqlFile.Statements[0].File = sqlFile.FilePath
+
+	// Renaming a file which doesn't exist yet should just update paths in memory
+	newPath := filepath.Join(dir, "bar.sql")
+	if err := sqlFile.Rename(newPath); err != nil {
+		t.Fatalf("Unexpected error from Rename: %v", err)
+	} else if sqlFile.FilePath != newPath || sqlFile.Statements[0].File != newPath {
+		t.Fatalf("Paths not updated as expected: %s / %s", sqlFile.FilePath, sqlFile.Statements[0].File)
+	}
+
+	// Renaming an existing file should move it on disk
+	if _, err := sqlFile.Write(); err != nil {
+		t.Fatalf("Unexpected error from Write: %v", err)
+	}
+	newPath = filepath.Join(dir, "baz.sql")
+	if err := sqlFile.Rename(newPath); err != nil {
+		t.Fatalf("Unexpected error from Rename: %v", err)
+	} else if sqlFile.FilePath != newPath || sqlFile.Statements[0].File != newPath {
+		t.Fatalf("Paths not updated as expected: %s / %s", sqlFile.FilePath, sqlFile.Statements[0].File)
+	}
+	if contents := ReadTestFile(t, newPath); contents != sqlFile.Statements[0].Text {
+		t.Errorf("Unexpected file contents after rename: %q", contents)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "bar.sql")); !os.IsNotExist(err) {
+		t.Errorf("Expected old path to no longer exist, instead Stat returned %v", err)
+	}
+
+	// Renaming to a path which already exists should fail without clobbering
+	otherPath := filepath.Join(dir, "other.sql")
+	WriteTestFile(t, otherPath, "CREATE TABLE other (id int);\n")
+	if err := sqlFile.Rename(otherPath); err == nil {
+		t.Error("Expected error from Rename to existing path, but err was nil")
+	} else if sqlFile.FilePath != newPath {
+		t.Errorf("Expected FilePath to be unchanged after failed Rename, instead found %s", sqlFile.FilePath)
+	}
+	if contents := ReadTestFile(t, otherPath); contents != "CREATE TABLE other (id int);\n" {
+		t.Errorf("Destination file was unexpectedly clobbered: %q", contents)
+	}
+}
+
+func TestSQLFileOrderStatements(t *testing.T) {
+	contents := "-- bar table\nCREATE TABLE bar (id int);\n" +
+		"DELIMITER //\nCREATE PROCEDURE foo()\nBEGIN\n  SELECT 1;\nEND//\nDELIMITER ;\n" +
+		"CREATE TABLE foo (id int);\n"
+	statements, err := tengo.ParseStatementsInString(contents)
+	if err != nil {
+		t.Fatalf("Unexpected error from ParseStatementsInString: %v", err)
+	}
+	sqlFile := &SQLFile{
+		FilePath:   "testdata/combined.sql",
+		Statements: statements,
+	}
+
+	// Order which already matches the file: no change
+	order := []tengo.ObjectKey{{Name: "bar"}, {Type: tengo.ObjectTypeProc, Name: "foo"}}
+	if sqlFile.OrderStatements(order) || sqlFile.Dirty {
+		t.Error("Expected no changes from OrderStatements, but file was modified")
+	}
+
+	// Unlisted objects should be moved to the end, and DELIMITER commands should
+	// be regenerated as needed
+	order = []tengo.ObjectKey{{Type: tengo.ObjectTypeTable, Name: "foo"}, {Name: "nonexistent"}}
+	if !sqlFile.OrderStatements(order) || !sqlFile.Dirty {
+		t.Fatal("Expected OrderStatements to modify the file, but it did not")
+	}
+	expected := "CREATE TABLE foo (id int);\n-- bar table\nCREATE TABLE bar (id int);\n" +
+		"DELIMITER //\nCREATE PROCEDURE foo()\nBEGIN\n  SELECT 1;\nEND//\nDELIMITER ;\n"
+	if actual := string(sqlFile.WritePreview()); actual != expected {
+		t.Errorf("Unexpected file contents\nExpected: %q\nActual:   %q", expected, actual)
+	}
+
+	// Files containing other statement types are not reordered
+	sqlFile.AddStatement(tengo.ParseStatementInString("INSERT INTO foo VALUES (1)"))
+	sqlFile.Dirty = false
+	if sqlFile.OrderStatements([]tengo.ObjectKey{{Name: "bar"}}) || sqlFile.Dirty {
+		t.Error("Expected file containing an INSERT to be left as-is, but it was modified")
+	}
+}
+
+func TestSQLFileLineEnding(t *testing.T) {
+	contents := "CREATE TABLE foo (\r\n  id int\r\n);\r\n\r\nCREATE TABLE bar (id int);\r\n"
+	statements, err := tengo.ParseStatementsInString(contents)
+	if err != nil {
+		t.Fatalf("Unexpected error from ParseStatementsInString: %v", err)
+	}
+	sqlFile := &SQLFile{
+		FilePath:   "testdata/crlf.sql",
+		Statements: statements,
+	}
+	if sqlFile.LineEnding = DetectLineEnding(statements); sqlFile.LineEnding != "\r\n" {
+		t.Fatalf("Expected CRLF line ending to be detected, instead found %q", sqlFile.LineEnding)
+	}
+	if actual := string(sqlFile.WritePreview()); actual != contents {
+		t.Errorf("Expected unmodified file to round-trip, instead found %q", actual)
+	}
+
+	// Adding and editing statements should retain CRLF line endings
+	sqlFile.AddStatement(tengo.ParseStatementInString("CREATE PROCEDURE p()\nBEGIN\n  SELECT 1;\nEND"))
+	sqlFile.EditStatementText(statements[2], "CREATE TABLE bar (\n  id bigint\n)", false)
+	expected := "CREATE TABLE foo (\r\n  id int\r\n);\r\n\r\nCREATE TABLE bar (\r\n  id bigint\r\n);\r\n" +
+		"DELIMITER //\r\nCREATE PROCEDURE p()\r\nBEGIN\r\n  SELECT 1;\r\nEND//\r\nDELIMITER ;\r\n"
+	if actual := string(sqlFile.WritePreview()); actual != expected {
+		t.Errorf("Unexpected file contents\nExpected: %q\nActual:   %q", expected, actual)
+	}
+
+	// Files with mostly LF line endings should not be converted
+	statements, _ = tengo.ParseStatementsInString("CREATE TABLE foo (\r\n  id int\n);\n")
+	if lineEnding := DetectLineEnding(statements); lineEnding != "\n" {
+		t.Errorf("Expected LF line ending to be detected, instead found %q", lineEnding)
+	}
+}
+
+func TestSQLFileStatementsByType(t *testing.T) {
+	statements, err := tengo.ParseStatementsInString("USE foo;\n-- hi\nCREATE TABLE a (id int);\nINSERT INTO a VALUES (1);\nCREATE TABLE b (id int);\n")
+	if err != nil {
+		t.Fatalf("Unexpected error from ParseStatementsInString: %v", err)
+	}
+	sqlFile := &SQLFile{Statements: statements}
+	creates := sqlFile.StatementsByType(tengo.StatementTypeCreate)
+	if len(creates) != 2 || creates[0].ObjectName != "a" || creates[1].ObjectName != "b" {
+		t.Errorf("Unexpected result from StatementsByType: %+v", creates)
+	}
+	if result := sqlFile.StatementsByType(tengo.StatementTypeCommand, tengo.StatementTypeNoop); len(result) != 2 {
+		t.Errorf("Expected 2 command or noop statements, instead found %d", len(result))
+	}
+	if result := sqlFile.StatementsByType(); len(result) != 0 {
+		t.Errorf("Expected no statements when no types supplied, instead found %d", len(result))
+	}
+}
+
+func TestSQLFileSize(t *testing.T) {
+	contents := ReadTestFile(t, "../tengo/testdata/statements.sql")
+	statements, err := tengo.ParseStatementsInFile("../tengo/testdata/statements.sql")
+	if err != nil {
+		t.Fatalf("Unexpected error from ParseStatementsInFile: %v", err)
+	}
+	sqlFile := &SQLFile{
+		FilePath:   "../tengo/testdata/statements.sql",
+		Statements: statements,
+	}
+	if size := sqlFile.ByteSize(); size != len(contents) {
+		t.Errorf("Expected ByteSize to return %d, instead found %d", len(contents), size)
+	}
+	if count, expected := sqlFile.LineCount(), len(splitLines([]byte(contents))); count != expected {
+		t.Errorf("Expected LineCount to return %d, instead found %d", expected, count)
+	}
+
+	sqlFile = &SQLFile{}
+	if size, count := sqlFile.ByteSize(), sqlFile.LineCount(); size != 0 || count != 0 {
+		t.Errorf("Expected empty SQLFile to have size 0 and 0 lines, instead found %d and %d", size, count)
+	}
+
+	// Final line without a trailing newline should still be counted
+	sqlFile.Statements, _ = tengo.ParseStatementsInString("CREATE TABLE foo (\n  id int\n);\nCREATE TABLE bar (id int)")
+	if size, count := sqlFile.ByteSize(), sqlFile.LineCount(); size != 56 || count != 4 {
+		t.Errorf("Expected size 56 and 4 lines, instead found %d and %d", size, count)
+	}
+}
+
+func TestSQLFilePatchPreview(t *testing.T) {
+	contents := "CREATE TABLE foo (\n  id int\n);\n"
+	WriteTestFile(t, "testdata/patchpreview.sql", contents)
+	defer RemoveTestFile(t, "testdata/patchpreview.sql")
+	statements, err := tengo.ParseStatementsInFile("testdata/patchpreview.sql")
+	if err != nil {
+		t.Fatalf("Unexpected error from ParseStatementsInFile: %v", err)
+	}
+	sqlFile := &SQLFile{
+		FilePath:   "testdata/patchpreview.sql",
+		Statements: statements,
+	}
+	if preview := sqlFile.WritePreview(); string(preview) != contents {
+		t.Errorf("Unexpected result from WritePreview: %q", preview)
+	}
+	if patch, err := sqlFile.PatchPreview("patchpreview.sql"); patch != "" || err != nil {
+		t.Errorf("Expected no patch for unchanged file, instead found %q / %v", patch, err)
+	}
+
+	// Modify the statement and confirm the patch reflects the change
+	sqlFile.EditStatementText(sqlFile.Statements[0], "CREATE TABLE foo (\n  id bigint\n)", false)
+	expected := "--- a/patchpreview.sql\n+++ b/patchpreview.sql\n@@ -1,3 +1,3 @@\n CREATE TABLE foo (\n-  id int\n+  id bigint\n );\n"
+	if patch, err := sqlFile.PatchPreview("patchpreview.sql"); patch != expected || err != nil {
+		t.Errorf("Unexpected result from PatchPreview: %q / %v", patch, err)
+	}
+	if ReadTestFile(t, "testdata/patchpreview.sql") != contents {
+		t.Error("PatchPreview unexpectedly modified the file")
+	}
+
+	// Removing the statement should yield a patch deleting the file
+	sqlFile.RemoveStatement(sqlFile.Statements[0])
+	if sqlFile.WritePreview() != nil {
+		t.Error("Expected WritePreview to return nil for file lacking any statements")
+	}
+	if patch, err := sqlFile.PatchPreview("patchpreview.sql"); !strings.Contains(patch, "+++ /dev/null") || err != nil {
+		t.Errorf("Unexpected result from PatchPreview: %q / %v", patch, err)
+	}
+
+	// A new file should yield a patch with /dev/null as the source
+	newFile := &SQLFile{FilePath: "testdata/doesnotexist.sql"}
+	newFile.AddStatement(&tengo.Statement{Text: "CREATE TABLE bar (id int)", Type: tengo.StatementTypeCreate})
+	if patch, err := newFile.PatchPreview("doesnotexist.sql"); !strings.HasPrefix(patch, "--- /dev/null\n+++ b/doesnotexist.sql\n") || err != nil {
+		t.Errorf("Unexpected result from PatchPreview: %q / %v", patch, err)
+	}
+}
+
 func TestPathForObject(t *testing.T) {
 	cases := []struct {
 		DirPath    string
@@ -229,3 +748,477 @@ func TestPathForObject(t *testing.T) {
 		}
 	}
 }
+
+func TestFileNameForObjectWithOptions(t *testing.T) {
+	cases := []struct {
+		ObjectName string
+		Opts       FileNameOptions
+		Expected   string
+	}{
+		{"my-table", FileNameOptions{}, "mytable.sql"},
+		{"my-table", FileNameOptions{Preserve: "-"}, "my-table.sql"},
+		{"order#2023", FileNameOptions{Preserve: "-"}, "order2023.sql"},
+		{"order#2023", FileNameOptions{Replacement: '_'}, "order_2023.sql"},
+		{"my-table #2", FileNameOptions{Preserve: "-", Replacement: '_'}, "my-table__2.sql"},
+		{"../etc/passwd", FileNameOptions{Preserve: "/\\", Replacement: '_'}, "___etc_passwd.sql"},
+		{"a/b\\c", FileNameOptions{Replacement: '/'}, "abc.sql"},
+		{"a b", FileNameOptions{Replacement: '-'}, "ab.sql"},
+		{"a b", FileNameOptions{Preserve: "-", Replacement: '-'}, "a-b.sql"},
+		{"***", FileNameOptions{Preserve: "-"}, "symbols.sql"},
+	}
+	for _, c := range cases {
+		expected := NormalizeFileName(c.Expected)
+		if actual := FileNameForObjectWithOptions(c.ObjectName, c.Opts); actual != expected {
+			t.Errorf("Expected FileNameForObjectWithOptions(%q, %+v) to return %q, instead found %q", c.ObjectName, c.Opts, expected, actual)
+		}
+	}
+}
+
+func TestFileNameCollisions(t *testing.T) {
+	names := []string{"foo-bar", "foobar", "baz", "foo_bar", "#order", "order", "foobar", "(order)"}
+	expected := map[string][]string{
+		"foobar.sql": {"foo-bar", "foobar"},
+		"order.sql":  {"#order", "(order)", "order"},
+	}
+	if actual := FileNameCollisions(names); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Unexpected result from FileNameCollisions: expected %v, found %v", expected, actual)
+	}
+	if actual := FileNameCollisions([]string{"foo", "bar", "foo"}); len(actual) != 0 {
+		t.Errorf("Expected no collisions, instead found %v", actual)
+	}
+}
+
+func TestGroupByFileName(t *testing.T) {
+	objects := map[string]string{
+		"Users":    "CREATE TABLE Users (id int)",
+		"users":    "CREATE TABLE users (id int)",
+		"user-log": "CREATE TABLE `user-log` (id int)",
+		"userlog":  "CREATE TABLE userlog (id int)",
+		"orders":   "CREATE TABLE orders (id int)",
+	}
+	expected := map[string][]string{
+		"users.sql":   {"Users", "users"},
+		"userlog.sql": {"user-log", "userlog"},
+		"orders.sql":  {"orders"},
+	}
+	if actual := GroupByFileName(objects, FileNameOptions{}, true); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Unexpected result from GroupByFileName with lowerCase=true: expected %v, found %v", expected, actual)
+	}
+	expected = map[string][]string{
+		"Users.sql":   {"Users"},
+		"users.sql":   {"users"},
+		"userlog.sql": {"user-log", "userlog"},
+		"orders.sql":  {"orders"},
+	}
+	if actual := GroupByFileName(objects, FileNameOptions{}, false); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Unexpected result from GroupByFileName with lowerCase=false: expected %v, found %v", expected, actual)
+	}
+	expected = map[string][]string{
+		"Users.sql":    {"Users"},
+		"users.sql":    {"users"},
+		"user-log.sql": {"user-log"},
+		"userlog.sql":  {"userlog"},
+		"orders.sql":   {"orders"},
+	}
+	if actual := GroupByFileName(objects, FileNameOptions{Preserve: "-"}, false); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Unexpected result from GroupByFileName with Preserve option: expected %v, found %v", expected, actual)
+	}
+}
+
+func TestSQLFileReferences(t *testing.T) {
+	contents := "CREATE TABLE a (id int, b_id int, FOREIGN KEY (b_id) REFERENCES b (id));\n" +
+		"CREATE TABLE c (id int, b_id int, a_id int, FOREIGN KEY (b_id) REFERENCES b (id), FOREIGN KEY (a_id) REFERENCES a (id));\n" +
+		"DELIMITER //\nCREATE PROCEDURE p() BEGIN SELECT * FROM d; CALL q(); END//\nDELIMITER ;\n"
+	statements, err := tengo.ParseStatementsInString(contents)
+	if err != nil {
+		t.Fatalf("Unexpected error from ParseStatementsInString: %v", err)
+	}
+	sqlFile := &SQLFile{
+		FilePath:   "testdata/references.sql",
+		Statements: statements,
+	}
+	expected := []tengo.ObjectKey{
+		{Type: tengo.ObjectTypeProc, Name: "q"},
+		{Type: tengo.ObjectTypeTable, Name: "a"},
+		{Type: tengo.ObjectTypeTable, Name: "b"},
+		{Type: tengo.ObjectTypeTable, Name: "d"},
+	}
+	if refs := sqlFile.References(); !reflect.DeepEqual(refs, expected) {
+		t.Errorf("Unexpected result from References: expected %v, found %v", expected, refs)
+	}
+}
+
+func TestSQLFileStatementsMissingDelimiter(t *testing.T) {
+	contents := "CREATE TABLE a (\n  id int\n)\nCREATE TABLE b (\n  id int\n);\n" +
+		"CREATE TABLE c (\n  id int,\n  `create` int COMMENT 'can\nCREATE confusion' -- \n  /* DROP */\n);\n" +
+		"DELIMITER //\nCREATE PROCEDURE p() BEGIN\nDROP TEMPORARY TABLE IF EXISTS t;\nEND//\nDELIMITER ;\n" +
+		"CREATE TABLE d (id int)\n"
+	statements, err := tengo.ParseStatementsInString(contents)
+	if err != nil {
+		t.Fatalf("Unexpected error from ParseStatementsInString: %v", err)
+	}
+	sqlFile := &SQLFile{
+		FilePath:   "testdata/merged.sql",
+		Statements: statements,
+	}
+	result := sqlFile.StatementsMissingDelimiter()
+	if len(result) != 1 || result[0].ObjectName != "a" {
+		t.Fatalf("Expected only statement for table a to be returned, instead found %v", result)
+	}
+}
+
+func TestSQLFileResetTrailingDelimiter(t *testing.T) {
+	cases := []struct {
+		contents string
+		expected string // empty string means no change expected
+	}{
+		{"CREATE TABLE a (id int);\n", ""},
+		{"CREATE TABLE a (id int);\nDELIMITER //\n", "CREATE TABLE a (id int);\n"},
+		{"CREATE TABLE a (id int);\nDELIMITER //\n-- hi\nDELIMITER ;\n", "CREATE TABLE a (id int);\n-- hi\n"},
+		{"DELIMITER //\nCREATE PROCEDURE p() BEGIN SELECT 1; END//\nDELIMITER ;\n", ""},
+		{"DELIMITER //\nCREATE PROCEDURE p() BEGIN SELECT 1; END//\n", "DELIMITER //\nCREATE PROCEDURE p() BEGIN SELECT 1; END//\nDELIMITER ;\n"},
+		{"DELIMITER //\nCREATE PROCEDURE p() BEGIN SELECT 1; END//\nDELIMITER $$\n", "DELIMITER //\nCREATE PROCEDURE p() BEGIN SELECT 1; END//\nDELIMITER ;\n"},
+		{"DELIMITER //\nCREATE PROCEDURE p() BEGIN SELECT 1; END//\nDELIMITER ;\nDELIMITER ;\n", "DELIMITER //\nCREATE PROCEDURE p() BEGIN SELECT 1; END//\nDELIMITER ;\n"},
+	}
+	for n, c := range cases {
+		statements, err := tengo.ParseStatementsInString(c.contents)
+		if err != nil {
+			t.Fatalf("cases[%d]: Unexpected error from ParseStatementsInString: %v", n, err)
+		}
+		sqlFile := &SQLFile{FilePath: "testdata/delim.sql", Statements: statements}
+		expectChange := (c.expected != "")
+		if changed := sqlFile.ResetTrailingDelimiter(); changed != expectChange || sqlFile.Dirty != expectChange {
+			t.Errorf("cases[%d]: Expected changed=%t, instead found changed=%t dirty=%t", n, expectChange, changed, sqlFile.Dirty)
+		} else if expectChange {
+			if actual := string(sqlFile.WritePreview()); actual != c.expected {
+				t.Errorf("cases[%d]: Expected contents %q, instead found %q", n, c.expected, actual)
+			}
+		}
+	}
+}
+
+func TestReconcileSQLFiles(t *testing.T) {
+	makeFile := func(contents string) *SQLFile {
+		statements, err := tengo.ParseStatementsInString(contents)
+		if err != nil {
+			t.Fatalf("Unexpected error from ParseStatementsInString: %v", err)
+		}
+		return &SQLFile{FilePath: "testdata/conflict.sql", Statements: statements}
+	}
+	base := makeFile("CREATE TABLE t (\n  id int,\n  name varchar(30),\n  PRIMARY KEY (id)\n) ENGINE=InnoDB;\n")
+	ours := makeFile("CREATE TABLE t (\n  id int,\n  name varchar(60),\n  email varchar(100),\n  PRIMARY KEY (id)\n) ENGINE=InnoDB;\n")
+	theirs := makeFile("CREATE TABLE t (\n  id   int,\n  name varchar(40),\n  PRIMARY KEY (id),\n  KEY name (name)\n) ENGINE=InnoDB;\n")
+	key := tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: "t"}
+
+	diffs, err := ReconcileSQLFiles(base, ours, theirs, key)
+	if err != nil {
+		t.Fatalf("Unexpected error from ReconcileSQLFiles: %v", err)
+	}
+	expected := []DefinitionDifference{
+		{Element: "column `name`", Base: "name varchar(30)", Ours: "name varchar(60)", Theirs: "name varchar(40)", OursChanged: true, TheirsChanged: true},
+		{Element: "column `email`", Ours: "email varchar(100)", OursChanged: true},
+		{Element: "index `name`", Theirs: "KEY name (name)", TheirsChanged: true},
+	}
+	if !reflect.DeepEqual(diffs, expected) {
+		t.Fatalf("Unexpected result from ReconcileSQLFiles: expected %+v, found %+v", expected, diffs)
+	}
+	if !diffs[0].Conflict() || diffs[1].Conflict() || diffs[2].Conflict() {
+		t.Error("Unexpected result from Conflict()")
+	}
+	if _, ok := diffs[0].Resolved(); ok {
+		t.Error("Expected conflicting difference to be unresolvable")
+	}
+	if text, ok := diffs[1].Resolved(); !ok || text != "email varchar(100)" {
+		t.Errorf("Unexpected result from Resolved(): %q, %t", text, ok)
+	}
+	if text, ok := diffs[2].Resolved(); !ok || text != "KEY name (name)" {
+		t.Errorf("Unexpected result from Resolved(): %q, %t", text, ok)
+	}
+
+	// Without a base, every difference is a conflict
+	if diffs, err = ReconcileSQLFiles(nil, ours, theirs, key); err != nil || len(diffs) != 3 {
+		t.Fatalf("Unexpected result from ReconcileSQLFiles: %+v, %v", diffs, err)
+	}
+	for _, dd := range diffs {
+		if !dd.Conflict() {
+			t.Errorf("Expected difference %+v to be a conflict without base", dd)
+		}
+	}
+
+	// Object missing from one side
+	missingKey := tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: "missing"}
+	if _, err := ReconcileSQLFiles(base, ours, theirs, missingKey); err == nil {
+		t.Error("Expected error for missing object, but err was nil")
+	}
+}
+
+func TestSQLFileSessionCommands(t *testing.T) {
+	contents := "SET @@session.sql_mode := 'STRICT_TRANS_TABLES,NO_ZERO_DATE';\n" +
+		"SET NAMES utf8mb4 COLLATE utf8mb4_bin;\n" +
+		"CREATE TABLE a (id int);\n" +
+		"set time_zone='+00:00', GLOBAL max_connections=10, @x = CONCAT(@@sql_mode, ',X');\n" +
+		"SET PASSWORD = 'hunter2';\n" +
+		"SET CHARACTER SET latin1;\n" +
+		"SELECT 1;\n"
+	statements, err := tengo.ParseStatementsInString(contents)
+	if err != nil {
+		t.Fatalf("Unexpected error from ParseStatementsInString: %v", err)
+	}
+	sqlFile := &SQLFile{FilePath: "testdata/session.sql", Statements: statements}
+	commands := sqlFile.SessionCommands()
+	expected := [][]SessionSetting{
+		{{Scope: "session", Name: "sql_mode", Value: "'STRICT_TRANS_TABLES,NO_ZERO_DATE'"}},
+		{{Scope: "session", Name: "names", Value: "utf8mb4 COLLATE utf8mb4_bin"}},
+		{
+			{Scope: "session", Name: "time_zone", Value: "'+00:00'"},
+			{Scope: "global", Name: "max_connections", Value: "10"},
+			{Scope: "user", Name: "@x", Value: "CONCAT(@@sql_mode, ',X')"},
+		},
+		{{Scope: "session", Name: "character set", Value: "latin1"}},
+	}
+	if len(commands) != len(expected) {
+		t.Fatalf("Expected %d session commands, instead found %d: %+v", len(expected), len(commands), commands)
+	}
+	for n, cmd := range commands {
+		if !reflect.DeepEqual(cmd.Settings, expected[n]) {
+			t.Errorf("commands[%d]: expected settings %+v, instead found %+v", n, expected[n], cmd.Settings)
+		}
+	}
+	if commands[2].Statement.LineNo != 4 {
+		t.Errorf("Expected commands[2] to be on line 4, instead found line %d", commands[2].Statement.LineNo)
+	}
+
+	// Session commands must be preserved by Write
+	if actual := string(sqlFile.WritePreview()); actual != contents {
+		t.Errorf("Expected WritePreview to preserve contents, instead found %q", actual)
+	}
+}
+
+func TestSQLFileReload(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "foo.sql")
+	contents := "-- comment\r\nCREATE TABLE foo (id int);\r\n"
+	WriteTestFile(t, filePath, contents)
+	sqlFile := &SQLFile{FilePath: filePath}
+	if err := sqlFile.Reload(); err != nil {
+		t.Fatalf("Unexpected error from Reload: %v", err)
+	} else if len(sqlFile.Statements) != 2 || sqlFile.LineEnding != "\r\n" {
+		t.Fatalf("Unexpected result from Reload: %d statements, line ending %q", len(sqlFile.Statements), sqlFile.LineEnding)
+	}
+
+	// Reload should discard in-memory edits
+	sqlFile.EditStatementText(sqlFile.Statements[1], "CREATE TABLE foo (id bigint)", false)
+	sqlFile.AddStatement(&tengo.Statement{Text: "CREATE TABLE bar (id int);\n", Type: tengo.StatementTypeCreate})
+	if err := sqlFile.Reload(); err != nil {
+		t.Fatalf("Unexpected error from Reload: %v", err)
+	} else if sqlFile.Dirty {
+		t.Error("Expected Reload to unmark file as dirty, but it did not")
+	} else if preview := string(sqlFile.WritePreview()); preview != contents {
+		t.Errorf("Unexpected contents after Reload: %q", preview)
+	}
+
+	// Reloading a deleted file should return the underlying error and leave
+	// statements intact
+	if err := sqlFile.Delete(); err != nil {
+		t.Fatalf("Unexpected error from Delete: %v", err)
+	}
+	if err := sqlFile.Reload(); !os.IsNotExist(err) {
+		t.Errorf("Expected Reload of deleted file to return a not-exist error, instead found %v", err)
+	} else if len(sqlFile.Statements) != 2 {
+		t.Errorf("Expected statements to be left intact, instead found %d statements", len(sqlFile.Statements))
+	}
+}
+
+func TestSQLFileValidate(t *testing.T) {
+	statements, err := tengo.ParseStatementsInFile("../tengo/testdata/statements.sql")
+	if err != nil {
+		t.Fatalf("Unexpected error from ParseStatementsInFile: %v", err)
+	}
+	sqlFile := &SQLFile{
+		FilePath:   "../tengo/testdata/statements.sql",
+		Statements: statements,
+	}
+	if err := sqlFile.Validate(); err != nil {
+		t.Errorf("Unexpected error from Validate on unmodified file: %v", err)
+	}
+
+	statements, _ = tengo.ParseStatementsInString("CREATE TABLE foo (id int);\nCREATE TABLE bar (id int);\n")
+	sqlFile = &SQLFile{FilePath: "foo.sql", Statements: statements}
+
+	// Valid edits, including converting to a compound statement, should pass
+	sqlFile.EditStatementText(statements[0], "CREATE TABLE foo (id bigint)", false)
+	if err := sqlFile.Validate(); err != nil {
+		t.Errorf("Unexpected error from Validate after valid edit: %v", err)
+	}
+	sqlFile.EditStatementText(statements[1], "CREATE PROCEDURE bar() BEGIN SELECT 1; SELECT 2; END", true)
+	if err := sqlFile.Validate(); err != nil {
+		t.Errorf("Unexpected error from Validate after valid compound edit: %v", err)
+	}
+
+	// An embedded unescaped delimiter should cause an error
+	sqlFile.EditStatementText(statements[0], "CREATE TABLE foo (id int); DROP TABLE foo", false)
+	if err := sqlFile.Validate(); err == nil || !strings.Contains(err.Error(), "foo.sql:1") {
+		t.Errorf("Expected error from Validate mentioning location after embedding a delimiter, instead found %v", err)
+	}
+}
+
+func TestSQLFilePreserveClean(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "foo.sql")
+	contents := "-- hand-formatted\nCREATE TABLE foo (\n\tid int,   Name varchar(30)\n)"
+	WriteTestFile(t, filePath, contents)
+	sqlFile := &SQLFile{FilePath: filePath, PreserveClean: true, Format: SQLFileFormat{TrailingNewline: true}}
+	if err := sqlFile.Reload(); err != nil {
+		t.Fatalf("Unexpected error from Reload: %v", err)
+	} else if !sqlFile.SemanticallyUnchanged() {
+		t.Fatal("Expected SemanticallyUnchanged to return true immediately after Reload, but it did not")
+	}
+
+	// Reformatting without semantic changes should not modify the file at all,
+	// even with formatting normalizations enabled
+	stmt := sqlFile.StatementsByType(tengo.StatementTypeCreate)[0]
+	if err := sqlFile.EditStatementText(stmt, "CREATE TABLE `foo` (\n  `id` INT,\n  `Name` VARCHAR(30)\n)", false); err != nil {
+		t.Fatalf("Unexpected error from EditStatementText: %v", err)
+	} else if !sqlFile.Dirty || !sqlFile.SemanticallyUnchanged() {
+		t.Fatalf("Unexpected values after keyword case edit: Dirty=%t, SemanticallyUnchanged=%t", sqlFile.Dirty, sqlFile.SemanticallyUnchanged())
+	}
+	if err := sqlFile.EditStatementText(stmt, "CREATE TABLE `foo` (\n  `id` int,\n  `Name` varchar(30)\n)", false); err != nil {
+		t.Fatalf("Unexpected error from EditStatementText: %v", err)
+	} else if !sqlFile.Dirty || !sqlFile.SemanticallyUnchanged() {
+		t.Fatalf("Unexpected values after cosmetic edit: Dirty=%t, SemanticallyUnchanged=%t", sqlFile.Dirty, sqlFile.SemanticallyUnchanged())
+	}
+	if n, err := sqlFile.Write(); err != nil || n != len(contents) {
+		t.Errorf("Unexpected return from Write: %d, %v", n, err)
+	} else if sqlFile.Dirty {
+		t.Error("Expected Write to unmark file as dirty, but it did not")
+	}
+	if actual := ReadTestFile(t, filePath); actual != contents {
+		t.Errorf("Expected file contents to be preserved byte-for-byte, instead found %q", actual)
+	}
+
+	// Without PreserveClean, the same edit should be written
+	sqlFile.PreserveClean = false
+	sqlFile.Dirty = true
+	if _, err := sqlFile.Write(); err != nil {
+		t.Fatalf("Unexpected error from Write: %v", err)
+	} else if actual := ReadTestFile(t, filePath); actual == contents {
+		t.Error("Expected file to be rewritten without PreserveClean, but it was not")
+	}
+
+	// Semantic changes should always be written, including changes solely in
+	// identifier case
+	sqlFile.PreserveClean = true
+	if err := sqlFile.Reload(); err != nil {
+		t.Fatalf("Unexpected error from Reload: %v", err)
+	}
+	stmt = sqlFile.StatementsByType(tengo.StatementTypeCreate)[0]
+	if err := sqlFile.EditStatementText(stmt, "CREATE TABLE `foo` (\n  `id` int,\n  `name` varchar(30)\n)", false); err != nil {
+		t.Fatalf("Unexpected error from EditStatementText: %v", err)
+	} else if sqlFile.SemanticallyUnchanged() {
+		t.Fatal("Expected SemanticallyUnchanged to return false after case-only column rename, but it did not")
+	}
+	if _, err := sqlFile.Write(); err != nil {
+		t.Fatalf("Unexpected error from Write: %v", err)
+	} else if actual := ReadTestFile(t, filePath); !strings.Contains(actual, "`name`") {
+		t.Errorf("Expected file to be rewritten after case-only column rename, instead found %q", actual)
+	}
+	if err := sqlFile.EditStatementText(stmt, "CREATE TABLE `foo` (\n  `id` bigint,\n  `name` varchar(30)\n)", false); err != nil {
+		t.Fatalf("Unexpected error from EditStatementText: %v", err)
+	} else if sqlFile.SemanticallyUnchanged() {
+		t.Fatal("Expected SemanticallyUnchanged to return false after functional edit, but it did not")
+	}
+	if _, err := sqlFile.Write(); err != nil {
+		t.Fatalf("Unexpected error from Write: %v", err)
+	} else if actual := ReadTestFile(t, filePath); !strings.Contains(actual, "bigint") {
+		t.Errorf("Expected file to be rewritten after functional edit, instead found %q", actual)
+	}
+
+	// SQLFiles which do not exist in the filesystem are never considered
+	// semantically unchanged
+	newPath := filepath.Join(filepath.Dir(filePath), "new.sql")
+	if newFile := (&SQLFile{FilePath: newPath}); newFile.SemanticallyUnchanged() {
+		t.Error("Expected SemanticallyUnchanged to return false for nonexistent file, but it did not")
+	}
+}
+
+func TestSQLFileHeader(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "multi.sql")
+	header := "-- DO NOT EDIT: managed by skeema\n-- Copyright Example Corp\n\n"
+	WriteTestFile(t, filePath, header+"CREATE TABLE a (id int);\n-- about b\nCREATE TABLE b (id int);\n")
+	sqlFile := &SQLFile{FilePath: filePath}
+	if err := sqlFile.Reload(); err != nil {
+		t.Fatalf("Unexpected error from Reload: %v", err)
+	} else if len(sqlFile.Header) != 1 || sqlFile.Header[0].Text != header {
+		t.Fatalf("Unexpected Header after Reload: %+v", sqlFile.Header)
+	}
+
+	// Rearranging statements would normally move the header along with the
+	// first CREATE, but it should remain first
+	if !sqlFile.OrderStatements([]tengo.ObjectKey{{Name: "b"}, {Name: "a"}}) {
+		t.Fatal("Expected OrderStatements to return true, but it did not")
+	}
+	expected := header + "-- about b\nCREATE TABLE b (id int);\nCREATE TABLE a (id int);\n"
+	if actual := string(sqlFile.WritePreview()); actual != expected {
+		t.Errorf("Unexpected contents after OrderStatements: expected %q, found %q", expected, actual)
+	}
+
+	if _, err := sqlFile.Write(); err != nil {
+		t.Fatalf("Unexpected error from Write: %v", err)
+	} else if actual := ReadTestFile(t, filePath); actual != expected {
+		t.Errorf("Unexpected file contents after Write: expected %q, found %q", expected, actual)
+	}
+
+	// After re-parsing, the header and the comment describing the first CREATE
+	// are a single run of comments, which should be split at the blank line
+	if err := sqlFile.Reload(); err != nil {
+		t.Fatalf("Unexpected error from Reload: %v", err)
+	} else if len(sqlFile.Header) != 1 || sqlFile.Header[0].Text != header {
+		t.Fatalf("Unexpected Header after Reload: %+v", sqlFile.Header)
+	} else if next := sqlFile.Statements[1]; next.Text != "-- about b\n" || next.LineNo != 4 {
+		t.Errorf("Unexpected statement after header: %+v", *next)
+	}
+
+	// Removing the header statement should actually remove it
+	sqlFile.RemoveStatement(sqlFile.Header[0])
+	expected = "-- about b\nCREATE TABLE b (id int);\nCREATE TABLE a (id int);\n"
+	if actual := string(sqlFile.WritePreview()); actual != expected || len(sqlFile.Header) != 0 {
+		t.Errorf("Unexpected contents after removing header: expected %q, found %q", expected, actual)
+	}
+
+	// Comments directly preceding the first CREATE, without a blank line between
+	// them, describe that CREATE and are not a header. They stay with it when
+	// rearranging statements.
+	WriteTestFile(t, filePath, "-- about a\nCREATE TABLE a (id int);\n-- about b\nCREATE TABLE b (id int);\n")
+	if err := sqlFile.Reload(); err != nil {
+		t.Fatalf("Unexpected error from Reload: %v", err)
+	} else if sqlFile.Header != nil {
+		t.Errorf("Expected no header, instead found %+v", sqlFile.Header)
+	}
+	sqlFile.OrderStatements([]tengo.ObjectKey{{Name: "b"}, {Name: "a"}})
+	expected = "-- about b\nCREATE TABLE b (id int);\n-- about a\nCREATE TABLE a (id int);\n"
+	if actual := string(sqlFile.WritePreview()); actual != expected {
+		t.Errorf("Unexpected contents after OrderStatements: expected %q, found %q", expected, actual)
+	}
+
+	// Blank lines inside of block comments are not considered
+	WriteTestFile(t, filePath, "/* about a\n\nwith a blank line */\nCREATE TABLE a (id int);\n")
+	if err := sqlFile.Reload(); err != nil {
+		t.Fatalf("Unexpected error from Reload: %v", err)
+	} else if sqlFile.Header != nil {
+		t.Errorf("Expected no header, instead found %+v", sqlFile.Header)
+	}
+
+	// CRLF line endings should also be handled when looking for a blank line
+	WriteTestFile(t, filePath, "-- header\r\n\r\nCREATE TABLE a (id int);\r\n")
+	if err := sqlFile.Reload(); err != nil {
+		t.Fatalf("Unexpected error from Reload: %v", err)
+	} else if len(sqlFile.Header) != 1 {
+		t.Errorf("Expected a header, instead found %+v", sqlFile.Header)
+	}
+
+	// Files beginning with whitespace but no comments do not have a header
+	WriteTestFile(t, filePath, "\n\nCREATE TABLE a (id int);\n")
+	if err := sqlFile.Reload(); err != nil {
+		t.Fatalf("Unexpected error from Reload: %v", err)
+	} else if sqlFile.Header != nil {
+		t.Errorf("Expected no header, instead found %+v", sqlFile.Header)
+	}
+}
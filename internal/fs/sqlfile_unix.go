@@ -0,0 +1,31 @@
+// This file contains SQLFile functionality that is specific to UNIX-like
+// operating systems.
+
+//go:build !windows
+// +build !windows
+
+package fs
+
+import (
+	"os"
+	"syscall"
+)
+
+// hardLinkCount returns the number of hard links to the file described by fi,
+// or 1 if this cannot be determined.
+func hardLinkCount(fi os.FileInfo) uint64 {
+	if st, ok := fi.Sys().(*syscall.Stat_t); ok {
+		return uint64(st.Nlink)
+	}
+	return 1
+}
+
+// copyOwner attempts to set the owner and group of the file at path to match
+// those described by fi. Typically this only succeeds if running as root, or if
+// the owner is already the same as the current user and only the group differs.
+func copyOwner(path string, fi os.FileInfo) error {
+	if st, ok := fi.Sys().(*syscall.Stat_t); ok {
+		return os.Chown(path, int(st.Uid), int(st.Gid))
+	}
+	return nil
+}
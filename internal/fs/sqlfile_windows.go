@@ -0,0 +1,20 @@
+// This file contains SQLFile functionality that is specific to Windows.
+
+//go:build windows
+// +build windows
+
+package fs
+
+import "os"
+
+// hardLinkCount always returns 1 on Windows, since os.FileInfo does not expose
+// link counts on this platform.
+func hardLinkCount(fi os.FileInfo) uint64 {
+	return 1
+}
+
+// copyOwner is a no-op on Windows, where files inherit ACLs from their parent
+// directory instead.
+func copyOwner(path string, fi os.FileInfo) error {
+	return nil
+}
@@ -0,0 +1,60 @@
+package linter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/skeema/skeema/internal/tengo"
+)
+
+func init() {
+	RegisterRule(Rule{
+		CheckerFunc:     TableChecker(constraintNameChecker),
+		Name:            "constraint-name",
+		Description:     "Flag foreign key or check constraint names which may collide with auto-generated names of other tables",
+		DefaultSeverity: SeverityWarning,
+	})
+}
+
+// reAutoConstraintName matches constraint names which follow the format of
+// automatically-generated foreign key or check constraint names.
+var reAutoConstraintName = regexp.MustCompile(`^(.+)_(ibfk|chk)_\d+$`)
+
+// constraintNameChecker flags constraint names which match the format of names
+// that the server automatically generates for a different table. Names which
+// actually collide with another table's constraint in the same schema aren't
+// checked here: the second table to be created fails in the workspace, which
+// is reported as a statement error instead.
+func constraintNameChecker(table *tengo.Table, createStatement string, _ *tengo.Schema, opts Options) []Note {
+	results := make([]Note, 0)
+	check := func(name, constraintType, autoSuffix string) {
+		matches := reAutoConstraintName.FindStringSubmatch(name)
+		if matches == nil || matches[2] != autoSuffix || strings.EqualFold(matches[1], table.Name) {
+			return
+		}
+		message := fmt.Sprintf(
+			"Table %s has a %s constraint named %s, which matches the format of names that the database server automatically generates for table %s. This name will collide if table %s exists with unnamed %s constraints, for example after a table rename or copying definitions between tables.",
+			table.Name, constraintType, name, matches[1], matches[1], constraintType,
+		)
+		re := regexp.MustCompile(fmt.Sprintf("(?i)constraint\\s+`?%s(?:`|\\s)", regexp.QuoteMeta(name)))
+		results = append(results, Note{
+			LineOffset: FindFirstLineOffset(re, createStatement),
+			Summary:    "Constraint name collision",
+			Message:    message + " Rename this constraint to avoid the problem.",
+		})
+	}
+
+	// Foreign key names are schema-wide. Check constraint names are schema-wide
+	// in MySQL, but per-table in MariaDB, so auto-generated names can only collide
+	// in MySQL.
+	for _, fk := range table.ForeignKeys {
+		check(fk.Name, "foreign key", "ibfk")
+	}
+	if !opts.Flavor.IsMariaDB() {
+		for _, cc := range table.Checks {
+			check(cc.Name, "check", "chk")
+		}
+	}
+	return results
+}
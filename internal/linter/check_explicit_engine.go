@@ -0,0 +1,34 @@
+package linter
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/skeema/skeema/internal/tengo"
+)
+
+func init() {
+	RegisterRule(Rule{
+		CheckerFunc:     TableBinaryChecker(explicitEngineChecker),
+		Name:            "explicit-engine",
+		Description:     "Flag tables lacking an ENGINE clause, which rely on the server's default_storage_engine",
+		DefaultSeverity: SeverityIgnore,
+	})
+}
+
+var reCloseParen = regexp.MustCompile(`\)`)
+
+func explicitEngineChecker(table *tengo.Table, createStatement string, _ *tengo.Schema, _ Options) *Note {
+	if tengo.ParseCreateEngine(createStatement) != "" {
+		return nil
+	}
+	message := fmt.Sprintf(
+		"Table %s does not specify a storage engine, so it will use the server's default_storage_engine (currently %s on the workspace server). Servers with different defaults would create this table differently. Add an explicit ENGINE clause to make this definition portable.",
+		table.Name, table.Engine,
+	)
+	return &Note{
+		LineOffset: FindLastLineOffset(reCloseParen, createStatement),
+		Summary:    "Table lacks explicit ENGINE",
+		Message:    message,
+	}
+}
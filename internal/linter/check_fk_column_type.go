@@ -0,0 +1,51 @@
+package linter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/skeema/skeema/internal/tengo"
+)
+
+func init() {
+	RegisterRule(Rule{
+		CheckerFunc:     TableChecker(fkColumnTypeChecker),
+		Name:            "fk-column-type",
+		Description:     "Flag foreign keys whose columns have types incompatible with the referenced columns",
+		DefaultSeverity: SeverityWarning,
+	})
+}
+
+func fkColumnTypeChecker(table *tengo.Table, createStatement string, schema *tengo.Schema, _ Options) []Note {
+	results := make([]Note, 0)
+	for _, fk := range table.ForeignKeys {
+		// Cross-schema references can't be checked, since only one schema is
+		// available here. References to a nonexistent table are also skipped,
+		// since the parent may be created separately.
+		if fk.ReferencedSchemaName != "" {
+			continue
+		}
+		referencedTable := schema.Table(fk.ReferencedTableName)
+		if referencedTable == nil {
+			continue
+		}
+		mismatches := fk.ColumnTypeMismatches(table, referencedTable)
+		if len(mismatches) == 0 {
+			continue
+		}
+		re := regexp.MustCompile(fmt.Sprintf("(?i)constraint\\s+`?%s(?:`|\\s)", regexp.QuoteMeta(fk.Name)))
+		if !re.MatchString(createStatement) && len(fk.ColumnNames) > 0 {
+			re = regexp.MustCompile(fmt.Sprintf("(?i)foreign\\s+key\\s*\\(\\s*`?%s(?:`|\\s|,|\\))", regexp.QuoteMeta(fk.ColumnNames[0])))
+		}
+		results = append(results, Note{
+			LineOffset: FindFirstLineOffset(re, createStatement),
+			Summary:    "Foreign key column types are incompatible",
+			Message: fmt.Sprintf(
+				"Foreign key %s of table %s references table %s, but the column types are not compatible: %s. This will cause creation of the foreign key to fail.",
+				fk.Name, table.Name, referencedTable.Name, strings.Join(mismatches, "; "),
+			),
+		})
+	}
+	return results
+}
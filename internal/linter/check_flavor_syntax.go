@@ -0,0 +1,34 @@
+package linter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/skeema/skeema/internal/tengo"
+)
+
+func init() {
+	RegisterRule(Rule{
+		CheckerFunc:     GenericChecker(flavorSyntaxChecker),
+		Name:            "flavor-syntax",
+		Description:     "Flag clauses which are not supported by the database server's flavor and version",
+		DefaultSeverity: SeverityWarning,
+	})
+}
+
+func flavorSyntaxChecker(object tengo.DefKeyer, createStatement string, _ *tengo.Schema, opts Options) (notes []Note) {
+	for _, issue := range tengo.ValidateFlavorSyntax(createStatement, opts.Flavor) {
+		words := strings.Fields(issue.Clause)
+		for n := range words {
+			words[n] = regexp.QuoteMeta(words[n])
+		}
+		re := regexp.MustCompile(`(?i)\b` + strings.Join(words, `\s+`) + `\b`)
+		notes = append(notes, Note{
+			LineOffset: FindFirstLineOffset(re, createStatement),
+			Summary:    "clause not supported by flavor",
+			Message:    fmt.Sprintf("%s uses %s, which is not supported by %s: %s.", object.ObjectKey(), issue.Clause, opts.Flavor, issue.Reason),
+		})
+	}
+	return notes
+}
@@ -0,0 +1,57 @@
+package linter
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/skeema/skeema/internal/tengo"
+)
+
+func init() {
+	RegisterRule(Rule{
+		CheckerFunc:     TableChecker(keyLengthChecker),
+		Name:            "key-length",
+		Description:     "Flag indexes exceeding the maximum key length for the configured flavor, or which would exceed it if converted from utf8mb3 to utf8mb4",
+		DefaultSeverity: SeverityIgnore,
+	})
+}
+
+func keyLengthChecker(table *tengo.Table, createStatement string, _ *tengo.Schema, opts Options) []Note {
+	issues := table.OversizedIndexes(tengo.KeyLengthOptions{Flavor: opts.Flavor})
+	var converted bool
+	if len(issues) == 0 && hasUTF8mb3Column(table) {
+		issues = table.OversizedIndexes(tengo.KeyLengthOptions{Flavor: opts.Flavor, CharSet: "utf8mb4"})
+		converted = true
+	}
+	results := make([]Note, 0, len(issues))
+	for _, issue := range issues {
+		var re *regexp.Regexp
+		if issue.Index.PrimaryKey {
+			re = regexp.MustCompile(`(?i)primary\s+key`)
+		} else {
+			re = regexp.MustCompile(fmt.Sprintf("(?i)(key|index)\\s+`?%s(?:`|\\s)", regexp.QuoteMeta(issue.Index.Name)))
+		}
+		note := Note{
+			LineOffset: FindFirstLineOffset(re, createStatement),
+			Summary:    "Index exceeds maximum key length",
+			Message:    fmt.Sprintf("In table %s, %s. This would cause CREATE TABLE or ALTER TABLE to fail with error 1071 (\"Specified key was too long\").", table.Name, issue),
+		}
+		if converted {
+			note.Summary = "Index would exceed maximum key length in utf8mb4"
+			note.Message = fmt.Sprintf("If table %s is converted from utf8mb3 to utf8mb4, %s. Consider using a shorter column length or index prefix length before converting.", table.Name, issue)
+		}
+		results = append(results, note)
+	}
+	return results
+}
+
+// hasUTF8mb3Column returns true if any column of table uses the utf8mb3
+// character set.
+func hasUTF8mb3Column(table *tengo.Table) bool {
+	for _, col := range table.Columns {
+		if col.CharSet == "utf8" || col.CharSet == "utf8mb3" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,122 @@
+package linter
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/skeema/mybase"
+	"github.com/skeema/skeema/internal/tengo"
+)
+
+func init() {
+	// This rule uses a customized RelatedOption and ConfigFunc, rather than using
+	// Rule.RelatedListOption, because the option value specifies numeric limits
+	// which should be parsed and validated a single time
+	RegisterRule(Rule{
+		CheckerFunc:     TableChecker(pkClusteringChecker),
+		Name:            "pk-clustering",
+		Description:     "Flag InnoDB tables with a wide primary key, or one likely populated in random order",
+		DefaultSeverity: SeverityIgnore,
+		RelatedOption:   mybase.StringOption("max-pk-width", 0, "4 columns,128 bytes", "Maximum primary key column count and/or byte length for --lint-pk-clustering"),
+		ConfigFunc:      RuleConfigFunc(pkClusteringConfiger),
+	})
+}
+
+// pkClusteringConfig is a custom configuration struct used by
+// pkClusteringChecker. A zero value for either limit means no limit.
+type pkClusteringConfig struct {
+	maxColumns int
+	maxBytes   int
+	rawValue   string
+}
+
+var reRandomPKColumnName = regexp.MustCompile(`(?i)uuid|guid`)
+
+func pkClusteringChecker(table *tengo.Table, createStatement string, _ *tengo.Schema, opts Options) []Note {
+	if table.PrimaryKey == nil || table.Engine != "InnoDB" {
+		return nil
+	}
+	pcc := opts.RuleConfig["pk-clustering"].(*pkClusteringConfig)
+	var results []Note
+	lineOffset := FindFirstLineOffset(regexp.MustCompile(`(?i)primary\s+key`), createStatement)
+
+	var totalBytes int
+	cols := table.ColumnsByName()
+	for _, part := range table.PrimaryKey.Parts {
+		if col, ok := cols[part.ColumnName]; ok {
+			totalBytes += col.KeyPartBytes(part.PrefixLength, "")
+		}
+	}
+	numCols := len(table.PrimaryKey.Parts)
+	if (pcc.maxColumns > 0 && numCols > pcc.maxColumns) || (pcc.maxBytes > 0 && totalBytes > pcc.maxBytes) {
+		message := fmt.Sprintf(
+			"Table %s has a primary key consisting of %d column(s), with a maximum length of approximately %d bytes. Option max-pk-width is set to %s.\nIn InnoDB, every secondary index includes a copy of the primary key, so a wide primary key increases the size of all secondary indexes. Consider using a narrower primary key, such as an auto_increment integer, and converting the current primary key to a UNIQUE index.",
+			table.Name, numCols, totalBytes, pcc.rawValue,
+		)
+		results = append(results, Note{
+			LineOffset: lineOffset,
+			Summary:    "Wide primary key",
+			Message:    message,
+		})
+	}
+
+	if col, ok := cols[table.PrimaryKey.Parts[0].ColumnName]; ok && likelyRandomColumn(col) {
+		message := fmt.Sprintf(
+			"Column %s is the leading column of the primary key of table %s, and appears to store UUIDs or other randomly-ordered values.\nIn InnoDB, the primary key is the clustered index, so inserting rows in random key order causes page splits and fragmentation, hurting write performance. Consider using a monotonically increasing primary key, such as an auto_increment integer or time-ordered UUIDs.",
+			col.Name, table.Name,
+		)
+		results = append(results, Note{
+			LineOffset: lineOffset,
+			Summary:    "Randomly-ordered primary key",
+			Message:    message,
+		})
+	}
+	return results
+}
+
+// likelyRandomColumn returns true if col appears to store UUIDs, based on its
+// name, type, or default expression.
+func likelyRandomColumn(col *tengo.Column) bool {
+	if col.AutoIncrement {
+		return false
+	}
+	colType := strings.ToLower(col.TypeInDB)
+	switch colType {
+	case "char(36)", "varchar(36)", "binary(16)", "uuid":
+		return true
+	}
+	return reRandomPKColumnName.MatchString(col.Name) || strings.Contains(strings.ToLower(col.Default), "uuid(")
+}
+
+// pkClusteringConfiger parses the max-pk-width option, which is a
+// comma-separated list containing values of the form "N columns" and/or
+// "N bytes".
+func pkClusteringConfiger(config *mybase.Config) interface{} {
+	pcc := &pkClusteringConfig{}
+	values := config.GetSlice("max-pk-width", ',', true)
+	for _, value := range values {
+		fields := strings.Fields(strings.ToLower(value))
+		if len(fields) != 2 {
+			return fmt.Errorf("Option max-pk-width contains invalid value %q: expected a number followed by \"columns\" or \"bytes\"", value)
+		}
+		n, err := strconv.Atoi(fields[0])
+		if err != nil || n < 0 {
+			return fmt.Errorf("Option max-pk-width contains invalid value %q: expected a non-negative number followed by \"columns\" or \"bytes\"", value)
+		}
+		switch strings.TrimSuffix(fields[1], "s") {
+		case "column":
+			pcc.maxColumns = n
+		case "byte":
+			pcc.maxBytes = n
+		default:
+			return fmt.Errorf("Option max-pk-width contains invalid value %q: expected a number followed by \"columns\" or \"bytes\"", value)
+		}
+	}
+	pcc.rawValue = strings.Join(values, ", ")
+	if pcc.rawValue == "" {
+		pcc.rawValue = "an empty value"
+	}
+	return pcc
+}
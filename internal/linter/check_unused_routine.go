@@ -0,0 +1,41 @@
+package linter
+
+import (
+	"fmt"
+
+	"github.com/skeema/skeema/internal/tengo"
+)
+
+func init() {
+	RegisterRule(Rule{
+		CheckerFunc:     RoutineChecker(unusedRoutineChecker),
+		Name:            "unused-routine",
+		Description:     "Flag stored procs or funcs which are not referenced by any other object in the schema; advisory only, since application references cannot be detected",
+		DefaultSeverity: SeverityIgnore,
+	})
+}
+
+func unusedRoutineChecker(routine *tengo.Routine, _ string, schema *tengo.Schema, opts Options) *Note {
+	unused := opts.unreferencedRoutines
+	if unused == nil {
+		unused = unreferencedRoutines(schema)
+	}
+	if unused[routine] {
+		return &Note{
+			Summary: "Routine not referenced",
+			Message: fmt.Sprintf("%s %s is not referenced by any other object in this schema. If it is also unused by applications, consider removing it.", routine.Type, routine.Name),
+		}
+	}
+	return nil
+}
+
+// unreferencedRoutines returns a set of the routines in schema which are not
+// referenced by any other object in schema, as per
+// Schema.UnreferencedRoutines.
+func unreferencedRoutines(schema *tengo.Schema) map[*tengo.Routine]bool {
+	unused := make(map[*tengo.Routine]bool)
+	for _, r := range schema.UnreferencedRoutines() {
+		unused[r] = true
+	}
+	return unused
+}
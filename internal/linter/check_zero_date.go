@@ -16,8 +16,17 @@ func init() {
 	})
 }
 
-func zeroDateChecker(table *tengo.Table, createStatement string, _ *tengo.Schema, _ Options) []Note {
+func zeroDateChecker(table *tengo.Table, createStatement string, _ *tengo.Schema, opts Options) []Note {
 	results := make([]Note, 0)
+
+	// If the target's sql_mode is known, note which defaults it would reject
+	problems := make(map[*tengo.Column]tengo.SQLModeProblem)
+	if opts.SQLMode != "" {
+		for _, p := range table.SQLModeProblems(opts.SQLMode) {
+			problems[p.Column] = p
+		}
+	}
+
 	for _, col := range table.Columns {
 		if strings.HasPrefix(col.TypeInDB, "timestamp") || strings.HasPrefix(col.TypeInDB, "date") {
 			var summary, subject string
@@ -35,10 +44,16 @@ func zeroDateChecker(table *tengo.Table, createStatement string, _ *tengo.Schema
 				if strings.HasPrefix(col.TypeInDB, "timestamp") {
 					recoNullable = "NULL "
 				}
+				message := fmt.Sprintf("Column %s of table %s has a default value of %s. %s prevent use of strict sql_mode, which provides important safety checks. Consider making the column %sDEFAULT NULL instead.", col.Name, table.Name, col.Default, subject, recoNullable)
+				if p, ok := problems[col]; ok && p.Rejected {
+					message += fmt.Sprintf(" The database server's sql_mode includes %s and strict mode, so it will reject this default value.", p.Mode)
+				} else if ok {
+					message += fmt.Sprintf(" The database server's sql_mode includes %s, so this default value will generate a warning.", p.Mode)
+				}
 				results = append(results, Note{
 					LineOffset: FindColumnLineOffset(col, createStatement),
 					Summary:    summary,
-					Message:    fmt.Sprintf("Column %s of table %s has a default value of %s. %s prevent use of strict sql_mode, which provides important safety checks. Consider making the column %sDEFAULT NULL instead.", col.Name, table.Name, col.Default, subject, recoNullable),
+					Message:    message,
 				})
 			}
 		}
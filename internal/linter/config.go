@@ -42,8 +42,10 @@ type Options struct {
 	RuleSeverity            map[string]Severity
 	RuleConfig              map[string]interface{}
 	Flavor                  tengo.Flavor
+	SQLMode                 string                   // target's sql_mode, if known; used to identify definitions the target would reject
 	StripAnnotationNewlines bool                     // if true, remove newlines inside annotation messages
 	onlyKeys                map[tengo.ObjectKey]bool // if map is non-nil, only format objects with true values
+	unreferencedRoutines    map[*tengo.Routine]bool  // computed once per CheckSchema call if unused-routine is enabled
 }
 
 // AllowList returns a slice of configured allowed values for the given rule.
@@ -63,6 +63,8 @@ func TestOptionsForDir(t *testing.T) {
 		"--allow-engine=''",
 		"--lint-engine=gentle-nudge",
 		"--allow-definer=''",
+		"--lint-pk-clustering=warning --max-pk-width='5 rows'",
+		"--lint-pk-clustering=warning --max-pk-width='many bytes'",
 	}
 	confirmError := func(cliArgs string) {
 		t.Helper()
@@ -77,6 +79,17 @@ func TestOptionsForDir(t *testing.T) {
 		confirmError(badOpt)
 	}
 
+	// Rules with a ConfigFunc only have their config populated if not ignored
+	dir = getDir(t, "testdata/validcfg", "--lint-pk-clustering=warning")
+	if opts, err := OptionsForDir(dir); err != nil {
+		t.Errorf("Unexpected error from OptionsForDir: %s", err)
+	} else {
+		expected := pkClusteringConfig{maxColumns: 4, maxBytes: 128, rawValue: "4 columns, 128 bytes"}
+		if actual := opts.RuleConfig["pk-clustering"].(*pkClusteringConfig); *actual != expected {
+			t.Errorf("pkClusteringConfig %+v did not match expectation %+v", *actual, expected)
+		}
+	}
+
 	// Confirm ConfigError implements Error interface and works as expected
 	var err error
 	err = NewConfigError(dir, "testing ConfigError")
@@ -17,6 +17,9 @@ import (
 func CheckSchema(wsSchema *workspace.Schema, opts Options) *Result {
 	result := &Result{}
 	objects := wsSchema.Objects()
+	if severity, ok := opts.RuleSeverity["unused-routine"]; ok && severity != SeverityIgnore {
+		opts.unreferencedRoutines = unreferencedRoutines(wsSchema.Schema)
+	}
 
 	for key, stmt := range wsSchema.LogicalSchema.Creates {
 		// Attempt to look up the corresponding object. Might not be found if there
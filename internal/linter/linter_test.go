@@ -340,6 +340,152 @@ func (s IntegrationSuite) TestCheckSchemaStripAnnotationNewlines(t *testing.T) {
 	}
 }
 
+func TestZeroDateCheckerSQLMode(t *testing.T) {
+	table := &tengo.Table{Name: "events", Columns: []*tengo.Column{
+		{Name: "zero_date", TypeInDB: "date", Default: "'0000-00-00'"},
+		{Name: "zero_day", TypeInDB: "date", Default: "'2020-01-00'"},
+		{Name: "fine_date", TypeInDB: "date", Default: "'2020-01-01'"},
+	}}
+	createStatement := table.GeneratedCreateStatement(tengo.FlavorMySQL80)
+	cases := []struct {
+		sqlMode  string
+		expected [2]string // expected message suffix for zero_date and zero_day
+	}{
+		{"", [2]string{"DEFAULT NULL instead.", "DEFAULT NULL instead."}},
+		{"NO_ZERO_DATE", [2]string{"includes NO_ZERO_DATE, so this default value will generate a warning.", "DEFAULT NULL instead."}},
+		{"TRADITIONAL", [2]string{"includes NO_ZERO_DATE and strict mode, so it will reject this default value.", "includes NO_ZERO_IN_DATE and strict mode, so it will reject this default value."}},
+	}
+	for _, c := range cases {
+		notes := zeroDateChecker(table, createStatement, nil, Options{SQLMode: c.sqlMode})
+		if len(notes) != 2 {
+			t.Errorf("With sql_mode %q, expected 2 notes, instead found %d", c.sqlMode, len(notes))
+			continue
+		}
+		for n := range notes {
+			if !strings.HasSuffix(notes[n].Message, c.expected[n]) {
+				t.Errorf("With sql_mode %q, unexpected message for notes[%d]: %s", c.sqlMode, n, notes[n].Message)
+			}
+		}
+	}
+}
+
+func TestConstraintNameChecker(t *testing.T) {
+	fk := func(name string) *tengo.ForeignKey {
+		return &tengo.ForeignKey{Name: name, ColumnNames: []string{"customer_id"}, ReferencedTableName: "customers", ReferencedColumnNames: []string{"id"}}
+	}
+	orders := &tengo.Table{Name: "orders", ForeignKeys: []*tengo.ForeignKey{fk("orders_ibfk_1"), fk("customer_fk")}}
+	invoices := &tengo.Table{Name: "invoices", ForeignKeys: []*tengo.ForeignKey{fk("invoices_ibfk_1"), fk("orders_ibfk_2")}, Checks: []*tengo.Check{{Name: "orders_chk_1", Clause: "id > 0"}}}
+	orders.Checks = []*tengo.Check{{Name: "positive", Clause: "id > 0"}}
+	schema := &tengo.Schema{Name: "sales", Tables: []*tengo.Table{orders, invoices}}
+
+	// Auto-generated names are only flagged if they refer to a different table
+	opts := Options{Flavor: tengo.FlavorMySQL80}
+	if notes := constraintNameChecker(orders, orders.GeneratedCreateStatement(opts.Flavor), schema, opts); len(notes) != 0 {
+		t.Errorf("Expected no notes for orders, instead found %+v", notes)
+	}
+	invoiceNotes := constraintNameChecker(invoices, invoices.GeneratedCreateStatement(opts.Flavor), schema, opts)
+	if len(invoiceNotes) != 2 {
+		t.Errorf("Expected 2 notes for invoices, instead found %d: %+v", len(invoiceNotes), invoiceNotes)
+	} else if !strings.Contains(invoiceNotes[0].Message, "automatically generates for table orders") || invoiceNotes[0].LineOffset != 2 {
+		t.Errorf("Unexpected note for auto-generated foreign key name: %+v", invoiceNotes[0])
+	} else if !strings.Contains(invoiceNotes[1].Message, "check constraint named orders_chk_1") {
+		t.Errorf("Unexpected note for auto-generated check name: %+v", invoiceNotes[1])
+	}
+
+	// MariaDB check constraint names are per-table, so only FKs are flagged
+	opts.Flavor = tengo.FlavorMariaDB105
+	if notes := constraintNameChecker(invoices, invoices.GeneratedCreateStatement(opts.Flavor), schema, opts); len(notes) != 1 {
+		t.Errorf("Expected 1 note for invoices in MariaDB, instead found %d: %+v", len(notes), notes)
+	}
+}
+
+// TestFKColumnTypeChecker uses synthetic tables rather than validcfg testdata,
+// since MySQL 8.0 refuses to create foreign keys with incompatible column types
+// even in a workspace with foreign_key_checks disabled.
+func TestFKColumnTypeChecker(t *testing.T) {
+	parent := &tengo.Table{
+		Name: "parent",
+		Columns: []*tengo.Column{
+			{Name: "id", TypeInDB: "int unsigned"},
+			{Name: "code", TypeInDB: "varchar(20)", CharSet: "latin1", Collation: "latin1_swedish_ci"},
+		},
+	}
+	child := &tengo.Table{
+		Name: "child",
+		Columns: []*tengo.Column{
+			{Name: "parent_id", TypeInDB: "int(10) unsigned"},
+			{Name: "parent_code", TypeInDB: "varchar(30)", CharSet: "utf8mb4", Collation: "utf8mb4_general_ci"},
+		},
+		ForeignKeys: []*tengo.ForeignKey{
+			{Name: "id_fk", ColumnNames: []string{"parent_id"}, ReferencedTableName: "parent", ReferencedColumnNames: []string{"id"}},
+			{Name: "code_fk", ColumnNames: []string{"parent_code"}, ReferencedTableName: "parent", ReferencedColumnNames: []string{"code"}},
+			{Name: "other_fk", ColumnNames: []string{"parent_id"}, ReferencedTableName: "missing", ReferencedColumnNames: []string{"id"}},
+		},
+	}
+	schema := &tengo.Schema{Name: "s", Tables: []*tengo.Table{parent, child}}
+	opts := Options{Flavor: tengo.FlavorMySQL80}
+	notes := fkColumnTypeChecker(child, child.GeneratedCreateStatement(opts.Flavor), schema, opts)
+	if len(notes) != 1 || !strings.Contains(notes[0].Message, "code_fk") || !strings.Contains(notes[0].Message, "character set or collation differs") {
+		t.Errorf("Unexpected notes: %+v", notes)
+	}
+}
+
+// TestCheckSchemaConstraintNameCollision confirms that constraint names which
+// actually collide between tables are reported as statement errors, since the
+// second table cannot be created in the workspace.
+func (s IntegrationSuite) TestCheckSchemaConstraintNameCollision(t *testing.T) {
+	dir := getDir(t, "testdata/constraintcollide")
+	forceOnlyRulesWarning(dir.Config, "constraint-name")
+	opts, err := OptionsForDir(dir)
+	if err != nil {
+		t.Fatalf("Unexpected error from OptionsForDir: %v", err)
+	}
+	opts.Flavor = s.d.Flavor()
+
+	wsOpts, err := workspace.OptionsForDir(dir, s.d.Instance)
+	if err != nil {
+		t.Fatalf("Unexpected error from workspace.OptionsForDir: %v", err)
+	}
+	wsSchema, err := workspace.ExecLogicalSchema(dir.LogicalSchemas[0], wsOpts)
+	if err != nil {
+		t.Fatalf("Unexpected error from workspace.ExecLogicalSchema: %v", err)
+	} else if len(wsSchema.Failures) != 1 {
+		t.Fatalf("Expected 1 workspace failure, instead found %d", len(wsSchema.Failures))
+	}
+
+	result := CheckSchema(wsSchema, opts)
+	if len(result.Annotations) != 0 {
+		t.Errorf("Expected no annotations from constraint-name, instead found %d", len(result.Annotations))
+	}
+	result.AnnotateStatementErrors(wsSchema.Failures, opts)
+	if result.ErrorCount != 1 {
+		t.Errorf("Expected 1 error after AnnotateStatementErrors(), instead found %d", result.ErrorCount)
+	}
+}
+
+func TestFlavorSyntaxChecker(t *testing.T) {
+	table := &tengo.Table{Name: "orders"}
+	createStatement := "CREATE TABLE `orders` (\n" +
+		"  `id` int NOT NULL,\n" +
+		"  `ip` inet6 DEFAULT NULL,\n" +
+		"  CONSTRAINT `chk` CHECK ((`id` > 0)) /*!80016 NOT ENFORCED */\n" +
+		") ENGINE=InnoDB"
+	opts := Options{Flavor: tengo.FlavorMySQL80.Dot(16)}
+	notes := flavorSyntaxChecker(table, createStatement, nil, opts)
+	if len(notes) != 1 || notes[0].LineOffset != 2 || !strings.Contains(notes[0].Message, "inet6") {
+		t.Errorf("Unexpected notes for %s: %+v", opts.Flavor, notes)
+	}
+	opts.Flavor = tengo.FlavorMariaDB105
+	notes = flavorSyntaxChecker(table, createStatement, nil, opts)
+	if len(notes) != 1 || notes[0].LineOffset != 3 || !strings.Contains(notes[0].Message, "NOT ENFORCED") {
+		t.Errorf("Unexpected notes for %s: %+v", opts.Flavor, notes)
+	}
+	opts.Flavor = tengo.FlavorUnknown
+	if notes := flavorSyntaxChecker(table, createStatement, nil, opts); len(notes) != 0 {
+		t.Errorf("Expected no notes for unknown flavor, instead found %+v", notes)
+	}
+}
+
 func (s *IntegrationSuite) Setup(backend string) (err error) {
 	s.d, err = s.manager.GetOrCreateInstance(tengo.DockerizedInstanceOptions{
 		Name:              fmt.Sprintf("skeema-test-%s", tengo.ContainerNameForImage(backend)),
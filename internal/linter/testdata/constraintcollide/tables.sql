@@ -0,0 +1,24 @@
+# Tables with colliding foreign key names. Whichever table is created second
+# fails in the workspace, so the collision is reported as a statement error
+# rather than by the constraint-name rule.
+
+CREATE TABLE customers (
+  id int unsigned NOT NULL,
+  PRIMARY KEY (id)
+) ENGINE=InnoDB;
+
+CREATE TABLE orders (
+  id int unsigned NOT NULL,
+  customer_id int unsigned NOT NULL,
+  PRIMARY KEY (id),
+  KEY customer (customer_id),
+  CONSTRAINT customer_fk FOREIGN KEY (customer_id) REFERENCES customers (id)
+) ENGINE=InnoDB;
+
+CREATE TABLE returns (
+  id int unsigned NOT NULL,
+  customer_id int unsigned NOT NULL,
+  PRIMARY KEY (id),
+  KEY customer (customer_id),
+  CONSTRAINT customer_fk FOREIGN KEY (customer_id) REFERENCES customers (id)
+) ENGINE=InnoDB;
@@ -1,5 +1,5 @@
 CREATE TABLE badpk (
- a varchar(100) not null primary key, /* annotations: pk-type */
+ a varchar(100) not null primary key, /* annotations: pk-type, pk-clustering */
  b int not null
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
 
@@ -7,11 +7,11 @@ CREATE TABLE badpk_composite (
  a int not null,
  b varchar(100) not null,  /* annotations: pk-type */
  c int not null,
- primary key (a,b)
+ primary key (a,b) /* annotations: pk-clustering */
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
 
 CREATE TABLE goodpk (
- a varbinary(255) not null primary key,
+ a varbinary(255) not null primary key, /* annotations: pk-clustering */
  b int not null
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
 
@@ -19,6 +19,6 @@ CREATE TABLE goodpk_composite (
  a int not null,
  b varbinary(255) not null, 
  c int not null,
- primary key (a,b)
+ primary key (a,b) /* annotations: pk-clustering */
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
 
@@ -0,0 +1,8 @@
+CREATE TABLE constraintname (
+  id int unsigned NOT NULL,
+  customer_id int unsigned DEFAULT NULL,
+  PRIMARY KEY (id),
+  KEY customer (customer_id),
+  CONSTRAINT constraintname_ibfk_1 FOREIGN KEY (customer_id) REFERENCES customers (id), /* annotations: has-fk */
+  CONSTRAINT hasfks_ibfk_9 FOREIGN KEY (customer_id) REFERENCES customers (id) /* annotations: constraint-name */
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,8 @@
+# This index fits within the 767-byte key part limit of ROW_FORMAT=COMPACT, but
+# would not if the table were converted to utf8mb4
+CREATE TABLE keylength (
+	id int unsigned NOT NULL,
+	name varchar(255) NOT NULL,
+	PRIMARY KEY (id),
+	KEY name (name) /* annotations:key-length */
+) ENGINE=InnoDB ROW_FORMAT=COMPACT DEFAULT CHARSET=utf8 /* annotations:charset */;
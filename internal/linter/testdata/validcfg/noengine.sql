@@ -0,0 +1,5 @@
+CREATE TABLE noengine (
+  id int unsigned NOT NULL,
+  name varchar(30) NOT NULL DEFAULT 'engine=InnoDB',
+  PRIMARY KEY (id)
+) /* annotations: explicit-engine */ DEFAULT CHARSET=utf8mb4 COMMENT='ENGINE=InnoDB';
@@ -0,0 +1,20 @@
+CREATE TABLE widepk (
+ a int unsigned not null,
+ b int unsigned not null,
+ c int unsigned not null,
+ d int unsigned not null,
+ e int unsigned not null,
+ primary key (a,b,c,d,e) /* annotations: pk-clustering */
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE uuidpk (
+ order_uuid varbinary(16) not null,
+ amount int unsigned not null,
+ primary key (order_uuid) /* annotations: pk-clustering */
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE narrowpk (
+ a int unsigned not null,
+ b bigint unsigned not null,
+ primary key (a,b)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,16 @@
+DELIMITER //
+
+# helper is called by caller, but nothing calls caller
+CREATE DEFINER=`root`@`127.0.0.1` FUNCTION `helper`(a int) RETURNS int /* annotations: has-routine */
+    DETERMINISTIC
+BEGIN
+	return a + 1;
+END//
+
+CREATE DEFINER=`root`@`127.0.0.1` FUNCTION `caller`(a int) RETURNS int /* annotations: has-routine, unused-routine */
+    DETERMINISTIC
+BEGIN
+	return helper(a) * 2;
+END//
+
+DELIMITER ;
@@ -3,6 +3,7 @@ package tengo
 import (
 	"fmt"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -21,6 +22,81 @@ type Unsafer interface {
 	Unsafe() bool
 }
 
+// explainClause returns a short lowercase human-readable description of an
+// alter clause, for example "modify column `email`: varchar(255) -> varchar(320)".
+// clauseString should be the non-empty result of clause.Clause(), which is used
+// as-is for clause types lacking a more specific description.
+func explainClause(clause TableAlterClause, clauseString string) string {
+	switch clause := clause.(type) {
+	case AddColumn:
+		return "add column " + EscapeIdentifier(clause.Column.Name)
+	case DropColumn:
+		return "drop column " + EscapeIdentifier(clause.Column.Name)
+	case ModifyColumn:
+		return clause.explain()
+	case AddIndex:
+		if clause.uniquenessChange && clause.Index.Unique {
+			return "re-add index " + EscapeIdentifier(clause.Index.Name) + " as unique"
+		} else if clause.uniquenessChange {
+			return "re-add index " + EscapeIdentifier(clause.Index.Name) + " as non-unique"
+		}
+		return "add index " + EscapeIdentifier(clause.Index.Name)
+	case DropIndex:
+		return "drop index " + EscapeIdentifier(clause.Index.Name)
+	case AddForeignKey:
+		return "add foreign key " + EscapeIdentifier(clause.ForeignKey.Name)
+	case DropForeignKey:
+		return "drop foreign key " + EscapeIdentifier(clause.ForeignKey.Name)
+	case AddCheck:
+		return "add check " + EscapeIdentifier(clause.Check.Name)
+	case DropCheck:
+		return "drop check " + EscapeIdentifier(clause.Check.Name)
+	case ForceRebuild:
+		return "rebuild table"
+	}
+	return clauseString
+}
+
+// RiskLevel classifies the operational impact of executing a DDL operation.
+// Higher values represent more impactful operations.
+type RiskLevel int
+
+// Constants enumerating RiskLevel values
+const (
+	RiskMetadata    RiskLevel = iota // instant or metadata-only change
+	RiskRebuild                      // may require rebuilding or reorganizing the table or its indexes
+	RiskDestructive                  // may destroy data
+)
+
+func (rl RiskLevel) String() string {
+	switch rl {
+	case RiskMetadata:
+		return "metadata"
+	case RiskRebuild:
+		return "rebuild"
+	default:
+		return "destructive"
+	}
+}
+
+// Risker interface represents a type of clause that can classify its own
+// RiskLevel. Clauses that do not satisfy this interface are conservatively
+// treated as RiskRebuild, unless they are unsafe.
+type Risker interface {
+	Risk() RiskLevel
+}
+
+// ClauseRisk returns the RiskLevel of the supplied clause. Unsafe clauses are
+// always considered RiskDestructive.
+func ClauseRisk(clause TableAlterClause) RiskLevel {
+	if unsafer, ok := clause.(Unsafer); ok && unsafer.Unsafe() {
+		return RiskDestructive
+	} else if risker, ok := clause.(Risker); ok {
+		return risker.Risk()
+	}
+	return RiskRebuild
+}
+
 ///// AddColumn ////////////////////////////////////////////////////////////////
 
 // AddColumn represents a new column that is present on the right-side ("to")
@@ -75,8 +151,9 @@ func (dc DropColumn) Unsafe() bool {
 // schema version of the table, but was not identically present on the left-
 // side ("from") version. It satisfies the TableAlterClause interface.
 type AddIndex struct {
-	Index       *Index
-	reorderOnly bool // true if index is being dropped and re-added just to re-order
+	Index            *Index
+	reorderOnly      bool // true if index is being dropped and re-added just to re-order
+	uniquenessChange bool // true if index is being dropped and re-added only to change whether it is UNIQUE
 }
 
 // Clause returns an ADD KEY clause of an ALTER TABLE statement.
@@ -87,6 +164,32 @@ func (ai AddIndex) Clause(mods StatementModifiers) string {
 	return fmt.Sprintf("ADD %s", ai.Index.Definition(mods.Flavor))
 }
 
+// UniqueWarning returns a non-empty warning message if the clause adds a
+// unique index (or primary key) to table from, and from does not already have
+// a unique index guaranteeing the new index's columns have distinct values.
+// This will cause the ALTER TABLE to fail if the table contains any rows with
+// duplicate values in the index's columns. Re-adding an index just to reorder
+// it, or demoting a unique index to non-unique, never generates a warning.
+func (ai AddIndex) UniqueWarning(from *Table) string {
+	if !ai.Index.Unique || ai.reorderOnly {
+		return ""
+	}
+	if from.PrimaryKey != nil && ai.Index.uniqueCoveredBy(from.PrimaryKey) {
+		return ""
+	}
+	for _, idx := range from.SecondaryIndexes {
+		if ai.Index.uniqueCoveredBy(idx) {
+			return ""
+		}
+	}
+	if ai.uniquenessChange {
+		return fmt.Sprintf("Changing index %s to UNIQUE will fail if the table contains any duplicate values in the indexed columns.", EscapeIdentifier(ai.Index.Name))
+	} else if ai.Index.PrimaryKey {
+		return "Adding a PRIMARY KEY will fail if the table contains any duplicate values in the indexed columns."
+	}
+	return fmt.Sprintf("Adding UNIQUE index %s will fail if the table contains any duplicate values in the indexed columns.", EscapeIdentifier(ai.Index.Name))
+}
+
 ///// DropIndex ////////////////////////////////////////////////////////////////
 
 // DropIndex represents an index that was present on the left-side ("from")
@@ -142,6 +245,12 @@ func (ai AlterIndex) Clause(mods StatementModifiers) string {
 	return "" // Flavor without invisible/ignored index support
 }
 
+// Risk returns RiskMetadata, since changing index visibility does not require
+// rebuilding the index.
+func (ai AlterIndex) Risk() RiskLevel {
+	return RiskMetadata
+}
+
 ///// AddForeignKey ////////////////////////////////////////////////////////////
 
 // AddForeignKey represents a new foreign key that is present on the right-side
@@ -300,10 +409,105 @@ func (mc ModifyColumn) Clause(mods StatementModifiers) string {
 	if !mods.StrictColumnDefinition && positionClause == "" && mc.OldColumn.Equivalent(mc.NewColumn) {
 		return ""
 	}
+	// Also emit a no-op if the target server has explicit_defaults_for_timestamp
+	// disabled, and the only difference is an implicit TIMESTAMP default which
+	// the server would re-add anyway upon running the MODIFY COLUMN.
+	if mods.ImplicitTSDefaults && positionClause == "" && mc.onlyImplicitTimestampDefaults() {
+		return ""
+	}
 
 	return fmt.Sprintf("MODIFY COLUMN %s%s", mc.NewColumn.Definition(mods.Flavor, mc.Table), positionClause)
 }
 
+var reZeroTimestamp = regexp.MustCompile(`^'0000-00-00 00:00:00(?:\.0+)?'$`)
+
+// onlyImplicitTimestampDefaults returns true if the old and new columns are
+// both NOT NULL timestamps, the new column lacks any DEFAULT and ON UPDATE
+// clauses, and the old column only differs by having the DEFAULT and ON UPDATE
+// that a server with explicit_defaults_for_timestamp=OFF implicitly applies:
+// CURRENT_TIMESTAMP for both, for the table's first timestamp column; or a
+// zero-date default for any other timestamp column. In this situation, running
+// a MODIFY COLUMN would have no effect.
+func (mc ModifyColumn) onlyImplicitTimestampDefaults() bool {
+	oldCol, newCol := mc.OldColumn, mc.NewColumn
+	if !strings.HasPrefix(strings.ToLower(newCol.TypeInDB), "timestamp") || !oldCol.sameTemporalType(newCol) {
+		return false
+	} else if oldCol.Nullable || newCol.Nullable || newCol.Default != "" || newCol.OnUpdate != "" {
+		return false
+	}
+	var firstTimestamp bool
+	if mc.Table != nil {
+		for _, col := range mc.Table.Columns {
+			if strings.HasPrefix(strings.ToLower(col.TypeInDB), "timestamp") {
+				firstTimestamp = (col.Name == newCol.Name)
+				break
+			}
+		}
+	}
+	oldCopy := *oldCol
+	if firstTimestamp && strings.HasPrefix(strings.ToUpper(oldCol.Default), "CURRENT_TIMESTAMP") && strings.HasPrefix(oldCol.normalizedOnUpdate(), "CURRENT_TIMESTAMP") {
+		oldCopy.Default, oldCopy.OnUpdate = "", ""
+	} else if !firstTimestamp && reZeroTimestamp.MatchString(oldCol.Default) && oldCol.OnUpdate == "" {
+		oldCopy.Default = ""
+	} else {
+		return false
+	}
+	return oldCopy.Equivalent(newCol)
+}
+
+// explain returns a description of the column modification, listing each
+// changed attribute of the column with its old and new values.
+func (mc ModifyColumn) explain() string {
+	oldCol, newCol := mc.OldColumn, mc.NewColumn
+	var changes []string
+	describe := func(attribute, oldVal, newVal string) {
+		if oldVal == "" {
+			oldVal = "(none)"
+		}
+		if newVal == "" {
+			newVal = "(none)"
+		}
+		changes = append(changes, fmt.Sprintf("%s%s -> %s", attribute, oldVal, newVal))
+	}
+	if oldCol.TypeInDB != newCol.TypeInDB {
+		describe("", oldCol.TypeInDB, newCol.TypeInDB)
+	}
+	if oldCol.Nullable != newCol.Nullable {
+		nullability := map[bool]string{true: "NULL", false: "NOT NULL"}
+		describe("", nullability[oldCol.Nullable], nullability[newCol.Nullable])
+	}
+	if oldCol.CharSet != newCol.CharSet {
+		describe("charset ", oldCol.CharSet, newCol.CharSet)
+	} else if oldCol.Collation != newCol.Collation {
+		describe("collation ", oldCol.Collation, newCol.Collation)
+	}
+	if oldCol.Default != newCol.Default {
+		describe("default ", oldCol.Default, newCol.Default)
+	}
+	if oldCol.OnUpdate != newCol.OnUpdate {
+		describe("on update ", oldCol.OnUpdate, newCol.OnUpdate)
+	}
+	if oldCol.AutoIncrement != newCol.AutoIncrement {
+		describe("auto_increment ", fmt.Sprint(oldCol.AutoIncrement), fmt.Sprint(newCol.AutoIncrement))
+	}
+	if oldCol.GenerationExpr != newCol.GenerationExpr {
+		describe("generation expression ", oldCol.GenerationExpr, newCol.GenerationExpr)
+	}
+	if oldCol.Invisible != newCol.Invisible {
+		describe("invisible ", fmt.Sprint(oldCol.Invisible), fmt.Sprint(newCol.Invisible))
+	}
+	if oldCol.Comment != newCol.Comment {
+		changes = append(changes, "comment changed")
+	}
+	if mc.PositionFirst || mc.PositionAfter != nil {
+		changes = append(changes, "reposition")
+	}
+	if len(changes) == 0 {
+		changes = append(changes, "cosmetic change")
+	}
+	return fmt.Sprintf("modify column %s: %s", EscapeIdentifier(newCol.Name), strings.Join(changes, ", "))
+}
+
 // Unsafe returns true if this clause is potentially destructive of data.
 // ModifyColumn's safety depends on the nature of the column change; for example,
 // increasing the size of a varchar is safe, but decreasing the size or (in most
@@ -315,7 +519,17 @@ func (mc ModifyColumn) Unsafe() bool {
 	if mc.OldColumn.Virtual {
 		return false
 	}
-	if mc.OldColumn.CharSet != mc.NewColumn.CharSet {
+
+	// Conversions to or from MySQL's native JSON type: converting to JSON is
+	// unsafe, since the server normalizes each value, and the ALTER fails if any
+	// existing value is not valid JSON. Converting from JSON to longtext is safe
+	// as long as the new character set is utf8mb4, which JSON values use for
+	// their textual representation; any other conversion from JSON is unsafe.
+	if oldJSON, newJSON := mc.OldColumn.hasJSONType(), mc.NewColumn.hasJSONType(); oldJSON != newJSON {
+		return newJSON || !strings.EqualFold(mc.NewColumn.TypeInDB, "longtext") || mc.NewColumn.CharSet != "utf8mb4"
+	}
+
+	if mc.OldColumn.effectiveCharSet() != mc.NewColumn.effectiveCharSet() {
 		return true
 	}
 	if strings.EqualFold(mc.OldColumn.TypeInDB, mc.NewColumn.TypeInDB) {
@@ -325,9 +539,11 @@ func (mc ModifyColumn) Unsafe() bool {
 	oldType := strings.ToLower(mc.OldColumn.TypeInDB)
 	newType := strings.ToLower(mc.NewColumn.TypeInDB)
 
-	// signed -> unsigned is always unsafe
-	// (The opposite is checked later specifically for the integer types)
-	if !strings.Contains(oldType, "unsigned") && strings.Contains(newType, "unsigned") {
+	// signed -> unsigned is always unsafe, since negative values cannot be
+	// represented. (The opposite is checked later specifically for the integer
+	// types.)
+	oldUnsigned, newUnsigned := mc.OldColumn.Unsigned(), mc.NewColumn.Unsigned()
+	if !oldUnsigned && newUnsigned {
 		return true
 	}
 
@@ -345,19 +561,16 @@ func (mc ModifyColumn) Unsafe() bool {
 		return !strings.HasPrefix(newType, oldType[0:len(oldType)-1])
 	}
 
-	// decimal(a,b) -> decimal(x,y) unsafe if x < a or y < b
-	if bothSamePrefix("decimal") {
-		re := regexp.MustCompile(`^decimal\((\d+),(\d+)\)`)
-		oldMatches := re.FindStringSubmatch(oldType)
-		newMatches := re.FindStringSubmatch(newType)
-		if oldMatches == nil || newMatches == nil {
+	// decimal(a,b) -> decimal(x,y) unsafe if y < b (fewer digits after the
+	// decimal point) or x-y < a-b (fewer digits before the decimal point). This
+	// means increasing the scale without also increasing the precision is unsafe.
+	if bothSamePrefix("decimal", "numeric") {
+		oldPrecision, oldScale, oldOK := decimalPrecisionScale(oldType)
+		newPrecision, newScale, newOK := decimalPrecisionScale(newType)
+		if !oldOK || !newOK {
 			return true
 		}
-		oldPrecision, _ := strconv.Atoi(oldMatches[1])
-		oldScale, _ := strconv.Atoi(oldMatches[2])
-		newPrecision, _ := strconv.Atoi(newMatches[1])
-		newScale, _ := strconv.Atoi(newMatches[2])
-		return (newPrecision < oldPrecision || newScale < oldScale)
+		return newScale < oldScale || newPrecision-newScale < oldPrecision-oldScale
 	}
 
 	// bit(x) -> bit(y) unsafe if y < x
@@ -375,21 +588,17 @@ func (mc ModifyColumn) Unsafe() bool {
 
 	// time, timestamp, datetime: unsafe if decreasing or removing fractional second precision
 	// but always safe if adding fsp when none was there before
-	if bothSamePrefix("time", "timestamp", "datetime") {
-		if !strings.ContainsRune(oldType, '(') {
-			return false
-		} else if !strings.ContainsRune(newType, '(') {
-			return true
-		}
-		re := regexp.MustCompile(`^[^(]+\((\d+)\)`)
-		oldMatches := re.FindStringSubmatch(oldType)
-		newMatches := re.FindStringSubmatch(newType)
-		if oldMatches == nil || newMatches == nil {
+	if mc.OldColumn.sameTemporalType(mc.NewColumn) {
+		return false
+	} else if bothSamePrefix("time", "timestamp", "datetime") {
+		oldFSP, oldOK := mc.OldColumn.FractionalSecondsPrecision()
+		newFSP, newOK := mc.NewColumn.FractionalSecondsPrecision()
+		oldBase, _, _ := strings.Cut(oldType, "(")
+		newBase, _, _ := strings.Cut(newType, "(")
+		if !oldOK || !newOK || oldBase != newBase {
 			return true
 		}
-		oldSize, _ := strconv.Atoi(oldMatches[1])
-		newSize, _ := strconv.Atoi(newMatches[1])
-		return newSize < oldSize
+		return newFSP < oldFSP
 	}
 
 	// float or double:
@@ -430,7 +639,7 @@ func (mc ModifyColumn) Unsafe() bool {
 		}
 	}
 	if oldRank > 0 && newRank > 0 {
-		if strings.Contains(oldType, "unsigned") && !strings.Contains(newType, "unsigned") {
+		if oldUnsigned && !newUnsigned {
 			return oldRank >= newRank
 		}
 		return oldRank > newRank
@@ -520,6 +729,113 @@ func (mc ModifyColumn) Unsafe() bool {
 	return true
 }
 
+// decimalPrecisionScale returns the precision (total number of digits) and
+// scale (number of digits after the decimal point) of a lowercased decimal or
+// numeric column type. Omitted values use the server defaults of precision 10
+// and scale 0. The final return value is false if typ could not be parsed.
+func decimalPrecisionScale(typ string) (precision, scale int, ok bool) {
+	re := regexp.MustCompile(`^(?:decimal|numeric)(?:\((\d+)(?:,\s*(\d+))?\))?(?:\s|$)`)
+	matches := re.FindStringSubmatch(typ)
+	if matches == nil {
+		return 0, 0, false
+	}
+	precision = 10
+	if matches[1] != "" {
+		precision, _ = strconv.Atoi(matches[1])
+	}
+	if matches[2] != "" {
+		scale, _ = strconv.Atoi(matches[2])
+	}
+	return precision, scale, true
+}
+
+// AutoIncrementWarning returns a non-empty warning message if the clause adds
+// AUTO_INCREMENT to an existing column. The server requires an auto-increment
+// column to be indexed: for InnoDB tables, it must be the first column of at
+// least one index in mc.Table; for other storage engines, it must be part of
+// at least one index. If this requirement is not met, the ALTER TABLE will
+// fail. Otherwise, the warning notes that any existing NULL or 0 values in the
+// column will be replaced with generated sequence values, unless sql_mode
+// includes NO_AUTO_VALUE_ON_ZERO. Removing AUTO_INCREMENT from a column never
+// generates a warning.
+func (mc ModifyColumn) AutoIncrementWarning() string {
+	if mc.OldColumn.AutoIncrement || !mc.NewColumn.AutoIncrement {
+		return ""
+	}
+	var indexed bool
+	if mc.Table != nil {
+		indexes := mc.Table.SecondaryIndexes
+		if mc.Table.PrimaryKey != nil {
+			indexes = append([]*Index{mc.Table.PrimaryKey}, indexes...)
+		}
+		for _, idx := range indexes {
+			for n, part := range idx.Parts {
+				if part.ColumnName == mc.NewColumn.Name && (n == 0 || mc.Table.Engine != "InnoDB") {
+					indexed = true
+				}
+			}
+		}
+	}
+	if !indexed {
+		requirement := "part of any index"
+		if mc.Table == nil || mc.Table.Engine == "InnoDB" {
+			requirement = "the first column of any index"
+		}
+		return fmt.Sprintf("Adding AUTO_INCREMENT to column %s will fail, since it is not %s", EscapeIdentifier(mc.NewColumn.Name), requirement)
+	}
+	return fmt.Sprintf("Adding AUTO_INCREMENT to column %s will replace any existing NULL or 0 values with generated values, unless sql_mode includes NO_AUTO_VALUE_ON_ZERO", EscapeIdentifier(mc.NewColumn.Name))
+}
+
+// JSONConversionWarning returns a non-empty warning message if the clause
+// converts an existing column to or from MySQL's native JSON type. Converting
+// to JSON will fail if any existing value is not valid JSON, and otherwise
+// normalizes each value's formatting. Converting from JSON to a type other
+// than longtext may truncate values or fail, depending on sql_mode.
+func (mc ModifyColumn) JSONConversionWarning() string {
+	oldJSON, newJSON := mc.OldColumn.hasJSONType(), mc.NewColumn.hasJSONType()
+	if oldJSON == newJSON {
+		return ""
+	} else if newJSON {
+		return fmt.Sprintf("Converting column %s to JSON will fail if any existing value is not valid JSON, and otherwise will normalize the formatting of all values", EscapeIdentifier(mc.NewColumn.Name))
+	} else if !strings.EqualFold(mc.NewColumn.TypeInDB, "longtext") {
+		return fmt.Sprintf("Converting column %s from JSON to %s may truncate values or fail if any existing value is too long", EscapeIdentifier(mc.NewColumn.Name), mc.NewColumn.TypeInDB)
+	}
+	return ""
+}
+
+// Steps returns a sequence of one or more ModifyColumn values which, if
+// executed in order in separate ALTER TABLE statements, transform
+// mc.OldColumn into mc.NewColumn. Changing a column's data type and character
+// set at the same time can be risky, since failures or data loss are difficult
+// to attribute to one change or the other. In this situation, Steps returns two
+// clauses: the first changes only the type (retaining the old character set
+// and collation), and the second changes only the character set and collation.
+// Any column repositioning is deferred to the last step. In all other cases,
+// the result just consists of mc itself.
+func (mc ModifyColumn) Steps() []ModifyColumn {
+	oldCol, newCol := mc.OldColumn, mc.NewColumn
+	oldCharSet, newCharSet := oldCol.effectiveCharSet(), newCol.effectiveCharSet()
+	if oldCol.Virtual || oldCharSet == "" || newCharSet == "" || oldCharSet == newCharSet || strings.EqualFold(oldCol.TypeInDB, newCol.TypeInDB) {
+		return []ModifyColumn{mc}
+	}
+	intermediate := *oldCol
+	intermediate.TypeInDB = newCol.TypeInDB
+	return []ModifyColumn{
+		{
+			Table:     mc.Table,
+			OldColumn: oldCol,
+			NewColumn: &intermediate,
+		},
+		{
+			Table:         mc.Table,
+			OldColumn:     &intermediate,
+			NewColumn:     newCol,
+			PositionFirst: mc.PositionFirst,
+			PositionAfter: mc.PositionAfter,
+		},
+	}
+}
+
 ///// ChangeAutoIncrement //////////////////////////////////////////////////////
 
 // ChangeAutoIncrement represents a difference in next-auto-increment value
@@ -541,6 +857,12 @@ func (cai ChangeAutoIncrement) Clause(mods StatementModifiers) string {
 	return fmt.Sprintf("AUTO_INCREMENT = %d", cai.NewNextAutoIncrement)
 }
 
+// Risk returns RiskMetadata, since changing the next auto-increment value does
+// not require rebuilding the table.
+func (cai ChangeAutoIncrement) Risk() RiskLevel {
+	return RiskMetadata
+}
+
 ///// ChangeCharSet ////////////////////////////////////////////////////////////
 
 // ChangeCharSet represents a difference in default character set and/or
@@ -573,19 +895,72 @@ func (ccs ChangeCharSet) Clause(_ StatementModifiers) string {
 	return fmt.Sprintf("DEFAULT CHARACTER SET = %s COLLATE = %s", ccs.ToCharSet, ccs.ToCollation)
 }
 
+///// ConvertCharSet ///////////////////////////////////////////////////////////
+
+// ConvertCharSet represents a change to the default character set and/or
+// collation of a table, which also changes every textual column to use the new
+// default. This is expressed as a single CONVERT TO clause, instead of a
+// ChangeCharSet clause along with a ModifyColumn clause per column. It
+// satisfies the TableAlterClause interface.
+type ConvertCharSet struct {
+	FromCharSet   string
+	FromCollation string
+	ToCharSet     string
+	ToCollation   string
+	Columns       []ModifyColumn // column changes performed implicitly by the conversion
+}
+
+// Clause returns a CONVERT TO CHARACTER SET clause of an ALTER TABLE statement.
+func (cvt ConvertCharSet) Clause(_ StatementModifiers) string {
+	return fmt.Sprintf("CONVERT TO CHARACTER SET %s COLLATE %s", cvt.ToCharSet, cvt.ToCollation)
+}
+
+// Unsafe returns true if any converted column's character set is changing. As
+// with ModifyColumn, this is considered potentially destructive of data, since
+// some characters may not be representable in the new character set. Changing
+// only the collation is safe.
+func (cvt ConvertCharSet) Unsafe() bool {
+	for _, mc := range cvt.Columns {
+		if mc.Unsafe() {
+			return true
+		}
+	}
+	return false
+}
+
+// Risk returns RiskRebuild, since converting columns requires rewriting all
+// rows of the table.
+func (cvt ConvertCharSet) Risk() RiskLevel {
+	return RiskRebuild
+}
+
 ///// ChangeCreateOptions //////////////////////////////////////////////////////
 
 // ChangeCreateOptions represents a difference in the create options
-// (row_format, stats_persistent, stats_auto_recalc, etc) between two versions
-// of a table. It satisfies the TableAlterClause interface.
+// (row_format, stats_persistent, stats_auto_recalc, pack_keys, etc) between two
+// versions of a table. It satisfies the TableAlterClause interface.
 type ChangeCreateOptions struct {
 	OldCreateOptions string
 	NewCreateOptions string
 }
 
 // Clause returns a clause of an ALTER TABLE statement that sets one or more
-// create options.
+// create options. Options are ordered by name, so that the output is
+// deterministic.
 func (cco ChangeCreateOptions) Clause(_ StatementModifiers) string {
+	changes := cco.changedOptions()
+	subclauses := make([]string, 0, len(changes))
+	for k, v := range changes {
+		subclauses = append(subclauses, fmt.Sprintf("%s=%s", k, v))
+	}
+	sort.Strings(subclauses)
+	return strings.Join(subclauses, " ")
+}
+
+// changedOptions returns a map of create option names to new values, for each
+// option which differs between the old and new create options. Options that
+// are no longer present are mapped to a value which resets them to default.
+func (cco ChangeCreateOptions) changedOptions() map[string]string {
 	// Map of known defaults that make options no longer show up in create_options
 	// or SHOW CREATE TABLE.
 	knownDefaults := map[string]string{
@@ -603,41 +978,120 @@ func (cco ChangeCreateOptions) Clause(_ StatementModifiers) string {
 		"COMPRESSION":        "''", // Undocumented way of removing clause entirely (vs "None" which sticks around)
 	}
 
-	splitOpts := func(full string) map[string]string {
-		result := make(map[string]string)
-		for _, kv := range strings.Split(full, " ") {
-			tokens := strings.Split(kv, "=")
-			if len(tokens) == 2 {
-				result[tokens[0]] = tokens[1]
-			}
-		}
-		return result
-	}
-
-	oldOpts := splitOpts(cco.OldCreateOptions)
-	newOpts := splitOpts(cco.NewCreateOptions)
-	subclauses := make([]string, 0, len(knownDefaults))
+	oldOpts := splitCreateOptions(cco.OldCreateOptions)
+	newOpts := splitCreateOptions(cco.NewCreateOptions)
+	changes := make(map[string]string)
 
 	// Determine which oldOpts changed in newOpts or are no longer present
 	for k, v := range oldOpts {
 		if newValue, ok := newOpts[k]; ok && newValue != v {
-			subclauses = append(subclauses, fmt.Sprintf("%s=%s", k, newValue))
+			changes[k] = newValue
 		} else if !ok {
 			def, known := knownDefaults[k]
 			if !known {
 				def = "DEFAULT"
 			}
-			subclauses = append(subclauses, fmt.Sprintf("%s=%s", k, def))
+			changes[k] = def
 		}
 	}
 
 	// Determine which newOpts were not in oldOpts
 	for k, v := range newOpts {
 		if _, ok := oldOpts[k]; !ok {
-			subclauses = append(subclauses, fmt.Sprintf("%s=%s", k, v))
+			changes[k] = v
+		}
+	}
+	return changes
+}
+
+// splitCreateOptions converts a space-separated string of create options into
+// a map of option names to values.
+func splitCreateOptions(full string) map[string]string {
+	result := make(map[string]string)
+	for _, kv := range strings.Split(full, " ") {
+		tokens := strings.Split(kv, "=")
+		if len(tokens) == 2 {
+			result[tokens[0]] = tokens[1]
+		}
+	}
+	return result
+}
+
+// removeCreateOption returns a space-separated string of create options with
+// any option of the supplied name removed.
+func removeCreateOption(full, name string) string {
+	kvs := strings.Fields(full)
+	result := kvs[:0]
+	for _, kv := range kvs {
+		if k, _, _ := strings.Cut(kv, "="); k != name {
+			result = append(result, kv)
+		}
+	}
+	return strings.Join(result, " ")
+}
+
+// Risk returns RiskMetadata if only statistics-related options are changing.
+// Any other option change, including a change to the page compression
+// algorithm, is considered RiskRebuild: either the table is rebuilt by the
+// ALTER, or it must be rebuilt afterwards for the change to actually affect
+// existing data.
+func (cco ChangeCreateOptions) Risk() RiskLevel {
+	for k := range cco.changedOptions() {
+		if !strings.HasPrefix(k, "STATS_") {
+			return RiskRebuild
 		}
 	}
+	return RiskMetadata
+}
+
+// CompressionWarning returns a non-empty warning message if the clause changes
+// the table's transparent page compression algorithm, and the supplied InnoDB
+// page size (in bytes) may prevent compression from being effective. Page
+// compression relies on hole punching at the filesystem block level, which
+// cannot save any space if the page size is 4KB or smaller, since this is the
+// typical filesystem block size. If pageSize is 0, it is assumed to be the
+// default of 16KB.
+func (cco ChangeCreateOptions) CompressionWarning(pageSize int) string {
+	newValue, changed := cco.changedOptions()["COMPRESSION"]
+	if !changed {
+		return ""
+	}
+	newValue = strings.ToLower(strings.Trim(newValue, "'"))
+	if newValue == "" || newValue == "none" {
+		return "Removing page compression does not decompress existing data until the table is rebuilt, for example by OPTIMIZE TABLE"
+	}
+	if pageSize == 0 {
+		pageSize = 16384
+	}
+	if pageSize <= 4096 {
+		return fmt.Sprintf("Page compression is ineffective with innodb_page_size=%d, since compressed pages cannot be smaller than the filesystem block size", pageSize)
+	}
+	return "Changing page compression does not compress existing data until the table is rebuilt, for example by OPTIMIZE TABLE"
+}
+
+///// ChangeEngineAttributes ///////////////////////////////////////////////////
+
+// ChangeEngineAttributes represents a difference in the table-level
+// ENGINE_ATTRIBUTE and/or SECONDARY_ENGINE_ATTRIBUTE between two versions of a
+// table. It satisfies the TableAlterClause interface.
+type ChangeEngineAttributes struct {
+	OldEngineAttribute          string
+	NewEngineAttribute          string
+	OldSecondaryEngineAttribute string
+	NewSecondaryEngineAttribute string
+}
 
+// Clause returns a clause of an ALTER TABLE statement that changes a table's
+// engine attributes. Attributes are compared as JSON, so an empty string is
+// returned if the only differences are in whitespace or object key order.
+func (cea ChangeEngineAttributes) Clause(_ StatementModifiers) string {
+	var subclauses []string
+	if !jsonEquivalent(cea.OldEngineAttribute, cea.NewEngineAttribute) {
+		subclauses = append(subclauses, fmt.Sprintf("/*!80021 ENGINE_ATTRIBUTE='%s' */", EscapeValueForCreateTable(cea.NewEngineAttribute)))
+	}
+	if !jsonEquivalent(cea.OldSecondaryEngineAttribute, cea.NewSecondaryEngineAttribute) {
+		subclauses = append(subclauses, fmt.Sprintf("/*!80021 SECONDARY_ENGINE_ATTRIBUTE='%s' */", EscapeValueForCreateTable(cea.NewSecondaryEngineAttribute)))
+	}
 	return strings.Join(subclauses, " ")
 }
 
@@ -655,6 +1109,12 @@ func (cc ChangeComment) Clause(_ StatementModifiers) string {
 	return fmt.Sprintf("COMMENT '%s'", EscapeValueForCreateTable(cc.NewComment))
 }
 
+// Risk returns RiskMetadata, since changing a table comment does not require
+// rebuilding the table.
+func (cc ChangeComment) Risk() RiskLevel {
+	return RiskMetadata
+}
+
 ///// ChangeTablespace /////////////////////////////////////////////////////////
 
 // ChangeTablespace represents a difference in the table's TABLESPACE clause
@@ -700,6 +1160,78 @@ func (cse ChangeStorageEngine) Unsafe() bool {
 	return true
 }
 
+///// ChangeMergeUnion ///////////////////////////////////////////////////////
+
+// ChangeMergeUnion represents a difference in the ordered list of underlying
+// tables of a MERGE table. It satisfies the TableAlterClause interface.
+type ChangeMergeUnion struct {
+	OldTables []string
+	NewTables []string
+}
+
+// Clause returns a clause of an ALTER TABLE statement that changes the
+// underlying tables of a MERGE table. The full list is always supplied, since
+// both membership and order of the underlying tables are significant: for
+// example, INSERT_METHOD=FIRST and INSERT_METHOD=LAST depend on the order.
+// An empty string is returned if NewTables is empty, since Table.Diff treats
+// removal of all underlying tables as an unsupported diff.
+func (cmu ChangeMergeUnion) Clause(_ StatementModifiers) string {
+	if len(cmu.NewTables) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("UNION=(%s)", mergeUnionList(cmu.NewTables))
+}
+
+// Risk returns RiskMetadata, since a MERGE table's UNION list only affects its
+// own metadata, without touching the data of any underlying table.
+func (cmu ChangeMergeUnion) Risk() RiskLevel {
+	return RiskMetadata
+}
+
+///// ChangeMergeInsertMethod ////////////////////////////////////////////////
+
+// ChangeMergeInsertMethod represents a difference in the INSERT_METHOD of a
+// MERGE table. It satisfies the TableAlterClause interface.
+type ChangeMergeInsertMethod struct {
+	OldInsertMethod string
+	NewInsertMethod string
+}
+
+// Clause returns a clause of an ALTER TABLE statement that changes the
+// INSERT_METHOD of a MERGE table. An empty NewInsertMethod is expressed as NO,
+// which disallows inserts.
+func (cmim ChangeMergeInsertMethod) Clause(_ StatementModifiers) string {
+	method := strings.ToUpper(cmim.NewInsertMethod)
+	if method == "" {
+		method = "NO"
+	}
+	return "INSERT_METHOD=" + method
+}
+
+// Risk returns RiskMetadata, since a MERGE table's INSERT_METHOD only affects
+// its own metadata, without touching the data of any underlying table.
+func (cmim ChangeMergeInsertMethod) Risk() RiskLevel {
+	return RiskMetadata
+}
+
+///// ForceRebuild /////////////////////////////////////////////////////////////
+
+// ForceRebuild represents a request to rebuild a table without changing its
+// definition, for example to reclaim space. It is never generated by
+// Table.Diff; see NewRebuildTable. It satisfies the TableAlterClause interface.
+type ForceRebuild struct{}
+
+// Clause returns a FORCE clause of an ALTER TABLE statement.
+func (fr ForceRebuild) Clause(_ StatementModifiers) string {
+	return "FORCE"
+}
+
+// Risk returns RiskRebuild, since the entire purpose of this clause is to
+// rebuild the table.
+func (fr ForceRebuild) Risk() RiskLevel {
+	return RiskRebuild
+}
+
 ///// PartitionBy //////////////////////////////////////////////////////////////
 
 // PartitionBy represents initially partitioning a previously-unpartitioned
@@ -757,7 +1289,10 @@ func (mp ModifyPartitions) Clause(mods StatementModifiers) string {
 	if !mp.ForDropTable || len(mp.Drop) == 0 {
 		return ""
 	}
-	if mp.ForDropTable && mods.SkipPreDropAlters {
+	// Pre-drop alters are skipped upon request, or if tables are being renamed
+	// instead of dropped. (Previously-renamed tables dropped after
+	// DropTableRenameRetain also skip these, since they're not in use anyway.)
+	if mp.ForDropTable && (mods.SkipPreDropAlters || mods.DropTableRenamePrefix != "") {
 		return ""
 	}
 	var names []string
@@ -771,3 +1306,28 @@ func (mp ModifyPartitions) Clause(mods StatementModifiers) string {
 func (mp ModifyPartitions) Unsafe() bool {
 	return len(mp.Drop) > 0
 }
+
+///// ChangePartitionCount /////////////////////////////////////////////////////
+
+// ChangePartitionCount represents a change to the number of partitions for a
+// table using HASH, LINEAR HASH, KEY, or LINEAR KEY partitioning. It satisfies
+// the TableAlterClause interface.
+type ChangePartitionCount struct {
+	OldCount int
+	NewCount int
+}
+
+// Clause returns a clause of an ALTER TABLE statement that adds or coalesces
+// partitions. If mods.Partitioning is PartitioningKeep or PartitioningRemove,
+// an empty string is returned, consistent with the handling of re-partitioning
+// by PartitionBy.
+func (cpc ChangePartitionCount) Clause(mods StatementModifiers) string {
+	if mods.Partitioning == PartitioningKeep || mods.Partitioning == PartitioningRemove {
+		return ""
+	} else if cpc.NewCount > cpc.OldCount {
+		return fmt.Sprintf("ADD PARTITION PARTITIONS %d", cpc.NewCount-cpc.OldCount)
+	} else if cpc.NewCount < cpc.OldCount {
+		return fmt.Sprintf("COALESCE PARTITION %d", cpc.OldCount-cpc.NewCount)
+	}
+	return ""
+}
@@ -2,6 +2,7 @@ package tengo
 
 import (
 	"fmt"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -19,6 +20,9 @@ func TestModifyColumnUnsafe(t *testing.T) {
 
 	expectUnsafe := [][]string{
 		{"int unsigned", "int"},
+		{"tinyint", "tinyint unsigned"},
+		{"int(10) unsigned zerofill", "int(10) zerofill"},
+		{"bigint unsigned", "bigint"},
 		{"bigint(11)", "bigint(11) unsigned"},
 		{"int(11)", "bigint(20) unsigned"},
 		{"enum('a', 'b', 'c')", "enum('a', 'aa', 'b', 'c'"},
@@ -27,6 +31,12 @@ func TestModifyColumnUnsafe(t *testing.T) {
 		{"decimal(10,5)", "decimal(9,5)"},
 		{"decimal(10,5)", "decimal(9,6)"},
 		{"decimal(9,4)", "decimal(10,5) unsigned"},
+		{"decimal(9,4)", "decimal(9,5)"},
+		{"decimal(10,2)", "decimal(8,2)"},
+		{"decimal(10,2)", "decimal(12,5)"},
+		{"decimal", "decimal(9,0)"},
+		{"decimal(12)", "decimal(12,1)"},
+		{"numeric(10,2)", "numeric(10,1)"},
 		{"varchar(20)", "varchar(19)"},
 		{"varbinary(40)", "varbinary(35)"},
 		{"varbinary(256)", "tinyblob"},
@@ -34,6 +44,9 @@ func TestModifyColumnUnsafe(t *testing.T) {
 		{"varchar(20)", "varbinary(20)"},
 		{"timestamp(5)", "timestamp"},
 		{"datetime(4)", "datetime(3)"},
+		{"datetime(6)", "datetime"},
+		{"time(2)", "time(0)"},
+		{"time", "timestamp"},
 		{"float", "float(10,5)"},
 		{"double", "float"},
 		{"float(10,5)", "float(10,4)"},
@@ -55,6 +68,10 @@ func TestModifyColumnUnsafe(t *testing.T) {
 		{"inet4", "char(10)"},
 		{"char(31)", "uuid"},
 		{"uuid", "binary(15)"},
+		{"text", "json"},
+		{"longtext", "json"},
+		{"json", "text"},
+		{"json", "varchar(255)"},
 	}
 	for _, types := range expectUnsafe {
 		assertUnsafe(types[0], types[1], true)
@@ -65,17 +82,27 @@ func TestModifyColumnUnsafe(t *testing.T) {
 		{"mediumint(4)", "mediumint(3)"},
 		{"int zerofill", "int"},
 		{"int(10) unsigned", "bigint(20)"},
+		{"smallint unsigned", "int"},
+		{"enum('signed')", "enum('signed', 'unsigned')"},
 		{"enum('a', 'b', 'c')", "enum('a', 'b', 'c', 'd')"},
 		{"set('abc', 'def', 'ghi')", "set('abc', 'def', 'ghi', 'jkl')"},
 		{"decimal(9,4)", "decimal(10,4)"},
-		{"decimal(9,4)", "decimal(9,5)"},
+		{"decimal(9,4)", "decimal(10,5)"},
 		{"decimal(9,4) unsigned", "decimal(9,4)"},
+		{"decimal(8,2)", "decimal(10,2)"},
+		{"decimal(10,2)", "decimal(11,2)"},
+		{"decimal", "decimal(11,0)"},
+		{"decimal(10)", "decimal(12,2)"},
+		{"numeric(10,1)", "numeric(11,2)"},
 		{"varchar(20)", "varchar(21)"},
 		{"varbinary(40)", "varbinary(45)"},
 		{"varbinary(255)", "tinyblob"},
 		{"tinyblob", "varbinary(255)"},
 		{"timestamp", "timestamp(5)"},
 		{"datetime(3)", "datetime(4)"},
+		{"datetime", "datetime(6)"},
+		{"time(0)", "time(3)"},
+		{"datetime(0)", "datetime"},
 		{"float(10,5)", "float"},
 		{"float", "double"},
 		{"float(10,4)", "float(10,5)"},
@@ -120,6 +147,20 @@ func TestModifyColumnUnsafe(t *testing.T) {
 		t.Error("For changing collation but not character set, expected unsafe=false, instead found unsafe=true")
 	}
 
+	// Special case: converting JSON to longtext is only safe if the new character
+	// set is utf8mb4
+	mc = ModifyColumn{
+		OldColumn: &Column{TypeInDB: "json"},
+		NewColumn: &Column{TypeInDB: "longtext", CharSet: "utf8mb4", Collation: "utf8mb4_bin"},
+	}
+	if mc.Unsafe() {
+		t.Error("For json -> longtext utf8mb4, expected unsafe=false, instead found unsafe=true")
+	}
+	mc.NewColumn.CharSet, mc.NewColumn.Collation = "latin1", "latin1_swedish_ci"
+	if !mc.Unsafe() {
+		t.Error("For json -> longtext latin1, expected unsafe=true, instead found unsafe=false")
+	}
+
 	// Special case: confirm changing the type of a column is safe for virtual
 	// generated columns but not stored generated columns
 	mc = ModifyColumn{
@@ -135,6 +176,46 @@ func TestModifyColumnUnsafe(t *testing.T) {
 	}
 }
 
+func TestModifyColumnSteps(t *testing.T) {
+	table := anotherTable()
+	after := table.Columns[0]
+	mc := ModifyColumn{
+		Table:         &table,
+		OldColumn:     &Column{Name: "name", TypeInDB: "varchar(30)", CharSet: "latin1", Collation: "latin1_swedish_ci", CollationIsDefault: true},
+		NewColumn:     &Column{Name: "name", TypeInDB: "varchar(60)", CharSet: "utf8mb4", Collation: "utf8mb4_general_ci", CollationIsDefault: true},
+		PositionAfter: after,
+	}
+	steps := mc.Steps()
+	if len(steps) != 2 {
+		t.Fatalf("Expected 2 steps for simultaneous type and charset change, instead found %d", len(steps))
+	}
+	expected := []string{
+		"MODIFY COLUMN `name` varchar(60) NOT NULL",
+		"MODIFY COLUMN `name` varchar(60) CHARACTER SET utf8mb4 NOT NULL AFTER " + EscapeIdentifier(after.Name),
+	}
+	for n, step := range steps {
+		if actual := step.Clause(StatementModifiers{}); actual != expected[n] {
+			t.Errorf("Step %d: expected clause %q, instead found %q", n, expected[n], actual)
+		}
+	}
+	if steps[0].Unsafe() {
+		t.Error("Expected first step (widening type only) to be safe, but Unsafe() returned true")
+	}
+	if steps[1].OldColumn != steps[0].NewColumn || steps[1].NewColumn != mc.NewColumn {
+		t.Error("Steps do not chain together as expected")
+	}
+
+	// Type-only or charset-only changes should be returned as a single step
+	mc.NewColumn.CharSet, mc.NewColumn.Collation = "latin1", "latin1_swedish_ci"
+	if steps := mc.Steps(); len(steps) != 1 || steps[0] != mc {
+		t.Errorf("Expected type-only change to be returned as-is, instead found %d steps", len(steps))
+	}
+	mc.NewColumn.TypeInDB, mc.NewColumn.CharSet, mc.NewColumn.Collation = "varchar(30)", "utf8mb4", "utf8mb4_general_ci"
+	if steps := mc.Steps(); len(steps) != 1 || steps[0] != mc {
+		t.Errorf("Expected charset-only change to be returned as-is, instead found %d steps", len(steps))
+	}
+}
+
 func (s TengoIntegrationSuite) TestAlterPageCompression(t *testing.T) {
 	flavor := s.d.Flavor()
 	// Skip test if flavor doesn't support page compression
@@ -198,6 +279,101 @@ func (s TengoIntegrationSuite) TestAlterPageCompression(t *testing.T) {
 	}
 }
 
+func (s TengoIntegrationSuite) TestAlterPackKeys(t *testing.T) {
+	s.SourceTestSQL(t, "integration-ext.sql")
+	orig := s.GetTable(t, "testing", "eww_myisam")
+	if orig.CreateOptions != "" || orig.Engine != "MyISAM" {
+		t.Fatal("Fixture table has changed without test logic being updated")
+	}
+	db, err := s.d.Connect("testing", "")
+	if err != nil {
+		t.Fatalf("Unable to connect to DockerizedInstance: %s", err)
+	}
+
+	// Toggle PACK_KEYS through each value, confirming that executing the
+	// generated ALTER results in no further diff
+	from := orig
+	for _, createOptions := range []string{"PACK_KEYS=1", "PACK_KEYS=0", ""} {
+		to := *from
+		to.CreateOptions = createOptions
+		to.CreateStatement = to.GeneratedCreateStatement(s.d.Flavor())
+		td := NewAlterTable(from, &to)
+		if td == nil {
+			t.Fatalf("Expected diff for create options %q to %q, but none found", from.CreateOptions, createOptions)
+		}
+		stmt, err := td.Statement(StatementModifiers{})
+		if err != nil {
+			t.Fatalf("Unexpected error from Statement: %v", err)
+		} else if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("Unexpected error from query %q: %v", stmt, err)
+		}
+		from = s.GetTable(t, "testing", "eww_myisam")
+		if from.CreateOptions != createOptions {
+			t.Errorf("Expected refetched table to have create options %q, instead found %q", createOptions, from.CreateOptions)
+		}
+		if td := NewAlterTable(from, &to); td != nil {
+			t.Errorf("Expected no diff after executing %q, but found one", stmt)
+		}
+	}
+}
+
+func (s TengoIntegrationSuite) TestAlterMerge(t *testing.T) {
+	db, err := s.d.Connect("testing", "")
+	if err != nil {
+		t.Fatalf("Unable to connect to DockerizedInstance: %s", err)
+	}
+	for _, name := range []string{"t1", "t2", "t3"} {
+		query := fmt.Sprintf("CREATE TABLE %s (id int unsigned NOT NULL, PRIMARY KEY (id)) ENGINE=MyISAM", name)
+		if _, err := db.Exec(query); err != nil {
+			t.Fatalf("Unexpected error from query %q: %v", query, err)
+		}
+	}
+	if _, err := db.Exec("CREATE TABLE merged (id int unsigned NOT NULL, KEY (id)) ENGINE=MRG_MyISAM UNION=(t1,t2)"); err != nil {
+		t.Skipf("MERGE storage engine not available in flavor %s: %v", s.d.Flavor(), err)
+	}
+
+	// Cycle through changes to the underlying tables and insert method,
+	// confirming that executing each generated ALTER results in no further diff
+	from := s.GetTable(t, "testing", "merged")
+	if from.UnsupportedDDL || !reflect.DeepEqual(from.MergeUnion, []string{"t1", "t2"}) {
+		t.Fatalf("Unexpected introspection of MERGE table: UnsupportedDDL=%t, MergeUnion=%v", from.UnsupportedDDL, from.MergeUnion)
+	}
+	changes := []struct {
+		union        []string
+		insertMethod string
+	}{
+		{[]string{"t1", "t2", "t3"}, ""},
+		{[]string{"t3", "t1", "t2"}, ""},
+		{[]string{"t3", "t1", "t2"}, "LAST"},
+		{[]string{"t2"}, "FIRST"},
+		{[]string{"t2"}, ""},
+	}
+	for _, c := range changes {
+		to := *from
+		to.MergeUnion = c.union
+		to.MergeInsertMethod = c.insertMethod
+		to.CreateStatement = to.GeneratedCreateStatement(s.d.Flavor())
+		td := NewAlterTable(from, &to)
+		if td == nil {
+			t.Fatalf("Expected diff for UNION=%v INSERT_METHOD=%q, but none found", c.union, c.insertMethod)
+		} else if risk := td.Risk(); risk != RiskMetadata {
+			t.Errorf("Expected risk %s, instead found %s", RiskMetadata, risk)
+		}
+		stmt, err := td.Statement(StatementModifiers{})
+		if err != nil {
+			t.Fatalf("Unexpected error from Statement: %v", err)
+		} else if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("Unexpected error from query %q: %v", stmt, err)
+		}
+		from = s.GetTable(t, "testing", "merged")
+		if from.CreateStatement != to.CreateStatement {
+			t.Errorf("Mismatch between actual and expected CREATE TABLE after %q.\nActual:\n%s\nExpected:\n%s", stmt, from.CreateStatement, to.CreateStatement)
+		} else if td := NewAlterTable(from, &to); td != nil {
+			t.Errorf("Expected no diff after executing %q, but found one", stmt)
+		}
+	}
+}
+
 // TestAlterCheckConstraints provides unit test coverage relating to diffs of
 // check constraints.
 func TestAlterCheckConstraints(t *testing.T) {
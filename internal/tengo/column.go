@@ -2,28 +2,34 @@ package tengo
 
 import (
 	"fmt"
+	"io"
+	"regexp"
+	"strconv"
 	"strings"
 )
 
 // Column represents a single column of a table.
 type Column struct {
-	Name               string `json:"name"`
-	TypeInDB           string `json:"type"`
-	Nullable           bool   `json:"nullable,omitempty"`
-	AutoIncrement      bool   `json:"autoIncrement,omitempty"`
-	Default            string `json:"default,omitempty"` // Stored as an expression, i.e. quote-wrapped if string
-	OnUpdate           string `json:"onUpdate,omitempty"`
-	GenerationExpr     string `json:"generationExpression,omitempty"` // Only populated if generated column
-	Virtual            bool   `json:"virtual,omitempty"`
-	CharSet            string `json:"charSet,omitempty"`            // Only populated if textual type
-	Collation          string `json:"collation,omitempty"`          // Only populated if textual type
-	CollationIsDefault bool   `json:"collationIsDefault,omitempty"` // Only populated if textual type; indicates default for CharSet
-	ForceShowCharSet   bool   `json:"forceShowCharSet,omitempty"`   // Always include CharSet in SHOW CREATE; only true in MySQL 8 edge cases
-	ForceShowCollation bool   `json:"forceShowCollation,omitempty"` // Always include Collation in SHOW CREATE; only true in MySQL 8 edge cases
-	Compression        string `json:"compression,omitempty"`        // Only non-empty if using column compression in Percona Server or MariaDB
-	Comment            string `json:"comment,omitempty"`
-	Invisible          bool   `json:"invisible,omitempty"` // True if an invisible column (MariaDB 10.3+, MySQL 8.0.23+)
-	CheckClause        string `json:"check,omitempty"`     // Only non-empty for MariaDB inline check constraint clause
+	Name                     string `json:"name"`
+	TypeInDB                 string `json:"type"`
+	Nullable                 bool   `json:"nullable,omitempty"`
+	AutoIncrement            bool   `json:"autoIncrement,omitempty"`
+	Default                  string `json:"default,omitempty"` // Stored as an expression, i.e. quote-wrapped if string
+	OnUpdate                 string `json:"onUpdate,omitempty"`
+	GenerationExpr           string `json:"generationExpression,omitempty"` // Only populated if generated column
+	Virtual                  bool   `json:"virtual,omitempty"`
+	Persistent               bool   `json:"persistent,omitempty"`         // MariaDB only: true if stored generated column is shown with PERSISTENT instead of STORED
+	CharSet                  string `json:"charSet,omitempty"`            // Only populated if textual type
+	Collation                string `json:"collation,omitempty"`          // Only populated if textual type
+	CollationIsDefault       bool   `json:"collationIsDefault,omitempty"` // Only populated if textual type; indicates default for CharSet
+	ForceShowCharSet         bool   `json:"forceShowCharSet,omitempty"`   // Always include CharSet in SHOW CREATE; only true in MySQL 8 edge cases
+	ForceShowCollation       bool   `json:"forceShowCollation,omitempty"` // Always include Collation in SHOW CREATE; only true in MySQL 8 edge cases
+	Compression              string `json:"compression,omitempty"`        // Only non-empty if using column compression in Percona Server or MariaDB
+	Comment                  string `json:"comment,omitempty"`
+	Invisible                bool   `json:"invisible,omitempty"`                // True if an invisible column (MariaDB 10.3+, MySQL 8.0.23+)
+	CheckClause              string `json:"check,omitempty"`                    // Only non-empty for MariaDB inline check constraint clause
+	EngineAttribute          string `json:"engineAttribute,omitempty"`          // JSON; only populated in MySQL 8.0.21+
+	SecondaryEngineAttribute string `json:"secondaryEngineAttribute,omitempty"` // JSON; only populated in MySQL 8.0.21+
 }
 
 // Definition returns this column's definition clause, for use as part of a DDL
@@ -36,7 +42,7 @@ func (c *Column) Definition(flavor Flavor, table *Table) string {
 		// MariaDB puts compression modifiers in a different place than Percona Server
 		compression = fmt.Sprintf(" /*!100301 %s*/", c.Compression)
 	}
-	if c.CharSet != "" && (table == nil || c.Collation != table.Collation || c.ForceShowCharSet) {
+	if c.CharSet != "" && !c.hasBinaryType() && (table == nil || c.Collation != table.Collation || c.ForceShowCharSet) {
 		charSet = fmt.Sprintf(" CHARACTER SET %s", c.CharSet)
 	}
 	// MySQL pre-8.0, MariaDB pre-Nov'22: Collations are displayed if not the
@@ -48,7 +54,7 @@ func (c *Column) Definition(flavor Flavor, table *Table) string {
 	// MariaDB Nov'22 onwards: Collations are displayed if (and only if) the
 	//     character set is displayed, based on charset display logic (which is
 	//     partially based on the collation anyway)
-	if c.Collation != "" {
+	if c.Collation != "" && !c.hasBinaryType() {
 		var showCollate bool
 		if flavor.AlwaysShowCollate() {
 			showCollate = (charSet != "")
@@ -63,6 +69,8 @@ func (c *Column) Definition(flavor Flavor, table *Table) string {
 		genKind := "STORED"
 		if c.Virtual {
 			genKind = "VIRTUAL"
+		} else if c.Persistent && flavor.IsMariaDB() {
+			genKind = "PERSISTENT"
 		}
 		generated = fmt.Sprintf(" GENERATED ALWAYS AS (%s) %s", c.GenerationExpr, genKind)
 	}
@@ -104,6 +112,7 @@ func (c *Column) Definition(flavor Flavor, table *Table) string {
 		clauses = append(clauses, visibility, autoIncrement, defaultValue, onUpdate, colFormat, comment, check)
 	} else {
 		clauses = append(clauses, autoIncrement, defaultValue, onUpdate, visibility, colFormat, comment)
+		clauses = append(clauses, engineAttributeClauses(c.EngineAttribute, c.SecondaryEngineAttribute, " "))
 	}
 	return strings.Join(clauses, "")
 }
@@ -128,9 +137,10 @@ func (c *Column) Equals(other *Column) bool {
 // Equivalent returns true if two columns are equal, or only differ in cosmetic/
 // non-functional ways. Cosmetic differences can come about in MySQL 8 when a
 // column was created with CHARACTER SET or COLLATION clauses that are
-// unnecessary (equal to table's default); or when comparing a table across
+// unnecessary (equal to table's default); when comparing a table across
 // different versions of MySQL 8 (one which supports int display widths, and
-// one that removes them).
+// one that removes them); or when a numeric column's default value is quoted
+// in one version but not the other, which varies by flavor.
 func (c *Column) Equivalent(other *Column) bool {
 	// If they're equal, they're also equivalent
 	if c.Equals(other) {
@@ -147,7 +157,12 @@ func (c *Column) Equivalent(other *Column) bool {
 	selfStrippedType, selfHadDisplayWidth := StripDisplayWidth(c.TypeInDB)
 	otherStrippedType, otherHadDisplayWidth := StripDisplayWidth(other.TypeInDB)
 	if selfStrippedType != otherStrippedType || (c.TypeInDB != other.TypeInDB && selfHadDisplayWidth && otherHadDisplayWidth) {
-		return false
+		// A temporal type with an explicit fractional-seconds precision of 0 is
+		// equivalent to the same type without any precision; any other difference
+		// is functional.
+		if !c.sameTemporalType(other) {
+			return false
+		}
 	}
 	// If we didn't return early, we know either TypeInDB didn't change at all, or
 	// it only differs in a cosmetic manner.
@@ -158,6 +173,25 @@ func (c *Column) Equivalent(other *Column) bool {
 	selfCopy.TypeInDB = other.TypeInDB
 	selfCopy.ForceShowCharSet = other.ForceShowCharSet
 	selfCopy.ForceShowCollation = other.ForceShowCollation
+	selfCopy.Persistent = other.Persistent
+	if c.normalizedDefault() == other.normalizedDefault() {
+		selfCopy.Default = other.Default
+	}
+	if c.normalizedOnUpdate() == other.normalizedOnUpdate() {
+		selfCopy.OnUpdate = other.OnUpdate
+	}
+	if c.normalizedGenerationExpr() == other.normalizedGenerationExpr() {
+		selfCopy.GenerationExpr = other.GenerationExpr
+	}
+	if jsonEquivalent(c.EngineAttribute, other.EngineAttribute) {
+		selfCopy.EngineAttribute = other.EngineAttribute
+	}
+	if jsonEquivalent(c.SecondaryEngineAttribute, other.SecondaryEngineAttribute) {
+		selfCopy.SecondaryEngineAttribute = other.SecondaryEngineAttribute
+	}
+	if c.effectiveCharSet() == other.effectiveCharSet() && c.hasBinaryType() {
+		selfCopy.CharSet, selfCopy.Collation, selfCopy.CollationIsDefault = other.CharSet, other.Collation, other.CollationIsDefault
+	}
 	if (other.CharSet == "utf8mb3" && c.CharSet == "utf8") || (other.CharSet == "utf8" && c.CharSet == "utf8mb3") {
 		selfCopy.CharSet = other.CharSet
 	}
@@ -168,3 +202,173 @@ func (c *Column) Equivalent(other *Column) bool {
 	}
 	return selfCopy == *other
 }
+
+// hasBinaryType returns true if c's type is one of the binary string types
+// (BINARY, VARBINARY, or any BLOB type). These types never have a character set
+// or collation, even if c.CharSet is "binary". In contrast, ENUM and SET
+// columns may use the binary character set, but still have that character set
+// shown in CREATE TABLE.
+func (c *Column) hasBinaryType() bool {
+	base, _, _ := strings.Cut(strings.ToLower(c.TypeInDB), "(")
+	switch base {
+	case "binary", "varbinary", "tinyblob", "blob", "mediumblob", "longblob":
+		return true
+	}
+	return false
+}
+
+// hasJSONType returns true if c uses MySQL's native JSON type. In MariaDB,
+// JSON is an alias for LONGTEXT, so MariaDB columns never have this type.
+func (c *Column) hasJSONType() bool {
+	return strings.EqualFold(c.TypeInDB, "json")
+}
+
+// hasBlobLikeType returns true if c's type is one of the types which could not
+// have a DEFAULT clause prior to MySQL 8.0.13 / MariaDB 10.2: any BLOB or TEXT
+// type, JSON, or a spatial type.
+func (c *Column) hasBlobLikeType() bool {
+	base, _, _ := strings.Cut(strings.ToLower(c.TypeInDB), "(")
+	base, _, _ = strings.Cut(base, " ")
+	if strings.HasSuffix(base, "blob") || strings.HasSuffix(base, "text") {
+		return true
+	}
+	switch base {
+	case "json", "geometry", "point", "linestring", "polygon", "multipoint", "multilinestring", "multipolygon", "geometrycollection", "geomcollection":
+		return true
+	}
+	return false
+}
+
+// defaultSupported returns false if c has a DEFAULT clause which flavor would
+// reject. This only applies to types covered by hasBlobLikeType: MySQL 8.0.13+
+// permits these to have a default expression (wrapped in parens), and MariaDB
+// 10.2+ permits either a literal or an expression. Other flavors, as well as
+// MySQL 8.0.13+ with a literal default, are not permitted anything beyond an
+// implicit DEFAULT NULL. If flavor is not known, true is returned.
+func (c *Column) defaultSupported(flavor Flavor) bool {
+	if !flavor.Known() || c.Default == "" || c.Default == "NULL" || !c.hasBlobLikeType() {
+		return true
+	} else if flavor.Min(FlavorMariaDB102) {
+		return true
+	}
+	return flavor.Min(FlavorMySQL80.Dot(13)) && c.Default[0] == '('
+}
+
+// effectiveCharSet returns c.CharSet, except for binary string types, which
+// always return "". This permits comparing a binary string column which has the
+// binary character set explicitly populated to one which does not.
+func (c *Column) effectiveCharSet() string {
+	if c.hasBinaryType() {
+		return ""
+	}
+	return c.CharSet
+}
+
+var numericLiteral = regexp.MustCompile(`^-?[0-9]+(?:\.[0-9]*)?(?:[eE][-+]?[0-9]+)?$`)
+
+// normalizedDefault returns the column's default value, removing any quotes
+// around a numeric literal default for a numeric column type. For example, a
+// default of '1' on an int column is returned as 1. MySQL quote-wraps these
+// defaults in SHOW CREATE TABLE, whereas MariaDB 10.2+ does not. All other
+// defaults are returned as-is.
+func (c *Column) normalizedDefault() string {
+	if len(c.Default) < 3 || c.Default[0] != '\'' || c.Default[len(c.Default)-1] != '\'' {
+		return c.Default
+	}
+	var numeric bool
+	for _, prefix := range []string{"tinyint", "smallint", "mediumint", "int", "bigint", "decimal", "float", "double"} {
+		if strings.HasPrefix(c.TypeInDB, prefix) {
+			numeric = true
+			break
+		}
+	}
+	if inner := c.Default[1 : len(c.Default)-1]; numeric && numericLiteral.MatchString(inner) {
+		return inner
+	}
+	return c.Default
+}
+
+// FractionalSecondsPrecision returns the fractional-seconds precision (fsp) of
+// a time, timestamp, or datetime column, along with true. These types have an
+// fsp of 0 if no precision is specified. For all other column types, ok will
+// be false.
+func (c *Column) FractionalSecondsPrecision() (fsp int, ok bool) {
+	colType := strings.ToLower(c.TypeInDB)
+	baseType, precision, hasPrecision := strings.Cut(colType, "(")
+	if baseType != "time" && baseType != "timestamp" && baseType != "datetime" {
+		return 0, false
+	} else if !hasPrecision {
+		return 0, true
+	}
+	if !strings.HasSuffix(precision, ")") {
+		return 0, false
+	}
+	fsp, err := strconv.Atoi(strings.TrimSuffix(precision, ")"))
+	return fsp, err == nil
+}
+
+// Unsigned returns true if c is a numeric column with the unsigned modifier,
+// with or without a subsequent zerofill modifier. Non-numeric types always
+// return false, even if their TypeInDB happens to contain the word "unsigned"
+// (for example in an enum value list).
+func (c *Column) Unsigned() bool {
+	colType := strings.ToLower(c.TypeInDB)
+	if strings.HasPrefix(colType, "enum") || strings.HasPrefix(colType, "set") {
+		return false
+	}
+	colType = strings.TrimSuffix(colType, " zerofill")
+	return strings.HasSuffix(colType, " unsigned")
+}
+
+// sameTemporalType returns true if c and other both have the same temporal
+// column type and the same fractional-seconds precision, even if their
+// TypeInDB strings differ (e.g. datetime vs datetime(0)).
+func (c *Column) sameTemporalType(other *Column) bool {
+	selfFSP, selfOK := c.FractionalSecondsPrecision()
+	otherFSP, otherOK := other.FractionalSecondsPrecision()
+	if !selfOK || !otherOK || selfFSP != otherFSP {
+		return false
+	}
+	selfBase, _, _ := strings.Cut(strings.ToLower(c.TypeInDB), "(")
+	otherBase, _, _ := strings.Cut(strings.ToLower(other.TypeInDB), "(")
+	return selfBase == otherBase
+}
+
+// normalizedGenerationExpr returns the column's generation expression with
+// function names uppercased. Built-in function names are case-insensitive, and
+// may be stored by the server using a different case than the original
+// definition. Whitespace, identifiers, and string literals are left as-is.
+func (c *Column) normalizedGenerationExpr() string {
+	if c.GenerationExpr == "" {
+		return ""
+	}
+	lex := NewLexer(strings.NewReader(c.GenerationExpr), "\000", 8192)
+	var tokens []Token
+	for {
+		val, typ, err := lex.Scan()
+		if err == io.EOF || (err != nil && len(val) == 0) {
+			break
+		}
+		tokens = append(tokens, Token{val: string(val), typ: typ})
+	}
+	var b strings.Builder
+	for n, t := range tokens {
+		if t.typ == TokenWord && n+1 < len(tokens) && tokens[n+1].typ == TokenSymbol && tokens[n+1].val == "(" {
+			b.WriteString(strings.ToUpper(t.val))
+		} else {
+			b.WriteString(t.val)
+		}
+	}
+	return b.String()
+}
+
+// normalizedOnUpdate returns the column's ON UPDATE expression in a
+// normalized form, for purposes of comparing equivalence: uppercased, and with
+// any empty or zero fractional-second precision removed. This way,
+// current_timestamp() (as shown by MariaDB) is considered equivalent to
+// CURRENT_TIMESTAMP, but CURRENT_TIMESTAMP(3) remains distinct.
+func (c *Column) normalizedOnUpdate() string {
+	onUpdate := strings.ToUpper(c.OnUpdate)
+	onUpdate = strings.TrimSuffix(onUpdate, "()")
+	return strings.TrimSuffix(onUpdate, "(0)")
+}
@@ -70,6 +70,12 @@ func TestColumnEquivalent(t *testing.T) {
 	a.Nullable, b.Nullable = false, false
 	a.Default, b.Default = "", ""
 	assertEquivalent(false)
+	a.TypeInDB, b.TypeInDB = "datetime(6)", "datetime"
+	assertEquivalent(false)
+	a.TypeInDB, b.TypeInDB = "datetime(0)", "datetime"
+	assertEquivalent(true)
+	a.TypeInDB, b.TypeInDB = "time(0)", "timestamp"
+	assertEquivalent(false)
 
 	// Test situations involving forcing show charset/collation
 	a = &Column{
@@ -101,4 +107,198 @@ func TestColumnEquivalent(t *testing.T) {
 	assertEquivalent(true)
 	a.Collation = "utf8mb3_general_ci"
 	assertEquivalent(true)
+
+	// Test situations involving quoting of numeric defaults
+	a = &Column{
+		Name:     "col",
+		TypeInDB: "int(10) unsigned",
+		Default:  "'1'",
+	}
+	b = &Column{}
+	*b = *a
+	b.Default = "1"
+	assertEquivalent(true)
+	b.Default = "2"
+	assertEquivalent(false)
+	a.TypeInDB, b.TypeInDB = "decimal(8,2)", "decimal(8,2)"
+	a.Default, b.Default = "'-1.50'", "-1.50"
+	assertEquivalent(true)
+	b.Default = "-1.5"
+	assertEquivalent(false)
+	a.TypeInDB, b.TypeInDB = "double", "double"
+	a.Default, b.Default = "'1e10'", "1e10"
+	assertEquivalent(true)
+	a.TypeInDB, b.TypeInDB = "varchar(10)", "varchar(10)"
+	a.Default, b.Default = "'1'", "1"
+	assertEquivalent(false)
+	a.TypeInDB, b.TypeInDB = "int", "int"
+	a.Default, b.Default = "'abc'", "abc"
+	assertEquivalent(false)
+
+	// Test situations involving ON UPDATE: gaining or losing the attribute, or
+	// changing its precision, is functional; case and empty parens are cosmetic
+	a = &Column{
+		Name:     "col",
+		TypeInDB: "timestamp",
+		Default:  "CURRENT_TIMESTAMP",
+	}
+	b = &Column{}
+	*b = *a
+	b.OnUpdate = "CURRENT_TIMESTAMP"
+	assertEquivalent(false)
+	a.OnUpdate = "current_timestamp()"
+	assertEquivalent(true)
+	a.TypeInDB, b.TypeInDB = "timestamp(3)", "timestamp(3)"
+	a.Default, b.Default = "CURRENT_TIMESTAMP(3)", "CURRENT_TIMESTAMP(3)"
+	a.OnUpdate, b.OnUpdate = "current_timestamp(3)", "CURRENT_TIMESTAMP(3)"
+	assertEquivalent(true)
+	b.OnUpdate = "CURRENT_TIMESTAMP"
+	assertEquivalent(false)
+	b.OnUpdate = ""
+	assertEquivalent(false)
+}
+
+func TestColumnFractionalSecondsPrecision(t *testing.T) {
+	cases := []struct {
+		typeInDB   string
+		expectFSP  int
+		expectedOK bool
+	}{
+		{"datetime", 0, true},
+		{"datetime(6)", 6, true},
+		{"TIMESTAMP(3)", 3, true},
+		{"time(0)", 0, true},
+		{"date", 0, false},
+		{"int(11)", 0, false},
+		{"timestampish", 0, false},
+	}
+	for _, c := range cases {
+		col := &Column{TypeInDB: c.typeInDB}
+		if fsp, ok := col.FractionalSecondsPrecision(); fsp != c.expectFSP || ok != c.expectedOK {
+			t.Errorf("Expected FractionalSecondsPrecision() on %q to return %d,%t; instead found %d,%t", c.typeInDB, c.expectFSP, c.expectedOK, fsp, ok)
+		}
+	}
+}
+
+func TestColumnBinaryCharSet(t *testing.T) {
+	table := &Table{Name: "test", CharSet: "utf8mb4", Collation: "utf8mb4_general_ci"}
+
+	// ENUM and SET columns may use the binary charset, which is shown in CREATE
+	// TABLE and is a functional difference vs other charsets
+	enumBinary := &Column{Name: "e", TypeInDB: "enum('a','b')", Nullable: true, Default: "NULL", CharSet: "binary", Collation: "binary", CollationIsDefault: true}
+	enumText := &Column{Name: "e", TypeInDB: "enum('a','b')", Nullable: true, Default: "NULL", CharSet: "utf8mb4", Collation: "utf8mb4_general_ci", CollationIsDefault: true}
+	if def := enumBinary.Definition(FlavorMySQL57, table); def != "`e` enum('a','b') CHARACTER SET binary DEFAULT NULL" {
+		t.Errorf("Unexpected definition for enum with binary charset: %s", def)
+	}
+	if enumBinary.Equivalent(enumText) {
+		t.Error("Expected enum columns with differing charsets to not be equivalent")
+	}
+	if mc := (ModifyColumn{Table: table, OldColumn: enumText, NewColumn: enumBinary}); !mc.Unsafe() {
+		t.Error("Expected charset conversion to binary to be unsafe")
+	}
+
+	// Binary string types never show a charset, even if one is populated, and are
+	// equivalent regardless of whether the binary charset was populated
+	blobBinary := &Column{Name: "b", TypeInDB: "blob", Nullable: true, CharSet: "binary", Collation: "binary", CollationIsDefault: true}
+	blobPlain := &Column{Name: "b", TypeInDB: "blob", Nullable: true}
+	if def := blobBinary.Definition(FlavorMySQL80, table); def != "`b` blob" {
+		t.Errorf("Unexpected definition for blob with binary charset: %s", def)
+	}
+	if !blobBinary.Equivalent(blobPlain) || !blobPlain.Equivalent(blobBinary) {
+		t.Error("Expected blob columns to be equivalent regardless of binary charset")
+	}
+	mc := ModifyColumn{Table: table, OldColumn: blobPlain, NewColumn: blobBinary}
+	if clause := mc.Clause(StatementModifiers{}); clause != "" {
+		t.Errorf("Expected no clause between blob columns, instead found %q", clause)
+	}
+
+	// Converting a textual column to a binary string type is not a charset
+	// conversion, so it should not be split into separate steps, and its safety
+	// is determined by the type change alone
+	setBinary := &Column{Name: "v", TypeInDB: "set('a','b')", Nullable: true, Default: "NULL", CharSet: "binary", Collation: "binary", CollationIsDefault: true}
+	varbinaryCol := &Column{Name: "v", TypeInDB: "varbinary(40)", Nullable: true, Default: "NULL", CharSet: "binary", Collation: "binary", CollationIsDefault: true}
+	mc = ModifyColumn{Table: table, OldColumn: varbinaryCol, NewColumn: &Column{Name: "v", TypeInDB: "varbinary(60)", Nullable: true, Default: "NULL"}}
+	if mc.Unsafe() {
+		t.Error("Expected increasing varbinary size to be safe, regardless of binary charset")
+	}
+	mc = ModifyColumn{Table: table, OldColumn: enumText, NewColumn: setBinary}
+	if steps := mc.Steps(); len(steps) != 2 {
+		t.Errorf("Expected type and charset change to be split into 2 steps, instead found %d", len(steps))
+	}
+	mc = ModifyColumn{Table: table, OldColumn: enumText, NewColumn: varbinaryCol}
+	if steps := mc.Steps(); len(steps) != 1 {
+		t.Errorf("Expected conversion to a binary string type to be 1 step, instead found %d", len(steps))
+	}
+}
+
+func TestColumnDefinitionPersistent(t *testing.T) {
+	col := &Column{
+		Name:           "full_name",
+		TypeInDB:       "varchar(90)",
+		Nullable:       true,
+		GenerationExpr: "concat(`first_name`,' ',`last_name`)",
+		Persistent:     true,
+	}
+	cases := map[Flavor]string{
+		FlavorMariaDB106: "`full_name` varchar(90) GENERATED ALWAYS AS (concat(`first_name`,' ',`last_name`)) PERSISTENT",
+		FlavorMariaDB101: "`full_name` varchar(90) GENERATED ALWAYS AS (concat(`first_name`,' ',`last_name`)) PERSISTENT",
+		FlavorMySQL80:    "`full_name` varchar(90) GENERATED ALWAYS AS (concat(`first_name`,' ',`last_name`)) STORED",
+		FlavorPercona57:  "`full_name` varchar(90) GENERATED ALWAYS AS (concat(`first_name`,' ',`last_name`)) STORED",
+	}
+	for flavor, expected := range cases {
+		if actual := col.Definition(flavor, nil); actual != expected {
+			t.Errorf("Unexpected Definition for flavor %s\nExpected: %s\nFound:    %s", flavor, expected, actual)
+		}
+	}
+
+	// PERSISTENT vs STORED is cosmetic, but VIRTUAL is a functional difference
+	stored := *col
+	stored.Persistent = false
+	if !col.Equivalent(&stored) || !stored.Equivalent(col) {
+		t.Error("Expected PERSISTENT and STORED columns to be equivalent")
+	}
+	mc := ModifyColumn{Table: &Table{Name: "test"}, OldColumn: &stored, NewColumn: col}
+	if clause := mc.Clause(StatementModifiers{Flavor: FlavorMariaDB106}); clause != "" {
+		t.Errorf("Expected no clause between PERSISTENT and STORED columns, instead found %q", clause)
+	}
+	virtual := stored
+	virtual.Virtual = true
+	if col.Equivalent(&virtual) {
+		t.Error("Expected PERSISTENT and VIRTUAL columns to not be equivalent")
+	}
+}
+
+func TestColumnGenerationExprFunctionCase(t *testing.T) {
+	col := &Column{
+		Name:           "full_name",
+		TypeInDB:       "varchar(90)",
+		Nullable:       true,
+		GenerationExpr: "concat(`first_name`,' ',upper(`last_name`))",
+	}
+	upperCol := *col
+	upperCol.GenerationExpr = "CONCAT(`first_name`,' ',Upper(`last_name`))"
+	if !col.Equivalent(&upperCol) || !upperCol.Equivalent(col) {
+		t.Error("Expected columns differing only in generation expression function case to be equivalent")
+	}
+	mc := ModifyColumn{Table: &Table{Name: "test"}, OldColumn: col, NewColumn: &upperCol}
+	if clause := mc.Clause(StatementModifiers{}); clause != "" {
+		t.Errorf("Expected no clause for function case difference, instead found %q", clause)
+	}
+	if clause := mc.Clause(StatementModifiers{StrictColumnDefinition: true}); clause == "" {
+		t.Error("Expected StrictColumnDefinition to still emit a clause for function case difference")
+	}
+
+	// Case differences in string literals or identifiers are still significant,
+	// as are other changes to the expression
+	for _, expr := range []string{
+		"concat(`first_name`,' ',upper(`LAST_NAME`))",
+		"concat(`first_name`,' X ',upper(`last_name`))",
+		"concat(`first_name`,upper(`last_name`))",
+	} {
+		other := *col
+		other.GenerationExpr = expr
+		if col.Equivalent(&other) {
+			t.Errorf("Expected generation expression %s to not be equivalent to %s", expr, col.GenerationExpr)
+		}
+	}
 }
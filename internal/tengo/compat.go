@@ -0,0 +1,88 @@
+package tengo
+
+import (
+	"sort"
+)
+
+// ObjectCompatibility describes the features of an object's definition which
+// are not supported by a particular flavor.
+type ObjectCompatibility struct {
+	Key    ObjectKey
+	Issues []SyntaxIssue
+}
+
+// tableFeatureIssues returns issues for table features which are detected
+// from the table's introspected structure, rather than from its CREATE
+// statement's syntax. This covers features which some flavors accept
+// syntactically but silently ignore, such as CHECK constraints prior to MySQL
+// 8.0.16, or descending indexes prior to MySQL 8.0.
+func tableFeatureIssues(t *Table, flavor Flavor) (issues []SyntaxIssue) {
+	for _, col := range t.Columns {
+		if col.GenerationExpr != "" && !flavor.Min(FlavorMySQL57) && !flavor.IsMariaDB() {
+			issues = append(issues, SyntaxIssue{
+				Clause: "GENERATED ALWAYS AS",
+				Reason: "generated columns require MySQL 5.7+ or MariaDB",
+			})
+			break
+		}
+	}
+	indexes := t.SecondaryIndexes
+	if t.PrimaryKey != nil {
+		indexes = append([]*Index{t.PrimaryKey}, indexes...)
+	}
+	var functional, descending bool
+	for _, idx := range indexes {
+		for _, part := range idx.Parts {
+			functional = functional || part.Expression != ""
+			descending = descending || part.Descending
+		}
+	}
+	if functional && !flavor.Min(FlavorMySQL80.Dot(13)) {
+		issues = append(issues, SyntaxIssue{
+			Clause: "functional index",
+			Reason: "functional key parts require MySQL 8.0.13+",
+		})
+	}
+	if descending && !flavor.Min(FlavorMySQL80) && !flavor.Min(FlavorMariaDB108) {
+		issues = append(issues, SyntaxIssue{
+			Clause: "DESC",
+			Reason: "descending indexes require MySQL 8.0+ or MariaDB 10.8+; older versions silently create ascending indexes",
+		})
+	}
+	if len(t.Checks) > 0 && !flavor.Min(FlavorMySQL80.Dot(16)) && !flavor.Min(FlavorMariaDB102) {
+		issues = append(issues, SyntaxIssue{
+			Clause: "CHECK",
+			Reason: "check constraints require MySQL 8.0.16+ or MariaDB 10.2+; older versions parse but ignore them",
+		})
+	}
+	return issues
+}
+
+// FlavorCompatibility examines each table and routine in the schema, and
+// returns information about any features which would not be supported by the
+// target flavor. This is intended for auditing a schema prior to migrating to
+// a different database vendor or version, and does not require a database
+// connection. Only objects with at least one issue are included in the result,
+// which is sorted by object type and name. See ValidateFlavorSyntax for
+// limitations of the syntax checks; these are supplemented by checks of the
+// introspected table structure for features which are not readily detectable
+// from syntax alone. If target is not known, nil is returned.
+func (s *Schema) FlavorCompatibility(target Flavor) []ObjectCompatibility {
+	if !target.Known() {
+		return nil
+	}
+	var result []ObjectCompatibility
+	for key, obj := range s.Objects() {
+		issues := ValidateFlavorSyntax(obj.Def(), target)
+		if table, ok := obj.(*Table); ok {
+			issues = append(issues, tableFeatureIssues(table, target)...)
+		}
+		if len(issues) > 0 {
+			result = append(result, ObjectCompatibility{Key: key, Issues: issues})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Key.Less(result[j].Key)
+	})
+	return result
+}
@@ -0,0 +1,77 @@
+package tengo
+
+import (
+	"testing"
+)
+
+func TestSchemaFlavorCompatibility(t *testing.T) {
+	plain := aTable(1)
+	fancy := anotherTable()
+	fancy.Name = "fancy"
+	fancy.SecondaryIndexes = append(fancy.SecondaryIndexes, &Index{
+		Name:  "lower_name",
+		Parts: []IndexPart{{Expression: "lower(`first_name`)"}, {ColumnName: "actor_id", Descending: true}},
+	})
+	fancy.Checks = []*Check{{Name: "positive", Clause: "(`actor_id` > 0)", Enforced: true}}
+	fancy.CreateStatement = fancy.GeneratedCreateStatement(FlavorMySQL80)
+	proc := aProc("latin1_swedish_ci", "")
+	proc.ParamString = "IN name varchar(30) COLLATE utf8mb4_0900_ai_ci"
+	proc.CreateStatement = proc.Definition(FlavorMySQL80)
+	s := aSchema("s1", &plain, &fancy)
+	s.Routines = []*Routine{&proc}
+
+	assertCompat := func(flavor Flavor, expected map[ObjectKey][]string) {
+		t.Helper()
+		results := s.FlavorCompatibility(flavor)
+		if len(results) != len(expected) {
+			t.Errorf("Expected %d objects with issues for flavor %s, instead found %d: %+v", len(expected), flavor, len(results), results)
+			return
+		}
+		for n, result := range results {
+			if n > 0 && result.Key.Type == results[n-1].Key.Type && result.Key.Name < results[n-1].Key.Name {
+				t.Errorf("Results not sorted as expected: %+v", results)
+			}
+			var clauses []string
+			for _, issue := range result.Issues {
+				clauses = append(clauses, issue.Clause)
+			}
+			if expectedClauses, ok := expected[result.Key]; !ok {
+				t.Errorf("Unexpected result for flavor %s: %+v", flavor, result)
+			} else if len(clauses) != len(expectedClauses) {
+				t.Errorf("Unexpected issues for %s with flavor %s\nExpected: %v\nFound:    %v", result.Key, flavor, expectedClauses, clauses)
+			} else {
+				for i := range clauses {
+					if clauses[i] != expectedClauses[i] {
+						t.Errorf("Unexpected issues for %s with flavor %s\nExpected: %v\nFound:    %v", result.Key, flavor, expectedClauses, clauses)
+						break
+					}
+				}
+			}
+		}
+	}
+
+	fancyKey := fancy.ObjectKey()
+	procKey := proc.ObjectKey()
+	assertCompat(FlavorMySQL80.Dot(30), map[ObjectKey][]string{})
+	assertCompat(FlavorMySQL80.Dot(13), map[ObjectKey][]string{
+		fancyKey: {"CHECK"},
+	})
+	assertCompat(FlavorMySQL80.Dot(12), map[ObjectKey][]string{
+		fancyKey: {"functional index", "CHECK"},
+	})
+	assertCompat(FlavorMySQL57, map[ObjectKey][]string{
+		fancyKey: {"functional index", "DESC", "CHECK"},
+		procKey:  {"utf8mb4_0900_ai_ci"},
+	})
+	assertCompat(FlavorMariaDB107, map[ObjectKey][]string{
+		fancyKey: {"functional index", "DESC"},
+		procKey:  {"utf8mb4_0900_ai_ci"},
+	})
+	assertCompat(FlavorMariaDB1011, map[ObjectKey][]string{
+		fancyKey: {"functional index"},
+		procKey:  {"utf8mb4_0900_ai_ci"},
+	})
+	if results := s.FlavorCompatibility(FlavorUnknown); results != nil {
+		t.Errorf("Expected nil results for unknown flavor, instead found %+v", results)
+	}
+}
@@ -2,7 +2,9 @@ package tengo
 
 import (
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/pmezard/go-difflib/difflib"
 )
@@ -82,6 +84,12 @@ type StatementModifiers struct {
 	CompareMetadata        bool             // If true, compare creation-time sql_mode and db collation for funcs, procs (and eventually events, triggers)
 	VirtualColValidation   bool             // If true, add WITH VALIDATION clause for ALTER TABLE affecting virtual columns
 	SkipPreDropAlters      bool             // If true, skip ALTERs that were only generated to make DROP TABLE faster
+	ImplicitTSDefaults     bool             // If true, target has explicit_defaults_for_timestamp=OFF, so ignore TIMESTAMP differences solely due to implicit defaults
+	DropTableRenamePrefix  string           // If non-empty, RENAME TABLE instead of DROP TABLE, using DroppedTableName with this prefix
+	DropTableRenameTime    time.Time        // Timestamp for DropTableRenamePrefix renames; zero value means use current time
+	DropTableRenameRetain  time.Duration    // With DropTableRenamePrefix, DROP tables which were renamed at least this long ago; zero value means never drop them
+	InnoDBPageSize         int              // Target's innodb_page_size in bytes, used in warnings; zero value means assume the default of 16KB
+	UnvalidatedForeignKeys bool             // If true, use ALGORITHM=INPLACE for ALTERs which only add foreign keys, if flavor supports it; requires foreign_key_checks=0
 	Flavor                 Flavor           // Adjust generated DDL to match vendor/version. Zero value is FlavorUnknown which makes no adjustments.
 }
 
@@ -124,7 +132,7 @@ func compareTables(from, to *Schema) []*TableDiff {
 			tableDiffs = append(tableDiffs, NewDropTable(fromTable))
 			continue
 		}
-		td := NewAlterTable(fromTable, toTable)
+		td := NewAlterTable(fromTable.withResolvedCollation(from), toTable.withResolvedCollation(to))
 		if td != nil {
 			otherAlter, addFKAlter := td.SplitAddForeignKeys()
 			alters := otherAlter.SplitConflicts()
@@ -241,11 +249,113 @@ func (sd *SchemaDiff) FilteredTableDiffs(onlyTypes ...DiffType) []*TableDiff {
 	return result
 }
 
+// OrderDrops reorders sd.TableDiffs so that all DROP TABLEs occur after all
+// other table diffs, ordered by foreign key dependencies: a table is dropped
+// before any other dropped table that it references. Any pre-drop ALTERs for
+// a dropped table (see PreDropAlters) stay immediately before its DROP.
+// MySQL and MariaDB do not support DROP TABLE ... CASCADE, so this permits
+// dropping related tables even when foreign_key_checks is enabled, for example
+// when using an external tool. If the dropped tables have circular foreign key
+// references, ALTERs to drop the relevant foreign keys are inserted prior to
+// dropping the tables involved in the cycle.
+func (sd *SchemaDiff) OrderDrops() {
+	drops := make(map[string]*TableDiff)
+	preDrops := make(map[string][]*TableDiff)
+	var names []string
+	for _, td := range sd.TableDiffs {
+		if td.Type == DiffTypeDrop {
+			drops[td.From.Name] = td
+			names = append(names, td.From.Name)
+		}
+	}
+	if len(drops) == 0 {
+		return
+	}
+	sort.Strings(names)
+	result := make([]*TableDiff, 0, len(sd.TableDiffs))
+	for _, td := range sd.TableDiffs {
+		if td.Type == DiffTypeAlter && drops[td.From.Name] != nil && drops[td.From.Name].From == td.From {
+			preDrops[td.From.Name] = append(preDrops[td.From.Name], td)
+		} else if td.Type != DiffTypeDrop {
+			result = append(result, td)
+		}
+	}
+
+	// referencedBy tracks, for each dropped table, which other remaining dropped
+	// tables have foreign keys referencing it
+	referencedBy := make(map[string]map[string]bool, len(names))
+	for _, name := range names {
+		for _, fk := range drops[name].From.ForeignKeys {
+			if ref := fk.ReferencedTableName; fk.ReferencedSchemaName == "" && ref != name && drops[ref] != nil {
+				if referencedBy[ref] == nil {
+					referencedBy[ref] = make(map[string]bool)
+				}
+				referencedBy[ref][name] = true
+			}
+		}
+	}
+	appendDrop := func(name string) {
+		result = append(result, preDrops[name]...)
+		result = append(result, drops[name])
+		for _, referencers := range referencedBy {
+			delete(referencers, name)
+		}
+		delete(drops, name)
+	}
+
+	for len(drops) > 0 {
+		var progress bool
+		for _, name := range names {
+			if drops[name] != nil && len(referencedBy[name]) == 0 {
+				appendDrop(name)
+				progress = true
+			}
+		}
+		if progress {
+			continue
+		}
+		// All remaining tables are part of, or depend on, a reference cycle. Drop
+		// the foreign keys among remaining tables first, and then the tables.
+		for _, name := range names {
+			if drops[name] == nil {
+				continue
+			}
+			table := drops[name].From
+			fakeTo := &Table{}
+			*fakeTo = *table
+			fakeTo.ForeignKeys = nil
+			var clauses []TableAlterClause
+			for _, fk := range table.ForeignKeys {
+				if ref := fk.ReferencedTableName; fk.ReferencedSchemaName == "" && ref != name && drops[ref] != nil {
+					clauses = append(clauses, DropForeignKey{ForeignKey: fk})
+				} else {
+					fakeTo.ForeignKeys = append(fakeTo.ForeignKeys, fk)
+				}
+			}
+			if len(clauses) > 0 {
+				result = append(result, &TableDiff{
+					Type:         DiffTypeAlter,
+					From:         table,
+					To:           fakeTo,
+					alterClauses: clauses,
+					supported:    true,
+				})
+			}
+		}
+		for _, name := range names {
+			if drops[name] != nil {
+				appendDrop(name)
+			}
+		}
+	}
+	sd.TableDiffs = result
+}
+
 ///// DatabaseDiff /////////////////////////////////////////////////////////////
 
 // DatabaseDiff represents differences of schema characteristics (default
-// character set or default collation), or a difference in the existence of the
-// the schema.
+// character set, default collation, or comment), or a difference in the
+// existence of the the schema.
 type DatabaseDiff struct {
 	From *Schema
 	To   *Schema
@@ -274,7 +384,7 @@ func (dd *DatabaseDiff) DiffType() DiffType {
 		return DiffTypeDrop
 	}
 
-	if dd.From.CharSet != dd.To.CharSet || dd.From.Collation != dd.To.Collation {
+	if dd.From.CharSet != dd.To.CharSet || dd.From.Collation != dd.To.Collation || dd.From.Comment != dd.To.Comment {
 		return DiffTypeAlter
 	}
 	return DiffTypeNone
@@ -297,7 +407,14 @@ func (dd *DatabaseDiff) Statement(_ StatementModifiers) (string, error) {
 		}
 		return stmt, err
 	case DiffTypeAlter:
-		return dd.From.AlterStatement(dd.To.CharSet, dd.To.Collation), nil
+		stmt := dd.From.AlterStatement(dd.To.CharSet, dd.To.Collation)
+		if dd.From.Comment != dd.To.Comment {
+			if stmt == "" {
+				stmt = "ALTER DATABASE " + EscapeIdentifier(dd.From.Name)
+			}
+			stmt += fmt.Sprintf(" COMMENT '%s'", EscapeValueForCreateTable(dd.To.Comment))
+		}
+		return stmt, nil
 	}
 	return "", nil
 }
@@ -334,6 +451,25 @@ func (td *TableDiff) DiffType() DiffType {
 	return td.Type
 }
 
+// Risk returns the highest RiskLevel among the operations in td. CREATE TABLE
+// is always RiskMetadata, and DROP TABLE is always RiskDestructive.
+func (td *TableDiff) Risk() RiskLevel {
+	switch td.DiffType() {
+	case DiffTypeDrop:
+		return RiskDestructive
+	case DiffTypeAlter:
+		risk := RiskMetadata
+		for _, clause := range td.alterClauses {
+			if clauseRisk := ClauseRisk(clause); clauseRisk > risk {
+				risk = clauseRisk
+			}
+		}
+		return risk
+	default:
+		return RiskMetadata
+	}
+}
+
 // NewCreateTable returns a *TableDiff representing a CREATE TABLE statement,
 // i.e. a table that only exists in the "to" side schema in a diff.
 func NewCreateTable(table *Table) *TableDiff {
@@ -362,6 +498,19 @@ func NewAlterTable(from, to *Table) *TableDiff {
 	}
 }
 
+// NewRebuildTable returns a *TableDiff representing an ALTER TABLE ... FORCE
+// statement, which rebuilds the table without changing its definition. This
+// is a maintenance operation, rather than the result of comparing two tables.
+func NewRebuildTable(table *Table) *TableDiff {
+	return &TableDiff{
+		Type:         DiffTypeAlter,
+		From:         table,
+		To:           table,
+		alterClauses: []TableAlterClause{ForceRebuild{}},
+		supported:    true,
+	}
+}
+
 // NewDropTable returns a *TableDiff representing a DROP TABLE statement,
 // i.e. a table that only exists in the "from" side schema in a diff.
 func NewDropTable(table *Table) *TableDiff {
@@ -454,6 +603,35 @@ func (td *TableDiff) SplitAddForeignKeys() (*TableDiff, *TableDiff) {
 	return result1, result2
 }
 
+// AddedForeignKeys returns the foreign keys added by an ALTER TABLE, excluding
+// any which are only being dropped and re-added for cosmetic reasons. The
+// result is nil for CREATE or DROP diffs.
+func (td *TableDiff) AddedForeignKeys() (fks []*ForeignKey) {
+	if td.Type != DiffTypeAlter {
+		return nil
+	}
+	for _, clause := range td.alterClauses {
+		if afk, ok := clause.(AddForeignKey); ok && !afk.cosmeticOnly {
+			fks = append(fks, afk.ForeignKey)
+		}
+	}
+	return fks
+}
+
+// onlyAddsForeignKeys returns true if the receiver is an ALTER TABLE which
+// consists solely of AddForeignKey clauses.
+func (td *TableDiff) onlyAddsForeignKeys() bool {
+	if td.Type != DiffTypeAlter || len(td.alterClauses) == 0 {
+		return false
+	}
+	for _, clause := range td.alterClauses {
+		if _, ok := clause.(AddForeignKey); !ok {
+			return false
+		}
+	}
+	return true
+}
+
 // SplitConflicts looks through a TableDiff's alterClauses and pulls out any
 // clauses that need to be placed into a separate TableDiff in order to yield
 // legal or error-free DDL. Currently this only handles attempts to add multiple
@@ -525,10 +703,25 @@ func (td *TableDiff) Statement(mods StatementModifiers) (string, error) {
 		if td.To.HasAutoIncrement() && (mods.NextAutoInc == NextAutoIncIgnore || mods.NextAutoInc == NextAutoIncIfAlready) {
 			stmt, _ = ParseCreateAutoInc(stmt)
 		}
+		if err := td.checkDefaultsSupported(td.To.Columns, mods.Flavor); err != nil {
+			return "", err
+		}
 		return stmt, nil
 	case DiffTypeAlter:
 		return td.alterStatement(mods)
 	case DiffTypeDrop:
+		// With DropTableRenamePrefix, rename the table instead of dropping it. Tables
+		// which were already renamed this way are left alone, unless they were
+		// renamed longer ago than DropTableRenameRetain.
+		if prefix := mods.DropTableRenamePrefix; prefix != "" {
+			if newName := td.DroppedTableName(mods); newName != "" {
+				return td.From.RenameForDropStatement(prefix, dropTableRenameTime(mods)), nil
+			}
+			_, renamed, _ := ParseDroppedTableName(prefix, td.From.Name)
+			if mods.DropTableRenameRetain <= 0 || dropTableRenameTime(mods).Sub(renamed) < mods.DropTableRenameRetain {
+				return "", nil
+			}
+		}
 		stmt := td.From.DropStatement()
 		if !mods.AllowUnsafe {
 			err = &ForbiddenDiffError{
@@ -542,6 +735,42 @@ func (td *TableDiff) Statement(mods StatementModifiers) (string, error) {
 	}
 }
 
+// DroppedTableName returns the new name of the table, if td is a DROP which
+// mods.DropTableRenamePrefix converts into a RENAME. Otherwise, an empty
+// string is returned.
+func (td *TableDiff) DroppedTableName(mods StatementModifiers) string {
+	if td == nil || td.Type != DiffTypeDrop || mods.DropTableRenamePrefix == "" {
+		return ""
+	} else if _, _, already := ParseDroppedTableName(mods.DropTableRenamePrefix, td.From.Name); already {
+		return ""
+	}
+	return DroppedTableName(mods.DropTableRenamePrefix, td.From.Name, dropTableRenameTime(mods))
+}
+
+// checkDefaultsSupported returns an *UnsupportedDefaultError if any of cols has
+// a DEFAULT clause which flavor would reject, or nil otherwise.
+func (td *TableDiff) checkDefaultsSupported(cols []*Column, flavor Flavor) error {
+	for _, col := range cols {
+		if !col.defaultSupported(flavor) {
+			return &UnsupportedDefaultError{
+				ObjectKey: td.ObjectKey(),
+				Column:    col.Name,
+				Flavor:    flavor,
+			}
+		}
+	}
+	return nil
+}
+
+// dropTableRenameTime returns the timestamp to use in DropTableRenamePrefix
+// renames.
+func dropTableRenameTime(mods StatementModifiers) time.Time {
+	if mods.DropTableRenameTime.IsZero() {
+		return time.Now()
+	}
+	return mods.DropTableRenameTime
+}
+
 // Clauses returns the body of the statement represented by the table diff.
 // For DROP statements, this will be an empty string. For CREATE statements,
 // it will be everything after "CREATE TABLE [name] ". For ALTER statements,
@@ -565,6 +794,65 @@ func (td *TableDiff) Clauses(mods StatementModifiers) (string, error) {
 	}
 }
 
+// Explain returns short human-readable descriptions of the changes made by
+// the statement represented by the table diff, such as "modify column `email`:
+// varchar(255) -> varchar(320)". For ALTER statements, there is one entry per
+// clause that is not a no-op under mods. For CREATE and DROP statements, a
+// single entry is returned. Descriptions never contain newlines.
+func (td *TableDiff) Explain(mods StatementModifiers) []string {
+	var result []string
+	switch td.Type {
+	case DiffTypeCreate:
+		result = []string{"create table " + EscapeIdentifier(td.To.Name)}
+	case DiffTypeDrop:
+		result = []string{"drop table " + EscapeIdentifier(td.From.Name)}
+	case DiffTypeAlter:
+		for _, clause := range td.alterClauses {
+			if clauseString := clause.Clause(mods); clauseString != "" {
+				result = append(result, explainClause(clause, clauseString))
+			}
+		}
+	}
+	newlineStripper := strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ")
+	for n := range result {
+		result[n] = newlineStripper.Replace(result[n])
+	}
+	return result
+}
+
+// Warnings returns human-readable warnings about clauses of an ALTER TABLE
+// which may fail or may modify existing values, such as adding AUTO_INCREMENT
+// to an existing column, converting a column to JSON, adding a UNIQUE index
+// on columns which weren't already unique, or changing page compression.
+// Clauses which are omitted due to mods do not generate warnings. CREATE and
+// DROP statements never have warnings.
+func (td *TableDiff) Warnings(mods StatementModifiers) (warnings []string) {
+	if td.Type != DiffTypeAlter {
+		return nil
+	}
+	for _, clause := range td.alterClauses {
+		if clause.Clause(mods) == "" {
+			continue
+		}
+		if mc, ok := clause.(ModifyColumn); ok {
+			for _, warning := range []string{mc.AutoIncrementWarning(), mc.JSONConversionWarning()} {
+				if warning != "" {
+					warnings = append(warnings, warning)
+				}
+			}
+		} else if ai, ok := clause.(AddIndex); ok {
+			if warning := ai.UniqueWarning(td.From); warning != "" {
+				warnings = append(warnings, warning)
+			}
+		} else if cco, ok := clause.(ChangeCreateOptions); ok {
+			if warning := cco.CompressionWarning(mods.InnoDBPageSize); warning != "" {
+				warnings = append(warnings, warning)
+			}
+		}
+	}
+	return warnings
+}
+
 func (td *TableDiff) alterStatement(mods StatementModifiers) (string, error) {
 	if !td.supported {
 		if td.To.UnsupportedDDL {
@@ -597,6 +885,22 @@ func (td *TableDiff) alterStatement(mods StatementModifiers) (string, error) {
 		}
 	}
 
+	// BLOB, TEXT, JSON, and spatial columns may only have a DEFAULT in some
+	// flavors, and MySQL only permits expression defaults for these types. Avoid
+	// generating DDL that the target flavor would reject.
+	var changedCols []*Column
+	for _, clause := range td.alterClauses {
+		switch clause := clause.(type) {
+		case AddColumn:
+			changedCols = append(changedCols, clause.Column)
+		case ModifyColumn:
+			changedCols = append(changedCols, clause.NewColumn)
+		}
+	}
+	if err := td.checkDefaultsSupported(changedCols, mods.Flavor); err != nil {
+		return "", err
+	}
+
 	// Force StrictIndexOrder to be enabled for InnoDB tables that have no primary
 	// key and at least one unique index with non-nullable columns
 	if !mods.StrictIndexOrder && td.To.Engine == "InnoDB" && td.To.ClusteredIndexKey() != td.To.PrimaryKey {
@@ -621,7 +925,7 @@ func (td *TableDiff) alterStatement(mods StatementModifiers) (string, error) {
 				// Adding or removing partitioning must occur at the end of the ALTER
 				// TABLE, and oddly *without* a preceeding comma
 				partitionClauseString = clauseString
-			case ModifyPartitions:
+			case ModifyPartitions, ChangePartitionCount:
 				// Other partitioning-related clauses cannot appear alongside any other
 				// clauses, including ALGORITHM or LOCK clauses
 				mods.LockClause = ""
@@ -636,6 +940,12 @@ func (td *TableDiff) alterStatement(mods StatementModifiers) (string, error) {
 		return "", nil
 	}
 
+	// With foreign_key_checks=0, MySQL 5.6+ and MariaDB can add foreign keys
+	// in-place, without copying the table or validating its existing rows. This
+	// is only permitted if the ALTER doesn't also require a table copy.
+	if mods.UnvalidatedForeignKeys && mods.AlgorithmClause == "" && td.onlyAddsForeignKeys() && (mods.Flavor.Min(FlavorMySQL56) || mods.Flavor.IsMariaDB()) {
+		mods.AlgorithmClause = "inplace"
+	}
 	if mods.LockClause != "" {
 		lockClause := fmt.Sprintf("LOCK=%s", strings.ToUpper(mods.LockClause))
 		clauseStrings = append([]string{lockClause}, clauseStrings...)
@@ -785,6 +1095,20 @@ func IsForbiddenDiff(err error) bool {
 	return ok
 }
 
+// UnsupportedDefaultError is returned by TableDiff.Statement if a column being
+// created or modified has a DEFAULT clause which the target flavor would
+// reject.
+type UnsupportedDefaultError struct {
+	ObjectKey ObjectKey
+	Column    string
+	Flavor    Flavor
+}
+
+// Error satisfies the builtin error interface.
+func (e *UnsupportedDefaultError) Error() string {
+	return fmt.Sprintf("%s: column %s has a DEFAULT which is not supported for its type in %s. BLOB, TEXT, JSON, and spatial columns require MySQL 8.0.13+ (default expressions only) or MariaDB 10.2+ to have a DEFAULT.", e.ObjectKey, EscapeIdentifier(e.Column), e.Flavor)
+}
+
 // UnsupportedDiffError can be returned by ObjectDiff.Statement if Tengo is
 // unable to transform the object due to use of unsupported features.
 type UnsupportedDiffError struct {
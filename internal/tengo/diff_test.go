@@ -2,8 +2,11 @@ package tengo
 
 import (
 	"fmt"
+	"reflect"
+	"sort"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestSchemaDiffEmpty(t *testing.T) {
@@ -90,6 +93,13 @@ func TestSchemaDiffDatabaseDiff(t *testing.T) {
 	s1.CharSet = "utf8mb4"
 	assertDiffSchemaDDL(&s1, &s2, "ALTER DATABASE `s1` CHARACTER SET latin1 COLLATE latin1_swedish_ci")
 	assertDiffSchemaDDL(&s2, &s1, "ALTER DATABASE `s2` CHARACTER SET utf8mb4 COLLATE utf8mb4_bin")
+
+	s1.Comment = "it's a comment"
+	assertDiffSchemaDDL(nil, &s1, "CREATE DATABASE `s1` CHARACTER SET utf8mb4 COLLATE utf8mb4_bin COMMENT 'it''s a comment'")
+	assertDiffSchemaDDL(&s2, &s1, "ALTER DATABASE `s2` CHARACTER SET utf8mb4 COLLATE utf8mb4_bin COMMENT 'it''s a comment'")
+	assertDiffSchemaDDL(&s1, &s2, "ALTER DATABASE `s1` CHARACTER SET latin1 COLLATE latin1_swedish_ci COMMENT ''")
+	s2.CharSet, s2.Collation = s1.CharSet, s1.Collation
+	assertDiffSchemaDDL(&s2, &s1, "ALTER DATABASE `s2` COMMENT 'it''s a comment'")
 }
 
 func TestSchemaDiffAddOrDropTable(t *testing.T) {
@@ -188,6 +198,74 @@ func TestSchemaDiffAddOrDropTable(t *testing.T) {
 	}
 }
 
+func TestTableDiffDropRename(t *testing.T) {
+	when := time.Date(2023, 4, 5, 6, 7, 8, 0, time.UTC)
+	mods := StatementModifiers{
+		AllowUnsafe:           true,
+		DropTableRenamePrefix: "_dropped_",
+		DropTableRenameTime:   when,
+	}
+	table := aTable(1)
+	td := NewDropTable(&table)
+	if stmt, err := td.Statement(mods); err != nil || stmt != "RENAME TABLE `actor` TO `_dropped_actor_20230405060708`" {
+		t.Errorf("Unexpected result from Statement(): %q, %v", stmt, err)
+	}
+
+	if newName := td.DroppedTableName(mods); newName != "_dropped_actor_20230405060708" {
+		t.Errorf("Unexpected result from DroppedTableName(): %q", newName)
+	}
+
+	// Renames are not destructive, so they don't require AllowUnsafe
+	mods.AllowUnsafe = false
+	if stmt, err := td.Statement(mods); err != nil || stmt != "RENAME TABLE `actor` TO `_dropped_actor_20230405060708`" {
+		t.Errorf("Unexpected result from Statement(): %q, %v", stmt, err)
+	}
+
+	// A table which was previously renamed this way is left alone, unless it was
+	// renamed longer ago than DropTableRenameRetain
+	table.Name = DroppedTableName("_dropped_", "actor", when.Add(-48*time.Hour))
+	if newName := td.DroppedTableName(mods); newName != "" {
+		t.Errorf("Expected DroppedTableName() to return empty string, instead found %q", newName)
+	}
+	if stmt, err := td.Statement(mods); err != nil || stmt != "" {
+		t.Errorf("Expected blank statement without DropTableRenameRetain, instead found %q, %v", stmt, err)
+	}
+	mods.DropTableRenameRetain = 72 * time.Hour
+	if stmt, err := td.Statement(mods); err != nil || stmt != "" {
+		t.Errorf("Expected blank statement within DropTableRenameRetain, instead found %q, %v", stmt, err)
+	}
+	mods.DropTableRenameRetain = 24 * time.Hour
+	if stmt, err := td.Statement(mods); !IsForbiddenDiff(err) || stmt != "DROP TABLE `_dropped_actor_20230403060708`" {
+		t.Errorf("Expected forbidden DROP after DropTableRenameRetain, instead found %q, %v", stmt, err)
+	}
+	mods.AllowUnsafe = true
+	if stmt, err := td.Statement(mods); err != nil || stmt != "DROP TABLE `_dropped_actor_20230403060708`" {
+		t.Errorf("Unexpected result from Statement(): %q, %v", stmt, err)
+	}
+
+	// Foreign keys are dropped as part of the rename, since their names must be
+	// unique per schema
+	fkTable := foreignKeyTable()
+	td = NewDropTable(&fkTable)
+	expected := "ALTER TABLE `" + fkTable.Name + "` DROP FOREIGN KEY `customer_fk`, DROP FOREIGN KEY `product_fk`, RENAME TO `_dropped_" + fkTable.Name + "_20230405060708`"
+	if stmt, err := td.Statement(mods); err != nil || stmt != expected {
+		t.Errorf("Unexpected result from Statement(): %q, %v", stmt, err)
+	}
+
+	// Pre-drop alters for partitioned tables are skipped, since the table is
+	// being renamed rather than dropped
+	partTable := partitionedTable(FlavorUnknown)
+	preDrops := PreDropAlters(&partTable)
+	if len(preDrops) == 0 {
+		t.Fatal("Expected partitioned table to have pre-drop alters")
+	}
+	for _, alter := range preDrops {
+		if stmt, err := alter.Statement(mods); stmt != "" || err != nil {
+			t.Errorf("Expected pre-drop alter to be blank with DropTableRenamePrefix, instead found %q, %v", stmt, err)
+		}
+	}
+}
+
 func TestSchemaDiffAlterTable(t *testing.T) {
 	// Helper method for testing various combinations of alters involving next-auto-inc changes
 	assertAutoIncAlter := func(from, to uint64, nextAutoInc NextAutoIncMode, expectAlter bool) {
@@ -277,6 +355,92 @@ func TestSchemaDiffAlterTable(t *testing.T) {
 	}
 }
 
+func TestSchemaDiffInheritedCollation(t *testing.T) {
+	// t1 states the schema's default charset and collation explicitly, while t2
+	// inherits them from the schema. This should not result in any diff.
+	t1 := anotherTable()
+	t2 := anotherTable()
+	t2.CharSet, t2.Collation = "", ""
+	t2.CreateStatement = strings.Replace(t2.CreateStatement, " DEFAULT CHARSET=latin1", "", 1)
+	s1 := aSchema("s1", &t1)
+	s2 := aSchema("s2", &t2)
+	if sd := NewSchemaDiff(&s1, &s2); len(sd.TableDiffs) != 0 {
+		t.Errorf("Expected no table diffs, instead found %d: %v", len(sd.TableDiffs), sd.TableDiffs)
+	}
+	if sd := NewSchemaDiff(&s2, &s1); len(sd.TableDiffs) != 0 {
+		t.Errorf("Expected no table diffs, instead found %d: %v", len(sd.TableDiffs), sd.TableDiffs)
+	}
+
+	// Inheriting only the charset from the schema works the same way
+	t2.CharSet = "latin1"
+	if sd := NewSchemaDiff(&s1, &s2); len(sd.TableDiffs) != 0 {
+		t.Errorf("Expected no table diffs, instead found %d: %v", len(sd.TableDiffs), sd.TableDiffs)
+	}
+
+	// If the effective collation actually changes, an ALTER should be emitted
+	s2.Collation = "latin1_general_ci"
+	sd := NewSchemaDiff(&s1, &s2)
+	if len(sd.TableDiffs) != 1 {
+		t.Fatalf("Expected 1 table diff, instead found %d", len(sd.TableDiffs))
+	}
+	if stmt, err := sd.TableDiffs[0].Statement(StatementModifiers{}); err != nil || !strings.Contains(stmt, "COLLATE = latin1_general_ci") {
+		t.Errorf("Unexpected result from Statement: %q / %v", stmt, err)
+	}
+
+	// A differing explicit charset without a collation can't be resolved
+	t2.CharSet = "utf8mb4"
+	if charSet, collation := t2.EffectiveCollation(&s2); charSet != "utf8mb4" || collation != "" {
+		t.Errorf("Unexpected result from EffectiveCollation: %q, %q", charSet, collation)
+	}
+}
+
+func TestSchemaDiffOrderDrops(t *testing.T) {
+	fkTo := func(name, referencedTable string) *ForeignKey {
+		return &ForeignKey{Name: name, ColumnNames: []string{"id"}, ReferencedTableName: referencedTable, ReferencedColumnNames: []string{"id"}}
+	}
+	// parent <- child <- grandchild; cyc1 <-> cyc2; self references itself
+	tables := []*Table{
+		{Name: "parent"},
+		{Name: "child", ForeignKeys: []*ForeignKey{fkTo("child_fk", "parent")}},
+		{Name: "grandchild", ForeignKeys: []*ForeignKey{fkTo("grandchild_fk", "child")}},
+		{Name: "cyc1", ForeignKeys: []*ForeignKey{fkTo("cyc1_fk", "cyc2")}},
+		{Name: "cyc2", ForeignKeys: []*ForeignKey{fkTo("cyc2_fk", "cyc1"), fkTo("cyc2_parent", "parent")}},
+		{Name: "self", ForeignKeys: []*ForeignKey{fkTo("self_fk", "self")}},
+	}
+	kept := anotherTable()
+	keptChanged := anotherTable()
+	keptChanged.Comment = "hello world"
+	keptChanged.CreateStatement = keptChanged.GeneratedCreateStatement(FlavorUnknown)
+	s1 := aSchema("s1", append(tables, &kept)...)
+	s2 := aSchema("s2", &keptChanged)
+	sd := NewSchemaDiff(&s1, &s2)
+	sd.OrderDrops()
+
+	mods := StatementModifiers{AllowUnsafe: true}
+	var actual []string
+	for _, td := range sd.TableDiffs {
+		stmt, err := td.Statement(mods)
+		if err != nil {
+			t.Fatalf("Unexpected error from Statement: %v", err)
+		}
+		actual = append(actual, stmt)
+	}
+	expected := []string{
+		"ALTER TABLE `actor_in_film` COMMENT 'hello world'",
+		"DROP TABLE `grandchild`",
+		"DROP TABLE `self`",
+		"DROP TABLE `child`",
+		"ALTER TABLE `cyc1` DROP FOREIGN KEY `cyc1_fk`",
+		"ALTER TABLE `cyc2` DROP FOREIGN KEY `cyc2_fk`, DROP FOREIGN KEY `cyc2_parent`",
+		"DROP TABLE `cyc1`",
+		"DROP TABLE `cyc2`",
+		"DROP TABLE `parent`",
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Unexpected statement order from OrderDrops:\nexpected %v\nfound    %v", expected, actual)
+	}
+}
+
 func TestSchemaDiffForeignKeys(t *testing.T) {
 	s1t1 := anotherTable()
 	s1t2 := foreignKeyTable()
@@ -745,6 +909,86 @@ func TestTableDiffClauses(t *testing.T) {
 	}
 }
 
+func TestTableDiffExplain(t *testing.T) {
+	mods := StatementModifiers{AllowUnsafe: true}
+	t1 := aTable(1)
+	if explanation := NewCreateTable(&t1).Explain(mods); !reflect.DeepEqual(explanation, []string{"create table `actor`"}) {
+		t.Errorf("Unexpected result for Explain on create table: %v", explanation)
+	}
+	if explanation := NewDropTable(&t1).Explain(mods); !reflect.DeepEqual(explanation, []string{"drop table `actor`"}) {
+		t.Errorf("Unexpected result for Explain on drop table: %v", explanation)
+	}
+
+	// Auto-increment changes are suppressed by default mods, so no explanation
+	t2 := aTable(5)
+	if explanation := NewAlterTable(&t1, &t2).Explain(mods); len(explanation) != 0 {
+		t.Errorf("Expected no explanation for noop alter, instead found %v", explanation)
+	}
+
+	t2 = aTable(1)
+	t2.Columns[1].TypeInDB = "varchar(60)"
+	t2.Columns[2].Default = "'multi\nline'"
+	t2.Columns = t2.Columns[0 : len(t2.Columns)-1]
+	t2.SecondaryIndexes = t2.SecondaryIndexes[0:1]
+	t2.CreateStatement = t2.GeneratedCreateStatement(FlavorUnknown)
+	expected := []string{
+		"drop column `alive_bit`",
+		"drop index `idx_actor_name`",
+		"modify column `first_name`: varchar(45) -> varchar(60)",
+		"modify column `last_name`: default NULL -> 'multi line'",
+	}
+	explanation := NewAlterTable(&t1, &t2).Explain(mods)
+	sort.Strings(explanation)
+	if !reflect.DeepEqual(explanation, expected) {
+		t.Errorf("Unexpected result for Explain on alter table:\nExpected: %v\nActual:   %v", expected, explanation)
+	}
+}
+
+func TestTableDiffRisk(t *testing.T) {
+	t1 := aTable(1)
+	t2 := aTable(5)
+	if risk := NewCreateTable(&t1).Risk(); risk != RiskMetadata {
+		t.Errorf("Expected CREATE TABLE to have risk %s, instead found %s", RiskMetadata, risk)
+	}
+	if risk := NewDropTable(&t1).Risk(); risk != RiskDestructive {
+		t.Errorf("Expected DROP TABLE to have risk %s, instead found %s", RiskDestructive, risk)
+	}
+	if risk := NewAlterTable(&t1, &t2).Risk(); risk != RiskMetadata {
+		t.Errorf("Expected auto-increment change to have risk %s, instead found %s", RiskMetadata, risk)
+	}
+
+	// Adding an index requires building it; dropping a column destroys data
+	t2.SecondaryIndexes = t2.SecondaryIndexes[0:1]
+	t2.CreateStatement = t2.GeneratedCreateStatement(FlavorUnknown)
+	if risk := NewAlterTable(&t2, &t1).Risk(); risk != RiskRebuild {
+		t.Errorf("Expected ADD KEY to have risk %s, instead found %s", RiskRebuild, risk)
+	}
+	t2.Columns = t2.Columns[0 : len(t2.Columns)-1]
+	t2.CreateStatement = t2.GeneratedCreateStatement(FlavorUnknown)
+	if risk := NewAlterTable(&t1, &t2).Risk(); risk != RiskDestructive {
+		t.Errorf("Expected DROP COLUMN to have risk %s, instead found %s", RiskDestructive, risk)
+	}
+}
+
+func TestNewRebuildTable(t *testing.T) {
+	table := aTable(1)
+	td := NewRebuildTable(&table)
+	if td.DiffType() != DiffTypeAlter {
+		t.Errorf("Expected diff type %s, instead found %s", DiffTypeAlter, td.DiffType())
+	}
+	if risk := td.Risk(); risk != RiskRebuild {
+		t.Errorf("Expected rebuild to have risk %s, instead found %s", RiskRebuild, risk)
+	}
+	expected := "ALTER TABLE `actor` FORCE"
+	if stmt, err := td.Statement(StatementModifiers{}); err != nil || stmt != expected {
+		t.Errorf("Unexpected result from Statement: %q / %v", stmt, err)
+	}
+	expected = "ALTER TABLE `actor` ALGORITHM=INPLACE, FORCE"
+	if stmt, err := td.Statement(StatementModifiers{AlgorithmClause: "inplace"}); err != nil || stmt != expected {
+		t.Errorf("Unexpected result from Statement: %q / %v", stmt, err)
+	}
+}
+
 func TestAlterTableStatementAllowUnsafeMods(t *testing.T) {
 	t1 := aTable(1)
 	t2 := aTable(1)
@@ -844,6 +1088,52 @@ func TestAlterTableStatementOnlineMods(t *testing.T) {
 	}
 }
 
+func TestAlterTableStatementUnvalidatedForeignKeys(t *testing.T) {
+	from := anotherTable()
+	to := anotherTable()
+	to.ForeignKeys = append(to.ForeignKeys, &ForeignKey{
+		Name:                  "actor_fk",
+		ColumnNames:           []string{to.Columns[0].Name},
+		ReferencedTableName:   "actor",
+		ReferencedColumnNames: []string{"actor_id"},
+	})
+	to.CreateStatement = to.GeneratedCreateStatement(FlavorUnknown)
+	alter := NewAlterTable(&from, &to)
+	if fks := alter.AddedForeignKeys(); len(fks) != 1 || fks[0] != to.ForeignKeys[len(to.ForeignKeys)-1] {
+		t.Errorf("Unexpected result from AddedForeignKeys: %v", fks)
+	}
+
+	cases := []struct {
+		flavor    Flavor
+		algorithm string
+		expected  string
+	}{
+		{FlavorMySQL80, "", "ALGORITHM=INPLACE, "},
+		{FlavorPercona57, "", "ALGORITHM=INPLACE, "},
+		{FlavorMariaDB105, "", "ALGORITHM=INPLACE, "},
+		{FlavorMySQL55, "", ""},
+		{FlavorUnknown, "", ""},
+		{FlavorMySQL80, "copy", "ALGORITHM=COPY, "},
+	}
+	for _, c := range cases {
+		mods := StatementModifiers{Flavor: c.flavor, AlgorithmClause: c.algorithm, UnvalidatedForeignKeys: true}
+		expected := fmt.Sprintf("ALTER TABLE `%s` %s%s", from.Name, c.expected, alter.alterClauses[0].Clause(mods))
+		if stmt, err := alter.Statement(mods); stmt != expected || err != nil {
+			t.Errorf("Unexpected result for %s with alter-algorithm=%q\n    Expected: %s\n    Found:    %s (err=%v)", c.flavor, c.algorithm, expected, stmt, err)
+		}
+	}
+
+	// ALTERs with other clauses don't receive the ALGORITHM clause, since adding
+	// a column may require a table copy in some flavors
+	to.Columns = append(to.Columns, &Column{Name: "something", TypeInDB: "smallint(5) unsigned"})
+	to.CreateStatement = to.GeneratedCreateStatement(FlavorUnknown)
+	alter = NewAlterTable(&from, &to)
+	mods := StatementModifiers{Flavor: FlavorMySQL80, UnvalidatedForeignKeys: true}
+	if stmt, _ := alter.Statement(mods); strings.Contains(stmt, "ALGORITHM") {
+		t.Errorf("Expected no ALGORITHM clause for ALTER with other clauses, instead found %s", stmt)
+	}
+}
+
 func TestAlterTableStatementVirtualColValidation(t *testing.T) {
 	from, to := aTable(1), aTable(1)
 
@@ -0,0 +1,171 @@
+package tengo
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ERDiagramOptions controls the output of Schema.ERDiagram.
+type ERDiagramOptions struct {
+	IncludeColumns bool           // if true, list each table's columns and key markers
+	TableFilter    *regexp.Regexp // if non-nil, only tables with matching names are included
+}
+
+// reMermaidName matches names which may be used unquoted in Mermaid erDiagram
+// syntax.
+var reMermaidName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// mermaidEntityName returns name in a form usable as a Mermaid entity name,
+// quoting it if necessary. Mermaid has no escape mechanism for double quotes,
+// so these are stripped.
+func mermaidEntityName(name string) string {
+	if reMermaidName.MatchString(name) {
+		return name
+	}
+	return `"` + strings.ReplaceAll(name, `"`, "") + `"`
+}
+
+// mermaidAttrWord returns s in a form usable as a Mermaid attribute type or
+// name, which must be a single word. Unsupported characters are replaced with
+// underscores.
+func mermaidAttrWord(s string) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= 'A' && r <= 'Z') || (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '_' || r == '-' {
+			return r
+		}
+		return '_'
+	}, s)
+}
+
+// ERDiagram returns a text representation of the schema's tables and foreign
+// keys in Mermaid erDiagram syntax, suitable for writing to a file for use in
+// auto-generated documentation. Each foreign key is rendered as a relationship
+// from the referenced (parent) table to the child table. Foreign keys which
+// reference a table in another schema are rendered using a schema-qualified
+// entity name; foreign keys referencing a same-schema table excluded by
+// opts.TableFilter are omitted.
+func (s *Schema) ERDiagram(opts ERDiagramOptions) string {
+	var b strings.Builder
+	b.WriteString("erDiagram\n")
+
+	included := make(map[string]bool, len(s.Tables))
+	tables := make([]*Table, 0, len(s.Tables))
+	for _, t := range s.Tables {
+		if opts.TableFilter == nil || opts.TableFilter.MatchString(t.Name) {
+			included[t.Name] = true
+			tables = append(tables, t)
+		}
+	}
+
+	for _, t := range tables {
+		if !opts.IncludeColumns {
+			fmt.Fprintf(&b, "    %s {\n    }\n", mermaidEntityName(t.Name))
+			continue
+		}
+		keyMarkers := erDiagramKeyMarkers(t)
+		fmt.Fprintf(&b, "    %s {\n", mermaidEntityName(t.Name))
+		for _, col := range t.Columns {
+			baseType, _, _ := strings.Cut(col.TypeInDB, "(")
+			baseType, _, _ = strings.Cut(baseType, " ")
+			fmt.Fprintf(&b, "        %s %s", mermaidAttrWord(baseType), mermaidAttrWord(col.Name))
+			if markers := keyMarkers[col.Name]; len(markers) > 0 {
+				fmt.Fprintf(&b, " %s", strings.Join(markers, ", "))
+			}
+			b.WriteByte('\n')
+		}
+		b.WriteString("    }\n")
+	}
+
+	for _, t := range tables {
+		cols := t.ColumnsByName()
+		for _, fk := range t.ForeignKeys {
+			parent := fk.ReferencedTableName
+			if fk.ReferencedSchemaName != "" && fk.ReferencedSchemaName != s.Name {
+				parent = fk.ReferencedSchemaName + "." + parent
+			} else if !included[parent] {
+				continue
+			}
+			// Parent side: exactly one if all FK columns are NOT NULL, otherwise zero
+			// or one. Child side: zero or one if the FK columns are unique, otherwise
+			// zero or more.
+			parentSide := "||"
+			for _, colName := range fk.ColumnNames {
+				if col := cols[colName]; col == nil || col.Nullable {
+					parentSide = "|o"
+					break
+				}
+			}
+			childSide := "o{"
+			if erDiagramUniqueColumns(t, fk.ColumnNames) {
+				childSide = "o|"
+			}
+			fmt.Fprintf(&b, "    %s %s--%s %s : %q\n", mermaidEntityName(parent), parentSide, childSide, mermaidEntityName(t.Name), strings.ReplaceAll(fk.Name, `"`, ""))
+		}
+	}
+	return b.String()
+}
+
+// erDiagramKeyMarkers returns a map of column name to Mermaid key markers (PK,
+// FK, UK) for table t.
+func erDiagramKeyMarkers(t *Table) map[string][]string {
+	markers := make(map[string][]string)
+	if t.PrimaryKey != nil {
+		for _, part := range t.PrimaryKey.Parts {
+			if part.ColumnName != "" {
+				markers[part.ColumnName] = append(markers[part.ColumnName], "PK")
+			}
+		}
+	}
+	for _, fk := range t.ForeignKeys {
+		for _, colName := range fk.ColumnNames {
+			if m := markers[colName]; len(m) == 0 || m[len(m)-1] != "FK" {
+				markers[colName] = append(m, "FK")
+			}
+		}
+	}
+	for _, idx := range t.SecondaryIndexes {
+		if !idx.Unique {
+			continue
+		}
+		for _, part := range idx.Parts {
+			if part.ColumnName == "" {
+				continue
+			}
+			if m := markers[part.ColumnName]; len(m) == 0 || m[len(m)-1] != "UK" {
+				markers[part.ColumnName] = append(m, "UK")
+			}
+		}
+	}
+	return markers
+}
+
+// erDiagramUniqueColumns returns true if t has a primary key or unique index
+// consisting of exactly the supplied columns, in any order, without prefix
+// lengths.
+func erDiagramUniqueColumns(t *Table, colNames []string) bool {
+	indexes := t.SecondaryIndexes
+	if t.PrimaryKey != nil {
+		indexes = append([]*Index{t.PrimaryKey}, indexes...)
+	}
+	for _, idx := range indexes {
+		if (!idx.PrimaryKey && !idx.Unique) || len(idx.Parts) != len(colNames) {
+			continue
+		}
+		wanted := make(map[string]bool, len(colNames))
+		for _, colName := range colNames {
+			wanted[colName] = true
+		}
+		matches := true
+		for _, part := range idx.Parts {
+			if part.PrefixLength > 0 || !wanted[part.ColumnName] {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return true
+		}
+	}
+	return false
+}
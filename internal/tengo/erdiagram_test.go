@@ -0,0 +1,52 @@
+package tengo
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestSchemaERDiagram(t *testing.T) {
+	warranties := foreignKeyTable()
+	productCols := []*Column{
+		{Name: "line", TypeInDB: "char(12)"},
+		{Name: "model", TypeInDB: "int(10) unsigned"},
+		{Name: "name", TypeInDB: "varchar(100)", Nullable: true},
+	}
+	products := Table{
+		Name:       "products",
+		Columns:    productCols,
+		PrimaryKey: primaryKey(productCols[0], productCols[1]),
+	}
+	s := aSchema("warehouse", &products, &warranties)
+
+	expected := `erDiagram
+    products {
+        char line PK
+        int model PK
+        varchar name
+    }
+    warranties {
+        int id PK
+        int customer_id FK
+        char product_line FK, UK
+        int model FK, UK
+    }
+    "purchasing.customers" |o--o{ warranties : "customer_fk"
+    products ||--o| warranties : "product_fk"
+`
+	if actual := s.ERDiagram(ERDiagramOptions{IncludeColumns: true}); actual != expected {
+		t.Errorf("Unexpected ERDiagram output.\nExpected:\n%s\nActual:\n%s", expected, actual)
+	}
+
+	// Filtering out the referenced table should omit the same-schema relationship,
+	// but not the cross-schema one
+	expected = `erDiagram
+    warranties {
+    }
+    "purchasing.customers" |o--o{ warranties : "customer_fk"
+`
+	opts := ERDiagramOptions{TableFilter: regexp.MustCompile("^warr")}
+	if actual := s.ERDiagram(opts); actual != expected {
+		t.Errorf("Unexpected ERDiagram output.\nExpected:\n%s\nActual:\n%s", expected, actual)
+	}
+}
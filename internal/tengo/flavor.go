@@ -397,6 +397,18 @@ func (fl Flavor) OmitIntDisplayWidth() bool {
 	return fl.Min(FlavorMySQL80.Dot(19))
 }
 
+// HasEngineAttributes returns true if the flavor supports ENGINE_ATTRIBUTE
+// and SECONDARY_ENGINE_ATTRIBUTE options on tables and columns.
+func (fl Flavor) HasEngineAttributes() bool {
+	return fl.Min(FlavorMySQL80.Dot(21))
+}
+
+// HasSchemaComments returns true if the flavor supports a COMMENT clause in
+// CREATE DATABASE and ALTER DATABASE.
+func (fl Flavor) HasSchemaComments() bool {
+	return fl.Min(FlavorMariaDB105)
+}
+
 // HasCheckConstraints returns true if the flavor supports check constraints
 // and exposes them in information_schema.
 func (fl Flavor) HasCheckConstraints() bool {
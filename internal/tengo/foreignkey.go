@@ -108,3 +108,105 @@ func (fk *ForeignKey) normalizedDeleteRule() string {
 	}
 	return fk.DeleteRule
 }
+
+// ColumnTypeMismatches compares the columns of fk, which must belong to table,
+// to the corresponding referenced columns in referencedTable. It returns a
+// description of each column pair whose types are incompatible in a way that
+// would cause creation of the foreign key to fail: differing base types, sizes
+// or precisions of integer, decimal, or temporal types, signedness, or
+// character set and collation of string types. String lengths are permitted
+// to differ. Missing columns on either side are also reported. A nil result
+// indicates no problems were found.
+func (fk *ForeignKey) ColumnTypeMismatches(table, referencedTable *Table) (mismatches []string) {
+	cols, refCols := table.ColumnsByName(), referencedTable.ColumnsByName()
+	for n, colName := range fk.ColumnNames {
+		col, ok := cols[colName]
+		if !ok {
+			mismatches = append(mismatches, fmt.Sprintf("column %s does not exist in table %s", EscapeIdentifier(colName), EscapeIdentifier(table.Name)))
+			continue
+		}
+		refColName := fk.ReferencedColumnNames[n]
+		refCol, ok := refCols[refColName]
+		if !ok {
+			mismatches = append(mismatches, fmt.Sprintf("referenced column %s does not exist in table %s", EscapeIdentifier(refColName), EscapeIdentifier(referencedTable.Name)))
+			continue
+		}
+		if problem := fkColumnTypeMismatch(col, refCol); problem != "" {
+			mismatches = append(mismatches, fmt.Sprintf("column %s %s vs referenced column %s %s: %s", EscapeIdentifier(col.Name), col.TypeInDB, EscapeIdentifier(refCol.Name), refCol.TypeInDB, problem))
+		}
+	}
+	return mismatches
+}
+
+// fkColumnTypeMismatch returns a short description of the reason why col
+// cannot reference refCol in a foreign key, or an empty string if the types
+// are compatible.
+func fkColumnTypeMismatch(col, refCol *Column) string {
+	colType, colUnsigned := fkNormalizedColumnType(col)
+	refType, refUnsigned := fkNormalizedColumnType(refCol)
+	colBase, _, _ := strings.Cut(colType, "(")
+	refBase, _, _ := strings.Cut(refType, "(")
+	colFamily, refFamily := fkTypeFamily(colBase), fkTypeFamily(refBase)
+
+	if colFamily != refFamily {
+		return "data types differ"
+	}
+	switch colFamily {
+	case "char", "binary":
+		// String lengths may differ; char vs varchar (or binary vs varbinary) is
+		// also permitted
+		if colFamily == "char" && (col.CharSet != refCol.CharSet || col.Collation != refCol.Collation) {
+			return "character set or collation differs"
+		}
+		return ""
+	case "int":
+		if colBase != refBase {
+			return "integer sizes differ"
+		}
+	default:
+		if colType != refType {
+			if colBase == refBase {
+				return "lengths or precisions differ"
+			}
+			return "data types differ"
+		}
+	}
+	if colUnsigned != refUnsigned {
+		return "signedness differs"
+	}
+	return ""
+}
+
+// fkNormalizedColumnType returns a lowercased version of the column's type,
+// with any integer display width, unsigned modifier, and zerofill modifier
+// removed. It also returns whether the unsigned modifier was present.
+func fkNormalizedColumnType(col *Column) (colType string, unsigned bool) {
+	colType = strings.ToLower(col.TypeInDB)
+	colType = strings.TrimSuffix(colType, " zerofill")
+	if unsigned = col.Unsigned(); unsigned {
+		colType = strings.TrimSuffix(colType, " unsigned")
+	}
+	if base, _, _ := strings.Cut(colType, "("); base == "integer" {
+		colType = "int"
+	} else if fkTypeFamily(base) == "int" {
+		colType = base
+	}
+	return colType, unsigned
+}
+
+// fkTypeFamily groups column base types which may reference each other in a
+// foreign key, subject to additional restrictions checked by
+// fkColumnTypeMismatch.
+func fkTypeFamily(baseType string) string {
+	switch baseType {
+	case "tinyint", "smallint", "mediumint", "int", "bigint":
+		return "int"
+	case "char", "varchar":
+		return "char"
+	case "binary", "varbinary":
+		return "binary"
+	case "numeric":
+		return "decimal"
+	}
+	return baseType
+}
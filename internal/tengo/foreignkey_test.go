@@ -0,0 +1,73 @@
+package tengo
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestForeignKeyColumnTypeMismatches(t *testing.T) {
+	parent := &Table{
+		Name: "parent",
+		Columns: []*Column{
+			{Name: "id", TypeInDB: "int(10) unsigned"},
+			{Name: "code", TypeInDB: "varchar(20)", CharSet: "utf8mb4", Collation: "utf8mb4_general_ci"},
+			{Name: "amount", TypeInDB: "decimal(10,2)"},
+			{Name: "created_at", TypeInDB: "datetime(3)"},
+		},
+	}
+	fk := &ForeignKey{
+		Name:                  "fk",
+		ColumnNames:           []string{"parent_id", "parent_code", "parent_amount", "parent_created_at"},
+		ReferencedTableName:   "parent",
+		ReferencedColumnNames: []string{"id", "code", "amount", "created_at"},
+	}
+	child := func(types ...string) *Table {
+		table := &Table{Name: "child"}
+		for n, colType := range types {
+			col := &Column{Name: fk.ColumnNames[n], TypeInDB: colType}
+			if n == 1 {
+				col.CharSet, col.Collation = "utf8mb4", "utf8mb4_general_ci"
+			}
+			table.Columns = append(table.Columns, col)
+		}
+		return table
+	}
+	cases := []struct {
+		child    *Table
+		expected string // substring of the single expected mismatch; empty if none expected
+	}{
+		{child("int unsigned", "varchar(20)", "decimal(10,2)", "datetime(3)"), ""},
+		{child("int(11) unsigned zerofill", "char(40)", "decimal(10,2)", "datetime(3)"), ""},
+		{child("int", "varchar(20)", "decimal(10,2)", "datetime(3)"), "`parent_id` int vs referenced column `id` int(10) unsigned: signedness differs"},
+		{child("bigint unsigned", "varchar(20)", "decimal(10,2)", "datetime(3)"), "integer sizes differ"},
+		{child("int unsigned", "varchar(20)", "decimal(12,2)", "datetime(3)"), "lengths or precisions differ"},
+		{child("int unsigned", "varchar(20)", "decimal(10,2) unsigned", "datetime(3)"), "signedness differs"},
+		{child("int unsigned", "varchar(20)", "decimal(10,2)", "datetime"), "lengths or precisions differ"},
+		{child("int unsigned", "varbinary(20)", "decimal(10,2)", "datetime(3)"), "data types differ"},
+		{child("int unsigned", "varchar(20)", "decimal(10,2)", "timestamp(3)"), "data types differ"},
+	}
+	for n, c := range cases {
+		mismatches := fk.ColumnTypeMismatches(c.child, parent)
+		if c.expected == "" && len(mismatches) > 0 {
+			t.Errorf("cases[%d]: Expected no mismatches, instead found %v", n, mismatches)
+		} else if c.expected != "" && (len(mismatches) != 1 || !strings.Contains(mismatches[0], c.expected)) {
+			t.Errorf("cases[%d]: Expected one mismatch containing %q, instead found %v", n, c.expected, mismatches)
+		}
+	}
+
+	// Character set and collation mismatches
+	table := child("int unsigned", "varchar(20)", "decimal(10,2)", "datetime(3)")
+	table.Columns[1].Collation = "utf8mb4_bin"
+	if mismatches := fk.ColumnTypeMismatches(table, parent); len(mismatches) != 1 || !strings.Contains(mismatches[0], "character set or collation differs") {
+		t.Errorf("Unexpected result for collation mismatch: %v", mismatches)
+	}
+
+	// Missing columns on either side
+	table = child("int unsigned", "varchar(20)", "decimal(10,2)")
+	if mismatches := fk.ColumnTypeMismatches(table, parent); len(mismatches) != 1 || !strings.Contains(mismatches[0], "does not exist in table `child`") {
+		t.Errorf("Unexpected result for missing column: %v", mismatches)
+	}
+	if mismatches := fk.ColumnTypeMismatches(child("int unsigned", "varchar(20)", "decimal(10,2)", "datetime(3)"), &Table{Name: "parent"}); len(mismatches) != 4 {
+		t.Errorf("Expected 4 mismatches for missing referenced columns, instead found %v", mismatches)
+	}
+}
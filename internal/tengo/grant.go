@@ -0,0 +1,175 @@
+package tengo
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Privilege represents a single privilege in a Grant. Column is only non-empty
+// for column-level privileges.
+type Privilege struct {
+	Name   string `json:"name"` // e.g. "SELECT" or "ALL PRIVILEGES"
+	Column string `json:"column,omitempty"`
+}
+
+// Grant represents privileges held by a user account at one privilege level.
+// For global privileges, Schema and Table are both "*". For schema-level
+// privileges, Table is "*". Column-level privileges are expressed as table-level
+// grants with a non-empty Privilege.Column.
+type Grant struct {
+	Schema      string      `json:"schema"`
+	Table       string      `json:"table"`
+	Privileges  []Privilege `json:"privileges"`
+	GrantOption bool        `json:"grantOption,omitempty"`
+}
+
+// Level returns the privilege level of the grant, in the format used by the
+// ON clause of GRANT and REVOKE statements.
+func (g Grant) Level() string {
+	if g.Schema == "*" || g.Schema == "" {
+		return "*.*"
+	} else if g.Table == "*" || g.Table == "" {
+		return EscapeIdentifier(g.Schema) + ".*"
+	}
+	return EscapeIdentifier(g.Schema) + "." + EscapeIdentifier(g.Table)
+}
+
+// normalizedPrivilegeName uppercases name, and converts the ALL synonym to ALL
+// PRIVILEGES.
+func normalizedPrivilegeName(name string) string {
+	name = strings.ToUpper(strings.Join(strings.Fields(name), " "))
+	if name == "ALL" {
+		return "ALL PRIVILEGES"
+	}
+	return name
+}
+
+// grantLevelState tracks the combined privileges held at one privilege level.
+type grantLevelState struct {
+	privileges  map[Privilege]bool
+	grantOption bool
+}
+
+// grantsByLevel combines grants into a map keyed by privilege level. Multiple
+// grants at the same level are merged. USAGE is ignored, since it represents
+// the lack of privileges.
+func grantsByLevel(grants []Grant) map[string]*grantLevelState {
+	result := make(map[string]*grantLevelState)
+	for _, g := range grants {
+		level := g.Level()
+		if result[level] == nil {
+			result[level] = &grantLevelState{privileges: make(map[Privilege]bool)}
+		}
+		for _, priv := range g.Privileges {
+			if priv.Name = normalizedPrivilegeName(priv.Name); priv.Name != "USAGE" {
+				result[level].privileges[priv] = true
+			}
+		}
+		result[level].grantOption = result[level].grantOption || g.GrantOption
+	}
+	return result
+}
+
+// privilegeList formats privs for use in a GRANT or REVOKE statement. Privileges
+// are sorted by name, and column-level privileges with the same name are
+// combined into a single parenthesized column list.
+func privilegeList(privs []Privilege) string {
+	sort.Slice(privs, func(i, j int) bool {
+		if privs[i].Name != privs[j].Name {
+			return privs[i].Name < privs[j].Name
+		}
+		return privs[i].Column < privs[j].Column
+	})
+	var parts []string
+	for n := 0; n < len(privs); n++ {
+		if privs[n].Column == "" {
+			parts = append(parts, privs[n].Name)
+			continue
+		}
+		cols := []string{EscapeIdentifier(privs[n].Column)}
+		for n+1 < len(privs) && privs[n+1].Name == privs[n].Name && privs[n+1].Column != "" {
+			n++
+			cols = append(cols, EscapeIdentifier(privs[n].Column))
+		}
+		parts = append(parts, fmt.Sprintf("%s (%s)", privs[n].Name, strings.Join(cols, ", ")))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// GrantDiff returns the GRANT and REVOKE statements needed to change the
+// privileges of the account user@host from the from grants to the to grants.
+// Privileges are compared independently at each privilege level (global,
+// schema, table, and column), and only the privileges which differ are
+// granted or revoked. At most one REVOKE and one GRANT statement are returned
+// per privilege level, with REVOKE first; this ordering permits replacing ALL
+// PRIVILEGES with a narrower set. Changes to WITH GRANT OPTION are handled as
+// well. Statements are ordered by privilege level, starting with global
+// privileges. No database interaction is required.
+func GrantDiff(user, host string, from, to []Grant) []string {
+	account := fmt.Sprintf("'%s'@'%s'", EscapeValueForCreateTable(user), EscapeValueForCreateTable(host))
+	fromLevels, toLevels := grantsByLevel(from), grantsByLevel(to)
+	levels := make([]string, 0, len(fromLevels)+len(toLevels))
+	for level := range fromLevels {
+		levels = append(levels, level)
+	}
+	for level := range toLevels {
+		if fromLevels[level] == nil {
+			levels = append(levels, level)
+		}
+	}
+	// Sort so that *.* comes first, followed by schema-level and then table-level
+	// privileges within each schema
+	sort.Slice(levels, func(i, j int) bool {
+		if levels[i] == "*.*" || levels[j] == "*.*" {
+			return levels[i] == "*.*" && levels[j] != "*.*"
+		}
+		return levels[i] < levels[j]
+	})
+
+	var result []string
+	empty := &grantLevelState{privileges: map[Privilege]bool{}}
+	for _, level := range levels {
+		fromState, toState := fromLevels[level], toLevels[level]
+		if fromState == nil {
+			fromState = empty
+		}
+		if toState == nil {
+			toState = empty
+		}
+		var revokes, grants []Privilege
+		for priv := range fromState.privileges {
+			if !toState.privileges[priv] {
+				revokes = append(revokes, priv)
+			}
+		}
+		for priv := range toState.privileges {
+			if !fromState.privileges[priv] {
+				grants = append(grants, priv)
+			}
+		}
+		revokeList := privilegeList(revokes)
+		if fromState.grantOption && !toState.grantOption {
+			if revokeList != "" {
+				revokeList += ", "
+			}
+			revokeList += "GRANT OPTION"
+		}
+		if revokeList != "" {
+			result = append(result, fmt.Sprintf("REVOKE %s ON %s FROM %s", revokeList, level, account))
+		}
+		addGrantOption := toState.grantOption && !fromState.grantOption
+		if len(grants) > 0 || addGrantOption {
+			grantList := privilegeList(grants)
+			if grantList == "" {
+				grantList = "USAGE"
+			}
+			stmt := fmt.Sprintf("GRANT %s ON %s TO %s", grantList, level, account)
+			if addGrantOption {
+				stmt += " WITH GRANT OPTION"
+			}
+			result = append(result, stmt)
+		}
+	}
+	return result
+}
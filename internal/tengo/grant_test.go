@@ -0,0 +1,78 @@
+package tengo
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGrantLevel(t *testing.T) {
+	cases := []struct {
+		grant    Grant
+		expected string
+	}{
+		{Grant{Schema: "*", Table: "*"}, "*.*"},
+		{Grant{}, "*.*"},
+		{Grant{Schema: "app", Table: "*"}, "`app`.*"},
+		{Grant{Schema: "app", Table: "users"}, "`app`.`users`"},
+	}
+	for _, c := range cases {
+		if actual := c.grant.Level(); actual != c.expected {
+			t.Errorf("Expected Level() of %+v to return %q, instead found %q", c.grant, c.expected, actual)
+		}
+	}
+}
+
+func TestGrantDiff(t *testing.T) {
+	priv := func(names ...string) (result []Privilege) {
+		for _, name := range names {
+			result = append(result, Privilege{Name: name})
+		}
+		return result
+	}
+	from := []Grant{
+		{Schema: "*", Table: "*", Privileges: priv("USAGE")},
+		{Schema: "app", Table: "*", Privileges: priv("SELECT", "INSERT", "delete"), GrantOption: true},
+		{Schema: "app", Table: "users", Privileges: []Privilege{{Name: "SELECT", Column: "id"}, {Name: "UPDATE", Column: "name"}}},
+		{Schema: "old", Table: "*", Privileges: priv("ALL")},
+	}
+	to := []Grant{
+		{Schema: "*", Table: "*", Privileges: priv("PROCESS")},
+		{Schema: "app", Table: "*", Privileges: priv("SELECT", "INSERT", "UPDATE")},
+		{Schema: "app", Table: "users", Privileges: []Privilege{{Name: "SELECT", Column: "id"}, {Name: "SELECT", Column: "email"}}},
+		{Schema: "app", Table: "users", Privileges: []Privilege{{Name: "SELECT", Column: "name"}}},
+		{Schema: "old", Table: "*", Privileges: priv("SELECT")},
+		{Schema: "reports", Table: "*", GrantOption: true},
+	}
+	expected := []string{
+		"GRANT PROCESS ON *.* TO 'app''s'@'%'",
+		"REVOKE DELETE, GRANT OPTION ON `app`.* FROM 'app''s'@'%'",
+		"GRANT UPDATE ON `app`.* TO 'app''s'@'%'",
+		"REVOKE UPDATE (`name`) ON `app`.`users` FROM 'app''s'@'%'",
+		"GRANT SELECT (`email`, `name`) ON `app`.`users` TO 'app''s'@'%'",
+		"REVOKE ALL PRIVILEGES ON `old`.* FROM 'app''s'@'%'",
+		"GRANT SELECT ON `old`.* TO 'app''s'@'%'",
+		"GRANT USAGE ON `reports`.* TO 'app''s'@'%' WITH GRANT OPTION",
+	}
+	if actual := GrantDiff("app's", "%", from, to); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Unexpected result from GrantDiff.\nExpected: %q\nActual:   %q", expected, actual)
+	}
+
+	// Reverse direction should also work, and identical sets should produce no
+	// statements
+	expected = []string{
+		"REVOKE PROCESS ON *.* FROM 'app''s'@'%'",
+		"REVOKE UPDATE ON `app`.* FROM 'app''s'@'%'",
+		"GRANT DELETE ON `app`.* TO 'app''s'@'%' WITH GRANT OPTION",
+		"REVOKE SELECT (`email`, `name`) ON `app`.`users` FROM 'app''s'@'%'",
+		"GRANT UPDATE (`name`) ON `app`.`users` TO 'app''s'@'%'",
+		"REVOKE SELECT ON `old`.* FROM 'app''s'@'%'",
+		"GRANT ALL PRIVILEGES ON `old`.* TO 'app''s'@'%'",
+		"REVOKE GRANT OPTION ON `reports`.* FROM 'app''s'@'%'",
+	}
+	if actual := GrantDiff("app's", "%", to, from); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Unexpected result from GrantDiff.\nExpected: %q\nActual:   %q", expected, actual)
+	}
+	if actual := GrantDiff("app", "%", from, from); len(actual) != 0 {
+		t.Errorf("Expected no statements for identical grants, instead found %q", actual)
+	}
+}
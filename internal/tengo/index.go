@@ -83,6 +83,14 @@ func (idx *Index) EqualsIgnoringVisibility(other *Index) bool {
 	return idx.Name == other.Name && idx.Comment == other.Comment && idx.Equivalent(other)
 }
 
+// withUnique returns a copy of idx with its Unique field set to the supplied
+// value.
+func (idx *Index) withUnique(unique bool) *Index {
+	result := *idx
+	result.Unique = unique
+	return &result
+}
+
 // sameParts returns true if two Indexes' Parts slices are identical.
 func (idx *Index) sameParts(other *Index) bool {
 	if len(idx.Parts) != len(other.Parts) {
@@ -148,6 +156,28 @@ func (idx *Index) RedundantTo(other *Index) bool {
 	return true
 }
 
+// uniqueCoveredBy returns true if other is a unique index guaranteeing that
+// rows' values for idx's parts are already distinct: each part of other is
+// also a part of idx (in any position), with the same or a longer prefix.
+func (idx *Index) uniqueCoveredBy(other *Index) bool {
+	if !other.Unique || other.Type != "BTREE" && other.Type != "" {
+		return false
+	}
+	for _, otherPart := range other.Parts {
+		var found bool
+		for _, part := range idx.Parts {
+			if part.ColumnName == otherPart.ColumnName && part.Expression == otherPart.Expression {
+				found = part.PrefixLength == 0 || (otherPart.PrefixLength > 0 && part.PrefixLength >= otherPart.PrefixLength)
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
 // Functional returns true if at least one IndexPart in idx is an expression
 // rather than a column.
 func (idx *Index) Functional() bool {
@@ -35,6 +35,8 @@ type Instance struct {
 	maxUserConns    int
 	bufferPoolSize  int64
 	lowerCaseNames  int
+	explicitTSDefs  bool
+	pageSize        int
 	sqlMode         []string
 	valid           bool // true if any conn has ever successfully been made yet
 }
@@ -263,6 +265,16 @@ func (instance *Instance) NameCaseMode() NameCaseMode {
 	return NameCaseMode(instance.lowerCaseNames)
 }
 
+// SQLMode returns the default session sql_mode for connections to this
+// instance, formatted as a comma-separated list, or an empty string if it could
+// not be queried.
+func (instance *Instance) SQLMode() string {
+	if ok, _ := instance.Valid(); !ok {
+		return ""
+	}
+	return strings.Join(instance.sqlMode, ",")
+}
+
 // LockWaitTimeout returns the default session lock_wait_timeout for connections
 // to this instance, or 0 if it could not be queried.
 func (instance *Instance) LockWaitTimeout() int {
@@ -272,6 +284,27 @@ func (instance *Instance) LockWaitTimeout() int {
 	return instance.lockWaitTimeout
 }
 
+// ExplicitDefaultsForTimestamp returns true if this instance's session value of
+// explicit_defaults_for_timestamp is enabled. It returns false if the variable
+// is disabled, or if it does not exist (MySQL 5.5), or if it could not be
+// queried. When false, TIMESTAMP columns lacking an explicit NULL clause are
+// implicitly NOT NULL, and receive implicit DEFAULT and ON UPDATE values.
+func (instance *Instance) ExplicitDefaultsForTimestamp() bool {
+	if ok, _ := instance.Valid(); !ok {
+		return false
+	}
+	return instance.explicitTSDefs
+}
+
+// InnoDBPageSize returns this instance's innodb_page_size in bytes, or 0 if it
+// could not be queried (including in MySQL 5.5, which lacks this variable).
+func (instance *Instance) InnoDBPageSize() int {
+	if ok, _ := instance.Valid(); !ok {
+		return 0
+	}
+	return instance.pageSize
+}
+
 // hydrateVars populates several non-exported Instance fields by querying
 // various global and session variables. Failures are ignored; these variables
 // are designed to help inform behavior but are not strictly mandatory.
@@ -320,6 +353,18 @@ func (instance *Instance) hydrateVars(db *sqlx.DB, lock bool) {
 	} else {
 		instance.maxUserConns = result.MaxConns
 	}
+
+	// explicit_defaults_for_timestamp doesn't exist in MySQL 5.5, so it must be
+	// queried separately
+	var explicitTSDefs int
+	if err := db.QueryRow("SELECT @@session.explicit_defaults_for_timestamp").Scan(&explicitTSDefs); err == nil {
+		instance.explicitTSDefs = (explicitTSDefs == 1)
+	}
+
+	// innodb_page_size doesn't exist in MySQL 5.5 either
+	if err := db.QueryRow("SELECT @@global.innodb_page_size").Scan(&instance.pageSize); err != nil {
+		instance.pageSize = 0
+	}
 }
 
 // Regular expression defining privileges that allow use of setting session
@@ -390,6 +435,13 @@ func (instance *Instance) Schemas(onlyNames ...string) ([]*Schema, error) {
 		Name      string `db:"schema_name"`
 		CharSet   string `db:"default_character_set_name"`
 		Collation string `db:"default_collation_name"`
+		Comment   string `db:"schema_comment"`
+	}
+
+	// MariaDB 10.5+ exposes schema-level comments in information_schema
+	commentSelect := "'' AS schema_comment"
+	if instance.Flavor().HasSchemaComments() {
+		commentSelect = "schema_comment AS schema_comment"
 	}
 
 	var args []interface{}
@@ -399,11 +451,11 @@ func (instance *Instance) Schemas(onlyNames ...string) ([]*Schema, error) {
 	// come back from queries in all caps, so we need to explicitly use AS clauses
 	// in order to get them back as lowercase and have sqlx Select() work
 	if len(onlyNames) == 0 {
-		query = `
+		query = fmt.Sprintf(`
 			SELECT schema_name AS schema_name, default_character_set_name AS default_character_set_name,
-			       default_collation_name AS default_collation_name
+			       default_collation_name AS default_collation_name, %s
 			FROM   information_schema.schemata
-			WHERE  schema_name NOT IN ('information_schema', 'performance_schema', 'mysql', 'test', 'sys')`
+			WHERE  schema_name NOT IN ('information_schema', 'performance_schema', 'mysql', 'test', 'sys')`, commentSelect)
 	} else {
 		// If instance is using lower_case_table_names=2, apply an explicit collation
 		// to ensure the schema name comes back with its original lettercasing. See
@@ -414,9 +466,9 @@ func (instance *Instance) Schemas(onlyNames ...string) ([]*Schema, error) {
 		}
 		query = fmt.Sprintf(`
 			SELECT schema_name AS schema_name, default_character_set_name AS default_character_set_name,
-			       default_collation_name AS default_collation_name
+			       default_collation_name AS default_collation_name, %s
 			FROM   information_schema.schemata
-			WHERE  schema_name%s IN (?)`, lctn2Collation)
+			WHERE  schema_name%s IN (?)`, commentSelect, lctn2Collation)
 		query, args, err = sqlx.In(query, onlyNames)
 	}
 	if err := db.Select(&rawSchemas, query, args...); err != nil {
@@ -429,6 +481,7 @@ func (instance *Instance) Schemas(onlyNames ...string) ([]*Schema, error) {
 			Name:      rawSchema.Name,
 			CharSet:   rawSchema.CharSet,
 			Collation: rawSchema.Collation,
+			Comment:   rawSchema.Comment,
 		}
 		// Create a non-cached connection pool with this schema as the default
 		// database. The instance.querySchemaX calls below can establish a lot of
@@ -602,6 +655,60 @@ func (instance *Instance) TableSize(schema, table string) (int64, error) {
 	return result, err
 }
 
+// ObjectStatuses introspects the named schema on instance, and returns the
+// status of each object relative to desired, which typically represents the
+// schema's definition in the filesystem. See Schema.ObjectStatuses for more
+// information. If the schema does not exist on instance, all objects in
+// desired are considered missing on the server.
+func (instance *Instance) ObjectStatuses(schema string, desired *Schema) (map[ObjectKey]ObjectStatus, error) {
+	actual, err := instance.Schema(schema)
+	if err == sql.ErrNoRows {
+		actual = nil
+	} else if err != nil {
+		return nil, err
+	}
+	return actual.ObjectStatuses(desired), nil
+}
+
+// ObjectLastDDLTimes returns a map of object keys to the approximate time of
+// each object's most recent DDL, based on data in information_schema. This is
+// best-effort: tables use CREATE_TIME, which is only updated by ALTERs that
+// rebuild the table; procs and funcs use LAST_ALTERED. Objects lacking any
+// timestamp, such as views or tables in some storage engines, are omitted from
+// the result. If the schema does not exist, an empty map is returned.
+func (instance *Instance) ObjectLastDDLTimes(schema string) (map[ObjectKey]time.Time, error) {
+	db, err := instance.CachedConnectionPool("", instance.introspectionParams())
+	if err != nil {
+		return nil, err
+	}
+	var rows []struct {
+		Type     string        `db:"object_type"`
+		Name     string        `db:"object_name"`
+		UnixTime sql.NullInt64 `db:"unix_time"`
+	}
+	query := `
+		SELECT  'table' AS object_type, table_name AS object_name,
+		        UNIX_TIMESTAMP(create_time) AS unix_time
+		FROM    information_schema.tables
+		WHERE   table_schema = ? AND table_type = 'BASE TABLE'
+		UNION ALL
+		SELECT  LOWER(routine_type) AS object_type, routine_name AS object_name,
+		        UNIX_TIMESTAMP(last_altered) AS unix_time
+		FROM    information_schema.routines
+		WHERE   routine_schema = ?`
+	if err := db.Select(&rows, query, schema, schema); err != nil {
+		return nil, fmt.Errorf("Error querying last DDL times for schema %s: %s", schema, err)
+	}
+	result := make(map[ObjectKey]time.Time, len(rows))
+	for _, row := range rows {
+		if row.UnixTime.Valid && row.UnixTime.Int64 > 0 {
+			key := ObjectKey{Type: ObjectType(row.Type), Name: row.Name}
+			result[key] = time.Unix(row.UnixTime.Int64, 0)
+		}
+	}
+	return result, nil
+}
+
 // TableHasRows returns true if the table has at least one row. If an error
 // occurs in querying, also returns true (along with the error) since a false
 // positive is generally less dangerous in this case than a false negative.
@@ -643,6 +750,7 @@ func confirmTablesEmpty(db *sqlx.DB, schema string, tables []string) error {
 type SchemaCreationOptions struct {
 	DefaultCharSet   string
 	DefaultCollation string
+	Comment          string // only used by CreateSchema
 	SkipBinlog       bool
 }
 
@@ -655,7 +763,8 @@ func (opts SchemaCreationOptions) params() string {
 
 // CreateSchema creates a new database schema with the supplied name, and
 // optionally the supplied default CharSet and Collation. (Leave these fields
-// blank to use server defaults.)
+// blank to use server defaults.) The supplied Comment is ignored if the
+// instance's flavor does not support schema comments.
 func (instance *Instance) CreateSchema(name string, opts SchemaCreationOptions) (*Schema, error) {
 	db, err := instance.CachedConnectionPool("", opts.params())
 	if err != nil {
@@ -682,6 +791,9 @@ func (instance *Instance) CreateSchema(name string, opts SchemaCreationOptions)
 		Collation: opts.DefaultCollation,
 		Tables:    []*Table{},
 	}
+	if instance.Flavor().HasSchemaComments() {
+		schema.Comment = opts.Comment
+	}
 	_, err = db.Exec(schema.CreateStatement())
 	if err != nil {
 		return nil, err
@@ -282,6 +282,26 @@ func (s TengoIntegrationSuite) TestInstanceLockWaitTimeout(t *testing.T) {
 	}
 }
 
+func (s TengoIntegrationSuite) TestInstanceExplicitDefaultsForTimestamp(t *testing.T) {
+	// explicit_defaults_for_timestamp defaults to ON in MySQL 8.0+ and MariaDB
+	// 10.10+, and OFF in older versions
+	expected := s.d.Flavor().Min(FlavorMySQL80) || s.d.Flavor().Min(FlavorMariaDB1010)
+	if actual := s.d.ExplicitDefaultsForTimestamp(); actual != expected {
+		t.Errorf("Expected ExplicitDefaultsForTimestamp to return %t, instead found %t", expected, actual)
+	}
+}
+
+func (s TengoIntegrationSuite) TestInstanceInnoDBPageSize(t *testing.T) {
+	// innodb_page_size defaults to 16KB, and does not exist in MySQL 5.5
+	expected := 16384
+	if !s.d.Flavor().Min(FlavorMySQL56) && s.d.Flavor().IsMySQL() {
+		expected = 0
+	}
+	if actual := s.d.InnoDBPageSize(); actual != expected {
+		t.Errorf("Expected InnoDBPageSize to return %d, instead found %d", expected, actual)
+	}
+}
+
 func (s TengoIntegrationSuite) TestInstanceCloseAll(t *testing.T) {
 	makePool := func(defaultSchema, params string) {
 		t.Helper()
@@ -557,6 +577,23 @@ func (s TengoIntegrationSuite) TestInstanceTableHasRows(t *testing.T) {
 	}
 }
 
+func (s TengoIntegrationSuite) TestInstanceObjectLastDDLTimes(t *testing.T) {
+	times, err := s.d.ObjectLastDDLTimes("testing")
+	if err != nil {
+		t.Fatalf("Unexpected error from ObjectLastDDLTimes: %v", err)
+	}
+	for _, key := range []ObjectKey{{Type: ObjectTypeTable, Name: "actor"}, {Type: ObjectTypeProc, Name: "proc1"}, {Type: ObjectTypeFunc, Name: "func1"}} {
+		if ts, ok := times[key]; !ok || ts.IsZero() {
+			t.Errorf("Expected non-zero last DDL time for %s, but found none", key)
+		}
+	}
+
+	// Nonexistent schema should return an empty map, without an error
+	if times, err := s.d.ObjectLastDDLTimes("doesnt_exist"); err != nil || len(times) != 0 {
+		t.Errorf("Expected empty result and no error for nonexistent schema, instead found %v, %v", times, err)
+	}
+}
+
 func (s TengoIntegrationSuite) TestInstanceCreateSchema(t *testing.T) {
 	opts := SchemaCreationOptions{
 		DefaultCharSet:   "utf8mb4",
@@ -588,6 +625,21 @@ func (s TengoIntegrationSuite) TestInstanceCreateSchema(t *testing.T) {
 	} else if schema.CharSet != defCharSet || schema.Collation != defCollation {
 		t.Errorf("Expected charset/collation to be %s/%s, instead found %s/%s", defCharSet, defCollation, schema.CharSet, schema.Collation)
 	}
+
+	// Schema comments should only be persisted in flavors supporting them
+	opts.Comment = "hello world"
+	if _, err := s.d.CreateSchema("commented", opts); err != nil {
+		t.Fatalf("CreateSchema returned unexpected error: %s", err)
+	}
+	expectComment := ""
+	if s.d.Flavor().HasSchemaComments() {
+		expectComment = opts.Comment
+	}
+	if refetch, err := s.d.Schema("commented"); err != nil {
+		t.Errorf("Unable to fetch newly created schema: %s", err)
+	} else if refetch.Comment != expectComment {
+		t.Errorf("Expected schema comment %q, instead found %q", expectComment, refetch.Comment)
+	}
 }
 
 func (s TengoIntegrationSuite) TestInstanceDropSchema(t *testing.T) {
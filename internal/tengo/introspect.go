@@ -100,6 +100,13 @@ func querySchemaTables(ctx context.Context, db *sqlx.DB, schema string, flavor F
 		// Obtain TABLESPACE clause from SHOW CREATE TABLE, if present
 		t.Tablespace = ParseCreateTablespace(t.CreateStatement)
 
+		// Obtain underlying tables and insert method of MERGE tables from SHOW
+		// CREATE TABLE, since these are not exposed anywhere in information_schema
+		if t.IsMerge() {
+			t.MergeUnion = ParseCreateMergeUnion(t.CreateStatement)
+			t.MergeInsertMethod = ParseCreateMergeInsertMethod(t.CreateStatement)
+		}
+
 		// Obtain next AUTO_INCREMENT value from SHOW CREATE TABLE, which avoids
 		// potential problems with information_schema discrepancies
 		_, t.NextAutoIncrement = ParseCreateAutoInc(t.CreateStatement)
@@ -130,8 +137,9 @@ func querySchemaTables(ctx context.Context, db *sqlx.DB, schema string, flavor F
 		// MySQL 5.7+ generated column expressions must be reparased from SHOW CREATE
 		// TABLE to properly obtain any 4-byte chars. Additionally in 8.0 the I_S
 		// representation has incorrect escaping and potentially different charset
-		// in string literal introducers.
-		if flavor.Min(FlavorMySQL57) {
+		// in string literal introducers. MariaDB's I_S representation can also
+		// differ from its SHOW CREATE TABLE formatting of the expression.
+		if flavor.GeneratedColumns() {
 			fixGenerationExpr(t, flavor)
 		}
 		// Percona Server column compression can only be parsed from SHOW CREATE
@@ -151,6 +159,11 @@ func querySchemaTables(ctx context.Context, db *sqlx.DB, schema string, flavor F
 			fixDefaultExpression(t, flavor)
 			fixIndexExpression(t, flavor)
 		}
+		// Engine attributes are only exposed in I_S via separate *_extensions
+		// tables, so parse them from SHOW CREATE TABLE instead
+		if flavor.HasEngineAttributes() && strings.Contains(t.CreateStatement, "ENGINE_ATTRIBUTE") {
+			fixEngineAttributes(t)
+		}
 		// Fix shortcoming in I_S data for check constraints
 		if len(t.Checks) > 0 {
 			fixChecks(t, flavor)
@@ -287,9 +300,14 @@ func queryColumnsInSchema(ctx context.Context, db *sqlx.DB, schema string, flavo
 			// Recent versions of MySQL do allow default *expressions* for these col
 			// types, but 8.0.13-8.0.22 erroneously omit them from I_S, so we need to
 			// catch this situation and parse from SHOW CREATE later.
-			if !flavor.Min(FlavorMariaDB102) && (strings.HasSuffix(col.TypeInDB, "blob") || strings.HasSuffix(col.TypeInDB, "text")) {
-				allowNullDefault = false
+			// The same I_S bug affects json and spatial types, although these still
+			// show an explicit DEFAULT NULL in MySQL when lacking a default.
+			if !flavor.Min(FlavorMariaDB102) && col.hasBlobLikeType() {
+				if strings.HasSuffix(col.TypeInDB, "blob") || strings.HasSuffix(col.TypeInDB, "text") {
+					allowNullDefault = false
+				}
 				if strings.Contains(rawColumn.Extra, "DEFAULT_GENERATED") {
+					allowNullDefault = false
 					col.Default = "(!!!BLOBDEFAULT!!!)"
 				}
 			}
@@ -322,7 +340,10 @@ func queryColumnsInSchema(ctx context.Context, db *sqlx.DB, schema string, flavo
 				col.OnUpdate = fmt.Sprintf("%s%s", col.OnUpdate, rawColumn.Type[openParen:])
 			}
 		}
-		if rawColumn.Collation.Valid { // only text-based column types have a notion of charset and collation
+		// Only text-based column types have a notion of charset and collation. Some
+		// flavors report the binary charset for binary string types, which is
+		// ignored here since these types cannot have any other charset.
+		if rawColumn.Collation.Valid && !(rawColumn.Collation.String == "binary" && col.hasBinaryType()) {
 			col.CharSet = rawColumn.CharSet.String
 			col.Collation = rawColumn.Collation.String
 			col.CollationIsDefault = (rawColumn.CollationIsDefault.String != "")
@@ -693,6 +714,10 @@ func fixShowCharSets(t *Table) {
 //   - MySQL 8 potentially uses different charsets introducers for string literals
 //     in I_S vs SHOW CREATE
 //
+// In MariaDB, PERSISTENT is accepted as a synonym for STORED, so either keyword
+// is matched here. The keyword used by SHOW CREATE is tracked in
+// Column.Persistent, so that the generated CREATE matches.
+//
 // This method modifies each generated Column.GenerationExpr to match SHOW
 // CREATE's version.
 func fixGenerationExpr(t *Table, flavor Flavor) {
@@ -704,13 +729,16 @@ func fixGenerationExpr(t *Table, flavor Flavor) {
 			var genKind string
 			if col.Virtual {
 				genKind = "VIRTUAL"
+			} else if flavor.IsMariaDB() {
+				genKind = "(?:STORED|PERSISTENT)"
 			} else {
 				genKind = "STORED"
 			}
-			reTemplate := `(?m)^\s*` + regexp.QuoteMeta(EscapeIdentifier(col.Name)) + `.+GENERATED ALWAYS AS \((.+)\) ` + genKind
+			reTemplate := `(?m)^\s*` + regexp.QuoteMeta(EscapeIdentifier(col.Name)) + `.+GENERATED ALWAYS AS \((.+)\) (` + genKind + `)`
 			re := regexp.MustCompile(reTemplate)
 			if matches := re.FindStringSubmatch(t.CreateStatement); matches != nil {
 				col.GenerationExpr = matches[1]
+				col.Persistent = (matches[2] == "PERSISTENT")
 			}
 		}
 	}
@@ -777,6 +805,33 @@ func fixPerconaColCompression(t *Table) {
 	}
 }
 
+var reEngineAttribute = regexp.MustCompile(`/\*!80021 (SECONDARY_)?ENGINE_ATTRIBUTE[= ]'((?:[^'\\]|\\.|'')*)' \*/`)
+var reColumnName = regexp.MustCompile("^\\s+`((?:[^`]|``)+)` ")
+
+// fixEngineAttributes parses the table's CREATE string in order to populate
+// the table-level and column-level engine attributes.
+func fixEngineAttributes(t *Table) {
+	setAttributes := func(s string, engineAttr, secondaryEngineAttr *string) {
+		for _, matches := range reEngineAttribute.FindAllStringSubmatch(s, -1) {
+			if matches[1] == "" {
+				*engineAttr = unescapeValueForCreateTable(matches[2])
+			} else {
+				*secondaryEngineAttr = unescapeValueForCreateTable(matches[2])
+			}
+		}
+	}
+	colsByName := t.ColumnsByName()
+	for _, line := range strings.Split(t.CreateStatement, "\n") {
+		if strings.HasPrefix(line, ")") {
+			setAttributes(line, &t.EngineAttribute, &t.SecondaryEngineAttribute)
+		} else if matches := reColumnName.FindStringSubmatch(line); matches != nil {
+			if col := colsByName[strings.ReplaceAll(matches[1], "``", "`")]; col != nil {
+				setAttributes(line, &col.EngineAttribute, &col.SecondaryEngineAttribute)
+			}
+		}
+	}
+}
+
 // fixFulltextIndexParsers parses the table's CREATE string in order to
 // populate Index.FullTextParser for any fulltext indexes that specify a parser.
 func fixFulltextIndexParsers(t *Table, flavor Flavor) {
@@ -410,6 +410,60 @@ func TestFixBlobDefaultExpression(t *testing.T) {
 	}
 }
 
+// TestFixGenerationExpr confirms that the same logical generated column is
+// introspected consistently in both MySQL and MariaDB, despite each flavor's
+// formatting quirks.
+func TestFixGenerationExpr(t *testing.T) {
+	fixedColumn := func(flavor Flavor, persistent bool) *Column {
+		table := aTableForFlavor(flavor, 0)
+		table.Columns = append(table.Columns, &Column{
+			Name:           "full_name",
+			TypeInDB:       "varchar(90)",
+			Nullable:       true,
+			Default:        "NULL",
+			GenerationExpr: "concat(`first_name`,' ',`last_name`)",
+			CharSet:        "utf8mb4",
+			Collation:      "utf8mb4_general_ci",
+		})
+		table.CreateStatement = table.GeneratedCreateStatement(flavor)
+		if persistent {
+			// MariaDB accepts PERSISTENT as a synonym for STORED; confirm the
+			// expression is still located if SHOW CREATE uses that keyword
+			table.CreateStatement = strings.Replace(table.CreateStatement, ") STORED", ") PERSISTENT", 1)
+		}
+
+		// Simulate a mangled version of the expression in information_schema
+		col := table.Columns[len(table.Columns)-1]
+		col.GenerationExpr = "concat(`first_name`,_utf8mb4\\' \\',`last_name`)"
+		fixGenerationExpr(&table, flavor)
+		if col.GenerationExpr != "concat(`first_name`,' ',`last_name`)" {
+			t.Errorf("fixGenerationExpr did not behave as expected for flavor %s: expression is %q", flavor, col.GenerationExpr)
+		}
+		if col.Persistent != persistent {
+			t.Errorf("fixGenerationExpr did not behave as expected for flavor %s: Persistent is %t", flavor, col.Persistent)
+		}
+		if generated := table.GeneratedCreateStatement(flavor); generated != table.CreateStatement {
+			t.Errorf("Generated DDL does not match actual DDL for flavor %s\nExpected:\n%s\nFound:\n%s", flavor, table.CreateStatement, generated)
+		}
+		return col
+	}
+
+	// Diffing the tables should not yield any changes to the generated column
+	mysqlCol, mariaCol := fixedColumn(FlavorMySQL80, false), fixedColumn(FlavorMariaDB106, false)
+	if !mysqlCol.Equals(mariaCol) {
+		t.Errorf("Expected generated columns to be equal across flavors, but they differ:\n%+v\n%+v", *mysqlCol, *mariaCol)
+	}
+
+	// Aside from the Persistent field, a column using the PERSISTENT keyword
+	// should be introspected identically to one using STORED
+	persistentCol := fixedColumn(FlavorMariaDB106, true)
+	expected := *mariaCol
+	expected.Persistent = true
+	if !persistentCol.Equals(&expected) {
+		t.Errorf("Unexpected result for PERSISTENT generated column:\nExpected: %+v\nFound:    %+v", expected, *persistentCol)
+	}
+}
+
 // TestFixShowCharSets provides unit test coverage for fixShowCharSets
 func TestFixShowCharSets(t *testing.T) {
 	flavor := FlavorMySQL80.Dot(24)
@@ -0,0 +1,157 @@
+package tengo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// KeyLengthOptions controls the behavior of Table.OversizedIndexes.
+type KeyLengthOptions struct {
+	Flavor    Flavor // used to determine the default row format if needed
+	CharSet   string // if non-empty, textual columns are evaluated as if converted to this character set
+	RowFormat string // if non-empty, overrides the table's ROW_FORMAT clause
+}
+
+// KeyLengthIssue describes an index which exceeds a maximum key length. If
+// Column is non-empty, a single column's key part is too long; otherwise, the
+// total length of all key parts is too long.
+type KeyLengthIssue struct {
+	Index    *Index
+	Column   string
+	Bytes    int
+	MaxBytes int
+}
+
+func (kli KeyLengthIssue) String() string {
+	if kli.Column != "" {
+		return fmt.Sprintf("index %s: key part %s is %d bytes, exceeding maximum of %d bytes", EscapeIdentifier(kli.Index.Name), EscapeIdentifier(kli.Column), kli.Bytes, kli.MaxBytes)
+	}
+	return fmt.Sprintf("index %s: total key length is %d bytes, exceeding maximum of %d bytes", EscapeIdentifier(kli.Index.Name), kli.Bytes, kli.MaxBytes)
+}
+
+// charSetMaxBytes maps multi-byte character sets to their maximum number of
+// bytes per character. Character sets not listed here use 1 byte per char.
+var charSetMaxBytes = map[string]int{
+	"utf8mb4": 4, "utf16": 4, "utf16le": 4, "utf32": 4, "gb18030": 4,
+	"utf8": 3, "utf8mb3": 3, "ujis": 3, "eucjpms": 3,
+	"ucs2": 2, "big5": 2, "sjis": 2, "cp932": 2, "euckr": 2, "gb2312": 2, "gbk": 2,
+}
+
+// KeyPartBytes returns the approximate maximum number of bytes that an index
+// entry on column c may require. If prefixLength is non-zero, only that many
+// chars (or bytes, for binary types) of the column are considered. If charSet
+// is non-empty, textual columns are evaluated as if converted to that
+// character set.
+func (c *Column) KeyPartBytes(prefixLength uint16, charSet string) int {
+	colType := strings.ToLower(c.TypeInDB)
+	baseType, args, _ := strings.Cut(colType, "(")
+	baseType, _, _ = strings.Cut(baseType, " ")
+	length := int(prefixLength)
+	if length == 0 {
+		args, _, _ = strings.Cut(args, ")")
+		args, _, _ = strings.Cut(args, ",")
+		length, _ = strconv.Atoi(args)
+	}
+	if c.CharSet != "" && baseType != "enum" && baseType != "set" {
+		if charSet == "" {
+			charSet = c.CharSet
+		}
+		if mb := charSetMaxBytes[charSet]; mb > 0 {
+			return length * mb
+		}
+		return length
+	}
+	switch baseType {
+	case "tinyint", "year":
+		return 1
+	case "smallint", "enum":
+		return 2
+	case "mediumint", "date":
+		return 3
+	case "int", "integer", "float":
+		return 4
+	case "bigint", "double", "set":
+		return 8
+	case "time", "datetime", "timestamp":
+		fsp, _ := c.FractionalSecondsPrecision()
+		base := map[string]int{"time": 3, "datetime": 5, "timestamp": 4}[baseType]
+		return base + (fsp+1)/2
+	case "decimal":
+		return length/2 + 1
+	case "bit":
+		return (length + 7) / 8
+	case "binary", "varbinary", "blob", "tinyblob", "mediumblob", "longblob":
+		return length
+	case "uuid":
+		return 16
+	}
+	return 8
+}
+
+// OversizedIndexes returns information about any indexes in t which exceed
+// the maximum key length, which would cause CREATE TABLE or ALTER TABLE to
+// fail with error 1071 ("Specified key was too long"). This is an offline
+// check, using Column.KeyPartBytes with opts.CharSet to compute the length of
+// each key part.
+//
+// For InnoDB tables, each key part may be at most 3072 bytes with the DYNAMIC
+// or COMPRESSED row formats, or 767 bytes with the REDUNDANT or COMPACT row
+// formats. If the table has no explicit ROW_FORMAT and opts.RowFormat is
+// empty, the default row format of opts.Flavor is assumed: DYNAMIC in MySQL
+// 5.7+ and MariaDB 10.2+, or COMPACT in older versions. If opts.Flavor is not
+// known, DYNAMIC is assumed. The total length of all key parts of an InnoDB
+// index may be at most 3072 bytes. For MyISAM tables, the total length may be
+// at most 1000 bytes. Other storage engines are not checked, and neither are
+// FULLTEXT or SPATIAL indexes, or functional key parts. Default page sizes are
+// assumed in all cases.
+func (t *Table) OversizedIndexes(opts KeyLengthOptions) (issues []KeyLengthIssue) {
+	var maxPartBytes, maxTotalBytes int
+	switch t.Engine {
+	case "InnoDB":
+		maxPartBytes, maxTotalBytes = 3072, 3072
+		rowFormat := opts.RowFormat
+		if rowFormat == "" {
+			rowFormat = t.RowFormatClause()
+		}
+		if rowFormat == "" && opts.Flavor.Known() && !opts.Flavor.Min(FlavorMySQL57) && !opts.Flavor.Min(FlavorMariaDB102) {
+			rowFormat = "COMPACT"
+		}
+		if rowFormat = strings.ToUpper(rowFormat); rowFormat == "REDUNDANT" || rowFormat == "COMPACT" {
+			maxPartBytes = 767
+		}
+	case "MyISAM":
+		maxPartBytes, maxTotalBytes = 1000, 1000
+	default:
+		return nil
+	}
+
+	cols := t.ColumnsByName()
+	indexes := t.SecondaryIndexes
+	if t.PrimaryKey != nil {
+		indexes = append([]*Index{t.PrimaryKey}, indexes...)
+	}
+	for _, idx := range indexes {
+		if idx.Type == "FULLTEXT" || idx.Type == "SPATIAL" {
+			continue
+		}
+		var total int
+		var partTooLong bool
+		for _, part := range idx.Parts {
+			col := cols[part.ColumnName]
+			if col == nil {
+				continue // functional key part
+			}
+			partBytes := col.KeyPartBytes(part.PrefixLength, opts.CharSet)
+			if partBytes > maxPartBytes {
+				issues = append(issues, KeyLengthIssue{Index: idx, Column: col.Name, Bytes: partBytes, MaxBytes: maxPartBytes})
+				partTooLong = true
+			}
+			total += partBytes
+		}
+		if total > maxTotalBytes && !partTooLong {
+			issues = append(issues, KeyLengthIssue{Index: idx, Bytes: total, MaxBytes: maxTotalBytes})
+		}
+	}
+	return issues
+}
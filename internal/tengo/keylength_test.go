@@ -0,0 +1,112 @@
+package tengo
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTableOversizedIndexes(t *testing.T) {
+	makeTable := func(colType string, prefixLength uint16) *Table {
+		cols := []*Column{
+			{Name: "id", TypeInDB: "int"},
+			{Name: "name", TypeInDB: colType, CharSet: "utf8mb4", Collation: "utf8mb4_general_ci"},
+		}
+		return &Table{
+			Name:       "t",
+			Engine:     "InnoDB",
+			CharSet:    "utf8mb4",
+			Columns:    cols,
+			PrimaryKey: &Index{Name: "PRIMARY", PrimaryKey: true, Type: "BTREE", Parts: []IndexPart{{ColumnName: "id"}}},
+			SecondaryIndexes: []*Index{
+				{Name: "name", Type: "BTREE", Parts: []IndexPart{{ColumnName: "name", PrefixLength: prefixLength}, {ColumnName: "id"}}},
+			},
+		}
+	}
+	assertIssues := func(table *Table, opts KeyLengthOptions, expected ...string) {
+		t.Helper()
+		issues := table.OversizedIndexes(opts)
+		if len(issues) != len(expected) {
+			t.Errorf("Expected %d issues, instead found %d: %v", len(expected), len(issues), issues)
+			return
+		}
+		for n := range issues {
+			if !strings.Contains(issues[n].String(), expected[n]) {
+				t.Errorf("Expected issue to contain %q, instead found %q", expected[n], issues[n])
+			}
+		}
+	}
+
+	// With utf8mb4, a 191-char prefix is the largest that fits within 767 bytes
+	table := makeTable("varchar(255)", 191)
+	compact := KeyLengthOptions{RowFormat: "COMPACT"}
+	assertIssues(table, compact)
+	table = makeTable("varchar(255)", 192)
+	assertIssues(table, compact, "key part `name` is 768 bytes, exceeding maximum of 767 bytes")
+	table = makeTable("varchar(191)", 0)
+	assertIssues(table, compact)
+	table = makeTable("varchar(192)", 0)
+	assertIssues(table, compact, "768 bytes")
+
+	// utf8mb3 would fit, but not if converting to utf8mb4
+	table.Columns[1].CharSet = "utf8mb3"
+	assertIssues(table, compact)
+	assertIssues(table, KeyLengthOptions{RowFormat: "compact", CharSet: "utf8mb4"}, "768 bytes")
+
+	// Default row format depends on flavor, unless the table specifies one
+	table = makeTable("varchar(255)", 192)
+	assertIssues(table, KeyLengthOptions{Flavor: FlavorMySQL56}, "768 bytes")
+	assertIssues(table, KeyLengthOptions{Flavor: FlavorMariaDB101}, "768 bytes")
+	assertIssues(table, KeyLengthOptions{Flavor: FlavorMySQL57})
+	assertIssues(table, KeyLengthOptions{})
+	table.CreateOptions = "ROW_FORMAT=DYNAMIC"
+	assertIssues(table, KeyLengthOptions{Flavor: FlavorMySQL56})
+	table.CreateOptions = "ROW_FORMAT=REDUNDANT"
+	assertIssues(table, KeyLengthOptions{Flavor: FlavorMySQL80}, "768 bytes")
+
+	// With DYNAMIC row format, a 768-char utf8mb4 prefix is the maximum, and
+	// the total across all key parts is also limited to 3072 bytes
+	table = makeTable("text", 768)
+	assertIssues(table, KeyLengthOptions{}, "total key length is 3076 bytes, exceeding maximum of 3072 bytes")
+	table.SecondaryIndexes[0].Parts = table.SecondaryIndexes[0].Parts[0:1]
+	assertIssues(table, KeyLengthOptions{})
+	table.SecondaryIndexes[0].Parts[0].PrefixLength = 769
+	assertIssues(table, KeyLengthOptions{}, "key part `name` is 3076 bytes")
+
+	// MyISAM limits the total to 1000 bytes; other engines and index types are
+	// not checked
+	table = makeTable("varchar(250)", 0)
+	table.Engine = "MyISAM"
+	assertIssues(table, KeyLengthOptions{}, "total key length is 1004 bytes, exceeding maximum of 1000 bytes")
+	table.SecondaryIndexes[0].Type = "FULLTEXT"
+	assertIssues(table, KeyLengthOptions{})
+	table.Engine = "MEMORY"
+	table.SecondaryIndexes[0].Type = "BTREE"
+	assertIssues(table, KeyLengthOptions{})
+}
+
+func TestColumnKeyPartBytes(t *testing.T) {
+	cases := []struct {
+		col          Column
+		prefixLength uint16
+		charSet      string
+		expected     int
+	}{
+		{Column{TypeInDB: "int(10) unsigned"}, 0, "", 4},
+		{Column{TypeInDB: "bigint"}, 0, "", 8},
+		{Column{TypeInDB: "decimal(10,2)"}, 0, "", 6},
+		{Column{TypeInDB: "datetime(6)"}, 0, "", 8},
+		{Column{TypeInDB: "bit(10)"}, 0, "", 2},
+		{Column{TypeInDB: "varbinary(16)"}, 0, "", 16},
+		{Column{TypeInDB: "blob"}, 20, "", 20},
+		{Column{TypeInDB: "enum('a','b')", CharSet: "utf8mb4"}, 0, "", 2},
+		{Column{TypeInDB: "varchar(100)", CharSet: "utf8mb3"}, 0, "", 300},
+		{Column{TypeInDB: "varchar(100)", CharSet: "utf8mb3"}, 0, "utf8mb4", 400},
+		{Column{TypeInDB: "varchar(100)", CharSet: "latin1"}, 10, "", 10},
+		{Column{TypeInDB: "text", CharSet: "utf8mb4"}, 50, "", 200},
+	}
+	for _, c := range cases {
+		if actual := c.col.KeyPartBytes(c.prefixLength, c.charSet); actual != c.expected {
+			t.Errorf("Expected KeyPartBytes(%d, %q) for %s %s to return %d, instead found %d", c.prefixLength, c.charSet, c.col.TypeInDB, c.col.CharSet, c.expected, actual)
+		}
+	}
+}
@@ -124,8 +124,10 @@ func (tp *TablePartitioning) Diff(other *TablePartitioning) (clauses []TableAlte
 	// LIST COLUMNS via generation of a no-op placeholder clause. This is done
 	// to side-step the safety mechanism at the end of Table.Diff() which treats 0
 	// clauses as indicative of an unsupported diff.
-	// For other partitioning methods, changing the partition list is currently
-	// unsupported.
+	// For HASH, LINEAR HASH, KEY, and LINEAR KEY, a change in the number of
+	// default-named partitions is supported via ADD PARTITION or COALESCE
+	// PARTITION. Other changes to the partition list are currently unsupported
+	// for these methods.
 	var foundPartitionsDiff bool
 	if len(tp.Partitions) != len(other.Partitions) {
 		foundPartitionsDiff = true
@@ -141,9 +143,31 @@ func (tp *TablePartitioning) Diff(other *TablePartitioning) (clauses []TableAlte
 	if foundPartitionsDiff && (strings.HasPrefix(tp.Method, "RANGE") || strings.HasPrefix(tp.Method, "LIST")) {
 		return []TableAlterClause{ModifyPartitions{}}, true
 	}
+	if foundPartitionsDiff && tp.SubMethod == "" && tp.countOnly() && other.countOnly() {
+		clause := ChangePartitionCount{
+			OldCount: len(tp.Partitions),
+			NewCount: len(other.Partitions),
+		}
+		return []TableAlterClause{clause}, true
+	}
 	return nil, !foundPartitionsDiff
 }
 
+// countOnly returns true if tp uses HASH or KEY partitioning (with or without
+// LINEAR), and its partition list is fully described by a partition count:
+// all partitions have default names and engines, and no other attributes.
+func (tp *TablePartitioning) countOnly() bool {
+	if !strings.HasSuffix(tp.Method, "HASH") && !strings.HasSuffix(tp.Method, "KEY") {
+		return false
+	}
+	for n, p := range tp.Partitions {
+		if p.Name != fmt.Sprintf("p%d", n) || p.SubName != "" || p.Values != "" || p.Comment != "" || p.DataDir != "" || p.Engine != tp.Partitions[0].Engine {
+			return false
+		}
+	}
+	return true
+}
+
 // Partition stores information on a single partition.
 type Partition struct {
 	Name    string `json:"name"`
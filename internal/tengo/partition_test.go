@@ -144,6 +144,74 @@ func TestTableAlterPartitioningOther(t *testing.T) {
 	assertUnsupported(&p2, &p1)
 }
 
+func TestTableAlterPartitionCount(t *testing.T) {
+	hashPartitioned := func(method string, count int) *Table {
+		t := unpartitionedTable(FlavorUnknown)
+		t.Partitioning = &TablePartitioning{
+			Method:     method,
+			Expression: "customer_id",
+		}
+		for n := 0; n < count; n++ {
+			t.Partitioning.Partitions = append(t.Partitioning.Partitions, &Partition{Name: fmt.Sprintf("p%d", n), Engine: "InnoDB"})
+		}
+		t.CreateStatement = t.GeneratedCreateStatement(FlavorUnknown)
+		return &t
+	}
+	assertAlter := func(from, to *Table, mods StatementModifiers, expected string) {
+		t.Helper()
+		td := NewAlterTable(from, to)
+		if td == nil {
+			t.Fatal("Unexpected nil TableDiff")
+		}
+		if actual, err := td.Statement(mods); err != nil {
+			t.Errorf("Unexpected error from Statement: %v", err)
+		} else if actual != expected {
+			t.Errorf("Unexpected return from Statement: expected %q, found %q", expected, actual)
+		}
+	}
+
+	// Increasing and decreasing the partition count, with or without LINEAR.
+	// ALGORITHM and LOCK clauses are omitted, since they cannot be combined with
+	// these operations.
+	mods := StatementModifiers{AlgorithmClause: "inplace", LockClause: "none"}
+	for _, method := range []string{"HASH", "LINEAR HASH", "KEY", "LINEAR KEY"} {
+		assertAlter(hashPartitioned(method, 4), hashPartitioned(method, 6), mods, "ALTER TABLE `prange` ADD PARTITION PARTITIONS 2")
+		assertAlter(hashPartitioned(method, 6), hashPartitioned(method, 4), mods, "ALTER TABLE `prange` COALESCE PARTITION 2")
+	}
+
+	// Adding or removing LINEAR requires re-partitioning, which also handles a
+	// simultaneous partition count change
+	from, to := hashPartitioned("HASH", 4), hashPartitioned("LINEAR HASH", 8)
+	assertAlter(from, to, StatementModifiers{}, "ALTER TABLE `prange` "+strings.TrimSpace(to.Partitioning.Definition(FlavorUnknown)))
+
+	// Partition count change alongside other changes requires re-partitioning,
+	// since ADD PARTITION and COALESCE PARTITION cannot be combined with other
+	// clauses
+	from, to = hashPartitioned("KEY", 4), hashPartitioned("KEY", 2)
+	to.Comment = "hello"
+	to.CreateStatement = to.GeneratedCreateStatement(FlavorUnknown)
+	assertAlter(from, to, StatementModifiers{}, "ALTER TABLE `prange` COMMENT 'hello' "+strings.TrimSpace(to.Partitioning.Definition(FlavorUnknown)))
+
+	// With PartitioningKeep or PartitioningRemove, partition count changes are
+	// suppressed, whether expressed as ADD/COALESCE PARTITION or re-partitioning
+	for _, mode := range []PartitioningMode{PartitioningKeep, PartitioningRemove} {
+		mods := StatementModifiers{Partitioning: mode}
+		assertAlter(hashPartitioned("HASH", 4), hashPartitioned("HASH", 6), mods, "")
+		assertAlter(hashPartitioned("KEY", 6), hashPartitioned("KEY", 4), mods, "")
+		assertAlter(from, to, mods, "ALTER TABLE `prange` COMMENT 'hello'")
+	}
+	mods = StatementModifiers{Partitioning: PartitioningPermissive, Flavor: FlavorMySQL80}
+	assertAlter(hashPartitioned("HASH", 4), hashPartitioned("HASH", 6), mods, "ALTER TABLE `prange` ADD PARTITION PARTITIONS 2")
+
+	// Changes to individual non-default partitions remain unsupported
+	from, to = hashPartitioned("HASH", 4), hashPartitioned("HASH", 2)
+	to.Partitioning.Partitions[1].Name = "second"
+	to.CreateStatement = ""
+	if _, supported := from.Diff(to); supported {
+		t.Error("Expected diff to be unsupported, but it was supported")
+	}
+}
+
 func TestTableUnpartitionedCreateStatement(t *testing.T) {
 	flavors := []Flavor{FlavorMySQL55, FlavorMySQL56, FlavorMySQL80, FlavorMariaDB102}
 	for _, flavor := range flavors {
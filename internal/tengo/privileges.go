@@ -0,0 +1,86 @@
+package tengo
+
+import (
+	"sort"
+)
+
+// RequiredPrivileges returns the names of the privileges needed to execute the
+// statements of the supplied diffs, sorted alphabetically and without
+// duplicates. This is determined offline, based solely on the type of each
+// operation:
+//
+//   - CREATE TABLE requires CREATE, plus REFERENCES if the table has foreign
+//     keys.
+//   - ALTER TABLE requires ALTER, CREATE, and INSERT; plus REFERENCES if adding
+//     a foreign key, or DROP if dropping a partition.
+//   - DROP TABLE requires DROP.
+//   - CREATE PROCEDURE or CREATE FUNCTION requires CREATE ROUTINE. Dropping or
+//     replacing a routine requires ALTER ROUTINE.
+//   - CREATE DATABASE, ALTER DATABASE, and DROP DATABASE require CREATE, ALTER,
+//     and DROP, respectively.
+//
+// Privileges which depend on server configuration or object metadata are not
+// included. For example, creating a routine with a DEFINER other than the
+// current user requires SET_USER_ID (MySQL 8) or SUPER, and creating a
+// function with binary logging enabled may require SUPER.
+func RequiredPrivileges(diffs ...ObjectDiff) []string {
+	privs := make(map[string]bool)
+	for _, diff := range diffs {
+		switch diff := diff.(type) {
+		case *DatabaseDiff:
+			switch diff.DiffType() {
+			case DiffTypeCreate:
+				privs["CREATE"] = true
+			case DiffTypeAlter:
+				privs["ALTER"] = true
+			case DiffTypeDrop:
+				privs["DROP"] = true
+			}
+		case *TableDiff:
+			switch diff.DiffType() {
+			case DiffTypeCreate:
+				privs["CREATE"] = true
+				if len(diff.To.ForeignKeys) > 0 {
+					privs["REFERENCES"] = true
+				}
+			case DiffTypeAlter:
+				privs["ALTER"], privs["CREATE"], privs["INSERT"] = true, true, true
+				for _, clause := range diff.alterClauses {
+					switch clause := clause.(type) {
+					case AddForeignKey:
+						privs["REFERENCES"] = true
+					case ModifyPartitions:
+						if len(clause.Drop) > 0 {
+							privs["DROP"] = true
+						}
+					}
+				}
+			case DiffTypeDrop:
+				privs["DROP"] = true
+			}
+		case *RoutineDiff:
+			switch diff.DiffType() {
+			case DiffTypeCreate:
+				privs["CREATE ROUTINE"] = true
+				if diff.ForReplace {
+					privs["ALTER ROUTINE"] = true // MariaDB uses CREATE OR REPLACE
+				}
+			case DiffTypeDrop:
+				privs["ALTER ROUTINE"] = true
+			}
+		}
+	}
+	result := make([]string, 0, len(privs))
+	for priv := range privs {
+		result = append(result, priv)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// RequiredPrivileges returns the names of the privileges needed to execute all
+// statements in the diff. See the package-level RequiredPrivileges function for
+// details.
+func (sd *SchemaDiff) RequiredPrivileges() []string {
+	return RequiredPrivileges(sd.ObjectDiffs()...)
+}
@@ -0,0 +1,44 @@
+package tengo
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRequiredPrivileges(t *testing.T) {
+	assertPrivs := func(diff *SchemaDiff, expected ...string) {
+		t.Helper()
+		if expected == nil {
+			expected = []string{}
+		}
+		if actual := diff.RequiredPrivileges(); !reflect.DeepEqual(actual, expected) {
+			t.Errorf("Unexpected result from RequiredPrivileges\nExpected: %q\nActual:   %q", expected, actual)
+		}
+	}
+
+	fkTable := foreignKeyTable()
+	plainTable := anotherTable()
+	proc := aProc("latin1_swedish_ci", "")
+	s1 := aSchema("s1", &plainTable)
+	s2 := aSchema("s1", &plainTable, &fkTable)
+	s2.Routines = []*Routine{&proc}
+	assertPrivs(NewSchemaDiff(&s1, &s1))
+	assertPrivs(NewSchemaDiff(&s1, &s2), "CREATE", "CREATE ROUTINE", "REFERENCES")
+	assertPrivs(NewSchemaDiff(&s2, &s1), "ALTER ROUTINE", "DROP")
+	assertPrivs(NewSchemaDiff(nil, &s1), "CREATE")
+
+	// Altering a table requires ALTER, CREATE, and INSERT; adding a foreign key
+	// additionally requires REFERENCES
+	noFKTable := foreignKeyTable()
+	noFKTable.ForeignKeys = nil
+	noFKTable.CreateStatement = noFKTable.GeneratedCreateStatement(FlavorUnknown)
+	s3 := aSchema("s1", &plainTable, &noFKTable)
+	s3.Routines = s2.Routines
+	assertPrivs(NewSchemaDiff(&s2, &s3), "ALTER", "CREATE", "INSERT")
+	assertPrivs(NewSchemaDiff(&s3, &s2), "ALTER", "CREATE", "INSERT", "REFERENCES")
+
+	// Database-level changes
+	s4 := aSchema("s1", &plainTable)
+	s4.CharSet, s4.Collation = "utf8mb4", "utf8mb4_general_ci"
+	assertPrivs(NewSchemaDiff(&s1, &s4), "ALTER")
+}
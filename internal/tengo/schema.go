@@ -2,6 +2,7 @@ package tengo
 
 import (
 	"fmt"
+	"io"
 	"strings"
 )
 
@@ -10,6 +11,7 @@ type Schema struct {
 	Name      string     `json:"databaseName"`
 	CharSet   string     `json:"defaultCharSet"`
 	Collation string     `json:"defaultCollation"`
+	Comment   string     `json:"comment,omitempty"` // only supported in MariaDB 10.5+
 	Tables    []*Table   `json:"tables,omitempty"`
 	Routines  []*Routine `json:"routines,omitempty"`
 }
@@ -106,6 +108,163 @@ func (s *Schema) Objects() map[ObjectKey]DefKeyer {
 	return dict
 }
 
+// UnreferencedRoutines returns any stored procedures and functions in the
+// schema which are not referenced by any other object in the schema: procedures
+// which are never invoked via CALL by another routine, and functions which are
+// never called by another routine or by a table's generated column, default
+// expression, or check constraint. Procedure references are determined by
+// Statement.References, and function calls by a lexical scan for the function
+// name followed by an opening parenthesis. Recursive references from a routine
+// to itself are not counted. Since references from outside of the database
+// cannot be detected, the result is only suitable for advisory purposes.
+func (s *Schema) UnreferencedRoutines() []*Routine {
+	if s == nil || len(s.Routines) == 0 {
+		return nil
+	}
+	funcNames := make(map[string]bool)
+	for _, r := range s.Routines {
+		if r.Type == ObjectTypeFunc {
+			funcNames[strings.ToLower(r.Name)] = true
+		}
+	}
+
+	// Routine names are case-insensitive, so keys are tracked using lowercased
+	// names
+	referenced := make(map[ObjectKey]bool)
+	for key, obj := range s.Objects() {
+		stmt := ParseStatementInString(obj.Def())
+		for _, ref := range stmt.References() {
+			if ref.Type == ObjectTypeProc {
+				referenced[ObjectKey{Type: ObjectTypeProc, Name: strings.ToLower(ref.Name)}] = true
+			}
+		}
+		for name := range calledFunctions(stmt, funcNames) {
+			if key.Type != ObjectTypeFunc || !strings.EqualFold(key.Name, name) {
+				referenced[ObjectKey{Type: ObjectTypeFunc, Name: name}] = true
+			}
+		}
+	}
+
+	var result []*Routine
+	for _, r := range s.Routines {
+		if !referenced[ObjectKey{Type: r.Type, Name: strings.ToLower(r.Name)}] {
+			result = append(result, r)
+		}
+	}
+	return result
+}
+
+// calledFunctions returns the subset of funcNames (which must be lowercased)
+// which appear to be called in stmt's body. Matches inside of string literals
+// and comments are ignored. Keys of the result are lowercased.
+func calledFunctions(stmt *Statement, funcNames map[string]bool) map[string]bool {
+	result := make(map[string]bool)
+	if len(funcNames) == 0 || stmt.Type != StatementTypeCreate {
+		return result
+	}
+	body, _ := stmt.SplitTextBody()
+	lex := NewLexer(strings.NewReader(body), "\000", 8192)
+	var prev Token
+	var seenParen bool // first paren follows the statement's own object name
+	for {
+		val, typ, err := lex.Scan()
+		if err == io.EOF || (err != nil && len(val) == 0) {
+			return result
+		} else if typ == TokenFiller {
+			continue
+		}
+		if typ == TokenSymbol && val[0] == '(' && !seenParen {
+			seenParen = true
+		} else if typ == TokenSymbol && val[0] == '(' && (prev.typ == TokenWord || prev.typ == TokenIdent) {
+			if name, ok := getNameFromToken(prev); ok && funcNames[strings.ToLower(name)] {
+				result[strings.ToLower(name)] = true
+			}
+		}
+		prev = Token{val: string(val), typ: typ}
+	}
+}
+
+// ObjectStatus indicates whether an object's definition on a database server
+// matches its desired definition.
+type ObjectStatus int
+
+// Constants enumerating ObjectStatus values
+const (
+	ObjectInSync          ObjectStatus = iota // object exists on both sides and is functionally equivalent
+	ObjectNeedsChange                         // object exists on both sides but differs
+	ObjectMissingOnServer                     // object only exists in the desired schema
+	ObjectExtraOnServer                       // object only exists on the server
+)
+
+func (status ObjectStatus) String() string {
+	switch status {
+	case ObjectInSync:
+		return "in-sync"
+	case ObjectNeedsChange:
+		return "needs-change"
+	case ObjectMissingOnServer:
+		return "missing-on-server"
+	default:
+		return "extra-on-server"
+	}
+}
+
+// ObjectStatuses compares the objects in s, which should represent a schema
+// as it currently exists on a database server, to those in desired, which
+// typically represents the schema's definition in the filesystem. It returns
+// the status of every object present on either side. This is faster than a
+// full SchemaDiff, since no DDL is generated. Differences in tables' next
+// AUTO_INCREMENT values are ignored. Either schema may be nil, in which case
+// it is treated as having no objects.
+func (s *Schema) ObjectStatuses(desired *Schema) map[ObjectKey]ObjectStatus {
+	actualObjects, desiredObjects := s.Objects(), desired.Objects()
+	result := make(map[ObjectKey]ObjectStatus, len(desiredObjects))
+	for key, desiredObj := range desiredObjects {
+		actualObj, ok := actualObjects[key]
+		if !ok {
+			result[key] = ObjectMissingOnServer
+		} else if objectsEquivalent(actualObj, desiredObj) {
+			result[key] = ObjectInSync
+		} else {
+			result[key] = ObjectNeedsChange
+		}
+	}
+	for key := range actualObjects {
+		if _, ok := desiredObjects[key]; !ok {
+			result[key] = ObjectExtraOnServer
+		}
+	}
+	return result
+}
+
+// objectsEquivalent returns true if from and to are functionally equivalent,
+// ignoring differences in next AUTO_INCREMENT value for tables. Table
+// differences which would not generate any DDL with default statement
+// modifiers, such as cosmetic index reordering, are also ignored.
+func objectsEquivalent(from, to DefKeyer) bool {
+	if from.Def() == to.Def() {
+		return true
+	}
+	fromTable, fromIsTable := from.(*Table)
+	toTable, toIsTable := to.(*Table)
+	if !fromIsTable || !toIsTable {
+		fromRoutine, fromIsRoutine := from.(*Routine)
+		toRoutine, toIsRoutine := to.(*Routine)
+		return fromIsRoutine && toIsRoutine && fromRoutine.Equals(toRoutine)
+	}
+	clauses, supported := fromTable.Diff(toTable)
+	if !supported {
+		return false
+	}
+	mods := StatementModifiers{NextAutoInc: NextAutoIncIgnore}
+	for _, clause := range clauses {
+		if clause.Clause(mods) != "" {
+			return false
+		}
+	}
+	return true
+}
+
 // StripMatches removes objects from s if they match any supplied pattern. The
 // in-memory representation of the schema is modified in-place. This does not
 // affect any actual database instances.
@@ -145,14 +304,17 @@ func (s *Schema) DropStatement() string {
 // CreateStatement returns a SQL statement that, if run, would create this
 // schema.
 func (s *Schema) CreateStatement() string {
-	var charSet, collate string
+	var charSet, collate, comment string
 	if s.CharSet != "" {
 		charSet = fmt.Sprintf(" CHARACTER SET %s", s.CharSet)
 	}
 	if s.Collation != "" {
 		collate = fmt.Sprintf(" COLLATE %s", s.Collation)
 	}
-	return fmt.Sprintf("CREATE DATABASE %s%s%s", EscapeIdentifier(s.Name), charSet, collate)
+	if s.Comment != "" {
+		comment = fmt.Sprintf(" COMMENT '%s'", EscapeValueForCreateTable(s.Comment))
+	}
+	return fmt.Sprintf("CREATE DATABASE %s%s%s%s", EscapeIdentifier(s.Name), charSet, collate, comment)
 }
 
 // AlterStatement returns a SQL statement that, if run, would alter this
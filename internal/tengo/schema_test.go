@@ -2,6 +2,7 @@ package tengo
 
 import (
 	"encoding/json"
+	"reflect"
 	"regexp"
 	"testing"
 )
@@ -119,3 +120,76 @@ func (s TengoIntegrationSuite) TestSchemaStripMatches(t *testing.T) {
 	schema = nil
 	schema.StripMatches([]ObjectPattern{matchFunc})
 }
+
+func TestSchemaObjectStatuses(t *testing.T) {
+	actualT1, desiredT1 := aTable(1), aTable(5) // only next auto-inc differs
+	actualT2, desiredT2 := anotherTable(), anotherTable()
+	desiredT2.Comment = "hello"
+	desiredT2.CreateStatement = desiredT2.GeneratedCreateStatement(FlavorUnknown)
+	onlyActual, onlyDesired := unpartitionedTable(FlavorUnknown), foreignKeyTable()
+	actualProc, desiredProc := aProc("latin1_swedish_ci", ""), aProc("latin1_swedish_ci", "")
+	actualFunc, desiredFunc := aFunc("latin1_swedish_ci", ""), aFunc("latin1_swedish_ci", "")
+	desiredFunc.Body = "return mult * 3.0"
+	desiredFunc.CreateStatement = desiredFunc.Definition(FlavorUnknown)
+	actualT3, desiredT3 := aTable(1), aTable(1) // only index order differs
+	actualT3.Name, desiredT3.Name = "reordered", "reordered"
+	desiredT3.SecondaryIndexes[0], desiredT3.SecondaryIndexes[1] = desiredT3.SecondaryIndexes[1], desiredT3.SecondaryIndexes[0]
+	actualT3.CreateStatement = actualT3.GeneratedCreateStatement(FlavorUnknown)
+	desiredT3.CreateStatement = desiredT3.GeneratedCreateStatement(FlavorUnknown)
+
+	actual := aSchema("s1", &actualT1, &actualT2, &actualT3, &onlyActual)
+	actual.Routines = []*Routine{&actualProc, &actualFunc}
+	desired := aSchema("s1", &desiredT1, &desiredT2, &desiredT3, &onlyDesired)
+	desired.Routines = []*Routine{&desiredProc, &desiredFunc}
+
+	expected := map[ObjectKey]ObjectStatus{
+		actualT1.ObjectKey():    ObjectInSync,
+		actualT2.ObjectKey():    ObjectNeedsChange,
+		actualT3.ObjectKey():    ObjectInSync,
+		onlyActual.ObjectKey():  ObjectExtraOnServer,
+		onlyDesired.ObjectKey(): ObjectMissingOnServer,
+		actualProc.ObjectKey():  ObjectInSync,
+		actualFunc.ObjectKey():  ObjectNeedsChange,
+	}
+	if statuses := actual.ObjectStatuses(&desired); !reflect.DeepEqual(statuses, expected) {
+		t.Errorf("Unexpected result from ObjectStatuses: expected %v, found %v", expected, statuses)
+	}
+
+	// With no server schema, all objects are missing
+	var nilSchema *Schema
+	for key, status := range nilSchema.ObjectStatuses(&desired) {
+		if status != ObjectMissingOnServer {
+			t.Errorf("Expected %s to have status %s, instead found %s", key, ObjectMissingOnServer, status)
+		}
+	}
+}
+
+func TestSchemaUnreferencedRoutines(t *testing.T) {
+	newRoutine := func(objType ObjectType, name, create string) *Routine {
+		return &Routine{Name: name, Type: objType, CreateStatement: create}
+	}
+	table := aTable(1)
+	table.Columns = append(table.Columns, &Column{Name: "code", TypeInDB: "int", GenerationExpr: "`gen_code`(`actor_id`)"})
+	table.CreateStatement = "CREATE TABLE `actor` (\n  `actor_id` int NOT NULL,\n  `code` int GENERATED ALWAYS AS (`gen_code`(`actor_id`)) VIRTUAL\n) ENGINE=InnoDB"
+	s := aSchema("s1", &table)
+	s.Routines = []*Routine{
+		newRoutine(ObjectTypeFunc, "gen_code", "CREATE FUNCTION `gen_code`(a int) RETURNS int DETERMINISTIC RETURN a * 2"),
+		newRoutine(ObjectTypeFunc, "helper", "CREATE FUNCTION helper(a int) RETURNS int DETERMINISTIC RETURN a + 1"),
+		newRoutine(ObjectTypeFunc, "fact", "CREATE FUNCTION fact(n int) RETURNS int DETERMINISTIC RETURN IF(n <= 1, 1, n * fact(n - 1))"),
+		newRoutine(ObjectTypeProc, "inner_proc", "CREATE PROCEDURE inner_proc() BEGIN SELECT 'outer_proc(' ; END"),
+		newRoutine(ObjectTypeProc, "outer_proc", "CREATE PROCEDURE outer_proc() BEGIN CALL Inner_Proc(); SELECT HELPER(1); END"),
+	}
+	var actual []string
+	for _, r := range s.UnreferencedRoutines() {
+		actual = append(actual, r.Name)
+	}
+	expected := []string{"fact", "outer_proc"}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected unreferenced routines %v, instead found %v", expected, actual)
+	}
+
+	s.Routines = nil
+	if result := s.UnreferencedRoutines(); len(result) != 0 {
+		t.Errorf("Expected no unreferenced routines in schema without routines, instead found %v", result)
+	}
+}
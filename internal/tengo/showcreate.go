@@ -0,0 +1,33 @@
+package tengo
+
+import (
+	"fmt"
+)
+
+// ShowCreate returns the CREATE statement for obj, formatted exactly as SHOW
+// CREATE TABLE, SHOW CREATE PROCEDURE, or SHOW CREATE FUNCTION would return it
+// from a database server of the supplied flavor. Unlike obj.Def(), which may
+// have been normalized during introspection, obtained from a server of a
+// different flavor, or written by hand, the result is generated entirely from
+// obj's fields. This makes it useful for comparing against real server output,
+// for example to validate introspection fidelity in tests. For InnoDB tables,
+// no-op options which are removed by NormalizeCreateOptions are never included,
+// since they cannot be determined from information_schema.
+//
+// Views are not supported, since this package does not introspect or represent
+// them. An error is returned if obj is a table using features which are not
+// supported for generation (see Table.UnsupportedDDL), or if obj is of any type
+// other than a table or routine.
+func ShowCreate(obj DefKeyer, flavor Flavor) (string, error) {
+	switch obj := obj.(type) {
+	case *Table:
+		if obj.UnsupportedDDL {
+			return "", fmt.Errorf("%s uses features which are not supported for SHOW CREATE generation", obj.ObjectKey())
+		}
+		return obj.GeneratedCreateStatement(flavor), nil
+	case *Routine:
+		return obj.Definition(flavor), nil
+	default:
+		return "", fmt.Errorf("SHOW CREATE generation is not supported for %s", obj.ObjectKey())
+	}
+}
@@ -0,0 +1,101 @@
+package tengo
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestShowCreate(t *testing.T) {
+	// Expected output matches the format of SHOW CREATE TABLE in MySQL 8.0
+	table := &Table{
+		Name:               "widgets",
+		Engine:             "InnoDB",
+		CharSet:            "utf8mb4",
+		Collation:          "utf8mb4_0900_ai_ci",
+		CollationIsDefault: true,
+		NextAutoIncrement:  12,
+		Columns: []*Column{
+			{Name: "id", TypeInDB: "int unsigned", AutoIncrement: true},
+			{Name: "name", TypeInDB: "varchar(40)", Nullable: true, Default: "NULL", CharSet: "utf8mb4", Collation: "utf8mb4_0900_ai_ci", CollationIsDefault: true},
+		},
+		PrimaryKey: &Index{Name: "PRIMARY", PrimaryKey: true, Unique: true, Type: "BTREE", Parts: []IndexPart{{ColumnName: "id"}}},
+	}
+	expected := "CREATE TABLE `widgets` (\n  `id` int unsigned NOT NULL AUTO_INCREMENT,\n  `name` varchar(40) DEFAULT NULL,\n  PRIMARY KEY (`id`)\n) ENGINE=InnoDB AUTO_INCREMENT=12 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci"
+	if actual, err := ShowCreate(table, ParseFlavor("mysql:8.0")); err != nil || actual != expected {
+		t.Errorf("Unexpected result from ShowCreate: %q / err=%v", actual, err)
+	}
+
+	// Expected output matches the format of SHOW CREATE FUNCTION
+	routine := &Routine{
+		Name:           "add_tax",
+		Type:           ObjectTypeFunc,
+		Body:           "RETURN amount * 1.08",
+		ParamString:    "amount decimal(10,2)",
+		ReturnDataType: "decimal(10,2)",
+		Definer:        "root@localhost",
+		Deterministic:  true,
+		SQLDataAccess:  "NO SQL",
+		SecurityType:   "DEFINER",
+	}
+	expected = "CREATE DEFINER=`root`@`localhost` FUNCTION `add_tax`(amount decimal(10,2)) RETURNS decimal(10,2)\n    NO SQL\n    DETERMINISTIC\nRETURN amount * 1.08"
+	if actual, err := ShowCreate(routine, ParseFlavor("mysql:8.0")); err != nil || actual != expected {
+		t.Errorf("Unexpected result from ShowCreate: %q / err=%v", actual, err)
+	}
+
+	// The test table fixtures should be consistent with their own generated form;
+	// comparison against real server output occurs in TestShowCreateIntegration
+	for _, flavor := range []Flavor{FlavorMySQL55, FlavorMySQL57, FlavorMySQL80.Dot(30), FlavorMariaDB103, FlavorMariaDB106.Dot(11)} {
+		table := aTableForFlavor(flavor, 1)
+		if actual, err := ShowCreate(&table, flavor); err != nil || actual != table.CreateStatement {
+			t.Errorf("Unexpected result from ShowCreate for %s:\nexpected: %s\nfound:    %s\nerr=%v", flavor, table.CreateStatement, actual, err)
+		}
+	}
+
+	// Unsupported tables and other object types return an error
+	table.UnsupportedDDL = true
+	if _, err := ShowCreate(table, ParseFlavor("mysql:8.0")); err == nil {
+		t.Error("Expected error from ShowCreate on unsupported table, but err was nil")
+	}
+	schema := aSchema("s1")
+	if _, err := ShowCreate(&schema, ParseFlavor("mysql:8.0")); err == nil {
+		t.Error("Expected error from ShowCreate on schema, but err was nil")
+	}
+}
+
+// TestShowCreateIntegration confirms that ShowCreate matches real SHOW CREATE
+// output for all supported tables and routines in the integration test schema.
+func (s TengoIntegrationSuite) TestShowCreateIntegration(t *testing.T) {
+	flavor := s.d.Flavor()
+	schema := s.GetSchema(t, "testing")
+	db, err := s.d.Connect("testing", "")
+	if err != nil {
+		t.Fatalf("Unexpected error from Connect: %v", err)
+	}
+	ctx := context.Background()
+	for _, table := range schema.Tables {
+		if table.UnsupportedDDL {
+			continue
+		}
+		expected, err := showCreateTable(ctx, db, table.Name)
+		if err != nil {
+			t.Fatalf("Unexpected error from SHOW CREATE TABLE %s: %v", table.Name, err)
+		}
+		if table.Engine == "InnoDB" {
+			expected = NormalizeCreateOptions(expected)
+		}
+		if actual, err := ShowCreate(table, flavor); err != nil || actual != expected {
+			t.Errorf("Unexpected result from ShowCreate for %s:\nexpected: %s\nfound:    %s\nerr=%v", table.ObjectKey(), expected, actual, err)
+		}
+	}
+	for _, routine := range schema.Routines {
+		expected, err := showCreateRoutine(ctx, db, routine.Name, routine.Type)
+		if err != nil {
+			t.Fatalf("Unexpected error from SHOW CREATE %s %s: %v", routine.Type.Caps(), routine.Name, err)
+		}
+		expected = strings.Replace(expected, "\r\n", "\n", -1)
+		if actual, err := ShowCreate(routine, flavor); err != nil || actual != expected {
+			t.Errorf("Unexpected result from ShowCreate for %s:\nexpected: %s\nfound:    %s\nerr=%v", routine.ObjectKey(), expected, actual, err)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package tengo
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SQLModeProblem describes a clause of a table definition which would be
+// rejected, or would behave differently, under a particular sql_mode.
+type SQLModeProblem struct {
+	Column   *Column
+	Mode     string // the sql_mode value responsible for the problem
+	Rejected bool   // true if the CREATE or ALTER would fail outright; false if it just emits a warning
+	Reason   string
+}
+
+// String returns a human-readable description of the problem.
+func (p SQLModeProblem) String() string {
+	return fmt.Sprintf("column %s: %s", EscapeIdentifier(p.Column.Name), p.Reason)
+}
+
+// ParseSQLMode splits a comma-separated sql_mode value into a set of
+// individual modes, expanding combination modes (e.g. TRADITIONAL) into the
+// modes they imply. Mode names are normalized to uppercase.
+func ParseSQLMode(sqlMode string) map[string]bool {
+	combinations := map[string][]string{
+		"TRADITIONAL": {"STRICT_TRANS_TABLES", "STRICT_ALL_TABLES", "NO_ZERO_IN_DATE", "NO_ZERO_DATE", "ERROR_FOR_DIVISION_BY_ZERO", "NO_ENGINE_SUBSTITUTION"},
+		"ANSI":        {"REAL_AS_FLOAT", "PIPES_AS_CONCAT", "ANSI_QUOTES", "IGNORE_SPACE", "ONLY_FULL_GROUP_BY"},
+	}
+	modes := make(map[string]bool)
+	for _, mode := range strings.Split(strings.ToUpper(sqlMode), ",") {
+		mode = strings.TrimSpace(strings.Trim(mode, "'\""))
+		if mode == "" {
+			continue
+		}
+		modes[mode] = true
+		for _, implied := range combinations[mode] {
+			modes[implied] = true
+		}
+	}
+	return modes
+}
+
+// SQLModeProblems returns information about any column clauses in t which
+// would be rejected, or would behave differently, if the table was created
+// under the supplied sql_mode. The sqlMode arg should be formatted like
+// @@sql_mode: a comma-separated list of modes.
+// Currently this only examines zero-date default values, which are affected by
+// the NO_ZERO_DATE and NO_ZERO_IN_DATE modes. These modes only cause outright
+// errors in combination with a strict mode; otherwise they just emit warnings.
+func (t *Table) SQLModeProblems(sqlMode string) (problems []SQLModeProblem) {
+	modes := ParseSQLMode(sqlMode)
+	strict := modes["STRICT_TRANS_TABLES"] || modes["STRICT_ALL_TABLES"]
+	for _, col := range t.Columns {
+		if !strings.HasPrefix(col.TypeInDB, "timestamp") && !strings.HasPrefix(col.TypeInDB, "date") {
+			continue
+		}
+		if !strings.HasPrefix(col.Default, "'") {
+			continue // no default, NULL default, or expression default
+		}
+		datePart, _, _ := strings.Cut(strings.Trim(col.Default, "'"), " ")
+		if modes["NO_ZERO_DATE"] && strings.Trim(datePart, "0-") == "" {
+			problems = append(problems, SQLModeProblem{
+				Column:   col,
+				Mode:     "NO_ZERO_DATE",
+				Rejected: strict,
+				Reason:   "default value " + col.Default + " is a zero date",
+			})
+		} else if modes["NO_ZERO_IN_DATE"] && hasZeroInDate(datePart) {
+			problems = append(problems, SQLModeProblem{
+				Column:   col,
+				Mode:     "NO_ZERO_IN_DATE",
+				Rejected: strict,
+				Reason:   "default value " + col.Default + " has a zero month or day",
+			})
+		}
+	}
+	return problems
+}
+
+// hasZeroInDate returns true if the month or day component of a date string
+// formatted as YYYY-MM-DD consists entirely of zeroes, and the year component
+// does not. NO_ZERO_IN_DATE does not apply to dates with a zero year, including
+// the all-zero date, which is instead governed by NO_ZERO_DATE.
+func hasZeroInDate(date string) bool {
+	components := strings.Split(date, "-")
+	if strings.Trim(components[0], "0") == "" {
+		return false
+	}
+	for _, component := range components[1:] {
+		if strings.Trim(component, "0") == "" {
+			return true
+		}
+	}
+	return false
+}
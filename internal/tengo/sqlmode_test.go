@@ -0,0 +1,69 @@
+package tengo
+
+import (
+	"testing"
+)
+
+func TestParseSQLMode(t *testing.T) {
+	modes := ParseSQLMode("'traditional,ONLY_FULL_GROUP_BY'")
+	for _, expected := range []string{"TRADITIONAL", "ONLY_FULL_GROUP_BY", "STRICT_ALL_TABLES", "NO_ZERO_DATE", "NO_ZERO_IN_DATE"} {
+		if !modes[expected] {
+			t.Errorf("Expected mode %s to be present, but it was not", expected)
+		}
+	}
+	if modes["ANSI_QUOTES"] {
+		t.Error("Unexpectedly found ANSI_QUOTES mode")
+	}
+	if modes := ParseSQLMode(""); len(modes) != 0 {
+		t.Errorf("Expected empty sql_mode to yield no modes, instead found %v", modes)
+	}
+}
+
+func TestTableSQLModeProblems(t *testing.T) {
+	table := aTable(1)
+	table.Columns = append(table.Columns,
+		&Column{Name: "zero_date", TypeInDB: "date", Default: "'0000-00-00'"},
+		&Column{Name: "zero_datetime", TypeInDB: "datetime", Default: "'0000-00-00 00:00:00'"},
+		&Column{Name: "zero_day", TypeInDB: "date", Default: "'2020-01-00'"},
+		&Column{Name: "zero_year", TypeInDB: "date", Default: "'0000-01-01'"},
+		&Column{Name: "fine_date", TypeInDB: "date", Default: "'2020-01-01'"},
+		&Column{Name: "null_date", TypeInDB: "date", Default: "NULL", Nullable: true},
+		&Column{Name: "not_a_date", TypeInDB: "varchar(20)", Default: "'0000-00-00'"},
+	)
+	cases := []struct {
+		sqlMode          string
+		expectedColumns  []string
+		expectedRejected bool
+	}{
+		{"", nil, false},
+		{"NO_ENGINE_SUBSTITUTION", nil, false},
+		{"NO_ZERO_DATE", []string{"zero_date", "zero_datetime"}, false},
+		{"STRICT_TRANS_TABLES,NO_ZERO_DATE", []string{"zero_date", "zero_datetime"}, true},
+		{"STRICT_ALL_TABLES,NO_ZERO_IN_DATE", []string{"zero_day"}, true},
+		{"TRADITIONAL", []string{"zero_date", "zero_datetime", "zero_day"}, true},
+	}
+	for _, c := range cases {
+		problems := table.SQLModeProblems(c.sqlMode)
+		if len(problems) != len(c.expectedColumns) {
+			t.Errorf("With sql_mode %q, expected %d problems, instead found %d: %v", c.sqlMode, len(c.expectedColumns), len(problems), problems)
+			continue
+		}
+		for n, p := range problems {
+			if p.Column.Name != c.expectedColumns[n] {
+				t.Errorf("With sql_mode %q, expected problems[%d] to reference column %s, instead found %s", c.sqlMode, n, c.expectedColumns[n], p.Column.Name)
+			}
+			if p.Rejected != c.expectedRejected {
+				t.Errorf("With sql_mode %q, expected problems[%d].Rejected to be %t, instead found %t", c.sqlMode, n, c.expectedRejected, p.Rejected)
+			}
+		}
+	}
+
+	// Confirm the responsible mode is identified properly
+	problems := table.SQLModeProblems("TRADITIONAL")
+	if problems[0].Mode != "NO_ZERO_DATE" || problems[2].Mode != "NO_ZERO_IN_DATE" {
+		t.Errorf("Unexpected modes in problems: %v", problems)
+	}
+	if expected := "column `zero_day`: default value '2020-01-00' has a zero month or day"; problems[2].String() != expected {
+		t.Errorf("Unexpected String() output: expected %q, found %q", expected, problems[2].String())
+	}
+}
@@ -2,6 +2,7 @@ package tengo
 
 import (
 	"fmt"
+	"io"
 	"strings"
 )
 
@@ -113,6 +114,40 @@ func (stmt *Statement) NormalizeTrailer() {
 	}
 }
 
+// MissingDelimiter returns true if stmt appears to actually consist of
+// multiple statements, due to a missing delimiter between them. This typically
+// occurs after manual editing of a file. Detection is heuristic: a
+// non-compound CREATE is considered suspect if a reserved statement-leading
+// keyword (CREATE, ALTER, DROP, USE) appears at the start of a line anywhere
+// after the statement's beginning. Keywords inside of strings or comments are
+// ignored. Compound statements are never considered suspect, since their
+// BEGIN blocks may legitimately contain other statements.
+func (stmt *Statement) MissingDelimiter() bool {
+	if stmt.Type != StatementTypeCreate || stmt.Compound {
+		return false
+	}
+	body, _ := stmt.SplitTextBody()
+	lex := NewLexer(strings.NewReader(body), "\000", 8192)
+	afterNewline, first := false, true
+	for {
+		val, typ, err := lex.Scan()
+		if err == io.EOF || (err != nil && len(val) == 0) {
+			return false
+		}
+		if typ == TokenFiller {
+			afterNewline = strings.ContainsRune(string(val), '\n')
+			continue
+		}
+		if typ == TokenWord && afterNewline && !first {
+			switch strings.ToUpper(string(val)) {
+			case "CREATE", "ALTER", "DROP", "USE":
+				return true
+			}
+		}
+		afterNewline, first = false, false
+	}
+}
+
 // Compounder is implemented by types that have the ability to represent
 // compound statements, requiring special delimiter handling.
 type Compounder interface {
@@ -123,3 +158,441 @@ type Compounder interface {
 func (stmt *Statement) IsCompoundStatement() bool {
 	return stmt != nil && stmt.Compound
 }
+
+// References returns keys for other objects referenced by the statement's
+// body: tables referenced by foreign keys or by DML inside of stored programs,
+// and procedures invoked by CALL. Matches inside of string literals and
+// comments are ignored. Schema name qualifiers are stripped from the result,
+// and the statement's own object is never included. The result is sorted and
+// has no duplicates. This is a best-effort lexical scan, not a full parse.
+func (stmt *Statement) References() []ObjectKey {
+	if stmt.Type != StatementTypeCreate {
+		return nil
+	}
+	body, _ := stmt.SplitTextBody()
+	lex := NewLexer(strings.NewReader(body), "\000", 8192)
+
+	// Collect words and identifiers, ignoring filler and strings, but retaining
+	// dot symbols to permit handling of schema-qualified names, and parens to
+	// permit distinguishing subqueries from function calls
+	var tokens []Token
+	for {
+		val, typ, err := lex.Scan()
+		if err == io.EOF || (err != nil && len(val) == 0) {
+			break
+		} else if typ == TokenWord || typ == TokenIdent || (typ == TokenSymbol && (val[0] == '.' || val[0] == '(' || val[0] == ')')) {
+			tokens = append(tokens, Token{val: string(val), typ: typ})
+		} else if typ != TokenFiller {
+			tokens = append(tokens, Token{typ: typ})
+		}
+	}
+
+	// Keywords which are followed by a reference to another object
+	keywordTypes := map[string]ObjectType{
+		"REFERENCES": ObjectTypeTable,
+		"CALL":       ObjectTypeProc,
+	}
+	if stmt.Compound || stmt.ObjectType == ObjectTypeProc || stmt.ObjectType == ObjectTypeFunc {
+		for _, kw := range []string{"FROM", "JOIN", "INTO", "UPDATE"} {
+			keywordTypes[kw] = ObjectTypeTable
+		}
+	}
+
+	seen := make(map[ObjectKey]bool)
+	var parenIsSubquery []bool // stack tracking each currently-open paren
+	for n := 0; n < len(tokens)-1; n++ {
+		if tokens[n].typ == TokenSymbol && tokens[n].val == "(" {
+			next := strings.ToUpper(tokens[n+1].val)
+			parenIsSubquery = append(parenIsSubquery, tokens[n+1].typ == TokenWord && (next == "SELECT" || next == "WITH"))
+			continue
+		} else if tokens[n].typ == TokenSymbol && tokens[n].val == ")" {
+			if len(parenIsSubquery) > 0 {
+				parenIsSubquery = parenIsSubquery[:len(parenIsSubquery)-1]
+			}
+			continue
+		} else if tokens[n].typ != TokenWord {
+			continue
+		}
+		keyword := strings.ToUpper(tokens[n].val)
+		objType, ok := keywordTypes[keyword]
+		if !ok {
+			continue
+		} else if keyword == "INTO" && (n == 0 || !isInsertKeyword(tokens[n-1])) {
+			continue // SELECT ... INTO variable, rather than INSERT INTO table
+		} else if keyword == "FROM" && len(parenIsSubquery) > 0 && !parenIsSubquery[len(parenIsSubquery)-1] {
+			continue // function call such as EXTRACT(YEAR FROM dt), rather than a subquery
+		}
+		name, ok := getNameFromToken(tokens[n+1])
+		if !ok || (tokens[n+1].typ == TokenWord && IsReservedWord(name, FlavorUnknown)) {
+			continue
+		}
+		// Handle schema-qualified name: use the part after the dot
+		if n+3 < len(tokens) && tokens[n+2].typ == TokenSymbol && tokens[n+2].val == "." {
+			if name, ok = getNameFromToken(tokens[n+3]); !ok {
+				continue
+			}
+		}
+		key := ObjectKey{Type: objType, Name: name}
+		if key != stmt.ObjectKey() {
+			seen[key] = true
+		}
+	}
+
+	result := make([]ObjectKey, 0, len(seen))
+	for key := range seen {
+		result = append(result, key)
+	}
+	SortObjectKeys(result)
+	return result
+}
+
+// RenamedBody returns the statement's body (see SplitTextBody) with object
+// names rewritten according to renames, which maps old object keys to new
+// object names. The statement's own name is rewritten if its key is present in
+// renames, retaining any schema name qualifier. References to other objects
+// are rewritten when they are unambiguous: unqualified names following
+// REFERENCES in any CREATE; and, in stored programs, unqualified
+// names following FROM, JOIN, INSERT INTO, UPDATE, or CALL, as well as calls
+// to renamed stored functions. Matches inside of string literals and comments
+// are never rewritten. Any other occurrence of a renamed object's name, such as
+// a schema-qualified reference or a table name used as a column qualifier, is
+// left as-is and its key is returned in unresolved, sorted and without
+// duplicates. Like References, this is a best-effort lexical scan, not a full
+// parse.
+func (stmt *Statement) RenamedBody(renames map[ObjectKey]string) (body string, unresolved []ObjectKey) {
+	body, _ = stmt.SplitTextBody()
+	if stmt.Type != StatementTypeCreate || len(renames) == 0 {
+		return body, nil
+	}
+	keysByName := make(map[string][]ObjectKey, len(renames))
+	for key := range renames {
+		keysByName[key.Name] = append(keysByName[key.Name], key)
+	}
+
+	// Tokenize the body, tracking positions, and noting the indexes of
+	// non-filler tokens to permit looking at the previous and next ones
+	lex := NewLexer(strings.NewReader(body), "\000", 8192)
+	var tokens []Token
+	var significant []int
+	var pos int
+	for {
+		val, typ, err := lex.Scan()
+		if len(val) > 0 {
+			if typ != TokenFiller {
+				significant = append(significant, len(tokens))
+			}
+			tokens = append(tokens, Token{val: string(val), typ: typ, offset: uint32(pos)})
+		}
+		pos += len(val)
+		if err != nil {
+			break
+		}
+	}
+	symbolAt := func(n int, symbol string) bool {
+		return n >= 0 && n < len(significant) && tokens[significant[n]].typ == TokenSymbol && tokens[significant[n]].val == symbol
+	}
+
+	// Determine the range of the statement's own name clause
+	nameStart, nameEnd := -1, -1
+	if stmt.nameClause != "" {
+		if nameStart = strings.Index(body, stmt.nameClause); nameStart >= 0 {
+			nameEnd = nameStart + len(stmt.nameClause)
+		}
+	}
+
+	keywordTypes := map[string]ObjectType{
+		"REFERENCES": ObjectTypeTable,
+	}
+	inProgram := stmt.Compound || stmt.ObjectType != ObjectTypeTable
+	if inProgram {
+		for _, kw := range []string{"FROM", "JOIN", "INTO", "UPDATE"} {
+			keywordTypes[kw] = ObjectTypeTable
+		}
+		keywordTypes["CALL"] = ObjectTypeProc
+	}
+
+	replacements := make(map[int]string) // token index -> new text
+	seen := make(map[ObjectKey]bool)
+	for sn, n := range significant {
+		t := tokens[n]
+		name, ok := getNameFromToken(t)
+		if !ok || t.typ == TokenString || len(keysByName[name]) == 0 {
+			continue
+		}
+
+		// Statement's own name: the last token of the name clause
+		if int(t.offset) >= nameStart && int(t.offset)+len(t.val) == nameEnd {
+			if newName, ok := renames[stmt.ObjectKey()]; ok && name == stmt.ObjectName {
+				replacements[n] = EscapeIdentifier(newName)
+			}
+			continue
+		} else if int(t.offset) >= nameStart && int(t.offset) < nameEnd {
+			continue // schema name qualifier of own name
+		}
+		if t.typ == TokenWord && IsReservedWord(name, FlavorUnknown) {
+			continue
+		}
+
+		if symbolAt(sn-1, "@") || symbolAt(sn+1, "@") {
+			continue // user or host in a DEFINER clause
+		}
+		var prevKeyword string
+		if sn > 0 && tokens[significant[sn-1]].typ == TokenWord {
+			prevKeyword = strings.ToUpper(tokens[significant[sn-1]].val)
+		}
+		qualified := symbolAt(sn-1, ".") || symbolAt(sn+1, ".")
+		if objType, ok := keywordTypes[prevKeyword]; ok && !qualified {
+			if prevKeyword == "INTO" && (sn < 2 || !isInsertKeyword(tokens[significant[sn-2]])) {
+				// SELECT ... INTO variable, rather than INSERT INTO table
+			} else if newName, ok := renames[ObjectKey{Type: objType, Name: name}]; ok {
+				replacements[n] = EscapeIdentifier(newName)
+				continue
+			}
+		}
+		if inProgram && !qualified && symbolAt(sn+1, "(") {
+			if newName, ok := renames[ObjectKey{Type: ObjectTypeFunc, Name: name}]; ok {
+				replacements[n] = EscapeIdentifier(newName)
+				continue
+			}
+		}
+		// In a CREATE TABLE, other objects can only be referenced by foreign keys,
+		// so any other match is just a column or index name
+		if !inProgram && prevKeyword != "REFERENCES" && !(symbolAt(sn-1, ".") && sn >= 3 && strings.EqualFold(tokens[significant[sn-3]].val, "REFERENCES")) {
+			continue
+		}
+		for _, key := range keysByName[name] {
+			seen[key] = true
+		}
+	}
+
+	if len(replacements) > 0 {
+		var b strings.Builder
+		for n, t := range tokens {
+			if replacement, ok := replacements[n]; ok {
+				b.WriteString(replacement)
+			} else {
+				b.WriteString(t.val)
+			}
+		}
+		body = b.String()
+	}
+	for key := range seen {
+		unresolved = append(unresolved, key)
+	}
+	SortObjectKeys(unresolved)
+	return body, unresolved
+}
+
+func isInsertKeyword(t Token) bool {
+	if t.typ != TokenWord {
+		return false
+	}
+	word := strings.ToUpper(t.val)
+	return word == "INSERT" || word == "REPLACE" || word == "IGNORE"
+}
+
+// DefinitionElement is one component of a CREATE statement's definition, as
+// returned by Statement.DefinitionElements.
+type DefinitionElement struct {
+	Name string // e.g. "column `email`", "index `idx_email`", "primary key", "table options"
+	Text string // element's text, with whitespace and comments collapsed to single spaces
+}
+
+// DefinitionElements splits the statement's body into its logical components.
+// For CREATE TABLE, each column, index, and constraint definition is a separate
+// element, followed by an element for any table options after the closing
+// parenthesis. For all other CREATE statements, the entire body is returned as
+// a single element named "definition". This is a lexical split, not a full
+// parse.
+func (stmt *Statement) DefinitionElements() []DefinitionElement {
+	if stmt.Type != StatementTypeCreate {
+		return nil
+	}
+	body := stmt.Body()
+	if stmt.ObjectType != ObjectTypeTable {
+		return []DefinitionElement{{Name: "definition", Text: normalizeDefinitionText(body)}}
+	}
+
+	lex := NewLexer(strings.NewReader(body), "\000", 8192)
+	var result []DefinitionElement
+	var current []Token
+	var depth int
+	var inOptions bool
+	addElement := func() {
+		if text := joinDefinitionTokens(current); text != "" {
+			name := "table options"
+			if !inOptions {
+				name = definitionElementName(current, len(result))
+			}
+			result = append(result, DefinitionElement{Name: name, Text: text})
+		}
+		current = nil
+	}
+	for {
+		val, typ, err := lex.Scan()
+		if err == io.EOF || (err != nil && len(val) == 0) {
+			break
+		}
+		t := Token{val: string(val), typ: typ}
+		if typ == TokenSymbol && val[0] == '(' {
+			depth++
+			if depth == 1 && !inOptions {
+				continue // opening paren of table body
+			}
+		} else if typ == TokenSymbol && val[0] == ')' {
+			depth--
+			if depth == 0 && !inOptions {
+				addElement()
+				inOptions = true
+				continue
+			}
+		} else if typ == TokenSymbol && val[0] == ',' && depth == 1 && !inOptions {
+			addElement()
+			continue
+		}
+		if depth > 0 || inOptions {
+			current = append(current, t)
+		}
+	}
+	if inOptions {
+		addElement()
+	}
+	return result
+}
+
+// definitionElementName returns a descriptive name for a component of a
+// CREATE TABLE, based on its leading tokens. position is used to describe
+// unnamed constraints.
+func definitionElementName(tokens []Token, position int) string {
+	var words []Token
+	for _, t := range tokens {
+		if t.typ != TokenFiller {
+			words = append(words, t)
+		}
+	}
+	if len(words) == 0 {
+		return fmt.Sprintf("element %d", position+1)
+	}
+	nameAfter := func(n int) string {
+		for ; n < len(words); n++ {
+			if words[n].typ == TokenWord && (strings.EqualFold(words[n].val, "KEY") || strings.EqualFold(words[n].val, "INDEX")) {
+				continue
+			}
+			if words[n].typ == TokenSymbol {
+				break
+			}
+			if name, ok := getNameFromToken(words[n]); ok {
+				return EscapeIdentifier(name)
+			}
+		}
+		return ""
+	}
+	first := strings.ToUpper(words[0].val)
+	switch {
+	case words[0].typ == TokenIdent:
+		name, _ := getNameFromToken(words[0])
+		return "column " + EscapeIdentifier(name)
+	case words[0].typ != TokenWord:
+		return fmt.Sprintf("element %d", position+1)
+	case first == "PRIMARY":
+		return "primary key"
+	case first == "KEY" || first == "INDEX" || first == "UNIQUE" || first == "FULLTEXT" || first == "SPATIAL":
+		if name := nameAfter(1); name != "" {
+			return "index " + name
+		}
+		return fmt.Sprintf("unnamed index %d", position+1)
+	case first == "CONSTRAINT":
+		if len(words) > 1 && (words[1].typ != TokenWord || !IsReservedWord(words[1].val, FlavorUnknown)) {
+			if name, ok := getNameFromToken(words[1]); ok {
+				return "constraint " + EscapeIdentifier(name)
+			}
+		}
+		return fmt.Sprintf("unnamed constraint %d", position+1)
+	case first == "FOREIGN" || first == "CHECK":
+		return fmt.Sprintf("unnamed constraint %d", position+1)
+	}
+	return "column " + EscapeIdentifier(words[0].val)
+}
+
+// joinDefinitionTokens concatenates tokens, replacing filler with single
+// spaces, and trimming leading and trailing filler.
+func joinDefinitionTokens(tokens []Token) string {
+	var b strings.Builder
+	for _, t := range tokens {
+		if t.typ == TokenFiller {
+			b.WriteByte(' ')
+		} else {
+			b.WriteString(t.val)
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// CanonicalText returns the statement's Body in a form which is independent of
+// cosmetic formatting: comments are removed, tokens are separated by single
+// spaces, and keywords and identifiers are lowercased with any backtick quoting
+// removed. String literals are left as-is. Two statements with the same
+// CanonicalText define the same object in the same way, although the converse
+// is not guaranteed. Note that differences solely in identifier case are not
+// reflected, even though some identifiers may be case-sensitive depending on
+// server configuration; see CaseSensitiveText for an alternative.
+func (stmt *Statement) CanonicalText() string {
+	return canonicalText(stmt.Body(), true)
+}
+
+// CaseSensitiveText behaves like CanonicalText, but retains the letter case of
+// identifiers, so that differences in identifier case are reflected. Keywords
+// are still lowercased. An unquoted word is treated as a keyword if it is a
+// reserved word, or if it is not in a position where an identifier is
+// expected.
+func (stmt *Statement) CaseSensitiveText() string {
+	return canonicalText(stmt.Body(), false)
+}
+
+// canonicalText returns body with comments removed and tokens separated by
+// single spaces. Backtick quoting is removed from identifiers. Keywords are
+// always lowercased, and if foldIdentifiers is true, identifiers are as well.
+func canonicalText(body string, foldIdentifiers bool) string {
+	lex := NewLexer(strings.NewReader(body), "\000", 8192)
+	var words []string
+	var prev Token // most recent non-filler token
+	for {
+		val, typ, err := lex.Scan()
+		if err == io.EOF || (err != nil && len(val) == 0) {
+			break
+		}
+		word := string(val)
+		cur := Token{val: word, typ: typ}
+		switch typ {
+		case TokenFiller:
+			continue
+		case TokenIdent:
+			word = stripBackticks(word)
+			if foldIdentifiers {
+				word = strings.ToLower(word)
+			}
+		case TokenWord:
+			if foldIdentifiers || prev.typ == TokenNone || keywordPosition([]Token{prev, cur}, 1) || IsVendorReservedWord(word, VendorMySQL) || IsVendorReservedWord(word, VendorMariaDB) {
+				word = strings.ToLower(word)
+			}
+		}
+		words = append(words, word)
+		prev = cur
+	}
+	return strings.Join(words, " ")
+}
+
+// normalizeDefinitionText collapses whitespace and comments in s to single
+// spaces.
+func normalizeDefinitionText(s string) string {
+	lex := NewLexer(strings.NewReader(s), "\000", 8192)
+	var tokens []Token
+	for {
+		val, typ, err := lex.Scan()
+		if err == io.EOF || (err != nil && len(val) == 0) {
+			break
+		}
+		tokens = append(tokens, Token{val: string(val), typ: typ})
+	}
+	return joinDefinitionTokens(tokens)
+}
@@ -1,6 +1,7 @@
 package tengo
 
 import (
+	"reflect"
 	"testing"
 )
 
@@ -128,3 +129,194 @@ func TestStatementNormalizeTrailer(t *testing.T) {
 		}
 	}
 }
+
+func TestStatementMissingDelimiter(t *testing.T) {
+	cases := map[string]bool{
+		"CREATE TABLE a (id int);\n":                                  false,
+		"CREATE TABLE a (\n  id int\n)\nCREATE TABLE b (id int);\n":   true,
+		"CREATE TABLE a (\n  id int\n)\n\nALTER TABLE a ADD x int;\n": true,
+		"CREATE TABLE a (\n  id int\n)\n-- oops\nDROP TABLE b;\n":     true,
+		"CREATE TABLE a (\n  id int COMMENT '\nDROP'\n);\n":           false,
+		"CREATE TABLE a (\n  id int /*\nUSE foo */\n);\n":             false,
+		"CREATE TABLE a (\n  id int, `create` int\n);\n":              false,
+		"CREATE TABLE a (id int) DROP TABLE b;\n":                     false, // not at start of line
+	}
+	for text, expected := range cases {
+		stmt := ParseStatementInString(text)
+		if actual := stmt.MissingDelimiter(); actual != expected {
+			t.Errorf("Expected MissingDelimiter() on %q to return %t, instead found %t", text, expected, actual)
+		}
+	}
+}
+
+func TestStatementReferences(t *testing.T) {
+	cases := []struct {
+		text     string
+		expected []ObjectKey
+	}{
+		{"USE foo;\n", nil},
+		{"CREATE TABLE foo (id int);\n", []ObjectKey{}},
+		{
+			"CREATE TABLE warranties (\n  customer_id int,\n  product_id int,\n  CONSTRAINT c_fk FOREIGN KEY (customer_id) REFERENCES `customers` (id),\n  CONSTRAINT p_fk FOREIGN KEY (product_id) REFERENCES otherdb.`products` (id) ON UPDATE CASCADE,\n  CONSTRAINT self_fk FOREIGN KEY (customer_id) REFERENCES warranties (customer_id)\n) COMMENT 'REFERENCES fake (id)'; # REFERENCES alsofake\n",
+			[]ObjectKey{{Type: ObjectTypeTable, Name: "customers"}, {Type: ObjectTypeTable, Name: "products"}},
+		},
+		{
+			"CREATE PROCEDURE doit(IN x int)\nBEGIN\n  DECLARE n int;\n  SELECT COUNT(*) INTO n FROM widgets w JOIN `gadgets` g ON w.id = g.id;\n  /* SELECT * FROM commented_out; */\n  INSERT INTO log_entries (msg) VALUES ('FROM nowhere');\n  UPDATE counters SET total = total + n;\n  CALL other_proc(n);\n  CALL doit(x - 1);\nEND",
+			[]ObjectKey{
+				{Type: ObjectTypeProc, Name: "other_proc"},
+				{Type: ObjectTypeTable, Name: "counters"},
+				{Type: ObjectTypeTable, Name: "gadgets"},
+				{Type: ObjectTypeTable, Name: "log_entries"},
+				{Type: ObjectTypeTable, Name: "widgets"},
+			},
+		},
+		{
+			"CREATE FUNCTION recent(d date) RETURNS int\nRETURN (SELECT COUNT(*) FROM orders WHERE EXTRACT(YEAR FROM created_at) = EXTRACT(YEAR FROM d) AND TRIM(LEADING '0' FROM code) IN (SELECT code FROM (SELECT code FROM promos) p))",
+			[]ObjectKey{
+				{Type: ObjectTypeTable, Name: "orders"},
+				{Type: ObjectTypeTable, Name: "promos"},
+			},
+		},
+	}
+	for n, c := range cases {
+		stmt := ParseStatementInString(c.text)
+		refs := stmt.References()
+		if (refs == nil) != (c.expected == nil) || len(refs) != len(c.expected) {
+			t.Errorf("cases[%d]: Expected %v, instead found %v", n, c.expected, refs)
+			continue
+		}
+		for i := range refs {
+			if refs[i] != c.expected[i] {
+				t.Errorf("cases[%d]: Expected %v, instead found %v", n, c.expected, refs)
+				break
+			}
+		}
+	}
+}
+
+func TestStatementRenamedBody(t *testing.T) {
+	renames := map[ObjectKey]string{
+		{Type: ObjectTypeTable, Name: "customers"}: "clients",
+		{Type: ObjectTypeProc, Name: "other_proc"}: "new_proc",
+		{Type: ObjectTypeFunc, Name: "calc"}:       "compute",
+	}
+	cases := []struct {
+		text               string
+		expectedBody       string
+		expectedUnresolved []ObjectKey
+	}{
+		{"USE customers;\n", "USE customers", nil},
+		{"CREATE TABLE foo (id int);\n", "CREATE TABLE foo (id int)", nil},
+		{
+			"CREATE TABLE otherdb.`customers` (id int, customers int, KEY customers (customers(10))) COMMENT 'customers';\n",
+			"CREATE TABLE otherdb.`clients` (id int, customers int, KEY customers (customers(10))) COMMENT 'customers'",
+			nil,
+		},
+		{
+			"CREATE TABLE warranties (\n  customer_id int,\n  CONSTRAINT c_fk FOREIGN KEY (customer_id) REFERENCES `customers` (id),\n  CONSTRAINT c2_fk FOREIGN KEY (customer_id) REFERENCES otherdb.customers (id)\n) COMMENT 'REFERENCES customers (id)'; # REFERENCES customers\n",
+			"CREATE TABLE warranties (\n  customer_id int,\n  CONSTRAINT c_fk FOREIGN KEY (customer_id) REFERENCES `clients` (id),\n  CONSTRAINT c2_fk FOREIGN KEY (customer_id) REFERENCES otherdb.customers (id)\n) COMMENT 'REFERENCES customers (id)'",
+			[]ObjectKey{{Type: ObjectTypeTable, Name: "customers"}},
+		},
+		{
+			"CREATE DEFINER=`customers`@`%` PROCEDURE doit(IN x int)\nBEGIN\n  DECLARE n int;\n  SELECT calc(id) INTO n FROM customers;\n  INSERT INTO customers (msg) VALUES ('FROM customers');\n  UPDATE customers SET total = calc(total);\n  CALL other_proc(n);\nEND",
+			"CREATE DEFINER=`customers`@`%` PROCEDURE doit(IN x int)\nBEGIN\n  DECLARE n int;\n  SELECT `compute`(id) INTO n FROM `clients`;\n  INSERT INTO `clients` (msg) VALUES ('FROM customers');\n  UPDATE `clients` SET total = `compute`(total);\n  CALL `new_proc`(n);\nEND",
+			nil,
+		},
+		{
+			"CREATE PROCEDURE doit()\nBEGIN\n  SELECT customers.id FROM customers;\nEND",
+			"CREATE PROCEDURE doit()\nBEGIN\n  SELECT customers.id FROM `clients`;\nEND",
+			[]ObjectKey{{Type: ObjectTypeTable, Name: "customers"}},
+		},
+	}
+	for n, c := range cases {
+		stmt := ParseStatementInString(c.text)
+		body, unresolved := stmt.RenamedBody(renames)
+		if body != c.expectedBody {
+			t.Errorf("cases[%d]: Expected body %q, instead found %q", n, c.expectedBody, body)
+		}
+		if len(unresolved) != len(c.expectedUnresolved) {
+			t.Errorf("cases[%d]: Expected unresolved %v, instead found %v", n, c.expectedUnresolved, unresolved)
+			continue
+		}
+		for i := range unresolved {
+			if unresolved[i] != c.expectedUnresolved[i] {
+				t.Errorf("cases[%d]: Expected unresolved %v, instead found %v", n, c.expectedUnresolved, unresolved)
+				break
+			}
+		}
+	}
+}
+
+func TestStatementCanonicalText(t *testing.T) {
+	a := ParseStatementInString("CREATE TABLE `Foo` (\n  `id` INT NOT NULL, -- the id\n  name varchar(20) DEFAULT 'Bob' /* hi */\n);\n")
+	b := ParseStatementInString("create table foo (id int not null, `name` VARCHAR ( 20 ) default 'Bob')")
+	expected := "create table foo ( id int not null , name varchar ( 20 ) default 'Bob' )"
+	if actual := a.CanonicalText(); actual != expected {
+		t.Errorf("Unexpected CanonicalText\nExpected: %s\nActual:   %s", expected, actual)
+	}
+	if a.CanonicalText() != b.CanonicalText() {
+		t.Errorf("Expected equal CanonicalText, instead found:\n%s\n%s", a.CanonicalText(), b.CanonicalText())
+	}
+
+	// String literal contents are significant
+	c := ParseStatementInString("CREATE TABLE foo (id int NOT NULL, name varchar(20) DEFAULT 'bob')")
+	if a.CanonicalText() == c.CanonicalText() {
+		t.Error("Expected differing string literals to produce different CanonicalText")
+	}
+
+	// CaseSensitiveText retains letter case of identifiers, but otherwise
+	// normalizes formatting, including keyword case
+	expected = "create table Foo ( id int not null , name varchar ( 20 ) default 'Bob' )"
+	if actual := a.CaseSensitiveText(); actual != expected {
+		t.Errorf("Unexpected CaseSensitiveText\nExpected: %s\nActual:   %s", expected, actual)
+	}
+	d := ParseStatementInString("CREATE TABLE Foo (\n\tid INT NOT NULL,\n\t`name` varchar(20) DEFAULT 'Bob'\n)")
+	if a.CaseSensitiveText() != d.CaseSensitiveText() {
+		t.Errorf("Expected equal CaseSensitiveText, instead found:\n%s\n%s", a.CaseSensitiveText(), d.CaseSensitiveText())
+	}
+	if a.CaseSensitiveText() == b.CaseSensitiveText() {
+		t.Error("Expected differing identifier case to produce different CaseSensitiveText")
+	}
+	e := ParseStatementInString("create table Foo (id int not null, `name` VARCHAR(20) default 'Bob')")
+	if a.CaseSensitiveText() != e.CaseSensitiveText() {
+		t.Errorf("Expected differing keyword case to produce equal CaseSensitiveText, instead found:\n%s\n%s", a.CaseSensitiveText(), e.CaseSensitiveText())
+	}
+}
+
+func TestStatementDefinitionElements(t *testing.T) {
+	stmt := ParseStatementInString("CREATE TABLE `foo` (\n" +
+		"  id int unsigned NOT NULL,\n" +
+		"  `name`   varchar(30) DEFAULT 'a, b' /* hi */,\n" +
+		"  price decimal(10,2),\n" +
+		"  PRIMARY KEY (id),\n" +
+		"  UNIQUE KEY `name` (`name`),\n" +
+		"  KEY (price),\n" +
+		"  CONSTRAINT `fk` FOREIGN KEY (id) REFERENCES bar (id),\n" +
+		"  CONSTRAINT CHECK (price > 0)\n" +
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;\n")
+	expected := []DefinitionElement{
+		{Name: "column `id`", Text: "id int unsigned NOT NULL"},
+		{Name: "column `name`", Text: "`name` varchar(30) DEFAULT 'a, b'"},
+		{Name: "column `price`", Text: "price decimal(10,2)"},
+		{Name: "primary key", Text: "PRIMARY KEY (id)"},
+		{Name: "index `name`", Text: "UNIQUE KEY `name` (`name`)"},
+		{Name: "unnamed index 6", Text: "KEY (price)"},
+		{Name: "constraint `fk`", Text: "CONSTRAINT `fk` FOREIGN KEY (id) REFERENCES bar (id)"},
+		{Name: "unnamed constraint 8", Text: "CONSTRAINT CHECK (price > 0)"},
+		{Name: "table options", Text: "ENGINE=InnoDB DEFAULT CHARSET=utf8mb4"},
+	}
+	if actual := stmt.DefinitionElements(); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Unexpected result from DefinitionElements: expected %+v, found %+v", expected, actual)
+	}
+
+	stmt = ParseStatementInString("CREATE FUNCTION f() RETURNS int\n  DETERMINISTIC\n  RETURN 1;\n")
+	expected = []DefinitionElement{{Name: "definition", Text: "CREATE FUNCTION f() RETURNS int DETERMINISTIC RETURN 1"}}
+	if actual := stmt.DefinitionElements(); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Unexpected result from DefinitionElements: expected %+v, found %+v", expected, actual)
+	}
+
+	stmt = ParseStatementInString("USE foo;\n")
+	if actual := stmt.DefinitionElements(); actual != nil {
+		t.Errorf("Expected nil result for non-CREATE statement, instead found %+v", actual)
+	}
+}
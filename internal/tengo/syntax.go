@@ -0,0 +1,290 @@
+package tengo
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// SyntaxIssue describes a clause of a statement which would be rejected by a
+// particular flavor.
+type SyntaxIssue struct {
+	Clause string // offending clause, with tokens separated by single spaces
+	Reason string
+}
+
+func (si SyntaxIssue) String() string {
+	return fmt.Sprintf("%s: %s", si.Clause, si.Reason)
+}
+
+// syntaxRule represents a single flavor-specific syntax check. The match
+// function examines tokens[n] and returns the number of tokens matched
+// starting at that position, or 0 if there is no match.
+type syntaxRule struct {
+	match     func(tokens []Token, n int) int
+	supported func(Flavor) bool
+	reason    string
+}
+
+// keywordPosition returns true if tokens[n] may be a keyword, rather than an
+// identifier. Words directly after an opening paren, comma, dot, or operator
+// are column names or other identifiers, as are words directly after keywords
+// which are followed by a name.
+func keywordPosition(tokens []Token, n int) bool {
+	if n == 0 {
+		return true
+	}
+	prev := tokens[n-1]
+	if prev.typ == TokenSymbol {
+		return prev.val == ")"
+	} else if prev.typ == TokenWord {
+		switch strings.ToUpper(prev.val) {
+		case "TABLE", "KEY", "INDEX", "CONSTRAINT", "REFERENCES", "COLUMN":
+			return false
+		}
+	}
+	return true
+}
+
+// matchWords returns a match function for a sequence of consecutive keywords.
+func matchWords(words ...string) func([]Token, int) int {
+	return func(tokens []Token, n int) int {
+		if n+len(words) > len(tokens) || !keywordPosition(tokens, n) {
+			return 0
+		}
+		for i, word := range words {
+			if tokens[n+i].typ != TokenWord || !strings.EqualFold(tokens[n+i].val, word) {
+				return 0
+			}
+		}
+		return len(words)
+	}
+}
+
+// matchColumnType returns a match function for a column data type, which must
+// directly follow a column name. The column name may be backtick-quoted, or an
+// unquoted word at the start of a column definition.
+func matchColumnType(typ string) func([]Token, int) int {
+	return func(tokens []Token, n int) int {
+		if n == 0 || tokens[n].typ != TokenWord || !strings.EqualFold(tokens[n].val, typ) {
+			return 0
+		}
+		prev := tokens[n-1]
+		if prev.typ == TokenIdent {
+			return 1
+		} else if prev.typ == TokenWord && n >= 2 && tokens[n-2].typ == TokenSymbol && (tokens[n-2].val == "(" || tokens[n-2].val == ",") {
+			return 1
+		}
+		return 0
+	}
+}
+
+// matchCollationInfix returns a match function for a collation name
+// containing the supplied substring.
+func matchCollationInfix(infix string) func([]Token, int) int {
+	return func(tokens []Token, n int) int {
+		if tokens[n].typ != TokenWord || !strings.Contains(strings.ToLower(tokens[n].val), infix) {
+			return 0
+		}
+		return 1
+	}
+}
+
+// matchAfterParen wraps a match function to only match directly after a
+// closing parenthesis, for example the column list of an index definition.
+func matchAfterParen(match func([]Token, int) int) func([]Token, int) int {
+	return func(tokens []Token, n int) int {
+		if n == 0 || tokens[n-1].typ != TokenSymbol || tokens[n-1].val != ")" {
+			return 0
+		}
+		return match(tokens, n)
+	}
+}
+
+// matchNotAfterParen is the inverse of matchAfterParen.
+func matchNotAfterParen(match func([]Token, int) int) func([]Token, int) int {
+	return func(tokens []Token, n int) int {
+		if n > 0 && tokens[n-1].typ == TokenSymbol && tokens[n-1].val == ")" {
+			return 0
+		}
+		return match(tokens, n)
+	}
+}
+
+var syntaxRules = []syntaxRule{
+	{
+		match:     matchWords("ENGINE_ATTRIBUTE"),
+		supported: Flavor.HasEngineAttributes,
+		reason:    "ENGINE_ATTRIBUTE requires MySQL 8.0.21+",
+	},
+	{
+		match:     matchWords("SECONDARY_ENGINE_ATTRIBUTE"),
+		supported: Flavor.HasEngineAttributes,
+		reason:    "SECONDARY_ENGINE_ATTRIBUTE requires MySQL 8.0.21+",
+	},
+	{
+		match:     matchWords("NOT", "ENFORCED"),
+		supported: func(fl Flavor) bool { return fl.Min(FlavorMySQL80.Dot(16)) },
+		reason:    "NOT ENFORCED check constraints require MySQL 8.0.16+",
+	},
+	{
+		match:     matchWords("SRID"),
+		supported: func(fl Flavor) bool { return fl.Min(FlavorMySQL80) },
+		reason:    "SRID column attribute requires MySQL 8.0+",
+	},
+	{
+		match:     matchAfterParen(matchWords("VISIBLE")),
+		supported: func(fl Flavor) bool { return fl.Min(FlavorMySQL80) },
+		reason:    "VISIBLE indexes require MySQL 8.0+",
+	},
+	{
+		match:     matchAfterParen(matchWords("INVISIBLE")),
+		supported: func(fl Flavor) bool { return fl.Min(FlavorMySQL80) },
+		reason:    "INVISIBLE indexes require MySQL 8.0+; MariaDB 10.6+ uses IGNORED instead",
+	},
+	{
+		match:     matchNotAfterParen(matchWords("INVISIBLE")),
+		supported: func(fl Flavor) bool { return fl.Min(FlavorMySQL80.Dot(23)) || fl.Min(FlavorMariaDB103) },
+		reason:    "INVISIBLE columns require MySQL 8.0.23+ or MariaDB 10.3+",
+	},
+	{
+		match:     matchWords("IGNORED"),
+		supported: func(fl Flavor) bool { return fl.Min(FlavorMariaDB106) },
+		reason:    "IGNORED indexes require MariaDB 10.6+",
+	},
+	{
+		match:     matchWords("PERSISTENT"),
+		supported: Flavor.IsMariaDB,
+		reason:    "PERSISTENT generated columns are only supported by MariaDB; use STORED instead",
+	},
+	{
+		match:     matchWords("WITH", "SYSTEM", "VERSIONING"),
+		supported: func(fl Flavor) bool { return fl.Min(FlavorMariaDB103) },
+		reason:    "system-versioned tables require MariaDB 10.3+",
+	},
+	{
+		match:     matchColumnType("json"),
+		supported: func(fl Flavor) bool { return fl.Min(FlavorMySQL57) || fl.Min(FlavorMariaDB102) },
+		reason:    "json column type requires MySQL 5.7+ or MariaDB 10.2+",
+	},
+	{
+		match:     matchColumnType("inet6"),
+		supported: func(fl Flavor) bool { return fl.Min(FlavorMariaDB105) },
+		reason:    "inet6 column type requires MariaDB 10.5+",
+	},
+	{
+		match:     matchColumnType("uuid"),
+		supported: func(fl Flavor) bool { return fl.Min(FlavorMariaDB107) },
+		reason:    "uuid column type requires MariaDB 10.7+",
+	},
+	{
+		match:     matchColumnType("inet4"),
+		supported: func(fl Flavor) bool { return fl.Min(FlavorMariaDB1010) },
+		reason:    "inet4 column type requires MariaDB 10.10+",
+	},
+	{
+		match:     matchCollationInfix("_0900_"),
+		supported: func(fl Flavor) bool { return fl.Min(FlavorMySQL80) },
+		reason:    "0900 collations require MySQL 8.0+",
+	},
+	{
+		match:     matchCollationInfix("_uca1400_"),
+		supported: func(fl Flavor) bool { return fl.Min(FlavorMariaDB1010) },
+		reason:    "uca1400 collations require MariaDB 10.10+",
+	},
+}
+
+// ValidateFlavorSyntax examines a CREATE statement for common clauses which
+// would be rejected by the supplied flavor, for example MySQL 8-only features
+// used against MariaDB or vice versa. This is a best-effort lexical check, not
+// a full parse, and it does not require a database connection. Contents of
+// string literals and comments are ignored, except for version-gated comments
+// such as /*!80016 ... */ or /*M!100600 ... */ which flavor would execute.
+// Each offending clause is returned along with the reason it is unsupported.
+// If flavor is not known, no checks are performed and nil is returned.
+func ValidateFlavorSyntax(createStatement string, flavor Flavor) []SyntaxIssue {
+	if !flavor.Known() {
+		return nil
+	}
+	tokens := flavorTokens(createStatement, flavor)
+
+	var issues []SyntaxIssue
+	for n := range tokens {
+		for _, rule := range syntaxRules {
+			if length := rule.match(tokens, n); length > 0 && !rule.supported(flavor) {
+				words := make([]string, length)
+				for i := range words {
+					words[i] = tokens[n+i].val
+				}
+				issues = append(issues, SyntaxIssue{
+					Clause: strings.Join(words, " "),
+					Reason: rule.reason,
+				})
+			}
+		}
+	}
+	return issues
+}
+
+// flavorTokens returns the non-filler tokens of text. The contents of any
+// version-gated comments which flavor would execute are tokenized in place.
+func flavorTokens(text string, flavor Flavor) (tokens []Token) {
+	lex := NewLexer(strings.NewReader(text), "\000", 8192)
+	for {
+		val, typ, err := lex.Scan()
+		if err == io.EOF || (err != nil && len(val) == 0) {
+			break
+		} else if typ != TokenFiller {
+			tokens = append(tokens, Token{val: string(val), typ: typ})
+			continue
+		}
+		for _, body := range executedComments(string(val), flavor) {
+			tokens = append(tokens, flavorTokens(body, flavor)...)
+		}
+	}
+	return tokens
+}
+
+// executedComments returns the bodies of any version-gated comments in filler
+// which flavor would execute. A comment beginning with /*! is executed by both
+// MySQL and MariaDB, as long as the server version is at least the optional
+// version number which follows; /*M! comments are only executed by MariaDB.
+// Note that MariaDB also executes /*!80016 comments and the like, since its
+// version numbers are larger than any MySQL version number.
+func executedComments(filler string, flavor Flavor) (bodies []string) {
+	for filler != "" {
+		if strings.HasPrefix(filler, "#") || strings.HasPrefix(filler, "--") {
+			_, filler, _ = strings.Cut(filler, "\n")
+			continue
+		} else if !strings.HasPrefix(filler, "/*") {
+			filler = filler[1:]
+			continue
+		}
+		comment, rest, _ := strings.Cut(filler[2:], "*/")
+		filler = rest
+		if strings.HasPrefix(comment, "M!") && flavor.IsMariaDB() {
+			comment = comment[2:]
+		} else if strings.HasPrefix(comment, "!") {
+			comment = comment[1:]
+		} else {
+			continue
+		}
+		var digits int
+		for digits < len(comment) && digits < 6 && comment[digits] >= '0' && comment[digits] <= '9' {
+			digits++
+		}
+		if digits == 6 && !flavor.IsMariaDB() {
+			digits = 5 // MySQL only uses 5-digit version numbers in these comments
+		}
+		if digits >= 5 {
+			version, _ := strconv.Atoi(comment[:digits])
+			if flavorVersion := int(flavor.Version[0])*10000 + int(flavor.Version[1])*100 + int(flavor.Version[2]); flavorVersion < version {
+				continue
+			}
+			comment = comment[digits:]
+		}
+		bodies = append(bodies, comment)
+	}
+	return bodies
+}
@@ -0,0 +1,88 @@
+package tengo
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateFlavorSyntax(t *testing.T) {
+	mysqlStmt := "CREATE TABLE `t` (\n" +
+		"  `id` int NOT NULL,\n" +
+		"  `doc` json DEFAULT NULL,\n" +
+		"  `pt` point NOT NULL /*!80003 SRID 4326 */,\n" +
+		"  `name` varchar(20) COLLATE utf8mb4_0900_ai_ci DEFAULT 'not enforced',\n" +
+		"  PRIMARY KEY (`id`),\n" +
+		"  KEY `name` (`name`) INVISIBLE,\n" +
+		"  CONSTRAINT `chk` CHECK ((`id` > 0)) NOT ENFORCED\n" +
+		") ENGINE=InnoDB ENGINE_ATTRIBUTE='{}'"
+	mariaStmt := "CREATE TABLE `t` (\n" +
+		"  `id` uuid NOT NULL,\n" +
+		"  `ip` inet6 DEFAULT NULL,\n" +
+		"  `ip_name` varchar(40) GENERATED ALWAYS AS (inet6_ntoa(`ip`)) PERSISTENT,\n" +
+		"  PRIMARY KEY (`id`),\n" +
+		"  KEY `ip` (`ip`) IGNORED\n" +
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_uca1400_ai_ci WITH SYSTEM VERSIONING"
+
+	assertIssues := func(stmt string, flavor Flavor, expectedClauses ...string) {
+		t.Helper()
+		issues := ValidateFlavorSyntax(stmt, flavor)
+		var clauses []string
+		for _, issue := range issues {
+			if issue.Reason == "" {
+				t.Errorf("Issue %q unexpectedly has no reason", issue.Clause)
+			}
+			clauses = append(clauses, issue.Clause)
+		}
+		if strings.Join(clauses, ", ") != strings.Join(expectedClauses, ", ") {
+			t.Errorf("Unexpected issues for flavor %s\nExpected: %v\nFound:    %v", flavor, expectedClauses, issues)
+		}
+	}
+
+	// Strings are ignored, so "not enforced" is never flagged. SRID is inside a
+	// version-gated comment, which MySQL 5.7 ignores, but MariaDB executes.
+	assertIssues(mysqlStmt, FlavorMySQL80.Dot(30))
+	assertIssues(mysqlStmt, FlavorMySQL80.Dot(15), "NOT ENFORCED", "ENGINE_ATTRIBUTE")
+	assertIssues(mysqlStmt, FlavorMySQL57, "utf8mb4_0900_ai_ci", "INVISIBLE", "NOT ENFORCED", "ENGINE_ATTRIBUTE")
+	assertIssues(mysqlStmt, FlavorMySQL56, "json", "utf8mb4_0900_ai_ci", "INVISIBLE", "NOT ENFORCED", "ENGINE_ATTRIBUTE")
+	assertIssues(mysqlStmt, FlavorMariaDB106, "SRID", "utf8mb4_0900_ai_ci", "INVISIBLE", "NOT ENFORCED", "ENGINE_ATTRIBUTE")
+	assertIssues(mysqlStmt, FlavorUnknown)
+
+	assertIssues(mariaStmt, FlavorMariaDB1011)
+	assertIssues(mariaStmt, FlavorMariaDB106, "uuid", "utf8mb4_uca1400_ai_ci")
+	assertIssues(mariaStmt, FlavorMariaDB102, "uuid", "inet6", "IGNORED", "utf8mb4_uca1400_ai_ci", "WITH SYSTEM VERSIONING")
+	assertIssues(mariaStmt, FlavorMySQL80, "uuid", "inet6", "PERSISTENT", "IGNORED", "utf8mb4_uca1400_ai_ci", "WITH SYSTEM VERSIONING")
+
+	// Invisible columns have different requirements than invisible indexes
+	invisCol := "CREATE TABLE `t` (`id` int NOT NULL INVISIBLE, KEY `id` (`id`) VISIBLE)"
+	assertIssues(invisCol, FlavorMySQL80.Dot(23))
+	assertIssues(invisCol, FlavorMySQL80.Dot(22), "INVISIBLE")
+	assertIssues(invisCol, FlavorMariaDB103, "VISIBLE")
+
+	// Column names in hand-written files may be unquoted
+	unquoted := "CREATE TABLE t (id int, doc json, ip inet6, KEY (ip))"
+	assertIssues(unquoted, FlavorMariaDB105)
+	assertIssues(unquoted, FlavorMySQL57, "inet6")
+
+	// Column names matching type names or keywords should not be flagged, even
+	// if unquoted
+	assertIssues("CREATE TABLE `t` (`json` int, `uuid` varchar(36))", FlavorMySQL55)
+	unquoted = "CREATE TABLE t (persistent int, ignored int, KEY ignored (ignored, persistent), CHECK (persistent > ignored))"
+	assertIssues(unquoted, FlavorMySQL80.Dot(30))
+
+	// Version-gated comments are checked if the flavor would execute them
+	gated := "CREATE TABLE `t` (\n" +
+		"  `id` int NOT NULL,\n" +
+		"  KEY `id` (`id`) /*M!100300 IGNORED */,\n" +
+		"  CONSTRAINT `chk` CHECK ((`id` > 0)) /*!80016 NOT ENFORCED */\n" +
+		") ENGINE=InnoDB /* ENGINE_ATTRIBUTE='{}' */ # /*!50100 SRID 0 */\n"
+	assertIssues(gated, FlavorMySQL80.Dot(16))
+	assertIssues(gated, FlavorMySQL80.Dot(15))
+	assertIssues(gated, FlavorMySQL57)
+	assertIssues(gated, FlavorMariaDB106, "NOT ENFORCED")
+	assertIssues(gated, FlavorMariaDB105, "IGNORED", "NOT ENFORCED")
+	assertIssues("CREATE TABLE t (id int) /*!*/ /*! ENGINE_ATTRIBUTE='{}' */", FlavorMySQL57, "ENGINE_ATTRIBUTE")
+
+	if s := (SyntaxIssue{Clause: "uuid", Reason: "uuid column type requires MariaDB 10.7+"}).String(); s != "uuid: uuid column type requires MariaDB 10.7+" {
+		t.Errorf("Unexpected String() result: %q", s)
+	}
+}
@@ -3,29 +3,37 @@ package tengo
 import (
 	"errors"
 	"fmt"
+	"reflect"
 	"regexp"
+	"sort"
 	"strings"
+	"time"
+	"unicode/utf8"
 )
 
 // Table represents a single database table.
 type Table struct {
-	Name               string             `json:"name"`
-	Engine             string             `json:"storageEngine"`
-	CharSet            string             `json:"defaultCharSet"`
-	Collation          string             `json:"defaultCollation"`
-	CollationIsDefault bool               `json:"collationIsDefault"`      // true if Collation is default for CharSet
-	CreateOptions      string             `json:"createOptions,omitempty"` // row_format, stats_persistent, stats_auto_recalc, etc
-	Columns            []*Column          `json:"columns"`
-	PrimaryKey         *Index             `json:"primaryKey,omitempty"`
-	SecondaryIndexes   []*Index           `json:"secondaryIndexes,omitempty"`
-	ForeignKeys        []*ForeignKey      `json:"foreignKeys,omitempty"`
-	Checks             []*Check           `json:"checks,omitempty"`
-	Comment            string             `json:"comment,omitempty"`
-	Tablespace         string             `json:"tablespace,omitempty"`
-	NextAutoIncrement  uint64             `json:"nextAutoIncrement,omitempty"`
-	Partitioning       *TablePartitioning `json:"partitioning,omitempty"`       // nil if table isn't partitioned
-	UnsupportedDDL     bool               `json:"unsupportedForDiff,omitempty"` // If true, tengo cannot diff this table or auto-generate its CREATE TABLE
-	CreateStatement    string             `json:"showCreateTable"`              // complete SHOW CREATE TABLE obtained from an instance
+	Name                     string             `json:"name"`
+	Engine                   string             `json:"storageEngine"`
+	CharSet                  string             `json:"defaultCharSet"`
+	Collation                string             `json:"defaultCollation"`
+	CollationIsDefault       bool               `json:"collationIsDefault"`      // true if Collation is default for CharSet
+	CreateOptions            string             `json:"createOptions,omitempty"` // row_format, stats_persistent, stats_auto_recalc, etc
+	Columns                  []*Column          `json:"columns"`
+	PrimaryKey               *Index             `json:"primaryKey,omitempty"`
+	SecondaryIndexes         []*Index           `json:"secondaryIndexes,omitempty"`
+	ForeignKeys              []*ForeignKey      `json:"foreignKeys,omitempty"`
+	Checks                   []*Check           `json:"checks,omitempty"`
+	Comment                  string             `json:"comment,omitempty"`
+	Tablespace               string             `json:"tablespace,omitempty"`
+	EngineAttribute          string             `json:"engineAttribute,omitempty"`          // JSON; only populated in MySQL 8.0.21+
+	SecondaryEngineAttribute string             `json:"secondaryEngineAttribute,omitempty"` // JSON; only populated in MySQL 8.0.21+
+	NextAutoIncrement        uint64             `json:"nextAutoIncrement,omitempty"`
+	MergeUnion               []string           `json:"mergeUnion,omitempty"`         // underlying tables of a MERGE table, in UNION order
+	MergeInsertMethod        string             `json:"mergeInsertMethod,omitempty"`  // INSERT_METHOD of a MERGE table: "FIRST", "LAST", or empty for NO
+	Partitioning             *TablePartitioning `json:"partitioning,omitempty"`       // nil if table isn't partitioned
+	UnsupportedDDL           bool               `json:"unsupportedForDiff,omitempty"` // If true, tengo cannot diff this table or auto-generate its CREATE TABLE
+	CreateStatement          string             `json:"showCreateTable"`              // complete SHOW CREATE TABLE obtained from an instance
 }
 
 // ObjectKey returns a value useful for uniquely refering to a Table within a
@@ -45,6 +53,39 @@ func (t *Table) Def() string {
 	return t.CreateStatement
 }
 
+// EffectiveCollation returns the default character set and collation of t,
+// resolving any inheritance from the default character set and collation of
+// the supplied schema. Tables introspected from a live database always have
+// explicit values, but tables built by other means may leave CharSet and/or
+// Collation blank to indicate the schema default should be used. If t has an
+// explicit CharSet differing from the schema's but no Collation, the returned
+// collation will be blank, since the character set's default collation is not
+// known without querying the server.
+func (t *Table) EffectiveCollation(s *Schema) (charSet, collation string) {
+	if t.Collation != "" || s == nil {
+		return t.CharSet, t.Collation
+	}
+	if t.CharSet == "" || t.CharSet == s.CharSet {
+		return s.CharSet, s.Collation
+	}
+	return t.CharSet, ""
+}
+
+// withResolvedCollation returns t if its default character set and collation
+// are both explicit. Otherwise, it returns a shallow copy of t with these
+// fields set to their effective values from EffectiveCollation. This permits
+// diffing tables which inherit the schema's defaults against tables which
+// state the same values explicitly, without emitting a spurious ALTER.
+func (t *Table) withResolvedCollation(s *Schema) *Table {
+	charSet, collation := t.EffectiveCollation(s)
+	if charSet == t.CharSet && collation == t.Collation {
+		return t
+	}
+	resolved := *t
+	resolved.CharSet, resolved.Collation = charSet, collation
+	return &resolved
+}
+
 // AlterStatement returns the prefix to a SQL "ALTER TABLE" statement.
 func (t *Table) AlterStatement() string {
 	return fmt.Sprintf("ALTER TABLE %s", EscapeIdentifier(t.Name))
@@ -55,6 +96,74 @@ func (t *Table) DropStatement() string {
 	return fmt.Sprintf("DROP TABLE %s", EscapeIdentifier(t.Name))
 }
 
+// droppedTableTimeFormat is the time layout used as the suffix of table names
+// generated by DroppedTableName.
+const droppedTableTimeFormat = "20060102150405"
+
+// DroppedTableName returns the name to use for retaining table name as a
+// "soft-deleted" table, rather than dropping it: prefix, followed by the
+// original name, an underscore, and when formatted as a UTC timestamp. If the
+// result would exceed the 64-character limit on table names, the original name
+// portion is truncated.
+func DroppedTableName(prefix, name string, when time.Time) string {
+	suffix := "_" + when.UTC().Format(droppedTableTimeFormat)
+	nameRunes := []rune(name)
+	if maxLen := 64 - utf8.RuneCountInString(prefix+suffix); len(nameRunes) > maxLen && maxLen >= 0 {
+		nameRunes = nameRunes[:maxLen]
+	}
+	return prefix + string(nameRunes) + suffix
+}
+
+// ParseDroppedTableName determines whether name was generated by
+// DroppedTableName with the supplied prefix. If so, it returns the original
+// table name (which may have been truncated) and the time of the rename. This
+// is useful for cleanup tooling which drops soft-deleted tables after a grace
+// period.
+func ParseDroppedTableName(prefix, name string) (origName string, when time.Time, ok bool) {
+	if prefix == "" || !strings.HasPrefix(name, prefix) {
+		return "", time.Time{}, false
+	}
+	pos := strings.LastIndexByte(name, '_')
+	if pos < len(prefix) {
+		return "", time.Time{}, false
+	}
+	when, err := time.Parse(droppedTableTimeFormat, name[pos+1:])
+	if err != nil {
+		return "", time.Time{}, false
+	}
+	return name[len(prefix):pos], when, true
+}
+
+// DroppedTablePattern returns a regular expression matching table names which
+// DroppedTableName would generate with the supplied prefix. If prefix is
+// empty, nil is returned.
+func DroppedTablePattern(prefix string) *regexp.Regexp {
+	if prefix == "" {
+		return nil
+	}
+	return regexp.MustCompile("^" + regexp.QuoteMeta(prefix) + ".*_[0-9]{14}$")
+}
+
+// RenameForDropStatement returns a SQL statement that, if run, would rename
+// the table to the name returned by DroppedTableName, retaining its data
+// instead of dropping it.
+//
+// Since foreign key constraint names must be unique per schema, any foreign
+// keys of the table are dropped as part of the rename. Otherwise, recreating a
+// table with the original definition would fail.
+func (t *Table) RenameForDropStatement(prefix string, when time.Time) string {
+	newName := EscapeIdentifier(DroppedTableName(prefix, t.Name, when))
+	if len(t.ForeignKeys) == 0 {
+		return fmt.Sprintf("RENAME TABLE %s TO %s", EscapeIdentifier(t.Name), newName)
+	}
+	clauses := make([]string, 0, len(t.ForeignKeys)+1)
+	for _, fk := range t.ForeignKeys {
+		clauses = append(clauses, "DROP FOREIGN KEY "+EscapeIdentifier(fk.Name))
+	}
+	clauses = append(clauses, "RENAME TO "+newName)
+	return fmt.Sprintf("ALTER TABLE %s %s", EscapeIdentifier(t.Name), strings.Join(clauses, ", "))
+}
+
 // GeneratedCreateStatement generates a CREATE TABLE statement based on the
 // Table's Go field values. If t.UnsupportedDDL is false, this will match
 // the output of MySQL's SHOW CREATE TABLE statement. But if t.UnsupportedDDL
@@ -103,7 +212,18 @@ func (t *Table) GeneratedCreateStatement(flavor Flavor) string {
 	if t.Comment != "" {
 		comment = fmt.Sprintf(" COMMENT='%s'", EscapeValueForCreateTable(t.Comment))
 	}
-	result := fmt.Sprintf("CREATE TABLE %s (\n  %s\n)%s ENGINE=%s%s DEFAULT CHARSET=%s%s%s%s%s",
+	var union string
+	if t.IsMerge() {
+		if t.MergeInsertMethod != "" {
+			union = fmt.Sprintf(" INSERT_METHOD=%s", t.MergeInsertMethod)
+		}
+		// SHOW CREATE TABLE omits the UNION clause entirely if the list is empty
+		if len(t.MergeUnion) > 0 {
+			union += fmt.Sprintf(" UNION=(%s)", mergeUnionList(t.MergeUnion))
+		}
+	}
+	engineAttributes := engineAttributeClauses(t.EngineAttribute, t.SecondaryEngineAttribute, "=")
+	result := fmt.Sprintf("CREATE TABLE %s (\n  %s\n)%s ENGINE=%s%s DEFAULT CHARSET=%s%s%s%s%s%s%s",
 		EscapeIdentifier(t.Name),
 		strings.Join(defs, ",\n  "),
 		tablespaceClause,
@@ -113,6 +233,8 @@ func (t *Table) GeneratedCreateStatement(flavor Flavor) string {
 		collate,
 		createOptions,
 		comment,
+		union,
+		engineAttributes,
 		t.Partitioning.Definition(flavor),
 	)
 	return result
@@ -132,6 +254,54 @@ func (t *Table) UnpartitionedCreateStatement(flavor Flavor) string {
 	return base
 }
 
+// IsMerge returns true if t uses the MERGE (MRG_MyISAM) storage engine.
+func (t *Table) IsMerge() bool {
+	return CanonicalEngineName(t.Engine) == "MRG_MyISAM"
+}
+
+// canonicalEngineNames maps lowercased storage engine names, including
+// synonyms, to the casing used by the server in SHOW CREATE TABLE.
+var canonicalEngineNames = map[string]string{
+	"innodb":     "InnoDB",
+	"myisam":     "MyISAM",
+	"memory":     "MEMORY",
+	"heap":       "MEMORY",
+	"mrg_myisam": "MRG_MyISAM",
+	"merge":      "MRG_MyISAM",
+	"csv":        "CSV",
+	"archive":    "ARCHIVE",
+	"blackhole":  "BLACKHOLE",
+	"federated":  "FEDERATED",
+	"aria":       "Aria",
+	"rocksdb":    "ROCKSDB",
+}
+
+// CanonicalEngineName returns engine using the server's canonical casing,
+// resolving known synonyms such as HEAP (MEMORY) and MERGE (MRG_MyISAM).
+// Unknown engine names are returned unchanged.
+func CanonicalEngineName(engine string) string {
+	if canonical, ok := canonicalEngineNames[strings.ToLower(engine)]; ok {
+		return canonical
+	}
+	return engine
+}
+
+// SameEngine returns true if t and other use the same storage engine, ignoring
+// differences in case or use of engine name synonyms.
+func (t *Table) SameEngine(other *Table) bool {
+	return strings.EqualFold(CanonicalEngineName(t.Engine), CanonicalEngineName(other.Engine))
+}
+
+// mergeUnionList returns a comma-separated list of escaped table names, in the
+// format used by the UNION clause of a MERGE table.
+func mergeUnionList(tables []string) string {
+	escaped := make([]string, len(tables))
+	for n, name := range tables {
+		escaped[n] = EscapeIdentifier(name)
+	}
+	return strings.Join(escaped, ",")
+}
+
 // ColumnsByName returns a mapping of column names to Column value pointers,
 // for all columns in the table.
 func (t *Table) ColumnsByName() map[string]*Column {
@@ -214,6 +384,132 @@ func (t *Table) ClusteredIndexKey() *Index {
 
 var reTableRowFormatClause = regexp.MustCompile(`ROW_FORMAT=(\w+)`)
 
+var reTableCharSetClause = regexp.MustCompile(` (?:DEFAULT CHARSET|COLLATE)=\w+`)
+
+// stripTableCharSetClauses removes the DEFAULT CHARSET and COLLATE table
+// options from a CREATE TABLE statement. Only the table options following the
+// closing paren of the column and index definitions are affected.
+func stripTableCharSetClauses(create string) string {
+	pos := strings.LastIndex(create, "\n)")
+	if pos < 0 {
+		return create
+	}
+	return create[:pos] + reTableCharSetClause.ReplaceAllString(create[pos:], "")
+}
+
+var reTableEngineClause = regexp.MustCompile(` ENGINE=(\w+)`)
+
+// normalizeTableEngineClause rewrites the ENGINE table option of a CREATE TABLE
+// statement to use the canonical engine name. Only the table options following
+// the closing paren of the column and index definitions are affected.
+func normalizeTableEngineClause(create string) string {
+	pos := strings.LastIndex(create, "\n)")
+	if pos < 0 {
+		return create
+	}
+	return create[:pos] + reTableEngineClause.ReplaceAllStringFunc(create[pos:], func(clause string) string {
+		return " ENGINE=" + CanonicalEngineName(clause[len(" ENGINE="):])
+	})
+}
+
+var reTableDelayKeyWriteClause = regexp.MustCompile(` DELAY_KEY_WRITE=\d+`)
+
+// stripDelayKeyWriteClause removes the DELAY_KEY_WRITE table option from a
+// CREATE TABLE statement. Only the table options following the closing paren
+// of the column and index definitions are affected.
+func stripDelayKeyWriteClause(create string) string {
+	pos := strings.LastIndex(create, "\n)")
+	if pos < 0 {
+		return create
+	}
+	return create[:pos] + reTableDelayKeyWriteClause.ReplaceAllString(create[pos:], "")
+}
+
+// normalizeForeignKeyOrder sorts the foreign key definition lines of a CREATE
+// TABLE statement by constraint name, so that statements only differing in the
+// order of their foreign keys compare as equal. Foreign key order has no
+// functional impact, and MySQL 8.0.19+ lists foreign keys in creation order
+// rather than sorting them.
+func normalizeForeignKeyOrder(create string) string {
+	lines := strings.Split(create, "\n")
+	var positions []int
+	var defs []string
+	for n, line := range lines {
+		if strings.HasPrefix(line, "  CONSTRAINT ") && strings.Contains(line, " FOREIGN KEY (") {
+			positions = append(positions, n)
+			defs = append(defs, strings.TrimSuffix(line, ","))
+		}
+	}
+	if len(defs) < 2 {
+		return create
+	}
+	sort.Strings(defs)
+	for n, pos := range positions {
+		if strings.HasSuffix(lines[pos], ",") {
+			defs[n] += ","
+		}
+		lines[pos] = defs[n]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// charSetConversion determines whether the default character set change ccs,
+// along with the column modifications mods, can be expressed as a single
+// CONVERT TO CHARACTER SET clause. This is only possible if every textual
+// column of t (the "to" side table) uses the new default character set and
+// collation, and every modification of a textual column solely changes its
+// character set and/or collation to match. If so, the ConvertCharSet clause is
+// returned, along with the remaining mods which are not related to the
+// conversion. CONVERT TO is only used if at least one of the folded column
+// modifications is a functional change, rather than a purely cosmetic one.
+//
+// TEXT columns changing character set prevent use of CONVERT TO, since the
+// server may implicitly promote them to a larger TEXT type.
+func (t *Table) charSetConversion(ccs ChangeCharSet, mods []TableAlterClause) (convert ConvertCharSet, remaining []TableAlterClause, ok bool) {
+	if ccs.Clause(StatementModifiers{}) == "" {
+		return convert, mods, false
+	}
+	for _, col := range t.Columns {
+		if col.CharSet != "" && (col.CharSet != ccs.ToCharSet || col.Collation != ccs.ToCollation) {
+			return convert, mods, false
+		}
+	}
+	convert = ConvertCharSet{
+		FromCharSet:   ccs.FromCharSet,
+		FromCollation: ccs.FromCollation,
+		ToCharSet:     ccs.ToCharSet,
+		ToCollation:   ccs.ToCollation,
+	}
+	var functional bool
+	for _, clause := range mods {
+		mc, isModify := clause.(ModifyColumn)
+		if !isModify || mc.OldColumn.CharSet == "" || mc.NewColumn.CharSet == "" {
+			remaining = append(remaining, clause)
+			continue
+		}
+		if mc.PositionFirst || mc.PositionAfter != nil {
+			return ConvertCharSet{}, mods, false
+		}
+		if mc.OldColumn.CharSet != mc.NewColumn.CharSet && strings.HasSuffix(strings.ToLower(mc.NewColumn.TypeInDB), "text") {
+			return ConvertCharSet{}, mods, false
+		}
+		oldCopy := *mc.OldColumn
+		oldCopy.CharSet, oldCopy.Collation, oldCopy.CollationIsDefault = mc.NewColumn.CharSet, mc.NewColumn.Collation, mc.NewColumn.CollationIsDefault
+		oldCopy.ForceShowCharSet, oldCopy.ForceShowCollation = mc.NewColumn.ForceShowCharSet, mc.NewColumn.ForceShowCollation
+		if !oldCopy.Equals(mc.NewColumn) {
+			return ConvertCharSet{}, mods, false
+		}
+		convert.Columns = append(convert.Columns, mc)
+		if !mc.OldColumn.Equivalent(mc.NewColumn) {
+			functional = true
+		}
+	}
+	if !functional {
+		return ConvertCharSet{}, mods, false
+	}
+	return convert, remaining, true
+}
+
 // RowFormatClause returns the table's ROW_FORMAT clause, if one was explicitly
 // specified in the table's creation options. If no ROW_FORMAT clause was
 // specified, but a KEY_BLOCK_SIZE is, "COMPRESSED" will be returned since MySQL
@@ -269,7 +565,15 @@ func (t *Table) Diff(to *Table) (clauses []TableAlterClause, supported bool) {
 	// so that column reordering works properly.
 	cc := from.compareColumnExistence(to)
 	clauses = append(clauses, cc.columnDrops()...)
-	clauses = append(clauses, cc.columnModifications()...)
+	modifications := cc.columnModifications()
+	if len(clauses) > 0 {
+		if ccs, ok := clauses[0].(ChangeCharSet); ok {
+			if convert, remaining, ok := to.charSetConversion(ccs, modifications); ok {
+				clauses[0], modifications = convert, remaining
+			}
+		}
+	}
+	clauses = append(clauses, modifications...)
 	clauses = append(clauses, cc.columnAdds()...)
 
 	// Compare PK
@@ -305,7 +609,10 @@ func (t *Table) Diff(to *Table) (clauses []TableAlterClause, supported bool) {
 			clauses = append(clauses, AddIndex{Index: toIndex})
 			reorderIndexes = true
 		} else if !fromIndex.EqualsIgnoringVisibility(toIndex) {
-			clauses = append(clauses, DropIndex{Index: fromIndex}, AddIndex{Index: toIndex})
+			// MySQL cannot change an index's uniqueness in-place, so this also requires
+			// a drop and re-add
+			uniquenessChange := fromIndex.Unique != toIndex.Unique && fromIndex.withUnique(toIndex.Unique).EqualsIgnoringVisibility(toIndex)
+			clauses = append(clauses, DropIndex{Index: fromIndex}, AddIndex{Index: toIndex, uniquenessChange: uniquenessChange})
 			reorderIndexes = true
 		} else {
 			if fromIndex.Invisible != toIndex.Invisible {
@@ -329,7 +636,9 @@ func (t *Table) Diff(to *Table) (clauses []TableAlterClause, supported bool) {
 		}
 	}
 
-	// Compare foreign keys
+	// Compare foreign keys. These are matched by name, since the order of foreign
+	// keys has no functional impact. Iteration follows each table's ordering of
+	// foreign keys, so that the resulting clause order is deterministic.
 	fromForeignKeys := from.foreignKeysByName()
 	toForeignKeys := to.foreignKeysByName()
 	fkChangeCosmeticOnly := func(fk *ForeignKey, others []*ForeignKey) bool {
@@ -340,7 +649,7 @@ func (t *Table) Diff(to *Table) (clauses []TableAlterClause, supported bool) {
 		}
 		return false
 	}
-	for _, toFk := range toForeignKeys {
+	for _, toFk := range to.ForeignKeys {
 		if _, existedBefore := fromForeignKeys[toFk.Name]; !existedBefore {
 			clauses = append(clauses, AddForeignKey{
 				ForeignKey:   toFk,
@@ -348,7 +657,7 @@ func (t *Table) Diff(to *Table) (clauses []TableAlterClause, supported bool) {
 			})
 		}
 	}
-	for _, fromFk := range fromForeignKeys {
+	for _, fromFk := range from.ForeignKeys {
 		toFk, stillExists := toForeignKeys[fromFk.Name]
 		if !stillExists {
 			clauses = append(clauses, DropForeignKey{
@@ -406,9 +715,30 @@ func (t *Table) Diff(to *Table) (clauses []TableAlterClause, supported bool) {
 		}
 	}
 
-	// Compare storage engine
-	if from.Engine != to.Engine {
-		clauses = append(clauses, ChangeStorageEngine{NewStorageEngine: to.Engine})
+	// Compare storage engine. Differences in case or use of a synonym are purely
+	// cosmetic, and are ignored.
+	if !from.SameEngine(to) {
+		clauses = append(clauses, ChangeStorageEngine{NewStorageEngine: CanonicalEngineName(to.Engine)})
+	}
+
+	// Compare the underlying tables and insert method of MERGE tables. These are
+	// only meaningful to the MERGE engine; if the table is being converted away
+	// from MERGE, they are discarded by the engine change. Removing all of the
+	// underlying tables of an existing MERGE table is not supported, since SHOW
+	// CREATE TABLE has no way to express an empty UNION list.
+	if to.IsMerge() && len(to.MergeUnion) == 0 && from.IsMerge() && len(from.MergeUnion) > 0 {
+		return nil, false
+	} else if to.IsMerge() && !reflect.DeepEqual(from.MergeUnion, to.MergeUnion) && len(to.MergeUnion) > 0 {
+		clauses = append(clauses, ChangeMergeUnion{
+			OldTables: from.MergeUnion,
+			NewTables: to.MergeUnion,
+		})
+	}
+	if to.IsMerge() && !strings.EqualFold(from.MergeInsertMethod, to.MergeInsertMethod) {
+		clauses = append(clauses, ChangeMergeInsertMethod{
+			OldInsertMethod: from.MergeInsertMethod,
+			NewInsertMethod: to.MergeInsertMethod,
+		})
 	}
 
 	// Compare next auto-inc value
@@ -420,15 +750,31 @@ func (t *Table) Diff(to *Table) (clauses []TableAlterClause, supported bool) {
 		clauses = append(clauses, cai)
 	}
 
-	// Compare create options
-	if from.CreateOptions != to.CreateOptions {
+	// Compare create options. DELAY_KEY_WRITE is only meaningful to MyISAM, so
+	// differences in it are ignored if the table will not be MyISAM.
+	fromCreateOptions, toCreateOptions := from.CreateOptions, to.CreateOptions
+	delayKeyWriteRelevant := CanonicalEngineName(to.Engine) == "MyISAM"
+	if !delayKeyWriteRelevant {
+		fromCreateOptions, toCreateOptions = removeCreateOption(fromCreateOptions, "DELAY_KEY_WRITE"), removeCreateOption(toCreateOptions, "DELAY_KEY_WRITE")
+	}
+	if fromCreateOptions != toCreateOptions {
 		cco := ChangeCreateOptions{
-			OldCreateOptions: from.CreateOptions,
-			NewCreateOptions: to.CreateOptions,
+			OldCreateOptions: fromCreateOptions,
+			NewCreateOptions: toCreateOptions,
 		}
 		clauses = append(clauses, cco)
 	}
 
+	// Compare engine attributes
+	if from.EngineAttribute != to.EngineAttribute || from.SecondaryEngineAttribute != to.SecondaryEngineAttribute {
+		clauses = append(clauses, ChangeEngineAttributes{
+			OldEngineAttribute:          from.EngineAttribute,
+			NewEngineAttribute:          to.EngineAttribute,
+			OldSecondaryEngineAttribute: from.SecondaryEngineAttribute,
+			NewSecondaryEngineAttribute: to.SecondaryEngineAttribute,
+		})
+	}
+
 	// Compare comment
 	if from.Comment != to.Comment {
 		clauses = append(clauses, ChangeComment{NewComment: to.Comment})
@@ -445,6 +791,13 @@ func (t *Table) Diff(to *Table) (clauses []TableAlterClause, supported bool) {
 	// Note that some partitioning differences aren't supported yet, and others are
 	// intentionally ignored.
 	partClauses, partSupported := from.Partitioning.Diff(to.Partitioning)
+	if len(clauses) > 0 && len(partClauses) == 1 {
+		// ADD PARTITION and COALESCE PARTITION cannot be combined with other
+		// clauses in a single ALTER TABLE, so re-partition instead
+		if _, ok := partClauses[0].(ChangePartitionCount); ok {
+			partClauses[0] = PartitionBy{Partitioning: to.Partitioning, RePartition: true}
+		}
+	}
 	clauses = append(clauses, partClauses...)
 	if !partSupported {
 		return clauses, false
@@ -455,8 +808,21 @@ func (t *Table) Diff(to *Table) (clauses []TableAlterClause, supported bool) {
 	// unsupported (even though the two tables are individually supported). This
 	// normally shouldn't happen, but could be possible given differences between
 	// MySQL versions, vendors, storage engines, etc.
+	// The exception is a table's default charset and collation, which may be
+	// inherited from the schema on one side but stated explicitly on the other;
+	// since no ChangeCharSet clause was generated, the effective values match.
+	// Similarly, the engine name may differ only in case or by use of a synonym,
+	// and foreign keys may differ only in order. DELAY_KEY_WRITE may also differ
+	// on non-MyISAM tables, as it has no effect there.
 	if len(clauses) == 0 && from.CreateStatement != "" && to.CreateStatement != "" {
-		return clauses, false
+		fromCreate := normalizeForeignKeyOrder(normalizeTableEngineClause(stripTableCharSetClauses(from.CreateStatement)))
+		toCreate := normalizeForeignKeyOrder(normalizeTableEngineClause(stripTableCharSetClauses(to.CreateStatement)))
+		if !delayKeyWriteRelevant {
+			fromCreate, toCreate = stripDelayKeyWriteClause(fromCreate), stripDelayKeyWriteClause(toCreate)
+		}
+		if fromCreate != toCreate {
+			return clauses, false
+		}
 	}
 
 	return clauses, true
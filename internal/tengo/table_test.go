@@ -2,8 +2,10 @@ package tengo
 
 import (
 	"fmt"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestTableGeneratedCreateStatement(t *testing.T) {
@@ -25,6 +27,56 @@ func TestTableGeneratedCreateStatement(t *testing.T) {
 	}
 }
 
+func TestDroppedTableName(t *testing.T) {
+	when := time.Date(2023, 4, 5, 6, 7, 8, 0, time.UTC)
+	cases := []struct {
+		prefix   string
+		name     string
+		expected string
+	}{
+		{"_dropped_", "actor", "_dropped_actor_20230405060708"},
+		{"x", "with_underscores", "xwith_underscores_20230405060708"},
+		{"_dropped_", strings.Repeat("ñ", 60), "_dropped_" + strings.Repeat("ñ", 40) + "_20230405060708"},
+	}
+	for _, c := range cases {
+		actual := DroppedTableName(c.prefix, c.name, when)
+		if actual != c.expected {
+			t.Errorf("Unexpected result from DroppedTableName(%q, %q): expected %q, found %q", c.prefix, c.name, c.expected, actual)
+		}
+		origName, parsedWhen, ok := ParseDroppedTableName(c.prefix, actual)
+		if !ok || !strings.HasPrefix(c.name, origName) || !parsedWhen.Equal(when) {
+			t.Errorf("Unexpected result from ParseDroppedTableName(%q, %q): %q, %v, %t", c.prefix, actual, origName, parsedWhen, ok)
+		}
+	}
+
+	for _, name := range []string{"actor", "_dropped_actor", "_dropped_actor_2023", "dropped_actor_20230405060708"} {
+		if _, _, ok := ParseDroppedTableName("_dropped_", name); ok {
+			t.Errorf("Expected ParseDroppedTableName to return false for %q, but it returned true", name)
+		}
+	}
+
+	re := DroppedTablePattern("_dropped_")
+	for _, c := range cases[0:1] {
+		if name := DroppedTableName(c.prefix, c.name, when); !re.MatchString(name) {
+			t.Errorf("Expected DroppedTablePattern to match %q, but it did not", name)
+		}
+	}
+	for _, name := range []string{"actor", "_dropped_actor", "_dropped_actor_2023", "dropped_actor_20230405060708"} {
+		if re.MatchString(name) {
+			t.Errorf("Expected DroppedTablePattern not to match %q, but it did", name)
+		}
+	}
+	if DroppedTablePattern("") != nil {
+		t.Error("Expected DroppedTablePattern to return nil for empty prefix")
+	}
+
+	// Confirm time zone is normalized to UTC
+	pst := time.FixedZone("PST", -8*3600)
+	if actual := DroppedTableName("_d_", "foo", when.In(pst)); actual != "_d_foo_20230405060708" {
+		t.Errorf("Unexpected result from DroppedTableName with non-UTC time: %q", actual)
+	}
+}
+
 func TestTableClusteredIndexKey(t *testing.T) {
 	table := aTable(1)
 	if table.ClusteredIndexKey() == nil || table.ClusteredIndexKey() != table.PrimaryKey {
@@ -323,6 +375,89 @@ func TestTableAlterAddOrDropIndex(t *testing.T) {
 	if ta.Index != to.SecondaryIndexes[1] {
 		t.Error("Pointer in table alter[1] does not point to expected value")
 	}
+
+	// Start over; change the primary key's column to be descending. This should
+	// be treated as a drop and re-add of the PK, in both directions.
+	to = aTable(1)
+	to.PrimaryKey.Parts[0].Descending = true
+	to.CreateStatement = to.GeneratedCreateStatement(FlavorMySQL80)
+	for _, pair := range [][2]*Table{{&from, &to}, {&to, &from}} {
+		tableAlters, supported = pair[0].Diff(pair[1])
+		if len(tableAlters) != 2 || !supported {
+			t.Fatalf("Incorrect number of table alters: expected 2, found %d", len(tableAlters))
+		}
+		ta2, ok = tableAlters[0].(DropIndex)
+		if !ok {
+			t.Fatalf("Incorrect type of table alter[0] returned: expected %T, found %T", ta2, tableAlters[0])
+		} else if ta2.Index != pair[0].PrimaryKey {
+			t.Error("Pointer in table alter[0] does not point to expected value")
+		}
+		ta, ok = tableAlters[1].(AddIndex)
+		if !ok {
+			t.Fatalf("Incorrect type of table alter[1] returned: expected %T, found %T", ta, tableAlters[1])
+		} else if ta.Index != pair[1].PrimaryKey {
+			t.Error("Pointer in table alter[1] does not point to expected value")
+		}
+	}
+	if clause := (AddIndex{Index: to.PrimaryKey}).Clause(StatementModifiers{}); clause != "ADD PRIMARY KEY (`actor_id` DESC)" {
+		t.Errorf("Unexpected clause for descending primary key: %q", clause)
+	}
+
+	// Same thing, but for a unique secondary index. Since this is the first
+	// secondary index, the subsequent one also gets dropped and re-added to
+	// retain index order.
+	to = aTable(1)
+	to.SecondaryIndexes[0].Parts[0].Descending = true
+	to.CreateStatement = to.GeneratedCreateStatement(FlavorMySQL80)
+	tableAlters, supported = from.Diff(&to)
+	if len(tableAlters) != 4 || !supported {
+		t.Fatalf("Incorrect number of table alters: expected 4, found %d", len(tableAlters))
+	}
+	if ta2, ok = tableAlters[0].(DropIndex); !ok || ta2.Index != from.SecondaryIndexes[0] {
+		t.Errorf("Unexpected table alter[0]: %+v", tableAlters[0])
+	}
+	if ta, ok = tableAlters[1].(AddIndex); !ok || ta.Index != to.SecondaryIndexes[0] {
+		t.Errorf("Unexpected table alter[1]: %+v", tableAlters[1])
+	}
+	if from.SecondaryIndexes[0].Equivalent(to.SecondaryIndexes[0]) || from.SecondaryIndexes[0].RedundantTo(to.SecondaryIndexes[0]) {
+		t.Error("Expected unique indexes differing only by column direction to not be equivalent or redundant")
+	}
+}
+
+func TestTableAlterReorderForeignKeys(t *testing.T) {
+	from := foreignKeyTable()
+	from.CreateStatement = from.GeneratedCreateStatement(FlavorUnknown)
+	to := foreignKeyTable()
+	to.ForeignKeys[0], to.ForeignKeys[1] = to.ForeignKeys[1], to.ForeignKeys[0]
+	to.CreateStatement = to.GeneratedCreateStatement(FlavorUnknown)
+	if from.CreateStatement == to.CreateStatement {
+		t.Fatal("Expected CREATE TABLE statements to differ in foreign key order, but they are identical")
+	}
+
+	// Reordering alone should not yield any clauses, and should be supported
+	for _, pair := range [][2]*Table{{&from, &to}, {&to, &from}} {
+		tableAlters, supported := pair[0].Diff(pair[1])
+		if len(tableAlters) != 0 || !supported {
+			t.Errorf("Expected no alters and supported=true, instead found %+v, %t", tableAlters, supported)
+		}
+	}
+
+	// Reordering combined with a genuine definition change should only affect
+	// the changed FK
+	changedFk := *to.ForeignKeys[1]
+	changedFk.DeleteRule = "CASCADE"
+	to.ForeignKeys[1] = &changedFk
+	to.CreateStatement = to.GeneratedCreateStatement(FlavorUnknown)
+	tableAlters, supported := from.Diff(&to)
+	if len(tableAlters) != 2 || !supported {
+		t.Fatalf("Expected 2 alters and supported=true, instead found %+v, %t", tableAlters, supported)
+	}
+	if drop, ok := tableAlters[0].(DropForeignKey); !ok || drop.ForeignKey.Name != changedFk.Name {
+		t.Errorf("Expected first clause to drop %s, instead found %+v", changedFk.Name, tableAlters[0])
+	}
+	if add, ok := tableAlters[1].(AddForeignKey); !ok || add.ForeignKey != &changedFk {
+		t.Errorf("Expected second clause to add %s, instead found %+v", changedFk.Name, tableAlters[1])
+	}
 }
 
 func TestTableAlterAddOrDropForeignKey(t *testing.T) {
@@ -508,6 +643,85 @@ func TestTableAlterAddIndexOrder(t *testing.T) {
 	}
 }
 
+func TestTableAlterChangeIndexUniqueness(t *testing.T) {
+	getTable := func(ssnUnique, nameUnique bool) *Table {
+		t := aTable(1)
+		t.SecondaryIndexes[0].Unique = ssnUnique
+		t.SecondaryIndexes[1].Unique = nameUnique
+		t.CreateStatement = t.GeneratedCreateStatement(FlavorUnknown)
+		return &t
+	}
+	cases := []struct {
+		from, to        *Table
+		expected        string
+		expectWarning   bool
+		expectedExplain string
+	}{
+		{getTable(true, false), getTable(true, true), "ALTER TABLE `actor` DROP KEY `idx_actor_name`, ADD UNIQUE KEY `idx_actor_name` (`last_name`(10),`first_name`(1))", true, "as unique"},
+		{getTable(true, true), getTable(true, false), "ALTER TABLE `actor` DROP KEY `idx_actor_name`, ADD KEY `idx_actor_name` (`last_name`(10),`first_name`(1))", false, "as non-unique"},
+		{getTable(true, false), getTable(false, false), "ALTER TABLE `actor` DROP KEY `idx_ssn`, ADD KEY `idx_ssn` (`ssn`)", false, "as non-unique"},
+	}
+	for _, c := range cases {
+		td := NewAlterTable(c.from, c.to)
+		if actual, err := td.Statement(StatementModifiers{}); err != nil || actual != c.expected {
+			t.Errorf("Unexpected result from Statement: expected %q, found %q / err=%v", c.expected, actual, err)
+		}
+		warnings := td.Warnings(StatementModifiers{})
+		if c.expectWarning && (len(warnings) != 1 || !strings.Contains(warnings[0], "UNIQUE")) {
+			t.Errorf("Expected 1 warning about UNIQUE, instead found %v", warnings)
+		} else if !c.expectWarning && len(warnings) > 0 {
+			t.Errorf("Expected no warnings, instead found %v", warnings)
+		}
+		var foundExplain bool
+		for _, clause := range td.alterClauses {
+			if strings.HasSuffix(explainClause(clause, ""), c.expectedExplain) {
+				foundExplain = true
+			}
+		}
+		if !foundExplain {
+			t.Errorf("Expected a clause explanation ending in %q, but none found", c.expectedExplain)
+		}
+	}
+
+	// Changing uniqueness alongside other changes to the index should not be
+	// treated as a uniqueness change, but still warrants a warning since the
+	// index's columns weren't previously unique
+	from, to := getTable(true, false), getTable(true, true)
+	to.SecondaryIndexes[1].Parts = to.SecondaryIndexes[1].Parts[0:1]
+	to.CreateStatement = to.GeneratedCreateStatement(FlavorUnknown)
+	if warnings := NewAlterTable(from, to).Warnings(StatementModifiers{}); len(warnings) != 1 || !strings.Contains(warnings[0], "Adding UNIQUE index `idx_actor_name`") {
+		t.Errorf("Expected 1 warning about adding unique index, instead found %v", warnings)
+	}
+
+	// Adding a new unique index warrants a warning, unless its columns are
+	// already covered by an existing unique index or primary key
+	assertNewUniqueWarning := func(parts []IndexPart, expectWarning bool) {
+		t.Helper()
+		from, to := getTable(true, false), getTable(true, false)
+		to.SecondaryIndexes = append(to.SecondaryIndexes, &Index{Name: "idx_new", Parts: parts, Unique: true, Type: "BTREE"})
+		to.CreateStatement = to.GeneratedCreateStatement(FlavorUnknown)
+		warnings := NewAlterTable(from, to).Warnings(StatementModifiers{})
+		if expectWarning && (len(warnings) != 1 || !strings.Contains(warnings[0], "Adding UNIQUE index `idx_new`")) {
+			t.Errorf("Expected 1 warning about adding unique index for parts %+v, instead found %v", parts, warnings)
+		} else if !expectWarning && len(warnings) > 0 {
+			t.Errorf("Expected no warnings for parts %+v, instead found %v", parts, warnings)
+		}
+	}
+	assertNewUniqueWarning([]IndexPart{{ColumnName: "last_name"}}, true)
+	assertNewUniqueWarning([]IndexPart{{ColumnName: "last_name"}, {ColumnName: "actor_id"}}, false)
+	assertNewUniqueWarning([]IndexPart{{ColumnName: "ssn"}, {ColumnName: "first_name"}}, false)
+	assertNewUniqueWarning([]IndexPart{{ColumnName: "first_name"}, {ColumnName: "ssn", PrefixLength: 5}}, true)
+
+	// Uniqueness of a column prefix also guarantees uniqueness of a longer prefix
+	// or the entire column
+	from, to = getTable(true, true), getTable(true, true)
+	to.SecondaryIndexes = append(to.SecondaryIndexes, &Index{Name: "idx_new", Parts: []IndexPart{{ColumnName: "last_name"}, {ColumnName: "first_name", PrefixLength: 2}}, Unique: true, Type: "BTREE"})
+	to.CreateStatement = to.GeneratedCreateStatement(FlavorUnknown)
+	if warnings := NewAlterTable(from, to).Warnings(StatementModifiers{}); len(warnings) > 0 {
+		t.Errorf("Expected no warnings, instead found %v", warnings)
+	}
+}
+
 func TestTableAlterIndexReorder(t *testing.T) {
 	// Table with three secondary indexes:
 	// [0] is UNIQUE KEY `idx_ssn` (`ssn`)
@@ -835,6 +1049,400 @@ func TestTableAlterModifyColumn(t *testing.T) {
 			seen[mc.NewColumn.Name] = true
 		}
 	}
+
+	// Removing, adding, or changing precision of ON UPDATE should all generate
+	// a MODIFY COLUMN
+	assertOnUpdateChange := func(fromOnUpdate, toOnUpdate, expectClause string) {
+		t.Helper()
+		from, to := aTable(1), aTable(1)
+		var fromCol, toCol *Column
+		for n := range from.Columns {
+			if from.Columns[n].Name == "last_update" {
+				fromCol, toCol = from.Columns[n], to.Columns[n]
+			}
+		}
+		fromCol.OnUpdate, toCol.OnUpdate = fromOnUpdate, toOnUpdate
+		from.CreateStatement = from.GeneratedCreateStatement(FlavorUnknown)
+		to.CreateStatement = to.GeneratedCreateStatement(FlavorUnknown)
+		tableAlters, supported := from.Diff(&to)
+		if len(tableAlters) != 1 || !supported {
+			t.Fatalf("Incorrect number of table alters: expected 1, found %d", len(tableAlters))
+		}
+		if clause := tableAlters[0].Clause(StatementModifiers{}); clause != expectClause {
+			t.Errorf("Expected clause %q, instead found %q", expectClause, clause)
+		}
+	}
+	assertOnUpdateChange("CURRENT_TIMESTAMP(2)", "", "MODIFY COLUMN `last_update` timestamp(2) NOT NULL DEFAULT CURRENT_TIMESTAMP(2)")
+	assertOnUpdateChange("", "CURRENT_TIMESTAMP(2)", "MODIFY COLUMN `last_update` timestamp(2) NOT NULL DEFAULT CURRENT_TIMESTAMP(2) ON UPDATE CURRENT_TIMESTAMP(2)")
+	assertOnUpdateChange("CURRENT_TIMESTAMP", "CURRENT_TIMESTAMP(2)", "MODIFY COLUMN `last_update` timestamp(2) NOT NULL DEFAULT CURRENT_TIMESTAMP(2) ON UPDATE CURRENT_TIMESTAMP(2)")
+}
+
+func TestTableAlterModifyColumnPrecision(t *testing.T) {
+	// Changing fractional-seconds precision in either direction should generate a
+	// MODIFY COLUMN, along with any ON UPDATE and DEFAULT precision changes.
+	// Reducing precision is unsafe; increasing it is safe.
+	assertPrecisionChange := func(fromPrecision, toPrecision string, expectUnsafe bool) {
+		t.Helper()
+		from, to := aTable(1), aTable(1)
+		for n := range from.Columns {
+			if from.Columns[n].Name == "last_update" {
+				fromCol, toCol := from.Columns[n], to.Columns[n]
+				fromCol.TypeInDB = "timestamp" + fromPrecision
+				fromCol.Default = "CURRENT_TIMESTAMP" + fromPrecision
+				fromCol.OnUpdate = "CURRENT_TIMESTAMP" + fromPrecision
+				toCol.TypeInDB = "timestamp" + toPrecision
+				toCol.Default = "CURRENT_TIMESTAMP" + toPrecision
+				toCol.OnUpdate = "CURRENT_TIMESTAMP" + toPrecision
+			}
+		}
+		from.CreateStatement = from.GeneratedCreateStatement(FlavorUnknown)
+		to.CreateStatement = to.GeneratedCreateStatement(FlavorUnknown)
+		tableAlters, supported := from.Diff(&to)
+		if len(tableAlters) != 1 || !supported {
+			t.Fatalf("Incorrect number of table alters: expected 1, found %d", len(tableAlters))
+		}
+		mc, ok := tableAlters[0].(ModifyColumn)
+		if !ok {
+			t.Fatalf("Expected a ModifyColumn, instead found %T", tableAlters[0])
+		}
+		expectClause := fmt.Sprintf("MODIFY COLUMN `last_update` timestamp%s NOT NULL DEFAULT CURRENT_TIMESTAMP%s ON UPDATE CURRENT_TIMESTAMP%s", toPrecision, toPrecision, toPrecision)
+		if clause := mc.Clause(StatementModifiers{}); clause != expectClause {
+			t.Errorf("Expected clause %q, instead found %q", expectClause, clause)
+		}
+		if mc.Unsafe() != expectUnsafe {
+			t.Errorf("Expected Unsafe() to return %t, instead found %t", expectUnsafe, mc.Unsafe())
+		}
+	}
+	assertPrecisionChange("", "(6)", false)
+	assertPrecisionChange("(6)", "", true)
+	assertPrecisionChange("(2)", "(3)", false)
+	assertPrecisionChange("(3)", "(2)", true)
+}
+
+func TestTableAlterModifyColumnSignedness(t *testing.T) {
+	// Changing signedness in either direction should generate a MODIFY COLUMN.
+	// Signed to unsigned is always unsafe; unsigned to signed is unsafe unless
+	// the new integer type is also larger.
+	assertSignednessChange := func(fromType, toType string, expectUnsafe bool) {
+		t.Helper()
+		from, to := aTable(1), aTable(1)
+		for n := range from.Columns {
+			if from.Columns[n].Name == "alive" {
+				from.Columns[n].TypeInDB = fromType
+				to.Columns[n].TypeInDB = toType
+			}
+		}
+		from.CreateStatement = from.GeneratedCreateStatement(FlavorUnknown)
+		to.CreateStatement = to.GeneratedCreateStatement(FlavorUnknown)
+		tableAlters, supported := from.Diff(&to)
+		if len(tableAlters) != 1 || !supported {
+			t.Fatalf("Incorrect number of table alters: expected 1, found %d", len(tableAlters))
+		}
+		mc, ok := tableAlters[0].(ModifyColumn)
+		if !ok {
+			t.Fatalf("Expected a ModifyColumn, instead found %T", tableAlters[0])
+		}
+		expectClause := fmt.Sprintf("MODIFY COLUMN `alive` %s NOT NULL DEFAULT '1'", toType)
+		if clause := mc.Clause(StatementModifiers{}); clause != expectClause {
+			t.Errorf("Expected clause %q, instead found %q", expectClause, clause)
+		}
+		if mc.Unsafe() != expectUnsafe {
+			t.Errorf("For %s -> %s, expected Unsafe() to return %t, instead found %t", fromType, toType, expectUnsafe, mc.Unsafe())
+		}
+	}
+	assertSignednessChange("tinyint(1) unsigned", "tinyint(1)", true)
+	assertSignednessChange("tinyint(1)", "tinyint(1) unsigned", true)
+	assertSignednessChange("tinyint(1) unsigned", "smallint(1)", false)
+	assertSignednessChange("tinyint(1)", "smallint(1) unsigned", true)
+	assertSignednessChange("tinyint(1) unsigned zerofill", "tinyint(1) zerofill", true)
+}
+
+func TestTableAlterTextDefaultExpression(t *testing.T) {
+	// Adding a TEXT column with a default expression, and then changing that
+	// expression, should only generate DDL for flavors supporting it
+	from, to := aTable(1), aTable(1)
+	notes := &Column{
+		Name:               "notes",
+		TypeInDB:           "text",
+		Nullable:           true,
+		Default:            "(_utf8mb4'none')",
+		CharSet:            "utf8mb4",
+		Collation:          "utf8mb4_0900_ai_ci",
+		CollationIsDefault: true,
+	}
+	to.Columns = append(to.Columns, notes)
+	to.CreateStatement = to.GeneratedCreateStatement(FlavorMySQL80)
+	if !strings.Contains(to.CreateStatement, "`notes` text CHARACTER SET utf8mb4 DEFAULT (_utf8mb4'none')") {
+		t.Fatalf("Unexpected CREATE TABLE: %s", to.CreateStatement)
+	}
+
+	assertStatement := func(from, to *Table, flavor Flavor, expectContains string) {
+		t.Helper()
+		td := NewAlterTable(from, to)
+		stmt, err := td.Statement(StatementModifiers{Flavor: flavor})
+		if expectContains == "" {
+			if defaultErr, ok := err.(*UnsupportedDefaultError); !ok || defaultErr.Column != "notes" || defaultErr.Flavor != flavor {
+				t.Errorf("Expected unsupported default error for flavor %s, instead found statement %q, err=%v", flavor, stmt, err)
+			} else if !strings.Contains(err.Error(), "`notes`") || !strings.Contains(err.Error(), flavor.String()) {
+				t.Errorf("Expected error message to name column and flavor, instead found %q", err.Error())
+			}
+		} else if err != nil {
+			t.Errorf("Unexpected error for flavor %s: %v", flavor, err)
+		} else if !strings.Contains(stmt, expectContains) {
+			t.Errorf("Expected statement for flavor %s to contain %q, instead found %q", flavor, expectContains, stmt)
+		}
+	}
+	addClause := "ADD COLUMN `notes` text CHARACTER SET utf8mb4 DEFAULT (_utf8mb4'none')"
+	assertStatement(&from, &to, FlavorMySQL80.Dot(30), addClause)
+	assertStatement(&from, &to, FlavorMySQL80.Dot(13), addClause)
+	assertStatement(&from, &to, FlavorMySQL80.Dot(12), "")
+	assertStatement(&from, &to, FlavorMySQL57, "")
+	assertStatement(&from, &to, FlavorUnknown, addClause)
+
+	// The same check applies to CREATE TABLE
+	create := NewCreateTable(&to)
+	if stmt, err := create.Statement(StatementModifiers{Flavor: FlavorMySQL80.Dot(30)}); err != nil || stmt != to.CreateStatement {
+		t.Errorf("Unexpected result from CREATE Statement: %q, %v", stmt, err)
+	}
+	if _, err := create.Statement(StatementModifiers{Flavor: FlavorMySQL57}); err == nil {
+		t.Error("Expected error from CREATE Statement with unsupported DEFAULT, but err was nil")
+	} else if _, ok := err.(*UnsupportedDefaultError); !ok {
+		t.Errorf("Expected *UnsupportedDefaultError from CREATE Statement, instead found %T", err)
+	}
+
+	// Changing the expression is a MODIFY COLUMN, and is safe
+	changed := to
+	changed.Columns = append([]*Column{}, to.Columns...)
+	changedNotes := *notes
+	changedNotes.Default = "(_utf8mb4'n/a')"
+	changed.Columns[len(changed.Columns)-1] = &changedNotes
+	changed.CreateStatement = changed.GeneratedCreateStatement(FlavorMySQL80)
+	td := NewAlterTable(&to, &changed)
+	if td == nil || len(td.alterClauses) != 1 {
+		t.Fatalf("Expected exactly one alter clause, instead found %+v", td)
+	} else if mc, ok := td.alterClauses[0].(ModifyColumn); !ok || mc.Unsafe() {
+		t.Errorf("Expected safe ModifyColumn, instead found %+v", td.alterClauses[0])
+	}
+	assertStatement(&to, &changed, FlavorMySQL80.Dot(30), "MODIFY COLUMN `notes` text CHARACTER SET utf8mb4 DEFAULT (_utf8mb4'n/a')")
+
+	// MySQL only permits expression defaults on these types, whereas MariaDB also
+	// permits literals
+	changedNotes.Default = "'n/a'"
+	changed.CreateStatement = changed.GeneratedCreateStatement(FlavorMariaDB105)
+	assertStatement(&to, &changed, FlavorMariaDB105, "DEFAULT 'n/a'")
+	assertStatement(&to, &changed, FlavorMySQL80.Dot(30), "")
+}
+
+func TestTableAlterModifyColumnAutoIncrement(t *testing.T) {
+	withAutoInc, withoutAutoInc := aTable(1), aTable(1)
+	withoutAutoInc.Columns[0] = &Column{Name: "actor_id", TypeInDB: "smallint(5) unsigned"}
+	withoutAutoInc.NextAutoIncrement = 0
+	withoutAutoInc.CreateStatement = withoutAutoInc.GeneratedCreateStatement(FlavorUnknown)
+
+	getModifyColumn := func(from, to *Table) ModifyColumn {
+		t.Helper()
+		tableAlters, supported := from.Diff(to)
+		if !supported {
+			t.Fatal("Expected diff to be supported, but it was not")
+		}
+		var mcs []ModifyColumn
+		for _, clause := range tableAlters {
+			if mc, ok := clause.(ModifyColumn); ok {
+				mcs = append(mcs, mc)
+			}
+		}
+		if len(mcs) != 1 {
+			t.Fatalf("Expected exactly 1 ModifyColumn, instead found %+v", tableAlters)
+		}
+		return mcs[0]
+	}
+
+	// Adding AUTO_INCREMENT to an indexed column
+	mc := getModifyColumn(&withoutAutoInc, &withAutoInc)
+	if clause := mc.Clause(StatementModifiers{}); clause != "MODIFY COLUMN `actor_id` smallint(5) unsigned NOT NULL AUTO_INCREMENT" {
+		t.Errorf("Unexpected clause: %s", clause)
+	}
+	if mc.Unsafe() {
+		t.Error("Expected adding AUTO_INCREMENT to be safe, but Unsafe() returned true")
+	}
+	if warning := mc.AutoIncrementWarning(); !strings.Contains(warning, "NO_AUTO_VALUE_ON_ZERO") {
+		t.Errorf("Unexpected warning: %q", warning)
+	}
+	if warnings := NewAlterTable(&withoutAutoInc, &withAutoInc).Warnings(StatementModifiers{}); len(warnings) != 1 || warnings[0] != mc.AutoIncrementWarning() {
+		t.Errorf("Expected TableDiff.Warnings to include the AUTO_INCREMENT warning, instead found %v", warnings)
+	}
+
+	// Removing AUTO_INCREMENT
+	mc = getModifyColumn(&withAutoInc, &withoutAutoInc)
+	if clause := mc.Clause(StatementModifiers{}); clause != "MODIFY COLUMN `actor_id` smallint(5) unsigned NOT NULL" {
+		t.Errorf("Unexpected clause: %s", clause)
+	}
+	if mc.Unsafe() {
+		t.Error("Expected removing AUTO_INCREMENT to be safe, but Unsafe() returned true")
+	}
+	if warning := mc.AutoIncrementWarning(); warning != "" {
+		t.Errorf("Expected no warning when removing AUTO_INCREMENT, instead found %q", warning)
+	}
+
+	// Adding AUTO_INCREMENT to a column that isn't the first column of any index
+	// should warn that the ALTER will fail. For non-InnoDB tables, the column
+	// just needs to be part of any index.
+	unindexed := withAutoInc
+	unindexed.PrimaryKey = &Index{
+		Name:       "PRIMARY",
+		PrimaryKey: true,
+		Parts:      []IndexPart{{ColumnName: "first_name"}, {ColumnName: "actor_id"}},
+	}
+	mc = ModifyColumn{Table: &unindexed, OldColumn: withoutAutoInc.Columns[0], NewColumn: withAutoInc.Columns[0]}
+	if warning := mc.AutoIncrementWarning(); !strings.Contains(warning, "will fail") {
+		t.Errorf("Unexpected warning: %q", warning)
+	}
+	unindexed.Engine = "MyISAM"
+	if warning := mc.AutoIncrementWarning(); strings.Contains(warning, "will fail") {
+		t.Errorf("Unexpected warning: %q", warning)
+	}
+}
+
+func TestTableAlterModifyColumnJSON(t *testing.T) {
+	// Converting between a textual type and MySQL's native JSON type should
+	// generate a MODIFY COLUMN. Converting to JSON is always unsafe and generates
+	// a warning, since existing values may not be valid JSON. Converting back to
+	// longtext utf8mb4 is safe, but other textual types are not.
+	withText, withJSON, withLongtext := aTable(1), aTable(1), aTable(1)
+	for n, col := range withText.Columns {
+		if col.Name == "last_name" {
+			withText.Columns[n] = &Column{Name: "last_name", TypeInDB: "text", CharSet: "utf8mb4", Collation: "utf8mb4_general_ci", Nullable: true}
+			withJSON.Columns[n] = &Column{Name: "last_name", TypeInDB: "json", Nullable: true}
+			withLongtext.Columns[n] = &Column{Name: "last_name", TypeInDB: "longtext", CharSet: "utf8mb4", Collation: "utf8mb4_general_ci", Nullable: true}
+		}
+	}
+	for _, table := range []*Table{&withText, &withJSON, &withLongtext} {
+		table.CreateStatement = table.GeneratedCreateStatement(FlavorUnknown)
+	}
+	assertConversion := func(from, to *Table, expectClause string, expectUnsafe bool, expectWarning string) {
+		t.Helper()
+		td := NewAlterTable(from, to)
+		if td == nil || len(td.alterClauses) != 1 {
+			t.Fatalf("Expected exactly 1 clause, instead found %+v", td)
+		}
+		mc, ok := td.alterClauses[0].(ModifyColumn)
+		if !ok {
+			t.Fatalf("Expected a ModifyColumn, instead found %T", td.alterClauses[0])
+		}
+		if clause := mc.Clause(StatementModifiers{}); clause != expectClause {
+			t.Errorf("Expected clause %q, instead found %q", expectClause, clause)
+		}
+		if mc.Unsafe() != expectUnsafe {
+			t.Errorf("Expected Unsafe() to return %t, instead found %t", expectUnsafe, !expectUnsafe)
+		}
+		if expectUnsafe && td.Risk() != RiskDestructive {
+			t.Errorf("Expected risk to be destructive, instead found %s", td.Risk())
+		}
+		warnings := td.Warnings(StatementModifiers{})
+		if expectWarning == "" && len(warnings) > 0 {
+			t.Errorf("Expected no warnings, instead found %v", warnings)
+		} else if expectWarning != "" && (len(warnings) != 1 || !strings.Contains(warnings[0], expectWarning)) {
+			t.Errorf("Expected 1 warning containing %q, instead found %v", expectWarning, warnings)
+		}
+	}
+	assertConversion(&withText, &withJSON, "MODIFY COLUMN `last_name` json", true, "not valid JSON")
+	assertConversion(&withJSON, &withText, "MODIFY COLUMN `last_name` text CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci", true, "may truncate")
+	assertConversion(&withJSON, &withLongtext, "MODIFY COLUMN `last_name` longtext CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci", false, "")
+	assertConversion(&withLongtext, &withJSON, "MODIFY COLUMN `last_name` json", true, "not valid JSON")
+}
+
+func TestTableAlterModifyColumnDecimal(t *testing.T) {
+	// Changing precision or scale of a decimal column should generate a MODIFY
+	// COLUMN. Losing digits on either side of the decimal point is unsafe, and
+	// classified as destructive.
+	assertDecimalChange := func(fromType, toType string, expectUnsafe bool) {
+		t.Helper()
+		from, to := aTable(1), aTable(1)
+		for n := range from.Columns {
+			if from.Columns[n].Name == "alive" {
+				from.Columns[n].TypeInDB = fromType
+				to.Columns[n].TypeInDB = toType
+			}
+		}
+		from.CreateStatement = from.GeneratedCreateStatement(FlavorUnknown)
+		to.CreateStatement = to.GeneratedCreateStatement(FlavorUnknown)
+		tableAlters, supported := from.Diff(&to)
+		if len(tableAlters) != 1 || !supported {
+			t.Fatalf("Incorrect number of table alters: expected 1, found %d", len(tableAlters))
+		}
+		mc, ok := tableAlters[0].(ModifyColumn)
+		if !ok {
+			t.Fatalf("Expected a ModifyColumn, instead found %T", tableAlters[0])
+		}
+		expectClause := fmt.Sprintf("MODIFY COLUMN `alive` %s NOT NULL DEFAULT '1'", toType)
+		if clause := mc.Clause(StatementModifiers{}); clause != expectClause {
+			t.Errorf("Expected clause %q, instead found %q", expectClause, clause)
+		}
+		if mc.Unsafe() != expectUnsafe {
+			t.Errorf("For %s -> %s, expected Unsafe() to return %t, instead found %t", fromType, toType, expectUnsafe, mc.Unsafe())
+		}
+		expectRisk := RiskRebuild
+		if expectUnsafe {
+			expectRisk = RiskDestructive
+		}
+		if risk := NewAlterTable(&from, &to).Risk(); risk != expectRisk {
+			t.Errorf("For %s -> %s, expected Risk() to return %v, instead found %v", fromType, toType, expectRisk, risk)
+		}
+	}
+	assertDecimalChange("decimal(10,2)", "decimal(8,2)", true)
+	assertDecimalChange("decimal(8,2)", "decimal(10,2)", false)
+	assertDecimalChange("decimal(10,2)", "decimal(10,1)", true)
+	assertDecimalChange("decimal(10,1)", "decimal(11,2)", false)
+	assertDecimalChange("decimal(10,1)", "decimal(10,2)", true)
+	assertDecimalChange("decimal(10,2) unsigned", "decimal(10,2)", false)
+}
+
+func TestTableAlterImplicitTimestampDefaults(t *testing.T) {
+	// from has the implicit defaults that a server with
+	// explicit_defaults_for_timestamp=OFF would apply; to lacks them
+	from, to := aTable(1), aTable(1)
+	from.Columns = append(from.Columns, &Column{Name: "created_at", TypeInDB: "timestamp", Default: "'0000-00-00 00:00:00'"})
+	to.Columns = append(to.Columns, &Column{Name: "created_at", TypeInDB: "timestamp"})
+	for _, col := range to.Columns {
+		if col.Name == "last_update" {
+			col.Default, col.OnUpdate = "", ""
+		}
+	}
+	from.CreateStatement = from.GeneratedCreateStatement(FlavorUnknown)
+	to.CreateStatement = to.GeneratedCreateStatement(FlavorUnknown)
+
+	// With explicit_defaults_for_timestamp=ON, these are real differences
+	td := NewAlterTable(&from, &to)
+	if stmt, err := td.Statement(StatementModifiers{}); err != nil {
+		t.Errorf("Unexpected error from Statement: %v", err)
+	} else if !strings.Contains(stmt, "MODIFY COLUMN `last_update` timestamp(2) NOT NULL,") || !strings.HasSuffix(stmt, "MODIFY COLUMN `created_at` timestamp NOT NULL") {
+		t.Errorf("Unexpected statement: %s", stmt)
+	}
+
+	// With explicit_defaults_for_timestamp=OFF, the server would re-apply the
+	// same defaults, so no statement should be emitted
+	mods := StatementModifiers{ImplicitTSDefaults: true}
+	if stmt, err := td.Statement(mods); stmt != "" || err != nil {
+		t.Errorf("Expected no statement, instead found %q / %v", stmt, err)
+	}
+
+	// Implicit defaults depend on column position: only the first timestamp
+	// column gets CURRENT_TIMESTAMP, and others get a zero date
+	from.Columns[len(from.Columns)-1].Default = "CURRENT_TIMESTAMP"
+	from.Columns[len(from.Columns)-1].OnUpdate = "CURRENT_TIMESTAMP"
+	from.CreateStatement = from.GeneratedCreateStatement(FlavorUnknown)
+	td = NewAlterTable(&from, &to)
+	if stmt, err := td.Statement(mods); err != nil || !strings.HasSuffix(stmt, "MODIFY COLUMN `created_at` timestamp NOT NULL") || strings.Contains(stmt, "last_update") {
+		t.Errorf("Unexpected result from Statement: %q / %v", stmt, err)
+	}
+
+	// Nullability differences are never ignored
+	to.Columns[len(to.Columns)-1].Nullable = true
+	to.CreateStatement = to.GeneratedCreateStatement(FlavorUnknown)
+	td = NewAlterTable(&from, &to)
+	if stmt, err := td.Statement(mods); err != nil || !strings.HasSuffix(stmt, "MODIFY COLUMN `created_at` timestamp NULL") {
+		t.Errorf("Unexpected result from Statement: %q / %v", stmt, err)
+	}
 }
 
 func TestTableAlterNoModify(t *testing.T) {
@@ -973,6 +1581,181 @@ func TestTableAlterChangeStorageEngine(t *testing.T) {
 	to = getTableWithEngine("MyISAM")
 	assertChangeEngine(&from, &to, "ENGINE=MyISAM")
 	assertChangeEngine(&to, &from, "ENGINE=InnoDB")
+
+	// Differences in case or synonyms should not emit a clause, but genuine
+	// changes should use the canonical casing
+	to = getTableWithEngine("INNODB")
+	assertChangeEngine(&from, &to, "")
+	assertChangeEngine(&to, &from, "")
+	to = getTableWithEngine("myisam")
+	assertChangeEngine(&from, &to, "ENGINE=MyISAM")
+	from = getTableWithEngine("MEMORY")
+	to = getTableWithEngine("heap")
+	assertChangeEngine(&from, &to, "")
+	to = getTableWithEngine("SomeCustomEngine")
+	assertChangeEngine(&from, &to, "ENGINE=SomeCustomEngine")
+}
+
+func TestTableAlterStrippedDisplayWidths(t *testing.T) {
+	// Stripping int display widths, whether from the CREATE TABLE text or from
+	// the columns themselves, should not yield any ALTER
+	from := aTable(1)
+	if !strings.Contains(from.CreateStatement, "smallint(5) unsigned") {
+		t.Fatal("Assertion failed in test setup: CreateStatement unexpectedly lacks int display widths")
+	}
+	to := aTable(1)
+	stripIntDisplayWidths(&to)
+	if to.CreateStatement != StripCreateDisplayWidths(from.CreateStatement) {
+		t.Errorf("Expected StripCreateDisplayWidths to match stripping each column\nExpected:\n%s\nFound:\n%s", to.CreateStatement, StripCreateDisplayWidths(from.CreateStatement))
+	}
+	for _, pair := range [][2]*Table{{&from, &to}, {&to, &from}} {
+		alter := NewAlterTable(pair[0], pair[1])
+		if stmt, err := alter.Statement(StatementModifiers{}); stmt != "" || err != nil {
+			t.Errorf("Expected no ALTER between tables differing only in int display width, instead found %q / %v", stmt, err)
+		}
+		if stmt, _ := alter.Statement(StatementModifiers{StrictColumnDefinition: true}); stmt == "" {
+			t.Error("Expected StrictColumnDefinition to still emit an ALTER for int display width differences")
+		}
+	}
+}
+
+func TestTableAlterChangeMergeUnion(t *testing.T) {
+	getMergeTable := func(engine string, union ...string) Table {
+		t := aTable(1)
+		t.Engine = engine
+		t.MergeUnion = union
+		t.CreateStatement = t.GeneratedCreateStatement(FlavorUnknown)
+		return t
+	}
+	assertChangeUnion := func(a, b *Table, expected string) {
+		t.Helper()
+		tableAlters, supported := a.Diff(b)
+		if expected == "" {
+			if len(tableAlters) != 0 || !supported {
+				t.Fatalf("Incorrect result from Table.Diff(): expected len=0, true; found len=%d, %t", len(tableAlters), supported)
+			}
+			return
+		}
+		if len(tableAlters) != 1 || !supported {
+			t.Fatalf("Incorrect result from Table.Diff(): expected len=1, supported=true; found len=%d, supported=%t", len(tableAlters), supported)
+		}
+		ta, ok := tableAlters[0].(ChangeMergeUnion)
+		if !ok {
+			t.Fatalf("Incorrect type of table alter returned: expected %T, found %T", ta, tableAlters[0])
+		}
+		if actual := ta.Clause(StatementModifiers{}); actual != expected {
+			t.Errorf("Incorrect ALTER TABLE clause returned; expected: %s; found: %s", expected, actual)
+		}
+	}
+
+	from := getMergeTable("MRG_MyISAM", "t1", "t2")
+	if !strings.HasSuffix(from.CreateStatement, " UNION=(`t1`,`t2`)") {
+		t.Errorf("Generated CREATE TABLE missing expected UNION clause: %s", from.CreateStatement)
+	}
+	if actual := ParseCreateMergeUnion(from.CreateStatement); !reflect.DeepEqual(actual, from.MergeUnion) {
+		t.Errorf("Expected UNION to round-trip through CREATE TABLE, instead found %v", actual)
+	}
+	to := getMergeTable("MRG_MyISAM", "t1", "t2")
+	assertChangeUnion(&from, &to, "")
+
+	// Adding, removing, and reordering underlying tables
+	to = getMergeTable("MRG_MyISAM", "t1", "t2", "t3")
+	assertChangeUnion(&from, &to, "UNION=(`t1`,`t2`,`t3`)")
+	assertChangeUnion(&to, &from, "UNION=(`t1`,`t2`)")
+	to = getMergeTable("MRG_MyISAM", "t2", "t1")
+	assertChangeUnion(&from, &to, "UNION=(`t2`,`t1`)")
+	to = getMergeTable("MRG_MyISAM")
+	if strings.Contains(to.CreateStatement, "UNION") {
+		t.Errorf("Expected MERGE table without underlying tables to omit UNION clause, but found: %s", to.CreateStatement)
+	}
+	assertChangeUnion(&to, &from, "UNION=(`t1`,`t2`)")
+
+	// Removing all underlying tables is not supported
+	if tableAlters, supported := from.Diff(&to); supported {
+		t.Errorf("Expected removal of all underlying tables to be unsupported, instead found %d clauses, supported=%t", len(tableAlters), supported)
+	}
+	if clause := (ChangeMergeUnion{OldTables: from.MergeUnion}).Clause(StatementModifiers{}); clause != "" {
+		t.Errorf("Expected empty clause for empty NewTables, instead found %q", clause)
+	}
+
+	// Other engines never emit or diff a UNION clause
+	from = getMergeTable("InnoDB", "t1")
+	to = getMergeTable("InnoDB", "t2")
+	if strings.Contains(from.CreateStatement, "UNION") {
+		t.Errorf("Expected non-MERGE table to omit UNION clause, but found: %s", from.CreateStatement)
+	}
+	assertChangeUnion(&from, &to, "")
+
+	// Converting to MERGE should include the UNION list alongside the engine
+	// change; converting away from MERGE should not
+	from = getMergeTable("MyISAM")
+	to = getMergeTable("MRG_MyISAM", "t1", "t2")
+	clauses, supported := from.Diff(&to)
+	if len(clauses) != 2 || !supported {
+		t.Fatalf("Incorrect result from Table.Diff(): expected len=2, supported=true; found len=%d, supported=%t", len(clauses), supported)
+	}
+	if _, ok := clauses[1].(ChangeMergeUnion); !ok {
+		t.Errorf("Expected second clause to be ChangeMergeUnion, instead found %T", clauses[1])
+	}
+	if clauses, supported = to.Diff(&from); len(clauses) != 1 || !supported {
+		t.Errorf("Incorrect result from Table.Diff(): expected len=1, supported=true; found len=%d, supported=%t", len(clauses), supported)
+	}
+}
+
+func TestTableAlterChangeMergeInsertMethod(t *testing.T) {
+	getMergeTable := func(engine, insertMethod string) Table {
+		t := aTable(1)
+		t.Engine = engine
+		t.MergeUnion = []string{"t1", "t2"}
+		t.MergeInsertMethod = insertMethod
+		t.CreateStatement = t.GeneratedCreateStatement(FlavorUnknown)
+		return t
+	}
+
+	from := getMergeTable("MRG_MyISAM", "")
+	to := getMergeTable("MRG_MyISAM", "LAST")
+	if !strings.HasSuffix(to.CreateStatement, " INSERT_METHOD=LAST UNION=(`t1`,`t2`)") {
+		t.Errorf("Generated CREATE TABLE missing expected INSERT_METHOD clause: %s", to.CreateStatement)
+	}
+	if actual := ParseCreateMergeInsertMethod(to.CreateStatement); actual != "LAST" {
+		t.Errorf("Expected INSERT_METHOD to round-trip through CREATE TABLE, instead found %q", actual)
+	}
+	for _, tc := range []struct {
+		from, to *Table
+		expected string
+	}{
+		{&from, &to, "INSERT_METHOD=LAST"},
+		{&to, &from, "INSERT_METHOD=NO"},
+	} {
+		alter := NewAlterTable(tc.from, tc.to)
+		clauses, supported := tc.from.Diff(tc.to)
+		if len(clauses) != 1 || !supported {
+			t.Fatalf("Incorrect result from Table.Diff(): expected len=1, supported=true; found len=%d, supported=%t", len(clauses), supported)
+		}
+		if actual := clauses[0].Clause(StatementModifiers{}); actual != tc.expected {
+			t.Errorf("Incorrect ALTER TABLE clause returned; expected: %s; found: %s", tc.expected, actual)
+		}
+		if risk := alter.Risk(); risk != RiskMetadata {
+			t.Errorf("Expected INSERT_METHOD change to be RiskMetadata, instead found %s", risk)
+		}
+		if stmt, err := alter.Statement(StatementModifiers{}); stmt != "ALTER TABLE `actor` "+tc.expected || err != nil {
+			t.Errorf("Unexpected result from Statement: %q / %v", stmt, err)
+		}
+	}
+	to = getMergeTable("MRG_MyISAM", "")
+	if clauses, supported := from.Diff(&to); len(clauses) != 0 || !supported {
+		t.Errorf("Expected no clauses for identical INSERT_METHOD, instead found len=%d, supported=%t", len(clauses), supported)
+	}
+
+	// Non-MERGE tables never emit or diff an INSERT_METHOD clause
+	from = getMergeTable("MyISAM", "")
+	to = getMergeTable("MyISAM", "FIRST")
+	if strings.Contains(to.CreateStatement, "INSERT_METHOD") {
+		t.Errorf("Expected non-MERGE table to omit INSERT_METHOD clause, but found: %s", to.CreateStatement)
+	}
+	if clauses, supported := from.Diff(&to); len(clauses) != 0 || !supported {
+		t.Errorf("Expected no clauses for non-MERGE table, instead found len=%d, supported=%t", len(clauses), supported)
+	}
 }
 
 func TestTableAlterChangeAutoIncrement(t *testing.T) {
@@ -1098,6 +1881,105 @@ func TestTableAlterChangeCharSet(t *testing.T) {
 	assertChangeCharSet(&from, &to, "DEFAULT CHARACTER SET = utf8mb4 COLLATE = utf8mb4_general_ci")
 }
 
+func TestTableAlterConvertCharSet(t *testing.T) {
+	// getTable returns aTable(1) with the table default and all textual columns
+	// using the supplied charset and collation, except for any column names
+	// listed in skipCols
+	getTable := func(charSet, collation string, skipCols ...string) Table {
+		t := aTable(1)
+		t.CharSet, t.Collation, t.CollationIsDefault = charSet, collation, true
+	ColLoop:
+		for _, col := range t.Columns {
+			for _, skip := range skipCols {
+				if col.Name == skip {
+					continue ColLoop
+				}
+			}
+			if col.CharSet != "" {
+				col.CharSet, col.Collation, col.CollationIsDefault = charSet, collation, true
+			}
+		}
+		t.CreateStatement = t.GeneratedCreateStatement(FlavorUnknown)
+		return t
+	}
+
+	// Table-wide change to a new charset should collapse into one CONVERT TO,
+	// which is unsafe since the charset is changing
+	from := getTable("latin1", "latin1_swedish_ci")
+	to := getTable("utf8mb4", "utf8mb4_general_ci")
+	tableAlters, supported := from.Diff(&to)
+	if len(tableAlters) != 1 || !supported {
+		t.Fatalf("Incorrect result from Table.Diff(): expected len=1, supported=true; found len=%d, supported=%t", len(tableAlters), supported)
+	}
+	cvt, ok := tableAlters[0].(ConvertCharSet)
+	if !ok {
+		t.Fatalf("Incorrect type of table alter returned: expected %T, found %T", cvt, tableAlters[0])
+	}
+	if expected, actual := "CONVERT TO CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci", cvt.Clause(StatementModifiers{}); actual != expected {
+		t.Errorf("Incorrect ALTER TABLE clause returned; expected: %s; found: %s", expected, actual)
+	}
+	if len(cvt.Columns) != 3 {
+		t.Errorf("Expected CONVERT TO to encompass 3 columns, instead found %d", len(cvt.Columns))
+	}
+	if !cvt.Unsafe() {
+		t.Error("Expected CONVERT TO changing charset to be unsafe, but it was not")
+	}
+
+	// Table-wide collation change, along with an unrelated column change: CONVERT
+	// TO should be used, and be classified as a rebuild. The unrelated change
+	// remains a separate clause.
+	to = getTable("latin1", "latin1_general_ci")
+	to.Columns[0].TypeInDB = "int(10) unsigned"
+	to.CreateStatement = to.GeneratedCreateStatement(FlavorUnknown)
+	tableAlters, supported = from.Diff(&to)
+	if len(tableAlters) != 2 || !supported {
+		t.Fatalf("Incorrect result from Table.Diff(): expected len=2, supported=true; found len=%d, supported=%t", len(tableAlters), supported)
+	}
+	if cvt, ok = tableAlters[0].(ConvertCharSet); !ok {
+		t.Fatalf("Incorrect type of table alter returned: expected %T, found %T", cvt, tableAlters[0])
+	} else if cvt.Unsafe() || ClauseRisk(cvt) != RiskRebuild {
+		t.Errorf("Expected collation-only CONVERT TO to be classified as a rebuild; instead found unsafe=%t risk=%v", cvt.Unsafe(), ClauseRisk(cvt))
+	}
+	if _, ok = tableAlters[1].(ModifyColumn); !ok {
+		t.Errorf("Incorrect type of table alter returned: expected ModifyColumn, found %T", tableAlters[1])
+	}
+
+	// If any textual column uses a different charset than the new table default,
+	// or a column has other modifications besides charset, CONVERT TO cannot be
+	// used
+	assertNoConvert := func(from, to *Table) {
+		t.Helper()
+		tableAlters, _ := from.Diff(to)
+		if len(tableAlters) != 4 {
+			t.Errorf("Expected 4 alter clauses, instead found %d", len(tableAlters))
+		}
+		for _, ta := range tableAlters {
+			if _, ok := ta.(ConvertCharSet); ok {
+				t.Error("Expected no ConvertCharSet clause, but one was found")
+			}
+		}
+	}
+	to = getTable("utf8mb4", "utf8mb4_general_ci", "ssn")
+	assertNoConvert(&from, &to)
+
+	// If the columns already use the new charset and collation, and only differ
+	// cosmetically, CONVERT TO should not be used
+	from = getTable("utf8mb4", "utf8mb4_general_ci")
+	from.CharSet, from.Collation = "latin1", "latin1_swedish_ci"
+	for _, col := range from.Columns {
+		if col.CharSet != "" {
+			col.ForceShowCollation = true
+		}
+	}
+	from.CreateStatement = from.GeneratedCreateStatement(FlavorUnknown)
+	to = getTable("utf8mb4", "utf8mb4_general_ci")
+	assertNoConvert(&from, &to)
+	to = getTable("utf8mb4", "utf8mb4_general_ci")
+	to.Columns[1].TypeInDB = "varchar(50)"
+	to.CreateStatement = to.GeneratedCreateStatement(FlavorUnknown)
+	assertNoConvert(&from, &to)
+}
+
 func TestTableAlterChangeCreateOptions(t *testing.T) {
 	getTableWithCreateOptions := func(createOptions string) Table {
 		t := aTable(1)
@@ -1161,6 +2043,147 @@ func TestTableAlterChangeCreateOptions(t *testing.T) {
 	assertChangeCreateOptions(&to, &from, "STATS_AUTO_RECALC=DEFAULT ROW_FORMAT=REDUNDANT STATS_PERSISTENT=1 MAX_ROWS=1000")
 }
 
+func TestTableAlterChangePackKeys(t *testing.T) {
+	getMyISAMTable := func(createOptions string) *Table {
+		t := aTable(1)
+		t.Engine = "MyISAM"
+		t.CreateOptions = createOptions
+		t.CreateStatement = t.GeneratedCreateStatement(FlavorUnknown)
+		return &t
+	}
+	cases := []struct {
+		from, to       string
+		expectedClause string
+	}{
+		{"", "PACK_KEYS=1", "PACK_KEYS=1"},
+		{"PACK_KEYS=1", "PACK_KEYS=0", "PACK_KEYS=0"},
+		{"PACK_KEYS=0", "", "PACK_KEYS=DEFAULT"},
+		{"PACK_KEYS=1 CHECKSUM=1", "CHECKSUM=1", "PACK_KEYS=DEFAULT"},
+		{"PACK_KEYS=1", "PACK_KEYS=0 CHECKSUM=1", "CHECKSUM=1 PACK_KEYS=0"},
+	}
+	for _, c := range cases {
+		from, to := getMyISAMTable(c.from), getMyISAMTable(c.to)
+		if c.to != "" && !strings.Contains(to.CreateStatement, " "+c.to) {
+			t.Errorf("Expected CREATE TABLE to contain %q, but it did not: %s", c.to, to.CreateStatement)
+		}
+		td := NewAlterTable(from, to)
+		expected := "ALTER TABLE `actor` " + c.expectedClause
+		if actual, err := td.Statement(StatementModifiers{}); err != nil || actual != expected {
+			t.Errorf("Unexpected result from Statement for %q to %q: expected %q, found %q / err=%v", c.from, c.to, expected, actual, err)
+		}
+	}
+
+	// No diff should be generated between identical PACK_KEYS values
+	from, to := getMyISAMTable("PACK_KEYS=0"), getMyISAMTable("PACK_KEYS=0")
+	if td := NewAlterTable(from, to); td != nil {
+		t.Errorf("Expected no diff, instead found %+v", td)
+	}
+}
+
+func TestTableAlterChangeDelayKeyWrite(t *testing.T) {
+	getTable := func(engine, createOptions string) *Table {
+		t := aTable(1)
+		t.Engine = engine
+		t.CreateOptions = createOptions
+		t.CreateStatement = t.GeneratedCreateStatement(FlavorUnknown)
+		return &t
+	}
+	from, to := getTable("MyISAM", ""), getTable("MyISAM", "DELAY_KEY_WRITE=1")
+	if !strings.Contains(to.CreateStatement, " DELAY_KEY_WRITE=1") {
+		t.Errorf("Expected CREATE TABLE to contain DELAY_KEY_WRITE=1, but it did not: %s", to.CreateStatement)
+	}
+	cases := []struct {
+		from, to *Table
+		expected string
+	}{
+		{from, to, "ALTER TABLE `actor` DELAY_KEY_WRITE=1"},
+		{to, from, "ALTER TABLE `actor` DELAY_KEY_WRITE=0"},
+		{getTable("MyISAM", "CHECKSUM=1"), getTable("MyISAM", "CHECKSUM=1 DELAY_KEY_WRITE=1"), "ALTER TABLE `actor` DELAY_KEY_WRITE=1"},
+	}
+	for _, c := range cases {
+		td := NewAlterTable(c.from, c.to)
+		if actual, err := td.Statement(StatementModifiers{}); err != nil || actual != c.expected {
+			t.Errorf("Unexpected result from Statement: expected %q, found %q / err=%v", c.expected, actual, err)
+		}
+	}
+
+	// DELAY_KEY_WRITE is irrelevant to InnoDB, so differences should be ignored,
+	// even alongside other create option changes
+	from, to = getTable("InnoDB", ""), getTable("InnoDB", "DELAY_KEY_WRITE=1")
+	if clauses, supported := from.Diff(to); len(clauses) != 0 || !supported {
+		t.Errorf("Expected no clauses from InnoDB DELAY_KEY_WRITE change; instead found %d clauses, supported=%t", len(clauses), supported)
+	}
+	from, to = getTable("InnoDB", "DELAY_KEY_WRITE=1"), getTable("InnoDB", "STATS_PERSISTENT=1")
+	td := NewAlterTable(from, to)
+	if actual, err := td.Statement(StatementModifiers{}); err != nil || actual != "ALTER TABLE `actor` STATS_PERSISTENT=1" {
+		t.Errorf("Unexpected result from Statement: %q / err=%v", actual, err)
+	}
+}
+
+func TestTableAlterChangeCompression(t *testing.T) {
+	getTableWithCompression := func(algorithm string) *Table {
+		t := aTable(1)
+		if algorithm != "" {
+			t.CreateOptions = "COMPRESSION='" + algorithm + "'"
+		}
+		t.CreateStatement = t.GeneratedCreateStatement(FlavorMySQL80)
+		return &t
+	}
+	cases := []struct {
+		from, to          string
+		expectedClause    string
+		expectedWarning   string // substring
+		expectedWarning4k string // substring
+	}{
+		{"", "zlib", "COMPRESSION='zlib'", "does not compress existing data", "ineffective"},
+		{"zlib", "lz4", "COMPRESSION='lz4'", "does not compress existing data", "ineffective"},
+		{"lz4", "None", "COMPRESSION='None'", "does not decompress existing data", "does not decompress existing data"},
+		{"lz4", "", "COMPRESSION=''", "does not decompress existing data", "does not decompress existing data"},
+	}
+	for _, c := range cases {
+		from, to := getTableWithCompression(c.from), getTableWithCompression(c.to)
+		td := NewAlterTable(from, to)
+		if td == nil || len(td.alterClauses) != 1 {
+			t.Errorf("Expected 1 clause changing compression from %q to %q; instead found %+v", c.from, c.to, td)
+			continue
+		}
+		cco, ok := td.alterClauses[0].(ChangeCreateOptions)
+		if !ok {
+			t.Errorf("Incorrect type of table alter returned: expected %T, found %T", cco, td.alterClauses[0])
+			continue
+		}
+		if clause := cco.Clause(StatementModifiers{}); clause != c.expectedClause {
+			t.Errorf("Expected clause %q, instead found %q", c.expectedClause, clause)
+		}
+		if risk := td.Risk(); risk != RiskRebuild {
+			t.Errorf("Expected compression change from %q to %q to be classified as %s, instead found %s", c.from, c.to, RiskRebuild, risk)
+		}
+		if warning := cco.CompressionWarning(0); !strings.Contains(warning, c.expectedWarning) {
+			t.Errorf("Unexpected warning for compression change from %q to %q with default page size: %q", c.from, c.to, warning)
+		}
+		if warning := cco.CompressionWarning(4096); !strings.Contains(warning, c.expectedWarning4k) {
+			t.Errorf("Unexpected warning for compression change from %q to %q with 4k page size: %q", c.from, c.to, warning)
+		}
+		if warnings := td.Warnings(StatementModifiers{InnoDBPageSize: 4096}); len(warnings) != 1 || !strings.Contains(warnings[0], c.expectedWarning4k) {
+			t.Errorf("Unexpected warnings from TableDiff for compression change from %q to %q with 4k page size: %v", c.from, c.to, warnings)
+		}
+	}
+
+	// Changes to other create options should not yield a compression warning;
+	// stats-only changes should be classified as metadata-only
+	cco := ChangeCreateOptions{OldCreateOptions: "STATS_PERSISTENT=1", NewCreateOptions: "STATS_PERSISTENT=0 STATS_AUTO_RECALC=1"}
+	if warning := cco.CompressionWarning(4096); warning != "" {
+		t.Errorf("Unexpected compression warning: %q", warning)
+	}
+	if risk := ClauseRisk(cco); risk != RiskMetadata {
+		t.Errorf("Expected stats-only change to be classified as %s, instead found %s", RiskMetadata, risk)
+	}
+	cco.NewCreateOptions += " ROW_FORMAT=DYNAMIC"
+	if risk := ClauseRisk(cco); risk != RiskRebuild {
+		t.Errorf("Expected row format change to be classified as %s, instead found %s", RiskRebuild, risk)
+	}
+}
+
 func TestTableAlterChangeComment(t *testing.T) {
 	getTableWithComment := func(comment string) Table {
 		t := aTable(1)
@@ -1196,6 +2219,72 @@ func TestTableAlterChangeComment(t *testing.T) {
 	assertChangeComment(&to, &from, "COMMENT ''")
 }
 
+func TestTableAlterChangeEngineAttributes(t *testing.T) {
+	getTableWithAttributes := func(engineAttr, secondaryEngineAttr string) Table {
+		t := aTable(1)
+		t.EngineAttribute = engineAttr
+		t.SecondaryEngineAttribute = secondaryEngineAttr
+		t.CreateStatement = t.GeneratedCreateStatement(FlavorUnknown)
+		return t
+	}
+	assertChangeEngineAttributes := func(a, b *Table, expected string) {
+		t.Helper()
+		tableAlters, supported := a.Diff(b)
+		if len(tableAlters) != 1 || !supported {
+			t.Fatalf("Incorrect result from Table.Diff(): expected len=1, supported=true; found len=%d, supported=%t", len(tableAlters), supported)
+		}
+		ta, ok := tableAlters[0].(ChangeEngineAttributes)
+		if !ok {
+			t.Fatalf("Incorrect type of table alter returned: expected %T, found %T", ta, tableAlters[0])
+		}
+		if actual := ta.Clause(StatementModifiers{}); actual != expected {
+			t.Errorf("Incorrect ALTER TABLE clause returned; expected: %s; found: %s", expected, actual)
+		}
+	}
+
+	from := getTableWithAttributes("", "")
+	to := getTableWithAttributes(`{"key": "it's", "n": 1}`, "")
+	expectSuffix := ` /*!80021 ENGINE_ATTRIBUTE='{"key": "it''s", "n": 1}' */`
+	if !strings.HasSuffix(to.CreateStatement, expectSuffix) {
+		t.Errorf("Expected CREATE TABLE to end in %s, instead found %s", expectSuffix, to.CreateStatement)
+	}
+	assertChangeEngineAttributes(&from, &to, `/*!80021 ENGINE_ATTRIBUTE='{"key": "it''s", "n": 1}' */`)
+	assertChangeEngineAttributes(&to, &from, `/*!80021 ENGINE_ATTRIBUTE='' */`)
+
+	// Differences in JSON key order or whitespace should not emit a clause
+	reordered := getTableWithAttributes(`{"n":1,"key":"it's"}`, "")
+	assertChangeEngineAttributes(&to, &reordered, "")
+
+	// Secondary engine attribute changes only affect that attribute
+	secondary := getTableWithAttributes(`{"n":1,"key":"it's"}`, `{"x": true}`)
+	assertChangeEngineAttributes(&to, &secondary, `/*!80021 SECONDARY_ENGINE_ATTRIBUTE='{"x": true}' */`)
+
+	// Confirm parsing of table-level and column-level engine attributes from
+	// SHOW CREATE TABLE
+	parsed := aTable(1)
+	parsed.Columns[1].EngineAttribute = `{"col": "it's\\n"}`
+	parsed.EngineAttribute = `{"key": "it's", "n": 1}`
+	parsed.SecondaryEngineAttribute = `{"x": true}`
+	parsed.CreateStatement = parsed.GeneratedCreateStatement(FlavorMySQL80.Dot(21))
+	expected := parsed
+	expected.Columns = make([]*Column, len(parsed.Columns))
+	for n, col := range parsed.Columns {
+		colCopy := *col
+		expected.Columns[n] = &colCopy
+	}
+	parsed.Columns[1].EngineAttribute, parsed.EngineAttribute, parsed.SecondaryEngineAttribute = "", "", ""
+	fixEngineAttributes(&parsed)
+	if parsed.EngineAttribute != expected.EngineAttribute || parsed.SecondaryEngineAttribute != expected.SecondaryEngineAttribute {
+		t.Errorf("Table-level engine attributes not parsed as expected: found %q, %q", parsed.EngineAttribute, parsed.SecondaryEngineAttribute)
+	}
+	if parsed.Columns[1].EngineAttribute != expected.Columns[1].EngineAttribute {
+		t.Errorf("Column-level engine attribute not parsed as expected: found %q", parsed.Columns[1].EngineAttribute)
+	}
+	if parsed.GeneratedCreateStatement(FlavorMySQL80.Dot(21)) != parsed.CreateStatement {
+		t.Errorf("Generated CREATE does not match after parsing engine attributes:\n%s", parsed.GeneratedCreateStatement(FlavorMySQL80.Dot(21)))
+	}
+}
+
 func TestTableAlterTablespace(t *testing.T) {
 	getTableWithTablespace := func(tablespace string) *Table {
 		t := aTable(123)
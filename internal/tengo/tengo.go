@@ -6,6 +6,7 @@ package tengo
 import (
 	"fmt"
 	"regexp"
+	"sort"
 	"strings"
 )
 
@@ -45,6 +46,22 @@ func (key ObjectKey) ObjectKey() ObjectKey {
 	return key
 }
 
+// Less returns true if key sorts before other, ordering by type and then by
+// name.
+func (key ObjectKey) Less(other ObjectKey) bool {
+	if key.Type != other.Type {
+		return key.Type < other.Type
+	}
+	return key.Name < other.Name
+}
+
+// SortObjectKeys sorts keys in place, ordering by type and then by name.
+func SortObjectKeys(keys []ObjectKey) {
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].Less(keys[j])
+	})
+}
+
 // ObjectKeyer is an interface implemented by each type of database object,
 // providing a generic way of obtaining an object's type and name.
 type ObjectKeyer interface {
@@ -18,6 +18,7 @@ CREATE TABLE staff (
 	last_name varchar(40),
 	full_name varchar(162) AS (CONCAT(first_name, ' ', middle_name, ' ', last_name, '€')) VIRTUAL COMMENT 'hello world',
 	full_name_nonull varchar(162) AS (CONCAT(first_name, ' ', IFNULL(middle_name, ''), ' ', IFNULL(last_name, ''))) STORED,
+	full_name_persistent varchar(122) AS (CONCAT(first_name, ' ', IFNULL(last_name, ''))) PERSISTENT,
 	PRIMARY KEY (id),
 	KEY name (full_name_nonull)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
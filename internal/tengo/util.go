@@ -1,10 +1,13 @@
 package tengo
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net"
 	"net/url"
+	"reflect"
 	"regexp"
 	"strconv"
 	"strings"
@@ -36,6 +39,60 @@ func EscapeValueForCreateTable(input string) string {
 	return input
 }
 
+// unescapeValueForCreateTable reverses the escaping performed by
+// EscapeValueForCreateTable, converting a string literal's contents as shown
+// in SHOW CREATE TABLE back into its underlying value.
+func unescapeValueForCreateTable(input string) string {
+	if !strings.ContainsAny(input, "\\'") {
+		return input
+	}
+	unescapes := map[byte]byte{'0': '\000', 'n': '\n', 'r': '\r'}
+	var b strings.Builder
+	for n := 0; n < len(input); n++ {
+		c := input[n]
+		if n+1 < len(input) && (c == '\\' || (c == '\'' && input[n+1] == '\'')) {
+			n++
+			c = input[n]
+			if unescaped, ok := unescapes[c]; ok {
+				c = unescaped
+			}
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// engineAttributeClauses returns the ENGINE_ATTRIBUTE and
+// SECONDARY_ENGINE_ATTRIBUTE clauses for a table or column, in the same format
+// as SHOW CREATE TABLE, or an empty string if neither attribute is set. The
+// separator between each attribute name and value is "=" for tables, or " "
+// for columns.
+func engineAttributeClauses(engineAttr, secondaryEngineAttr, separator string) string {
+	var result string
+	if engineAttr != "" {
+		result = fmt.Sprintf(" /*!80021 ENGINE_ATTRIBUTE%s'%s' */", separator, EscapeValueForCreateTable(engineAttr))
+	}
+	if secondaryEngineAttr != "" {
+		result += fmt.Sprintf(" /*!80021 SECONDARY_ENGINE_ATTRIBUTE%s'%s' */", separator, EscapeValueForCreateTable(secondaryEngineAttr))
+	}
+	return result
+}
+
+// jsonEquivalent returns true if a and b are identical, or are both valid JSON
+// documents with equal values, ignoring whitespace and object key order.
+func jsonEquivalent(a, b string) bool {
+	if a == b {
+		return true
+	} else if a == "" || b == "" {
+		return false
+	}
+	var aVal, bVal interface{}
+	if json.Unmarshal([]byte(a), &aVal) != nil || json.Unmarshal([]byte(b), &bVal) != nil {
+		return false
+	}
+	return reflect.DeepEqual(aVal, bVal)
+}
+
 // SplitHostOptionalPort takes an address string containing a hostname, ipv4
 // addr, or ipv6 addr; *optionally* followed by a colon and port number. It
 // splits the hostname portion from the port portion and returns them
@@ -101,6 +158,85 @@ func ParseCreateAutoInc(createStmt string) (string, uint64) {
 	return newStmt, nextAutoInc
 }
 
+var (
+	reParseMergeUnion      = regexp.MustCompile(" UNION=\\(((?:`(?:[^`]|``)+`,?)*)\\)")
+	reParseMergeUnionTable = regexp.MustCompile("`((?:[^`]|``)+)`")
+)
+
+// ParseCreateMergeUnion parses the UNION clause out of a CREATE TABLE statement
+// for a MERGE table, returning the unescaped names of the underlying tables in
+// order. A nil slice is returned if no UNION clause is present, or if any
+// underlying table is qualified with a schema name, which is not supported.
+func ParseCreateMergeUnion(createStmt string) []string {
+	matches := reParseMergeUnion.FindStringSubmatch(createStmt)
+	if matches == nil {
+		return nil
+	}
+	tables := []string{}
+	for _, m := range reParseMergeUnionTable.FindAllStringSubmatch(matches[1], -1) {
+		tables = append(tables, strings.ReplaceAll(m[1], "``", "`"))
+	}
+	return tables
+}
+
+var reParseMergeInsertMethod = regexp.MustCompile(` INSERT_METHOD=(FIRST|LAST)\b`)
+
+// ParseCreateMergeInsertMethod parses the INSERT_METHOD clause out of a CREATE
+// TABLE statement for a MERGE table, returning "FIRST" or "LAST". An empty
+// string is returned if no INSERT_METHOD clause is present, which is
+// equivalent to INSERT_METHOD=NO. Only the table options following the closing
+// paren of the column and index definitions are examined.
+func ParseCreateMergeInsertMethod(createStmt string) string {
+	if pos := strings.LastIndex(createStmt, "\n)"); pos >= 0 {
+		createStmt = createStmt[pos:]
+	}
+	if matches := reParseMergeInsertMethod.FindStringSubmatch(createStmt); matches != nil {
+		return matches[1]
+	}
+	return ""
+}
+
+// ParseCreateEngine returns the storage engine explicitly specified in the
+// table options of a CREATE TABLE statement, or an empty string if the
+// statement has no ENGINE clause, meaning the server's default storage engine
+// would be used. Unlike most other functions in this file, createStmt may be
+// arbitrarily formatted, for example as written by a user in a *.sql file. The
+// engine name is returned as-is, aside from removal of any quoting.
+func ParseCreateEngine(createStmt string) string {
+	lex := NewLexer(strings.NewReader(createStmt), "\000", 8192)
+	var depth int
+	var seenDefinitions, afterEngine bool
+	for {
+		val, typ, err := lex.Scan()
+		if err == io.EOF || (err != nil && len(val) == 0) {
+			break
+		}
+		if typ == TokenFiller {
+			continue
+		} else if typ == TokenSymbol {
+			if val[0] == '(' {
+				depth++
+				seenDefinitions = true
+			} else if val[0] == ')' {
+				depth--
+			} else if val[0] == '=' && afterEngine {
+				continue
+			}
+		} else if depth == 0 && seenDefinitions {
+			if afterEngine {
+				return stripAnyQuote(string(val))
+			} else if typ == TokenWord && strings.EqualFold(string(val), "ENGINE") {
+				afterEngine = true
+				continue
+			} else if typ == TokenWord && strings.EqualFold(string(val), "PARTITION") {
+				return "" // table options may not follow the partitioning clause
+			}
+		}
+		afterEngine = false
+	}
+	return ""
+}
+
 var reParseCreatePartitioning = regexp.MustCompile(`(?is)(\s*(?:/\*!?\d*)?\s*partition\s+by .*)$`)
 
 // ParseCreatePartitioning parses a CREATE TABLE statement, formatted in the
@@ -190,6 +326,21 @@ func StripDisplayWidth(colType string) (strippedType string, didStrip bool) {
 	return colType[0:openParen] + modifier, true
 }
 
+var reColumnDisplayWidth = regexp.MustCompile("(?m)^(  `(?:[^`]|``)+` )((?:tiny|small|medium|big)?int\\(\\d+\\)(?: unsigned)?(?: zerofill)?|year\\(4\\))")
+
+// StripCreateDisplayWidths removes integer display widths from the column
+// definitions in a CREATE TABLE statement, formatted in the same manner as SHOW
+// CREATE TABLE. The same rules as StripDisplayWidth apply, so tinyint(1) and
+// zerofill columns retain their display widths. This permits writing
+// definitions which are consistent between MySQL 8.0.19+ and older servers.
+func StripCreateDisplayWidths(createStmt string) string {
+	return reColumnDisplayWidth.ReplaceAllStringFunc(createStmt, func(match string) string {
+		groups := reColumnDisplayWidth.FindStringSubmatch(match)
+		stripped, _ := StripDisplayWidth(groups[2])
+		return groups[1] + stripped
+	})
+}
+
 // baseDSN returns a DSN with the database (schema) name and params stripped.
 // Currently only supports MySQL, via go-sql-driver/mysql's DSN format.
 func baseDSN(dsn string) string {
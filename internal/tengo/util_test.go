@@ -2,6 +2,7 @@ package tengo
 
 import (
 	"fmt"
+	"reflect"
 	"strconv"
 	"strings"
 	"testing"
@@ -67,6 +68,53 @@ func TestParseCreateAutoInc(t *testing.T) {
 	}
 }
 
+func TestParseCreateMergeUnion(t *testing.T) {
+	cases := map[string][]string{
+		") ENGINE=MRG_MyISAM DEFAULT CHARSET=latin1 INSERT_METHOD=LAST UNION=(`t1`,`t2`)": {"t1", "t2"},
+		") ENGINE=MRG_MyISAM DEFAULT CHARSET=latin1 UNION=(`a``b`,`c,d`)":                 {"a`b", "c,d"},
+		") ENGINE=MRG_MyISAM DEFAULT CHARSET=latin1 UNION=()":                             {},
+		") ENGINE=MRG_MyISAM DEFAULT CHARSET=latin1":                                      nil,
+		") ENGINE=MRG_MyISAM DEFAULT CHARSET=latin1 UNION=(`t1`,`other`.`t2`)":            nil,
+	}
+	for input, expected := range cases {
+		if actual := ParseCreateMergeUnion(input); !reflect.DeepEqual(actual, expected) {
+			t.Errorf("Unexpected result from ParseCreateMergeUnion(%q): expected %#v, found %#v", input, expected, actual)
+		}
+	}
+}
+
+func TestParseCreateMergeInsertMethod(t *testing.T) {
+	cases := map[string]string{
+		") ENGINE=MRG_MyISAM DEFAULT CHARSET=latin1 INSERT_METHOD=LAST UNION=(`t1`,`t2`)":  "LAST",
+		") ENGINE=MRG_MyISAM DEFAULT CHARSET=latin1 INSERT_METHOD=FIRST UNION=(`t1`,`t2`)": "FIRST",
+		") ENGINE=MRG_MyISAM DEFAULT CHARSET=latin1 UNION=(`t1`,`t2`)":                     "",
+		"CREATE TABLE `t` (\n  `c` int COMMENT ' INSERT_METHOD=LAST'\n) ENGINE=MRG_MyISAM": "",
+	}
+	for input, expected := range cases {
+		if actual := ParseCreateMergeInsertMethod(input); actual != expected {
+			t.Errorf("Unexpected result from ParseCreateMergeInsertMethod(%q): expected %q, found %q", input, expected, actual)
+		}
+	}
+}
+
+func TestParseCreateEngine(t *testing.T) {
+	cases := map[string]string{
+		"CREATE TABLE `t` (\n  `id` int\n) ENGINE=InnoDB DEFAULT CHARSET=latin1":                             "InnoDB",
+		"create table t (id int) default charset=utf8mb4 engine myisam":                                      "myisam",
+		"CREATE TABLE t (id int) ENGINE = 'InnoDB'":                                                          "InnoDB",
+		"CREATE TABLE t (id int, `engine` varchar(20) COMMENT 'engine=MyISAM') /* ENGINE=MyISAM */":          "",
+		"CREATE TABLE t (id int) COMMENT='no engine' DEFAULT CHARSET=latin1":                                 "",
+		"CREATE TABLE t (id int) PARTITION BY RANGE (id) (PARTITION p0 VALUES LESS THAN (10) ENGINE=InnoDB)": "",
+		"CREATE TABLE t (id int) ENGINE=InnoDB PARTITION BY HASH (id) PARTITIONS 4":                          "InnoDB",
+		"CREATE TABLE t LIKE other": "",
+	}
+	for input, expected := range cases {
+		if actual := ParseCreateEngine(input); actual != expected {
+			t.Errorf("Unexpected result from ParseCreateEngine(%q): expected %q, found %q", input, expected, actual)
+		}
+	}
+}
+
 func TestReformatCreateOptions(t *testing.T) {
 	cases := map[string]string{
 		"":                                       "",
@@ -127,6 +175,35 @@ func TestStripDisplayWidth(t *testing.T) {
 	}
 }
 
+func TestStripCreateDisplayWidths(t *testing.T) {
+	input := "CREATE TABLE `widths` (\n" +
+		"  `id` int(10) unsigned NOT NULL AUTO_INCREMENT,\n" +
+		"  `flag` tinyint(1) NOT NULL,\n" +
+		"  `small` smallint(5) DEFAULT NULL,\n" +
+		"  `padded` int(8) unsigned zerofill DEFAULT NULL,\n" +
+		"  `yr` year(4) DEFAULT NULL,\n" +
+		"  `int(11)` bigint(20) NOT NULL,\n" +
+		"  `label` varchar(11) DEFAULT 'int(11)',\n" +
+		"  PRIMARY KEY (`id`)\n" +
+		") ENGINE=InnoDB DEFAULT CHARSET=latin1"
+	expected := "CREATE TABLE `widths` (\n" +
+		"  `id` int unsigned NOT NULL AUTO_INCREMENT,\n" +
+		"  `flag` tinyint(1) NOT NULL,\n" +
+		"  `small` smallint DEFAULT NULL,\n" +
+		"  `padded` int(8) unsigned zerofill DEFAULT NULL,\n" +
+		"  `yr` year DEFAULT NULL,\n" +
+		"  `int(11)` bigint NOT NULL,\n" +
+		"  `label` varchar(11) DEFAULT 'int(11)',\n" +
+		"  PRIMARY KEY (`id`)\n" +
+		") ENGINE=InnoDB DEFAULT CHARSET=latin1"
+	if actual := StripCreateDisplayWidths(input); actual != expected {
+		t.Errorf("Unexpected result from StripCreateDisplayWidths\nExpected:\n%s\nFound:\n%s", expected, actual)
+	}
+	if actual := StripCreateDisplayWidths(expected); actual != expected {
+		t.Errorf("Expected StripCreateDisplayWidths to be idempotent, instead found:\n%s", actual)
+	}
+}
+
 func TestLongestIncreasingSubsequence(t *testing.T) {
 	cases := map[string]string{
 		"":            "",
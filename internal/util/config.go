@@ -1,13 +1,16 @@
 package util
 
 import (
+	"bufio"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
-	"regexp"
 	"runtime"
 	"strings"
+	"sync"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/skeema/mybase"
@@ -25,6 +28,7 @@ func AddGlobalOptions(cmd *mybase.Command) {
 	cmd.AddOption(mybase.StringOption("schema", 0, "", "Database schema name").Hidden())
 	cmd.AddOption(mybase.StringOption("default-character-set", 0, "", "Schema-level default character set").Hidden())
 	cmd.AddOption(mybase.StringOption("default-collation", 0, "", "Schema-level default collation").Hidden())
+	cmd.AddOption(mybase.StringOption("schema-comment", 0, "", "Schema-level comment (MariaDB 10.5+ only)").Hidden())
 	cmd.AddOption(mybase.StringOption("flavor", 0, "", "Database server expressed in format vendor:major.minor, for use in vendor/version specific syntax").Hidden())
 	cmd.AddOption(mybase.StringOption("generator", 0, "", "Version of Skeema used for `skeema init` or most recent `skeema pull`").Hidden())
 
@@ -32,12 +36,16 @@ func AddGlobalOptions(cmd *mybase.Command) {
 	cmd.AddOptions("global",
 		mybase.StringOption("user", 'u', "root", "Username to connect to database host"),
 		mybase.StringOption("password", 'p', "$MYSQL_PWD", "Password for database user; omit value to prompt from TTY").ValueOptional(),
+		mybase.StringOption("password-exec-timeout", 0, "30s", "Maximum duration for an external command supplying the password via password=exec:"),
 		mybase.StringOption("host-wrapper", 'H', "", "External bin to shell out to for host lookup; see manual for template vars"),
 		mybase.StringOption("connect-options", 'o', "", "Comma-separated session options to set upon connecting to each database instance"),
 		mybase.StringOption("ignore-schema", 0, "", "Ignore schemas that match regex"),
 		mybase.StringOption("ignore-table", 0, "", "Ignore tables that match regex"),
 		mybase.StringOption("ignore-proc", 0, "", "Ignore stored procedures that match regex"),
 		mybase.StringOption("ignore-func", 0, "", "Ignore functions that match regex"),
+		mybase.StringOption("rename-drops-prefix", 0, "_dropped_", "Table name prefix used by `skeema push --rename-drops`; tables renamed this way are ignored"),
+		mybase.StringOption("file-layout", 0, "object", `Placement of *.sql files for new objects (valid values: "object", "schema", "type")`),
+		mybase.BoolOption("normalize-whitespace", 0, false, "When writing *.sql files, end each with a newline and separate statements by one blank line"),
 		mybase.StringOption("ssl-mode", 0, "", `Specify desired connection security SSL/TLS usage (valid values: "disabled", "preferred", "required")`),
 		mybase.BoolOption("debug", 0, false, "Enable debug logging"),
 		mybase.BoolOption("my-cnf", 0, true, "Parse ~/.my.cnf for configuration"),
@@ -148,6 +156,86 @@ func ProcessSpecialGlobalOptions(cfg *mybase.Config) error {
 	return nil
 }
 
+// ConfiguredPassword returns the value of the password option in cfg. If the
+// value has a "file:" prefix, the remainder is treated as a path to a file, and
+// the first line of that file (with surrounding whitespace trimmed) is returned
+// as the password. If the value has an "exec:" prefix, the remainder is treated
+// as a command to run via the shell, and the first line of its STDOUT (with
+// surrounding whitespace trimmed) is returned as the password; the command is
+// killed if it runs longer than the password-exec-timeout option. Values which
+// were quote-wrapped in their original source, including interactively-prompted
+// passwords, are always used literally. These prefixes are only recognized in
+// the raw option value, never in the result of environment variable expansion,
+// so for example a $MYSQL_PWD beginning with "file:" or "exec:" is also used
+// literally.
+// This function never prompts for a password; if the password option was
+// supplied without a value, an empty string is returned.
+func ConfiguredPassword(cfg *mybase.Config) (string, error) {
+	raw := cfg.GetRaw("password")
+	val := cfg.GetAllowEnvVar("password")
+	if raw == "" || raw[0] == '\'' || raw[0] == '"' {
+		return val, nil
+	}
+	if strings.HasPrefix(raw, "file:") {
+		return readPasswordFile(strings.TrimPrefix(raw, "file:"))
+	} else if strings.HasPrefix(raw, "exec:") {
+		timeout, err := time.ParseDuration(cfg.Get("password-exec-timeout"))
+		if err != nil {
+			return "", fmt.Errorf("Invalid value for password-exec-timeout: %w", err)
+		}
+		return execPasswordCommand(strings.TrimPrefix(raw, "exec:"), timeout)
+	}
+	return val, nil
+}
+
+// readPasswordFile returns the trimmed first line of the file at path.
+func readPasswordFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("Unable to read password from file: %w", err)
+	}
+	defer f.Close()
+	line, err := bufio.NewReader(f).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", fmt.Errorf("Unable to read password from file %s: %w", path, err)
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// Package-level cache of passwords obtained from password=exec: commands, to
+// avoid re-running the same command for each directory.
+var (
+	execPasswords     = make(map[string]string)
+	execPasswordsLock sync.Mutex
+)
+
+// execPasswordCommand runs command via the shell, and returns the trimmed first
+// line of its STDOUT. The command inherits the environment, STDIN, and STDERR
+// of the current process. Successful results are cached by command string.
+func execPasswordCommand(command string, timeout time.Duration) (string, error) {
+	execPasswordsLock.Lock()
+	defer execPasswordsLock.Unlock()
+	if password, ok := execPasswords[command]; ok {
+		return password, nil
+	}
+	s := &ShellOut{
+		Command: command,
+		Timeout: timeout,
+	}
+	start := time.Now()
+	out, err := s.RunCapture()
+	if err != nil {
+		if timeout > 0 && time.Since(start) >= timeout {
+			return "", fmt.Errorf("Password command %q did not complete within password-exec-timeout of %s", command, timeout)
+		}
+		return "", fmt.Errorf("Password command %q failed: %w", command, err)
+	}
+	password, _, _ := strings.Cut(out, "\n")
+	password = strings.TrimSpace(password)
+	execPasswords[command] = password
+	return password, nil
+}
+
 // PasswordInputSource is a function that can be used to obtain a password
 // interactively.
 type PasswordInputSource func() (string, error)
@@ -160,6 +248,24 @@ func InteractivePasswordInput() (string, error) {
 	return string(bytePassword), err
 }
 
+// newReaderPasswordInput returns a PasswordInputSource function which reads
+// the next line of r on each call, with its trailing newline removed. r is
+// buffered once up front, so that input beyond the current line remains
+// available to subsequent calls.
+func newReaderPasswordInput(r io.Reader) PasswordInputSource {
+	br, ok := r.(*bufio.Reader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+	return PasswordInputSource(func() (string, error) {
+		line, err := br.ReadString('\n')
+		if err == io.EOF && line != "" {
+			err = nil
+		}
+		return strings.TrimRight(line, "\r\n"), err
+	})
+}
+
 // NoInteractiveInput always returns an error instead of attempting to read a
 // password.
 func NoInteractiveInput() (string, error) {
@@ -184,8 +290,10 @@ func init() {
 	// running a test suite
 	if !StdinIsTerminal() || strings.HasSuffix(os.Args[0], ".test") || strings.HasSuffix(os.Args[0], ".test.exe") {
 		PasswordPromptInput = PasswordInputSource(NoInteractiveInput)
+		ConfirmPromptInput = ConfirmInputSource(NoInteractiveConfirm)
 	} else {
 		PasswordPromptInput = PasswordInputSource(InteractivePasswordInput)
+		ConfirmPromptInput = ConfirmInputSource(InteractiveConfirmInput)
 	}
 }
 
@@ -196,18 +304,86 @@ func init() {
 // STDERR is a non-terminal and STDOUT is a terminal, in which case STDOUT is
 // used.
 func PromptPassword(promptArgs ...interface{}) (string, error) {
+	var w io.Writer = os.Stderr
+	if !StderrIsTerminal() && StdoutIsTerminal() {
+		w = os.Stdout
+	}
+	return promptPassword(w, PasswordPromptInput, promptArgs)
+}
+
+func promptPassword(w io.Writer, input PasswordInputSource, promptArgs []interface{}) (string, error) {
 	if len(promptArgs) == 0 {
 		promptArgs = append(promptArgs, "Enter password: ")
 	}
+	fmt.Fprintf(w, promptArgs[0].(string), promptArgs[1:]...)
+	pw, err := input()
+	fmt.Fprintln(w) // since password input funcs won't echo the ENTER key as a newline
+	return pw, err
+}
+
+// ConfirmInputSource is a function that can be used to obtain a line of
+// confirmation input interactively.
+type ConfirmInputSource func() (string, error)
+
+// InteractiveConfirmInput reads a single line from STDIN.
+func InteractiveConfirmInput() (string, error) {
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	return strings.TrimSpace(line), err
+}
+
+// NoInteractiveConfirm always returns an error instead of attempting to read a
+// confirmation response.
+func NoInteractiveConfirm() (string, error) {
+	return "", errors.New("STDIN must be a TTY to prompt for confirmation")
+}
+
+// NewMockConfirmInput returns a ConfirmInputSource function which always
+// returns the specified response.
+func NewMockConfirmInput(mockResponse string) ConfirmInputSource {
+	return ConfirmInputSource(func() (string, error) {
+		return mockResponse, nil
+	})
+}
 
+// ConfirmPromptInput is the input source used by PromptConfirm to obtain a
+// response interactively, or to mock such an input for testing purposes.
+var ConfirmPromptInput ConfirmInputSource
+
+var confirmLock sync.Mutex
+
+// PromptConfirm displays a yes/no prompt and reads the response from STDIN,
+// returning true if the response was "y" or "yes" in any case. Requires that
+// STDIN is a TTY. Args behave like those to fmt.Printf(); " [y/N] " is appended
+// to the prompt automatically. Concurrent calls are serialized, so that
+// prompts from different goroutines are not interleaved. The prompt is written
+// to the same output stream as PromptPassword.
+func PromptConfirm(format string, args ...interface{}) (bool, error) {
+	confirmLock.Lock()
+	defer confirmLock.Unlock()
 	w := os.Stderr
 	if !StderrIsTerminal() && StdoutIsTerminal() {
 		w = os.Stdout
 	}
-	fmt.Fprintf(w, promptArgs[0].(string), promptArgs[1:]...)
-	pw, err := PasswordPromptInput()
-	fmt.Fprintln(w) // since password input funcs won't echo the ENTER key as a newline
-	return pw, err
+	fmt.Fprintf(w, format+" [y/N] ", args...)
+	response, err := ConfirmPromptInput()
+	if err != nil {
+		fmt.Fprintln(w)
+		return false, err
+	}
+	response = strings.ToLower(response)
+	return response == "y" || response == "yes", nil
+}
+
+// ConnectOption represents a single name=value pair from a connect-options
+// string. The value is retained exactly as supplied, including any surrounding
+// quotes or escape characters.
+type ConnectOption struct {
+	Name  string
+	Value string
+}
+
+func (co ConnectOption) String() string {
+	return co.Name + "=" + co.Value
 }
 
 // SplitConnectOptions takes a string containing a comma-separated list of
@@ -216,25 +392,76 @@ func PromptPassword(promptArgs ...interface{}) (string, error) {
 // understands single-quoted values may contain commas, and will properly
 // treat them not as delimiters. Single-quoted values may also include escaped
 // single quotes, and values in general may contain escaped commas; these are
-// all also treated properly.
+// all also treated properly. As a convenience for strings copied from other
+// tools, semicolons are used as the separator instead if the string contains
+// no unquoted, unescaped commas but does contain such semicolons.
 func SplitConnectOptions(connectOpts string) (map[string]string, error) {
+	options, err := SplitConnectOptionsOrdered(connectOpts)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]string, len(options))
+	for _, opt := range options {
+		result[opt.Name] = opt.Value
+	}
+	return result, nil
+}
+
+// SplitConnectOptionsOrdered behaves like SplitConnectOptions, but returns a
+// slice of options in their original order, rather than a map. This permits
+// callers to deterministically reconstruct a connect-options string.
+func SplitConnectOptionsOrdered(connectOpts string) ([]ConnectOption, error) {
 	if len(connectOpts) == 0 {
-		return map[string]string{}, nil
+		return []ConnectOption{}, nil
 	}
 	if connectOpts[len(connectOpts)-1] == '\\' {
 		return nil, fmt.Errorf("Trailing backslash in connect-options \"%s\"", connectOpts)
 	}
-	return parseConnectOptions(connectOpts)
+	return parseConnectOptions(connectOpts, connectOptionsSeparator(connectOpts))
+}
+
+// connectOptionsSeparator returns the separator character to use for parsing
+// input: a semicolon if input contains at least one unquoted, unescaped
+// semicolon but no unquoted, unescaped commas; otherwise a comma.
+func connectOptionsSeparator(input string) rune {
+	var inQuote, escapeNext, sawSemicolon bool
+	for _, c := range input {
+		if escapeNext {
+			escapeNext = false
+			continue
+		}
+		switch c {
+		case '\'':
+			inQuote = !inQuote
+		case '\\':
+			escapeNext = true
+		case ',':
+			if !inQuote {
+				return ','
+			}
+		case ';':
+			sawSemicolon = sawSemicolon || !inQuote
+		}
+	}
+	if sawSemicolon {
+		return ';'
+	}
+	return ','
 }
 
-func parseConnectOptions(input string) (map[string]string, error) {
-	result := make(map[string]string)
+func parseConnectOptions(input string, separator rune) ([]ConnectOption, error) {
+	var result []ConnectOption
+	seen := make(map[string]bool)
 	var startToken int
 	var name string
 	var inQuote, escapeNext bool
+	separatorName := "comma"
+	if separator == ';' {
+		separatorName = "semicolon"
+	}
 
-	// Add a trailing comma to simplify handling of end-of-string
-	for n, c := range input + "," {
+	// Add a trailing separator to simplify handling of end-of-string
+	for n, c := range input + string(separator) {
 		if escapeNext {
 			escapeNext = false
 			continue
@@ -257,22 +484,23 @@ func parseConnectOptions(input string) (map[string]string, error) {
 			} else {
 				return result, fmt.Errorf("Invalid equals-sign character in option value at byte offset %d in connect-options \"%s\"", n, input)
 			}
-		case ',':
+		case separator:
 			if inQuote {
 				continue
 			}
-			if startToken == n { // comma directly after equals sign, comma, or start of string
-				return result, fmt.Errorf("Invalid comma placement in option value at byte offset %d in connect-options \"%s\"", n, input)
+			if startToken == n { // separator directly after equals sign, separator, or start of string
+				return result, fmt.Errorf("Invalid %s placement in option value at byte offset %d in connect-options \"%s\"", separatorName, n, input)
 			}
 			if name == "" {
 				return result, fmt.Errorf("Option %s is missing a value at byte offset %d in connect-options \"%s\"", input[startToken:n], n, input)
 			}
-			if _, already := result[name]; already {
+			if seen[name] {
 				// Disallow this since it's inherently ordering-dependent, and would
 				// further complicate RealConnectOptions logic
 				return result, fmt.Errorf("Option %s is set multiple times in connect-options \"%s\"", name, input)
 			}
-			result[name] = input[startToken:n]
+			seen[name] = true
+			result = append(result, ConnectOption{Name: name, Value: input[startToken:n]})
 			name = ""
 			startToken = n + 1
 		}
@@ -285,10 +513,66 @@ func parseConnectOptions(input string) (map[string]string, error) {
 	return result, err
 }
 
+// ValidateConnectOptions confirms that every connect-options value in the
+// supplied option file, in any section, can be parsed by SplitConnectOptions.
+// The file must already have been parsed. If a value is malformed, the returned
+// error is a mybase.FileParseFormatError indicating the file path and line
+// number of the problematic value.
+func ValidateConnectOptions(f *mybase.File) error {
+	for _, section := range f.SectionsWithOption("connect-options") {
+		// Use a throwaway Config to unquote the raw value in a manner consistent
+		// with how it would normally be obtained
+		cmd := mybase.NewCommand("validate", "", "", nil)
+		cmd.AddOption(mybase.StringOption("connect-options", 0, "", ""))
+		values := mybase.StringMapValues{"connect-options": f.SectionValues(section)["connect-options"]}
+		cfg := mybase.NewConfig(&mybase.CommandLine{Command: cmd}, values)
+		if _, err := SplitConnectOptions(cfg.Get("connect-options")); err != nil {
+			return mybase.FileParseFormatError{
+				Problem:    err.Error(),
+				FilePath:   f.Path(),
+				LineNumber: optionLineNumber(f.Path(), section, "connect-options"),
+			}
+		}
+	}
+	return nil
+}
+
+// optionLineNumber returns the line number of the last line setting optionName
+// within the named section of the option file at filePath. If the line cannot
+// be found, 0 is returned.
+func optionLineNumber(filePath, sectionName, optionName string) (lineNumber int) {
+	contents, err := os.ReadFile(filePath)
+	if err != nil {
+		return 0
+	}
+	var currentSection string
+	for n, line := range strings.Split(string(contents), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		} else if line[0] == '[' {
+			currentSection = strings.TrimSpace(strings.Trim(line, "[]"))
+		} else if currentSection == sectionName && mybase.NormalizeOptionName(line) == optionName {
+			lineNumber = n + 1
+		}
+	}
+	return lineNumber
+}
+
 // RealConnectOptions takes a comma-separated string of connection options,
 // strips any Go driver-specific ones, and then returns the new string which
-// is now suitable for passing to an external tool.
+// is now suitable for passing to an external tool. The result always uses
+// commas as separators, even if the input used semicolons.
 func RealConnectOptions(connectOpts string) (string, error) {
+	result, _, err := RealConnectOptionsStripped(connectOpts)
+	return result, err
+}
+
+// RealConnectOptionsStripped behaves like RealConnectOptions, but also returns
+// the names of any options which were stripped, in their original order and
+// letter case. This permits callers to warn users about options which have no
+// effect on external tools.
+func RealConnectOptionsStripped(connectOpts string) (result string, stripped []string, err error) {
 	// list of lowercased versions of all go-sql-driver/mysql special params
 	ignored := map[string]bool{
 		"allowallfiles":           true, // banned in Dir.InstanceDefaultParams, listed here for sake of completeness
@@ -313,27 +597,22 @@ func RealConnectOptions(connectOpts string) (string, error) {
 		"writetimeout":            true,
 	}
 
-	options, err := SplitConnectOptions(connectOpts)
+	options, err := SplitConnectOptionsOrdered(connectOpts)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 
-	// Iterate through the returned map, and remove any driver-specific options.
-	// This is done via regular expressions substitution in order to keep the
-	// string in its original order.
-	for name, value := range options {
-		if ignored[strings.ToLower(name)] {
-			re, err := regexp.Compile(fmt.Sprintf(`%s=%s(,|$)`, regexp.QuoteMeta(name), regexp.QuoteMeta(value)))
-			if err != nil {
-				return "", err
-			}
-			connectOpts = re.ReplaceAllString(connectOpts, "")
+	// Rebuild the string from the remaining options, retaining their original
+	// order and formatting
+	kept := make([]string, 0, len(options))
+	for _, opt := range options {
+		if ignored[strings.ToLower(opt.Name)] {
+			stripped = append(stripped, opt.Name)
+		} else {
+			kept = append(kept, opt.String())
 		}
 	}
-	if len(connectOpts) > 0 && connectOpts[len(connectOpts)-1] == ',' {
-		connectOpts = connectOpts[0 : len(connectOpts)-1]
-	}
-	return connectOpts, nil
+	return strings.Join(kept, ","), stripped, nil
 }
 
 // This mapping of ignore-options to object types is stored in a slice (rather
@@ -350,9 +629,10 @@ var ignoreOptionToTypes = []struct {
 }
 
 // IgnorePatterns compiles the regexes in the supplied mybase.Config's ignore-*
-// options. If all supplied regex strings were valid, a slice of
-// tengo.ObjectPattern is returned; otherwise, an error with the first invalid
-// regex is returned.
+// options. Tables previously renamed by push's rename-drops option, using the
+// configured rename-drops-prefix, are always ignored as well. If all supplied
+// regex strings were valid, a slice of tengo.ObjectPattern is returned;
+// otherwise, an error with the first invalid regex is returned.
 func IgnorePatterns(cfg *mybase.Config) ([]tengo.ObjectPattern, error) {
 	var patterns []tengo.ObjectPattern
 	for _, opt := range ignoreOptionToTypes {
@@ -365,5 +645,8 @@ func IgnorePatterns(cfg *mybase.Config) ([]tengo.ObjectPattern, error) {
 			}
 		}
 	}
+	if re := tengo.DroppedTablePattern(cfg.Get("rename-drops-prefix")); re != nil {
+		patterns = append(patterns, tengo.ObjectPattern{Type: tengo.ObjectTypeTable, Pattern: re})
+	}
 	return patterns, nil
 }
@@ -1,7 +1,10 @@
 package util
 
 import (
+	"bytes"
+	"io"
 	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
@@ -212,6 +215,89 @@ func TestPasswordOption(t *testing.T) {
 	assertPassword(cfg, "")
 }
 
+func TestConfiguredPassword(t *testing.T) {
+	cmdSuite := mybase.NewCommandSuite("skeematest", "", "")
+	AddGlobalOptions(cmdSuite)
+	cmdSuite.AddSubCommand(mybase.NewCommand("diff", "", "", nil))
+	os.Unsetenv("MYSQL_PWD")
+
+	pwFile := filepath.Join(t.TempDir(), "pw")
+	if err := os.WriteFile(pwFile, []byte("  s3cret \nsecond line\n"), 0600); err != nil {
+		t.Fatalf("Unable to write password file: %v", err)
+	}
+	cases := map[string]string{
+		"skeema diff":                           "",
+		"skeema diff --password=plain":          "plain",
+		"skeema diff --password=file:" + pwFile: "s3cret",
+	}
+	for cmdLine, expected := range cases {
+		cfg := mybase.ParseFakeCLI(t, cmdSuite, cmdLine)
+		if actual, err := ConfiguredPassword(cfg); actual != expected || err != nil {
+			t.Errorf("Unexpected result from ConfiguredPassword with %q: %q, %v", cmdLine, actual, err)
+		}
+	}
+
+	// Quote-wrapped values should be used literally
+	fakeFileSource := mybase.SimpleSource(map[string]string{
+		"password": "'file:" + pwFile + "'",
+	})
+	cfg := mybase.ParseFakeCLI(t, cmdSuite, "skeema diff", fakeFileSource)
+	if actual, err := ConfiguredPassword(cfg); actual != "file:"+pwFile || err != nil {
+		t.Errorf("Unexpected result from ConfiguredPassword: %q, %v", actual, err)
+	}
+
+	// The file: prefix is not recognized if it only appears after env var
+	// expansion
+	os.Setenv("MYSQL_PWD", "file:"+pwFile)
+	cfg = mybase.ParseFakeCLI(t, cmdSuite, "skeema diff")
+	if actual, err := ConfiguredPassword(cfg); actual != "file:"+pwFile || err != nil {
+		t.Errorf("Unexpected result from ConfiguredPassword with file: in env var: %q, %v", actual, err)
+	}
+	os.Unsetenv("MYSQL_PWD")
+
+	// Password files without a trailing newline should still work
+	if err := os.WriteFile(pwFile, []byte("oneline"), 0600); err != nil {
+		t.Fatalf("Unable to write password file: %v", err)
+	}
+	cfg = mybase.ParseFakeCLI(t, cmdSuite, "skeema diff --password=file:"+pwFile)
+	if actual, err := ConfiguredPassword(cfg); actual != "oneline" || err != nil {
+		t.Errorf("Unexpected result from ConfiguredPassword: %q, %v", actual, err)
+	}
+
+	// Nonexistent password file should error
+	cfg = mybase.ParseFakeCLI(t, cmdSuite, "skeema diff --password=file:"+pwFile+".doesnotexist")
+	if _, err := ConfiguredPassword(cfg); err == nil {
+		t.Error("Expected error from ConfiguredPassword with nonexistent file, but err was nil")
+	}
+}
+
+func TestReaderPasswordInput(t *testing.T) {
+	input := newReaderPasswordInput(strings.NewReader("first\r\nsecond\nthird"))
+	for _, expected := range []string{"first", "second", "third"} {
+		if pw, err := input(); err != nil || pw != expected {
+			t.Errorf("Expected password %q, instead found %q with err=%v", expected, pw, err)
+		}
+	}
+	if _, err := input(); err != io.EOF {
+		t.Errorf("Expected io.EOF once input is exhausted, instead found %v", err)
+	}
+
+	var b bytes.Buffer
+	pw, err := promptPassword(&b, newReaderPasswordInput(strings.NewReader("hunter2\n")), []interface{}{"Password for %s: ", "foo"})
+	if err != nil || pw != "hunter2" {
+		t.Errorf("Unexpected result from promptPassword: %q, %v", pw, err)
+	}
+	if b.String() != "Password for foo: \n" {
+		t.Errorf("Unexpected prompt output: %q", b.String())
+	}
+	b.Reset()
+	if _, err := promptPassword(&b, newReaderPasswordInput(strings.NewReader("")), nil); err == nil {
+		t.Error("Expected error from promptPassword with empty input, but err was nil")
+	} else if b.String() != "Enter password: \n" {
+		t.Errorf("Unexpected prompt output: %q", b.String())
+	}
+}
+
 func TestSplitConnectOptions(t *testing.T) {
 	assertConnectOpts := func(connectOptions string, expectedPair ...string) {
 		result, err := SplitConnectOptions(connectOptions)
@@ -233,6 +319,13 @@ func TestSplitConnectOptions(t *testing.T) {
 	assertConnectOpts(`escaped=we\'re ok`, `escaped=we\'re ok`)
 	assertConnectOpts(`escquotes='we\'re still quoted',this=that`, `escquotes='we\'re still quoted'`, "this=that")
 
+	// Semicolons are used as separators only if no unquoted commas are present
+	assertConnectOpts("foo=1;bar=2", "foo=1", "bar=2")
+	assertConnectOpts("foo='a;b';bar='c,d'", "foo='a;b'", "bar='c,d'")
+	assertConnectOpts(`foo=a\;b;bar=2`, `foo=a\;b`, "bar=2")
+	assertConnectOpts("foo='a;b'", "foo='a;b'")
+	assertConnectOpts("foo=1,bar='x;y'", "foo=1", "bar='x;y'")
+
 	expectError := []string{
 		"foo=bar,'bip'=bap",
 		"flip=flap=flarb",
@@ -246,6 +339,9 @@ func TestSplitConnectOptions(t *testing.T) {
 		"bareword",
 		"twice=true,bool=true,twice=true",
 		"start=1,bareword",
+		"foo=1;;bar=2",
+		"foo=1;bar=2;",
+		"foo=1;bar=2,baz=3",
 	}
 	for _, connOpts := range expectError {
 		if _, err := SplitConnectOptions(connOpts); err == nil {
@@ -254,6 +350,67 @@ func TestSplitConnectOptions(t *testing.T) {
 	}
 }
 
+func TestSplitConnectOptionsOrdered(t *testing.T) {
+	input := `zeta=1,alpha='a,b',mid=we\'re,beta=2`
+	result, err := SplitConnectOptionsOrdered(input)
+	if err != nil {
+		t.Fatalf("Unexpected error from SplitConnectOptionsOrdered: %v", err)
+	}
+	expected := []ConnectOption{
+		{Name: "zeta", Value: "1"},
+		{Name: "alpha", Value: "'a,b'"},
+		{Name: "mid", Value: `we\'re`},
+		{Name: "beta", Value: "2"},
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Unexpected result from SplitConnectOptionsOrdered: %+v", result)
+	}
+	strs := make([]string, len(result))
+	for n, opt := range result {
+		strs[n] = opt.String()
+	}
+	if rebuilt := strings.Join(strs, ","); rebuilt != input {
+		t.Errorf("Expected options to rebuild original string %q, instead found %q", input, rebuilt)
+	}
+
+	if result, err := SplitConnectOptionsOrdered(""); err != nil || len(result) != 0 {
+		t.Errorf("Unexpected result from SplitConnectOptionsOrdered on empty string: %v, %v", result, err)
+	}
+	if _, err := SplitConnectOptionsOrdered("twice=true,twice=true"); err == nil {
+		t.Error("Expected error from SplitConnectOptionsOrdered with repeated option, but err was nil")
+	}
+}
+
+func TestValidateConnectOptions(t *testing.T) {
+	cfg := mybase.SimpleConfig(map[string]string{"connect-options": "", "host": ""})
+	parseFile := func(contents string) *mybase.File {
+		t.Helper()
+		f := mybase.NewFile(t.TempDir(), ".skeema")
+		if err := os.WriteFile(f.Path(), []byte(contents), 0666); err != nil {
+			t.Fatalf("Unable to write test file: %v", err)
+		}
+		if err := f.Parse(cfg); err != nil {
+			t.Fatalf("Unexpected error parsing test file: %v", err)
+		}
+		return f
+	}
+
+	// Valid values in any section should not yield an error
+	f := parseFile("host=localhost\nconnect-options='sql_mode=\\'STRICT_ALL_TABLES,NO_ZERO_DATE\\''\n\n[production]\nconnect_options=wait_timeout=10\n")
+	if err := ValidateConnectOptions(f); err != nil {
+		t.Errorf("Unexpected error from ValidateConnectOptions: %v", err)
+	}
+
+	// Malformed value in a non-default section should report correct line
+	f = parseFile("host=localhost\nconnect-options=wait_timeout=10\n\n[production]\n# comment\nloose-connect-options=foo=,bar=baz\n")
+	err := ValidateConnectOptions(f)
+	if fpfe, ok := err.(mybase.FileParseFormatError); !ok {
+		t.Errorf("Expected ValidateConnectOptions to return FileParseFormatError, instead found %T %v", err, err)
+	} else if fpfe.LineNumber != 6 || fpfe.FilePath != f.Path() || !strings.Contains(fpfe.Problem, "byte offset 4") {
+		t.Errorf("Unexpected error fields: %+v", fpfe)
+	}
+}
+
 func TestRealConnectOptions(t *testing.T) {
 	assertResult := func(input, expected string) {
 		actual, err := RealConnectOptions(input)
@@ -270,6 +427,7 @@ func TestRealConnectOptions(t *testing.T) {
 	assertResult("timeout=1s,bar=123", "bar=123")
 	assertResult("allowCleartextPasswords=1,foo=2,charset='utf8mb4,utf8'", "foo=2")
 	assertResult("timeout=10ms,TIMEOUT=20ms,timeOut=30ms", "")
+	assertResult("foo='a;b';timeout=1s;bar=123", "foo='a;b',bar=123")
 
 	// Ensure errors from SplitConnectOptions are passed through
 	if _, err := RealConnectOptions("foo='ok,cool',multiStatements=true,bareword"); err == nil {
@@ -277,6 +435,25 @@ func TestRealConnectOptions(t *testing.T) {
 	}
 }
 
+func TestRealConnectOptionsStripped(t *testing.T) {
+	result, stripped, err := RealConnectOptionsStripped("readTimeout=5s,foo=1,multiStatements=true,TIMEOUT=1s,bar='a,b'")
+	if err != nil {
+		t.Fatalf("Unexpected error from RealConnectOptionsStripped: %v", err)
+	}
+	if result != "foo=1,bar='a,b'" {
+		t.Errorf("Unexpected result string: %q", result)
+	}
+	if expected := []string{"readTimeout", "multiStatements", "TIMEOUT"}; !reflect.DeepEqual(stripped, expected) {
+		t.Errorf("Expected stripped options %v, instead found %v", expected, stripped)
+	}
+	if _, stripped, err := RealConnectOptionsStripped("foo=1"); err != nil || len(stripped) != 0 {
+		t.Errorf("Expected no stripped options and no error, instead found %v, %v", stripped, err)
+	}
+	if _, _, err := RealConnectOptionsStripped("bareword"); err == nil {
+		t.Error("Expected error from RealConnectOptionsStripped, but err is nil")
+	}
+}
+
 func TestIgnorePatterns(t *testing.T) {
 	cmd := mybase.NewCommand("skeematest", "", "", nil)
 	AddGlobalOptions(cmd)
@@ -287,8 +464,8 @@ func TestIgnorePatterns(t *testing.T) {
 	}
 
 	// Confirm length of result
-	if len(ignore) != 2 {
-		t.Fatalf("Expected IgnorePatterns to return 2 patterns, instead found %d", len(ignore))
+	if len(ignore) != 3 {
+		t.Fatalf("Expected IgnorePatterns to return 3 patterns, instead found %d", len(ignore))
 	}
 
 	// Confirm functionality
@@ -310,6 +487,8 @@ func TestIgnorePatterns(t *testing.T) {
 	assertShouldIgnore(tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: "foobert"}, true)
 	assertShouldIgnore(tengo.ObjectKey{Type: tengo.ObjectTypeProc, Name: "WHATEVER"}, true)
 	assertShouldIgnore(tengo.ObjectKey{Type: tengo.ObjectTypeFunc, Name: "foobar"}, false)
+	assertShouldIgnore(tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: "_dropped_actor_20230405060708"}, true)
+	assertShouldIgnore(tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: "_dropped_actor"}, false)
 
 	// Confirm consistent sort order for result
 	ignore2, _ := IgnorePatterns(cfg)
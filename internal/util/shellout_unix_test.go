@@ -6,8 +6,11 @@ package util
 import (
 	"os"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/skeema/mybase"
 )
 
 func TestShellOutRun(t *testing.T) {
@@ -182,3 +185,41 @@ func TestShellOutCombineOutput(t *testing.T) {
 		}
 	}
 }
+
+func TestConfiguredPasswordExec(t *testing.T) {
+	cmdSuite := mybase.NewCommandSuite("skeematest", "", "")
+	AddGlobalOptions(cmdSuite)
+	cmdSuite.AddSubCommand(mybase.NewCommand("diff", "", "", nil))
+	os.Setenv("SKEEMA_TEST_PW", "fromenv")
+	defer os.Unsetenv("SKEEMA_TEST_PW")
+
+	fakeFileSource := mybase.SimpleSource(map[string]string{
+		"password": `exec:printf "  $SKEEMA_TEST_PW \nsecond line\n"`,
+	})
+	cfg := mybase.ParseFakeCLI(t, cmdSuite, "skeema diff", fakeFileSource)
+	if actual, err := ConfiguredPassword(cfg); actual != "fromenv" || err != nil {
+		t.Errorf("Unexpected result from ConfiguredPassword: %q, %v", actual, err)
+	}
+
+	// The exec: prefix is not recognized if it only appears after env var
+	// expansion, so the command is not run
+	os.Setenv("SKEEMA_TEST_PW", "exec:false")
+	cfg = mybase.ParseFakeCLI(t, cmdSuite, "skeema diff", mybase.SimpleSource(map[string]string{"password": "$SKEEMA_TEST_PW"}))
+	if actual, err := ConfiguredPassword(cfg); actual != "exec:false" || err != nil {
+		t.Errorf("Unexpected result from ConfiguredPassword with exec: in env var: %q, %v", actual, err)
+	}
+
+	// Non-zero exit should return an error
+	fakeFileSource["password"] = "exec:echo hello && false"
+	cfg = mybase.ParseFakeCLI(t, cmdSuite, "skeema diff", fakeFileSource)
+	if _, err := ConfiguredPassword(cfg); err == nil {
+		t.Error("Expected error from ConfiguredPassword with failing command, but err was nil")
+	}
+
+	// Exceeding password-exec-timeout should return an error
+	fakeFileSource["password"] = "exec:sleep 1"
+	cfg = mybase.ParseFakeCLI(t, cmdSuite, "skeema diff --password-exec-timeout=100ms", fakeFileSource)
+	if _, err := ConfiguredPassword(cfg); err == nil || !strings.Contains(err.Error(), "password-exec-timeout") {
+		t.Errorf("Expected timeout error from ConfiguredPassword, instead found %v", err)
+	}
+}
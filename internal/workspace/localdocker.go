@@ -137,6 +137,7 @@ func NewLocalDocker(opts Options) (_ *LocalDocker, retErr error) {
 	createOpts := tengo.SchemaCreationOptions{
 		DefaultCharSet:   opts.DefaultCharacterSet,
 		DefaultCollation: opts.DefaultCollation,
+		Comment:          opts.SchemaComment,
 		SkipBinlog:       true,
 	}
 	if _, err := ld.d.CreateSchema(ld.schemaName, createOpts); err != nil {
@@ -73,6 +73,7 @@ func NewTempSchema(opts Options) (_ *TempSchema, retErr error) {
 	createOpts := tengo.SchemaCreationOptions{
 		DefaultCharSet:   opts.DefaultCharacterSet,
 		DefaultCollation: opts.DefaultCollation,
+		Comment:          opts.SchemaComment,
 		SkipBinlog:       opts.SkipBinlog,
 	}
 	if has, err := ts.inst.HasSchema(ts.schemaName); err != nil {
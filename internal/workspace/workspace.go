@@ -83,6 +83,7 @@ type Options struct {
 	SchemaName          string
 	DefaultCharacterSet string
 	DefaultCollation    string
+	SchemaComment       string
 	DefaultConnParams   string // only TypeLocalDocker
 	RootPassword        string // only TypeLocalDocker
 	NameCaseMode        tengo.NameCaseMode
@@ -286,6 +287,9 @@ func ExecLogicalSchema(logicalSchema *fs.LogicalSchema, opts Options) (_ *Schema
 	if logicalSchema.Collation != "" {
 		opts.DefaultCollation = logicalSchema.Collation
 	}
+	if logicalSchema.Comment != "" {
+		opts.SchemaComment = logicalSchema.Comment
+	}
 	if opts.NameCaseMode > tengo.NameCaseAsIs {
 		if err := logicalSchema.LowerCaseNames(opts.NameCaseMode); err != nil {
 			return nil, err
@@ -3,12 +3,14 @@ package main
 import (
 	"fmt"
 	"os"
+	"reflect"
 	"runtime"
 	"strings"
 	"testing"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/skeema/mybase"
+	"github.com/skeema/skeema/internal/dumper"
 	"github.com/skeema/skeema/internal/fs"
 	"github.com/skeema/skeema/internal/tengo"
 )
@@ -285,6 +287,59 @@ func (s SkeemaIntegrationSuite) TestPullHandler(t *testing.T) {
 	}
 }
 
+func (s SkeemaIntegrationSuite) TestPullDryRun(t *testing.T) {
+	s.handleCommand(t, CodeSuccess, ".", "skeema init --dir mydb -h %s -P %d", s.d.Instance.Host, s.d.Instance.Port)
+
+	// Reformat one file without functional changes, and make a functional change
+	// to another table plus a new schema in the db. Pull with --dry-run should
+	// not modify any files or create any dirs.
+	unformatted := strings.Replace(fs.ReadTestFile(t, "mydb/product/subscriptions.sql"), "`", "", -1)
+	fs.WriteTestFile(t, "mydb/product/subscriptions.sql", unformatted)
+	s.sourceSQL(t, "pull1.sql")
+	origUsers := fs.ReadTestFile(t, "mydb/product/users.sql")
+	s.dbExec(t, "product", "ALTER TABLE users ADD COLUMN dryrun int")
+	s.handleCommand(t, CodeSuccess, ".", "skeema pull --dry-run")
+	if contents := fs.ReadTestFile(t, "mydb/product/subscriptions.sql"); contents != unformatted {
+		t.Error("Expected pull --dry-run to leave mydb/product/subscriptions.sql unchanged, but it was rewritten")
+	}
+	if contents := fs.ReadTestFile(t, "mydb/product/users.sql"); contents != origUsers {
+		t.Error("Expected pull --dry-run to leave mydb/product/users.sql unchanged, but it was rewritten")
+	}
+	if _, err := os.Stat("mydb/archives"); !os.IsNotExist(err) {
+		t.Errorf("Expected os.Stat to return IsNotExist error for mydb/archives; instead err=%v", err)
+	}
+
+	// Existing objects with either functional or formatting-only differences
+	// should be reported as rewritten, but dropped or new tables should not
+	cfg := s.handleCommand(t, CodeSuccess, ".", "skeema pull --dry-run --skip-format")
+	dir, err := fs.ParseDir("mydb/product", cfg)
+	if err != nil {
+		t.Fatalf("Unexpected error from ParseDir: %v", err)
+	}
+	schema, err := s.d.Instance.Schema("product")
+	if err != nil {
+		t.Fatalf("Unexpected error from Schema: %v", err)
+	}
+	rewritten := dumper.RewrittenKeys(schema, dir, dumpOptionsForPull(dir.Config))
+	expected := []tengo.ObjectKey{
+		{Type: tengo.ObjectTypeTable, Name: "posts"},
+		{Type: tengo.ObjectTypeTable, Name: "subscriptions"},
+		{Type: tengo.ObjectTypeTable, Name: "users"},
+	}
+	if !reflect.DeepEqual(rewritten, expected) {
+		t.Errorf("Unexpected result from RewrittenKeys: expected %v, found %v", expected, rewritten)
+	}
+
+	// A real pull should now rewrite both files
+	s.handleCommand(t, CodeSuccess, ".", "skeema pull")
+	if contents := fs.ReadTestFile(t, "mydb/product/subscriptions.sql"); contents == unformatted {
+		t.Error("Expected pull to reformat mydb/product/subscriptions.sql, but it was not rewritten")
+	}
+	if contents := fs.ReadTestFile(t, "mydb/product/users.sql"); !strings.Contains(contents, "dryrun") {
+		t.Error("Expected pull to update mydb/product/users.sql, but it was not rewritten")
+	}
+}
+
 func (s SkeemaIntegrationSuite) TestLintHandler(t *testing.T) {
 	s.handleCommand(t, CodeSuccess, ".", "skeema init --dir mydb -h %s -P %d", s.d.Instance.Host, s.d.Instance.Port)
 
@@ -775,6 +830,14 @@ func (s SkeemaIntegrationSuite) TestAutoInc(t *testing.T) {
 		t.Error("Expected mydb/product/users.sql to contain AUTO_INCREMENT=4 after pull, but it did not")
 	}
 
+	// pull with --strip-auto-inc should remove the value from existing files,
+	// and cannot be combined with --include-auto-inc
+	s.handleCommand(t, CodeBadConfig, ".", "skeema pull --strip-auto-inc --include-auto-inc")
+	cfg = s.handleCommand(t, CodeSuccess, ".", "skeema pull --strip-auto-inc")
+	if strings.Contains(fs.ReadTestFile(t, "mydb/product/users.sql"), "AUTO_INCREMENT=") {
+		t.Error("Expected mydb/product/users.sql to lack an AUTO_INCREMENT clause after pull --strip-auto-inc, but it did not")
+	}
+	s.verifyFiles(t, cfg, "../golden/init")
 }
 
 func (s SkeemaIntegrationSuite) TestUnsupportedAlter(t *testing.T) {